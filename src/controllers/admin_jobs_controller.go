@@ -3,6 +3,7 @@ package controllers
 import (
 	DB "Backend-Bluelock-007/src/database"
 	"Backend-Bluelock-007/src/jobs"
+	"Backend-Bluelock-007/src/services/programs"
 	"context"
 	"encoding/json"
 	"net/http"
@@ -118,7 +119,7 @@ func RunCompleteProgramNow(c *fiber.Ctx) error {
 	t := asynq.NewTask(jobs.TypeCompleteProgram, b)
 
 	// Call handler directly
-	if err := jobs.HandleCompleteProgramTask(context.TODO(), t); err != nil {
+	if err := programs.HandleCompleteProgramTask(context.TODO(), t); err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
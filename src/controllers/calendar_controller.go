@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"Backend-Bluelock-007/src/services/calendar"
+	"Backend-Bluelock-007/src/utils"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetPublicProgramsICS godoc
+// @Summary      Public iCalendar feed
+// @Description  RFC 5545 feed of open / closed programs (cancelled programs are sent as STATUS:CANCELLED), times in Asia/Bangkok
+// @Tags         calendar
+// @Produce      text/calendar
+// @Success      200  {string}  string  "ICS feed"
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /calendar/programs.ics [get]
+func GetPublicProgramsICS(c *fiber.Ctx) error {
+	feed, err := calendar.PublicFeed(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return sendICS(c, "programs.ics", feed)
+}
+
+// GetStudentScheduleICS godoc
+// @Summary      Personal iCalendar feed
+// @Description  RFC 5545 feed of the program items a student enrolled in, secured by the signed token from /calendar/subscription
+// @Tags         calendar
+// @Produce      text/calendar
+// @Param        studentId  path   string  true  "Student ID"
+// @Param        token      query  string  true  "Signed calendar token"
+// @Success      200  {string}  string  "ICS feed"
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      403  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /calendar/students/{studentId}/schedule.ics [get]
+func GetStudentScheduleICS(c *fiber.Ctx) error {
+	studentID, err := primitive.ObjectIDFromHex(c.Params("studentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	if !utils.VerifyCalendarToken(studentID.Hex(), c.Query("token")) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Invalid calendar token"})
+	}
+
+	feed, err := calendar.StudentFeed(c.Context(), studentID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return sendICS(c, "my-programs.ics", feed)
+}
+
+// GetCalendarSubscription godoc
+// @Summary      Personal calendar subscription URL
+// @Description  Signed ICS URL of the logged-in student (Admin may pass studentId)
+// @Tags         calendar
+// @Produce      json
+// @Security     BearerAuth
+// @Param        studentId  query  string  false  "Student ID (Admin only)"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      403  {object}  models.ErrorResponse
+// @Router       /calendar/subscription [get]
+func GetCalendarSubscription(c *fiber.Ctx) error {
+	studentID, _ := c.Locals("userId").(string)
+	if requested := c.Query("studentId"); requested != "" && requested != studentID {
+		if utils.RoleFromCtx(c) != "Admin" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only Admin can get another student's calendar"})
+		}
+		studentID = requested
+	} else if utils.RoleFromCtx(c) != "Student" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "studentId is required"})
+	}
+	if _, err := primitive.ObjectIDFromHex(studentID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	url := c.BaseURL() + "/calendar/students/" + studentID + "/schedule.ics?token=" + utils.SignCalendarToken(studentID)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"url":       url,
+		"webcalUrl": "webcal://" + strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "http://"),
+	})
+}
+
+func sendICS(c *fiber.Ctx, filename string, body []byte) error {
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="`+filename+`"`)
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	return c.Status(fiber.StatusOK).Send(body)
+}
@@ -3,6 +3,7 @@ package controllers
 import (
 	models "Backend-Bluelock-007/src/models"
 	services "Backend-Bluelock-007/src/services/certificates"
+	"Backend-Bluelock-007/src/utils"
 	"fmt"
 	"net/url"
 	"strings"
@@ -210,7 +211,7 @@ func UpdateCertificateStatus(c *fiber.Ctx) error {
 		})
 	}
 
-	updatedCert, err := services.UpdateUploadCertificateStatus(id, req.Status, req.Remark)
+	updatedCert, err := services.UpdateUploadCertificateStatus(id, req.Status, req.Remark, utils.ActorFromCtx(c))
 	if err != nil {
 		if err.Error() == "upload certificate not found" {
 			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
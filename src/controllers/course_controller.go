@@ -4,6 +4,7 @@ import (
 	"Backend-Bluelock-007/src/models"
 	"Backend-Bluelock-007/src/services/courses"
 	"Backend-Bluelock-007/src/utils"
+	"errors"
 	"fmt"
 	"log"
 	"math"
@@ -148,8 +149,11 @@ func DeleteCourse(c *fiber.Ctx) error {
 	if err != nil {
 		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid ID")
 	}
-	err = courses.DeleteCourse(id)
+	err = courses.DeleteCourse(id, utils.ActorFromCtx(c))
 	if err != nil {
+		if errors.Is(err, courses.ErrCourseNotFound) {
+			return utils.HandleError(c, fiber.StatusNotFound, err.Error())
+		}
 		return utils.HandleError(c, fiber.StatusInternalServerError, err.Error())
 	}
 	return c.SendStatus(fiber.StatusOK)
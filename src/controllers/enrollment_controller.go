@@ -3,6 +3,10 @@ package controllers
 import (
 	"Backend-Bluelock-007/src/models"
 	"Backend-Bluelock-007/src/services/enrollments"
+	"Backend-Bluelock-007/src/services/programs"
+	"Backend-Bluelock-007/src/utils"
+	"bytes"
+	"encoding/csv"
 	"errors"
 	"fmt"
 	"log"
@@ -56,6 +60,61 @@ func GetEnrollmentsByStudent(c *fiber.Ctx) error {
 	})
 }
 
+// ✅ กิจกรรมแนะนำสำหรับ Student (ลงทะเบียนได้ + เรียงตามทักษะที่ยังขาดชั่วโมง)
+func GetProgramRecommendations(c *fiber.Ctx) error {
+	studentID, err := primitive.ObjectIDFromHex(c.Params("studentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid studentId format"})
+	}
+
+	result, err := enrollments.GetProgramRecommendations(c.Context(), studentID, c.QueryInt("limit", 10))
+	if err != nil {
+		if errors.Is(err, enrollments.ErrStudentNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(result)
+}
+
+// ✅ Timeline กิจกรรมของ Student (อดีต / ปัจจุบัน / กำลังจะมาถึง) พร้อมสถานะชั่วโมงและ check-in/out
+func GetStudentTimeline(c *fiber.Ctx) error {
+	studentID, err := primitive.ObjectIDFromHex(c.Params("studentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid studentId format"})
+	}
+
+	params := models.DefaultPagination()
+	params.Page, _ = strconv.Atoi(c.Query("page", strconv.Itoa(params.Page)))
+	params.Limit, _ = strconv.Atoi(c.Query("limit", strconv.Itoa(params.Limit)))
+	params.Search = c.Query("search", "")
+	params.SortBy = c.Query("sortBy", "startDate")
+	params.Order = c.Query("order", "desc")
+
+	var filters models.StudentTimelineFilters
+	if err := c.QueryParser(&filters); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid query parameters"})
+	}
+	for _, d := range []string{filters.From, filters.To} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "from/to must be in YYYY-MM-DD format"})
+		}
+	}
+
+	items, meta, err := enrollments.GetStudentTimeline(studentID, params, filters)
+	if err != nil {
+		if err.Error() == "invalid programId" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(models.StudentTimelinePaginatedResponse{Data: items, Meta: meta})
+}
+
 // ✅ 1.b Student หลายคน ลงทะเบียนกิจกรรมแบบ bulk: { studentCode, food } ต่อคน
 func RegisterStudentsByCodes(c *fiber.Ctx) error {
 	var req models.BulkEnrollRequest
@@ -76,6 +135,7 @@ func RegisterStudentsByCodes(c *fiber.Ctx) error {
 	for i, s := range req.Students {
 		students[i] = models.BulkEnrollItem{
 			StudentCode: s.StudentCode,
+			FoodID:      s.FoodID,
 			Food:        s.Food,
 		}
 	}
@@ -97,7 +157,8 @@ func RegisterStudent(c *fiber.Ctx) error {
 	var req struct {
 		ProgramItemID string  `json:"programItemId"`
 		StudentID     string  `json:"studentId"`
-		Food          *string `json:"food"` // ✅ รับชื่ออาหาร ถ้ามี
+		FoodID        *string `json:"foodId"` // ✅ ตัวเลือกอาหาร (FoodVote.foodId) ถ้ามี
+		Food          *string `json:"food"`   // รองรับ client เดิมที่ส่งชื่ออาหาร
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -106,9 +167,16 @@ func RegisterStudent(c *fiber.Ctx) error {
 
 	programItemID, _ := primitive.ObjectIDFromHex(req.ProgramItemID)
 	studentID, _ := primitive.ObjectIDFromHex(req.StudentID)
+	foodID, err := enrollments.ParseFoodID(req.FoodID)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
 
-	err := enrollments.RegisterStudent(programItemID, studentID, req.Food) // ✅ ส่ง food ไปด้วย
+	err = enrollments.RegisterStudent(programItemID, studentID, foodID, req.Food)
 	if err != nil {
+		if errors.Is(err, programs.ErrProgramNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
 	}
 
@@ -119,7 +187,8 @@ func RegisterStudentByAdmin(c *fiber.Ctx) error {
 	var req struct {
 		ProgramItemID string  `json:"programItemId"`
 		StudentID     string  `json:"studentId"`
-		Food          *string `json:"food"` // ✅ รับชื่ออาหาร ถ้ามี
+		FoodID        *string `json:"foodId"` // ✅ ตัวเลือกอาหาร (FoodVote.foodId) ถ้ามี
+		Food          *string `json:"food"`   // รองรับ client เดิมที่ส่งชื่ออาหาร
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -128,9 +197,16 @@ func RegisterStudentByAdmin(c *fiber.Ctx) error {
 
 	programItemID, _ := primitive.ObjectIDFromHex(req.ProgramItemID)
 	studentID, _ := primitive.ObjectIDFromHex(req.StudentID)
+	foodID, err := enrollments.ParseFoodID(req.FoodID)
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
 
-	err := enrollments.RegisterStudentByAdmin(programItemID, studentID, req.Food) // ✅ ส่ง food ไปด้วย
+	err = enrollments.RegisterStudentByAdmin(programItemID, studentID, foodID, req.Food, utils.ActorFromCtx(c))
 	if err != nil {
+		if errors.Is(err, programs.ErrProgramNotFound) {
+			return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(http.StatusConflict).JSON(fiber.Map{"error": err.Error()})
 	}
 
@@ -229,7 +305,7 @@ func UnregisterStudent(c *fiber.Ctx) error {
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Invalid enrollmentId format"})
 	}
 
-	err = enrollments.UnregisterStudent(enrollmentID)
+	err = enrollments.UnregisterStudent(enrollmentID, utils.ActorFromCtx(c))
 	if err != nil {
 		return c.Status(http.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -250,6 +326,138 @@ func GetEnrollmentByStudentAndProgram(c *fiber.Ctx) error {
 	return c.JSON(enrollment)
 }
 
+// ตรวจสอบสิทธิ์ลงทะเบียนของนักศึกษาใน programItem (คืนเหตุผลที่ไม่ผ่านทุกข้อ)
+func CheckEnrollmentEligibility(c *fiber.Ctx) error {
+	studentID, err := primitive.ObjectIDFromHex(c.Params("studentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid studentId"})
+	}
+	programItemID, err := primitive.ObjectIDFromHex(c.Params("programItemId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid programItemId"})
+	}
+
+	result, err := enrollments.CheckEligibility(programItemID, studentID)
+	if err != nil {
+		if err.Error() == "program item not found" || err.Error() == "student not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(result)
+}
+
+// ✅ เปลี่ยนอาหารที่เลือก (นิสิตเปลี่ยนได้ถึง foodDeadline, Admin เปลี่ยนได้ตลอด)
+func UpdateEnrollmentFood(c *fiber.Ctx) error {
+	enrollmentID, err := primitive.ObjectIDFromHex(c.Params("enrollmentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid enrollmentId format"})
+	}
+
+	var req models.FoodSelectionInput
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input format"})
+	}
+	foodID, err := enrollments.ParseFoodID(req.FoodID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	role, _ := c.Locals("role").(string)
+	enrollment, err := enrollments.UpdateEnrollmentFood(enrollmentID, foodID, req.Food, role != "Admin")
+	if err != nil {
+		switch err.Error() {
+		case "enrollment not found", "program not found":
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.JSON(enrollment)
+}
+
+// ✅ ตัวเลือกอาหารของ Program ที่เหมาะกับ dietaryProfile ของนิสิต (ใช้แสดงตอนลงทะเบียน/เปลี่ยนอาหาร)
+func GetFoodOptionsForStudent(c *fiber.Ctx) error {
+	studentID, err := primitive.ObjectIDFromHex(c.Params("studentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid studentId"})
+	}
+	programID, err := primitive.ObjectIDFromHex(c.Params("programId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid programId"})
+	}
+
+	options, err := enrollments.GetFoodOptionsForStudent(programID, studentID)
+	if err != nil {
+		if err.Error() == "program not found" || err.Error() == "student not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(options)
+}
+
+// ✅ สรุปอาหารสำหรับผู้จัดเลี้ยง ต่อ programItem และวันที่ (?date=YYYY-MM-DD&format=csv)
+func ExportCatererFood(c *fiber.Ctx) error {
+	programItemID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid programItemId"})
+	}
+
+	export, err := enrollments.GetCatererExport(programItemID, c.Query("date"))
+	if err != nil {
+		if err.Error() == "program item not found" || err.Error() == "program not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	if c.Query("format") != "csv" {
+		return c.JSON(export)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\uFEFF") // BOM ให้ Excel อ่านภาษาไทยได้
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"Program", export.ProgramName, "Item", export.ProgramItemName, "Date", export.Date})
+	_ = w.Write([]string{})
+	_ = w.Write([]string{"Food", "Count"})
+	for _, f := range export.Foods {
+		_ = w.Write([]string{f.FoodName, strconv.Itoa(f.Count)})
+	}
+	_ = w.Write([]string{"(ไม่รับอาหาร)", strconv.Itoa(export.NoSelection)})
+	_ = w.Write([]string{"Total", strconv.Itoa(export.Total)})
+	_ = w.Write([]string{})
+	_ = w.Write([]string{"Diet", "Count"})
+	for _, d := range export.Diets {
+		_ = w.Write([]string{d.Diet, strconv.Itoa(d.Count)})
+	}
+	_ = w.Write([]string{})
+	_ = w.Write([]string{"Allergy Notes"})
+	_ = w.Write([]string{"Student Code", "Name", "Food", "Allergies", "Note"})
+	for _, n := range export.AllergyNotes {
+		_ = w.Write([]string{n.StudentCode, n.StudentName, n.FoodName, strings.Join(n.Allergies, ", "), n.Note})
+	}
+	_ = w.Write([]string{})
+	_ = w.Write([]string{"Student Code", "Name", "Major", "Food", "Diets"})
+	for _, s := range export.Students {
+		diets := ""
+		if s.DietaryProfile != nil {
+			diets = strings.Join(s.DietaryProfile.Diets, ", ")
+		}
+		_ = w.Write([]string{s.StudentCode, s.StudentName, s.Major, s.FoodName, diets})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="food-%s-%s.csv"`, programItemID.Hex(), export.Date))
+	return c.Send(buf.Bytes())
+}
+
 // ตรวจสอบว่านักศึกษาลงทะเบียนในกิจกรรมหรือไม่ และส่งข้อมูลกิจกรรม
 func CheckEnrollmentByStudentAndProgram(c *fiber.Ctx) error {
 	studentIDHex := c.Params("studentId")
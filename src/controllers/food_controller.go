@@ -36,8 +36,10 @@ if input.Name == "" {
 
 // สร้าง food ใหม่โดย generate ID เอง
 food := models.Food{
-	ID:   primitive.NewObjectID(),
-	Name: input.Name,
+	ID:        primitive.NewObjectID(),
+	Name:      input.Name,
+	Tags:      models.NormalizeFoodTags(input.Tags),
+	Allergens: models.NormalizeFoodTags(input.Allergens),
 }
 
 err := services.CreateFood(&food)
@@ -113,6 +115,9 @@ func UpdateFood(c *fiber.Ctx) error {
 		})
 	}
 
+	food.Tags = models.NormalizeFoodTags(food.Tags)
+	food.Allergens = models.NormalizeFoodTags(food.Allergens)
+
 	err := services.UpdateFood(id, &food)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -12,6 +12,7 @@ import (
 
 	"Backend-Bluelock-007/src/models"
 	forms "Backend-Bluelock-007/src/services/forms"
+	"Backend-Bluelock-007/src/utils"
 )
 
 // CreateForm godoc
@@ -207,19 +208,19 @@ func DeleteFormByid(c *fiber.Ctx) error {
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	result, err := forms.DeleteFormByID(ctx, id)
+	result, err := forms.DeleteFormByID(ctx, id, utils.ActorFromCtx(c))
 	if err != nil {
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to delete form",
 		})
 	}
-	if result.DeletedCount == 0 {
+	if result.MatchedCount == 0 {
 		return c.Status(http.StatusNotFound).JSON(fiber.Map{
 			"error": "Form not found",
 		})
 	}
 	return c.JSON(fiber.Map{
-		"message":      "Form deleted successfully",
-		"deletedCount": result.DeletedCount,
+		"message":      "Form was moved to trash",
+		"deletedCount": result.ModifiedCount,
 	})
 }
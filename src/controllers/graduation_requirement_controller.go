@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"Backend-Bluelock-007/src/models"
+	hourhistory "Backend-Bluelock-007/src/services/hour-history"
+	"Backend-Bluelock-007/src/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetGraduationRequirements godoc
+// @Summary      List graduation requirements
+// @Description  List the hour requirements configured per major and entry year
+// @Tags         graduation-requirements
+// @Produce      json
+// @Success      200  {array}   models.GraduationRequirement
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /graduation-requirements [get]
+func GetGraduationRequirements(c *fiber.Ctx) error {
+	reqs, err := hourhistory.ListGraduationRequirements(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(reqs)
+}
+
+// ResolveGraduationRequirement godoc
+// @Summary      Resolve the requirement for a major and entry year
+// @Description  Return the most specific requirement that applies (major + entry year → major → entry year → default)
+// @Tags         graduation-requirements
+// @Produce      json
+// @Param        major      query  string  false  "Major (e.g. SE)"
+// @Param        entryYear  query  int     false  "Entry year (B.E.)"
+// @Success      200  {object}  models.GraduationRequirement
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /graduation-requirements/resolve [get]
+func ResolveGraduationRequirement(c *fiber.Ctx) error {
+	req, err := hourhistory.ResolveGraduationRequirement(c.Context(), c.Query("major"), c.QueryInt("entryYear", 0))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(req)
+}
+
+// CreateGraduationRequirement godoc
+// @Summary      Create a graduation requirement
+// @Description  Create the requirement for a major / entry year ("" / 0 = all) and recalculate affected student statuses
+// @Tags         graduation-requirements
+// @Accept       json
+// @Produce      json
+// @Param        body  body  models.GraduationRequirementInput  true  "Requirement"
+// @Success      201  {object}  models.GraduationRequirement
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /graduation-requirements [post]
+func CreateGraduationRequirement(c *fiber.Ctx) error {
+	var input models.GraduationRequirementInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input format"})
+	}
+	req, err := hourhistory.CreateGraduationRequirement(c.Context(), input, utils.ActorFromCtx(c))
+	if err != nil {
+		return graduationRequirementError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(req)
+}
+
+// UpdateGraduationRequirement godoc
+// @Summary      Update a graduation requirement
+// @Description  Update a requirement and recalculate affected student statuses
+// @Tags         graduation-requirements
+// @Accept       json
+// @Produce      json
+// @Param        id    path  string                             true  "Requirement ID"
+// @Param        body  body  models.GraduationRequirementInput  true  "Requirement"
+// @Success      200  {object}  models.GraduationRequirement
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /graduation-requirements/{id} [put]
+func UpdateGraduationRequirement(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	var input models.GraduationRequirementInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input format"})
+	}
+	req, err := hourhistory.UpdateGraduationRequirement(c.Context(), id, input, utils.ActorFromCtx(c))
+	if err != nil {
+		return graduationRequirementError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(req)
+}
+
+// DeleteGraduationRequirement godoc
+// @Summary      Delete a graduation requirement
+// @Description  Delete a requirement; affected students fall back to the broader requirement
+// @Tags         graduation-requirements
+// @Produce      json
+// @Param        id   path  string  true  "Requirement ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /graduation-requirements/{id} [delete]
+func DeleteGraduationRequirement(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	if err := hourhistory.DeleteGraduationRequirement(c.Context(), id, utils.ActorFromCtx(c)); err != nil {
+		return graduationRequirementError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Graduation requirement deleted"})
+}
+
+func graduationRequirementError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, hourhistory.ErrGraduationRequirementNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, hourhistory.ErrGraduationRequirementExists):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, hourhistory.ErrInvalidGraduationRequirement):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
@@ -0,0 +1,300 @@
+package controllers
+
+import (
+	"Backend-Bluelock-007/src/models"
+	hourhistory "Backend-Bluelock-007/src/services/hour-history"
+	"Backend-Bluelock-007/src/utils"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// หลักฐานของคำร้องไม่เปิดผ่าน /uploads (ดู main.go) — ดาวน์โหลดผ่าน GetHourAppealEvidence ที่ตรวจสิทธิ์แล้ว
+var appealEvidencePath = "./uploads/appeals/"
+
+var appealEvidenceExts = map[string]bool{".pdf": true, ".jpg": true, ".jpeg": true, ".png": true, ".webp": true}
+
+// CreateHourAppeal นิสิตยื่นคำร้องขอทบทวนผลชั่วโมง (late / incomplete / absent หรือใบรับรองที่ถูกปฏิเสธ) พร้อมหลักฐาน
+// @Summary File an hour appeal
+// @Description ยื่นได้เฉพาะ hour history ของตัวเองภายใน HOUR_APPEAL_WINDOW_DAYS วัน — หลักฐาน (pdf, jpg, png, webp) ไม่เกิน 5 ไฟล์
+// @Tags HourHistory
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param historyId formData string true "Hour history ID"
+// @Param reason formData string true "Reason"
+// @Param files formData file false "Evidence files"
+// @Success 201 {object} models.HourAppeal
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/appeals [post]
+func CreateHourAppeal(c *fiber.Ctx) error {
+	if utils.RoleFromCtx(c) != "Student" {
+		return utils.HandleError(c, fiber.StatusForbidden, "Only students can file hour appeals")
+	}
+	userID, _ := c.Locals("userId").(string)
+	studentID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusForbidden, "Invalid student")
+	}
+	historyID, err := primitive.ObjectIDFromHex(c.FormValue("historyId"))
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid historyId format")
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Failed to read form: "+err.Error())
+	}
+	files := form.File["files"]
+	if len(files) > hourhistory.MaxHourAppealEvidence {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Too many evidence files")
+	}
+	for _, file := range files {
+		if !appealEvidenceExts[strings.ToLower(filepath.Ext(file.Filename))] {
+			return utils.HandleError(c, fiber.StatusBadRequest, "Invalid file type. Only pdf, jpg, jpeg, png, webp files are allowed")
+		}
+	}
+
+	if err := os.MkdirAll(appealEvidencePath, 0755); err != nil {
+		return utils.HandleError(c, fiber.StatusInternalServerError, "Failed to create directory: "+err.Error())
+	}
+	evidence := []string{}
+	removeEvidence := func() {
+		for _, name := range evidence {
+			if err := os.Remove(filepath.Join(appealEvidencePath, name)); err != nil {
+				log.Println("Failed to remove uploaded file:", err)
+			}
+		}
+	}
+	for _, file := range files {
+		name := primitive.NewObjectID().Hex() + strings.ToLower(filepath.Ext(file.Filename))
+		if err := c.SaveFile(file, filepath.Join(appealEvidencePath, name)); err != nil {
+			removeEvidence()
+			return utils.HandleError(c, fiber.StatusInternalServerError, "Failed to save file: "+err.Error())
+		}
+		evidence = append(evidence, name)
+	}
+
+	appeal, err := hourhistory.CreateHourAppeal(c.Context(), studentID, historyID, c.FormValue("reason"), evidence)
+	if err != nil {
+		// 🔥 ลบไฟล์ที่อัปโหลดหากเกิดข้อผิดพลาด
+		removeEvidence()
+		return hourAppealError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(appeal)
+}
+
+// GetHourAppeals รายการคำร้อง (ล่าสุดก่อน) — นิสิตเห็นเฉพาะของตัวเอง
+// @Summary List hour appeals
+// @Tags HourHistory
+// @Produce json
+// @Security BearerAuth
+// @Param query query models.PaginationParams true "Pagination parameters"
+// @Param filters query models.HourAppealFilters true "Filter parameters"
+// @Success 200 {object} models.HourAppealPaginatedResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/appeals [get]
+func GetHourAppeals(c *fiber.Ctx) error {
+	params := models.DefaultPagination()
+	if err := c.QueryParser(&params); err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid query parameters")
+	}
+	var filters models.HourAppealFilters
+	if err := c.QueryParser(&filters); err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid filter parameters")
+	}
+	if utils.RoleFromCtx(c) != "Admin" {
+		filters.StudentID, _ = c.Locals("userId").(string)
+	}
+
+	skip := (params.Page - 1) * params.Limit
+	appeals, totalCount, err := hourhistory.ListHourAppeals(c.Context(), filters, params.Limit, skip)
+	if err != nil {
+		return hourAppealError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.HourAppealPaginatedResponse{
+		Data: appeals,
+		Meta: models.PaginationMeta{
+			Page:       params.Page,
+			Limit:      params.Limit,
+			Total:      totalCount,
+			TotalPages: int(math.Ceil(float64(totalCount) / float64(params.Limit))),
+		},
+	})
+}
+
+// GetHourAppeal รายละเอียดคำร้อง (นิสิตเจ้าของคำร้องหรือ Admin)
+// @Summary Get an hour appeal
+// @Tags HourHistory
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Appeal ID"
+// @Success 200 {object} models.HourAppeal
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/appeals/{id} [get]
+func GetHourAppeal(c *fiber.Ctx) error {
+	appeal, err := accessibleHourAppeal(c)
+	if err != nil {
+		return hourAppealError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(appeal)
+}
+
+// GetHourAppealEvidence ดาวน์โหลดไฟล์หลักฐานของคำร้อง (นิสิตเจ้าของคำร้องหรือ Admin)
+// @Summary Download an hour appeal evidence file
+// @Tags HourHistory
+// @Produce octet-stream
+// @Security BearerAuth
+// @Param id path string true "Appeal ID"
+// @Param file path string true "Evidence file name"
+// @Success 200 {file} file "Evidence file"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /hour-history/appeals/{id}/evidence/{file} [get]
+func GetHourAppealEvidence(c *fiber.Ctx) error {
+	appeal, err := accessibleHourAppeal(c)
+	if err != nil {
+		return hourAppealError(c, err)
+	}
+	name := c.Params("file")
+	if !slices.Contains(appeal.Evidence, name) {
+		return utils.HandleError(c, fiber.StatusNotFound, "Evidence file not found")
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.SendFile(filepath.Join(appealEvidencePath, name))
+}
+
+// WithdrawHourAppeal นิสิตถอนคำร้องที่ยังรอพิจารณา
+// @Summary Withdraw a pending hour appeal
+// @Tags HourHistory
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Appeal ID"
+// @Success 200 {object} models.HourAppeal
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/appeals/{id}/withdraw [post]
+func WithdrawHourAppeal(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid ID format")
+	}
+	userID, _ := c.Locals("userId").(string)
+	studentID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusForbidden, "Invalid student")
+	}
+	appeal, err := hourhistory.WithdrawHourAppeal(c.Context(), id, studentID)
+	if err != nil {
+		return hourAppealError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(appeal)
+}
+
+// ApproveHourAppeal อนุมัติคำร้อง — ปรับ hour history (ลง ledger) แล้วแจ้งผลนิสิตทางอีเมล
+// @Summary Approve an hour appeal
+// @Description hourChange ไม่ส่ง = ชั่วโมงเต็มของรายการ (expectedHours)
+// @Tags HourHistory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Appeal ID"
+// @Param body body models.HourAppealDecisionRequest false "Granted hours and note"
+// @Success 200 {object} models.HourAppeal
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/appeals/{id}/approve [post]
+func ApproveHourAppeal(c *fiber.Ctx) error {
+	return decideHourAppeal(c, true)
+}
+
+// RejectHourAppeal ไม่อนุมัติคำร้อง (ต้องระบุหมายเหตุ) — hour history คงเดิม แล้วแจ้งผลนิสิตทางอีเมล
+// @Summary Reject an hour appeal
+// @Tags HourHistory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Appeal ID"
+// @Param body body models.HourAppealDecisionRequest true "Note"
+// @Success 200 {object} models.HourAppeal
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/appeals/{id}/reject [post]
+func RejectHourAppeal(c *fiber.Ctx) error {
+	return decideHourAppeal(c, false)
+}
+
+func decideHourAppeal(c *fiber.Ctx, approve bool) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid ID format")
+	}
+	var input models.HourAppealDecisionRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return utils.HandleError(c, fiber.StatusBadRequest, "Invalid request body")
+		}
+	}
+	appeal, err := hourhistory.DecideHourAppeal(c.Context(), id, input, approve, utils.ActorFromCtx(c))
+	if err != nil {
+		return hourAppealError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(appeal)
+}
+
+// accessibleHourAppeal คำร้องตาม :id ที่ผู้เรียกดูได้ (Admin หรือนิสิตเจ้าของ)
+func accessibleHourAppeal(c *fiber.Ctx) (*models.HourAppeal, error) {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid ID format", hourhistory.ErrInvalidHourAppeal)
+	}
+	appeal, err := hourhistory.GetHourAppeal(c.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+	if utils.RoleFromCtx(c) != "Admin" {
+		if userID, _ := c.Locals("userId").(string); userID != appeal.StudentID.Hex() {
+			return nil, hourhistory.ErrHourAppealForbidden
+		}
+	}
+	return appeal, nil
+}
+
+func hourAppealError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, hourhistory.ErrInvalidHourAppeal):
+		return utils.HandleError(c, fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, hourhistory.ErrHourAppealForbidden):
+		return utils.HandleError(c, fiber.StatusForbidden, err.Error())
+	case errors.Is(err, hourhistory.ErrHourAppealNotFound), errors.Is(err, hourhistory.ErrHourHistoryNotFound):
+		return utils.HandleError(c, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, hourhistory.ErrHourAppealStateInvalid), errors.Is(err, hourhistory.ErrHourAppealNotAllowed):
+		return utils.HandleError(c, fiber.StatusConflict, err.Error())
+	}
+	return utils.HandleError(c, fiber.StatusInternalServerError, err.Error())
+}
@@ -4,7 +4,9 @@ import (
 	"Backend-Bluelock-007/src/models"
 	hourhistory "Backend-Bluelock-007/src/services/hour-history"
 	"Backend-Bluelock-007/src/utils"
+	"errors"
 	"math"
+	"path/filepath"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
@@ -58,6 +60,26 @@ func GetHourHistoryWithDetails(c *fiber.Ctx) error {
 		}
 	}
 
+	// Parse academic term range (optional, e.g. fromTerm=2567/1&toTerm=2567/2)
+	var terms models.AcademicTermRange
+	if filters.FromTerm != "" {
+		term, err := models.ParseAcademicTerm(filters.FromTerm)
+		if err != nil {
+			return utils.HandleError(c, fiber.StatusBadRequest, "fromTerm: "+err.Error())
+		}
+		terms.From = &term
+	}
+	if filters.ToTerm != "" {
+		term, err := models.ParseAcademicTerm(filters.ToTerm)
+		if err != nil {
+			return utils.HandleError(c, fiber.StatusBadRequest, "toTerm: "+err.Error())
+		}
+		terms.To = &term
+	}
+	if terms.From != nil && terms.To != nil && terms.To.Before(*terms.From) {
+		return utils.HandleError(c, fiber.StatusBadRequest, "toTerm must not be before fromTerm")
+	}
+
 	// Calculate skip for pagination
 	skip := (params.Page - 1) * params.Limit
 
@@ -68,6 +90,7 @@ func GetHourHistoryWithDetails(c *fiber.Ctx) error {
 		filters.SourceType,
 		statuses,
 		filters.Search,
+		terms,
 		params.Limit,
 		skip,
 	)
@@ -117,6 +140,37 @@ func GetStudentHoursSummary(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(summary)
 }
 
+// GetStudentTermHours ชั่วโมงของนิสิตแยกตามภาคการศึกษา เทียบกับขั้นต่ำ / เพดานต่อภาค
+// @Summary Get a student's hours per academic term
+// @Description ชั่วโมงที่ได้และที่นับรวม (หลังจำกัดเพดานต่อภาค) ของทุกภาคตั้งแต่ปีที่เข้าศึกษา พร้อมธงต่ำกว่าขั้นต่ำของภาคปกติ
+// @Tags HourHistory
+// @Produce json
+// @Param studentId query string true "Student ID"
+// @Success 200 {object} models.HourTermReport
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/terms [get]
+func GetStudentTermHours(c *fiber.Ctx) error {
+	studentIDStr := c.Query("studentId")
+	if studentIDStr == "" {
+		return utils.HandleError(c, fiber.StatusBadRequest, "studentId is required")
+	}
+	studentID, err := primitive.ObjectIDFromHex(studentIDStr)
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid studentId format")
+	}
+
+	report, err := hourhistory.GetStudentTermSummary(c.Context(), studentID)
+	if err != nil {
+		if errors.Is(err, hourhistory.ErrStudentNotFound) {
+			return utils.HandleError(c, fiber.StatusNotFound, err.Error())
+		}
+		return utils.HandleError(c, fiber.StatusInternalServerError, err.Error())
+	}
+	return c.Status(fiber.StatusOK).JSON(report)
+}
+
 // CreateDirectHourChange สร้างการเปลี่ยนแปลงชั่วโมงโดยตรงโดย Admin
 // @Summary Create direct hour change by admin
 // @Description สร้างการเปลี่ยนแปลงชั่วโมงโดยตรงโดย Admin โดยไม่ต้องผ่าน program หรือ certificate
@@ -125,7 +179,11 @@ func GetStudentHoursSummary(c *fiber.Ctx) error {
 // @Produce json
 // @Param body body models.CreateDirectHourChangeRequest true "Direct hour change data"
 // @Success 201 {object} models.HourChangeHistory
+// @Success 202 {object} models.HourChangeHistory "เกิน HOUR_ADJUSTMENT_APPROVAL_THRESHOLD — รอแอดมินอีกคนอนุมัติ (pending_approval)"
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /hour-history/direct [post]
 func CreateDirectHourChange(c *fiber.Ctx) error {
@@ -168,29 +226,504 @@ func CreateDirectHourChange(c *fiber.Ctx) error {
 		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid studentId format")
 	}
 
-	// Create direct hour change
-	history, err := hourhistory.CreateHourChangeHistory(
+	// Create direct hour change (เกินเกณฑ์ = รอแอดมินอีกคนอนุมัติ)
+	history, err := hourhistory.CreateDirectHourAdjustment(
 		ctx,
 		studentID,
 		req.SourceType,
-		nil, // ไม่มี sourceID สำหรับ manual entry
 		req.SkillType,
-		models.HCStatusManual,
 		req.HourChange,
 		req.Title,
 		req.Remark,
-		nil, // ไม่มี enrollmentID
-		nil, // ไม่มี programItemID
+		utils.ActorFromCtx(c),
 	)
+	if err != nil {
+		if errors.Is(err, hourhistory.ErrStudentNotFound) {
+			return utils.HandleError(c, fiber.StatusNotFound, err.Error())
+		}
+		return utils.HandleError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	if history.Status == models.HCStatusPendingApproval {
+		return c.Status(fiber.StatusAccepted).JSON(history)
+	}
+	return c.Status(fiber.StatusCreated).JSON(history)
+}
+
+// PreviewProgramHours ทดลองประเมินชั่วโมงของทุกคนในกิจกรรมตามเกณฑ์ (ไม่บันทึก)
+// @Summary Preview hour grading for a program
+// @Description "what-if" ประเมินชั่วโมงด้วย engine เดียวกับตอนกิจกรรมเสร็จสิ้น — ส่ง body เป็น hourPolicy เพื่อทดลองเกณฑ์ใหม่ หรือไม่ส่งเพื่อใช้เกณฑ์ที่บันทึกไว้
+// @Tags HourHistory
+// @Accept json
+// @Produce json
+// @Param programId path string true "Program ID"
+// @Param body body models.HourPolicy false "Hour policy to try"
+// @Success 200 {object} models.HourPolicyPreview
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/programs/{programId}/preview [post]
+func PreviewProgramHours(c *fiber.Ctx) error {
+	programID, err := primitive.ObjectIDFromHex(c.Params("programId"))
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid programId format")
+	}
+
+	var override *models.HourPolicy
+	if len(c.Body()) > 0 {
+		override = &models.HourPolicy{}
+		if err := c.BodyParser(override); err != nil {
+			return utils.HandleError(c, fiber.StatusBadRequest, "Invalid request body")
+		}
+	}
 
+	preview, err := hourhistory.PreviewProgramHours(c.Context(), programID, override)
 	if err != nil {
+		switch {
+		case errors.Is(err, hourhistory.ErrInvalidHourPolicy):
+			return utils.HandleError(c, fiber.StatusBadRequest, err.Error())
+		case errors.Is(err, hourhistory.ErrProgramNotFound):
+			return utils.HandleError(c, fiber.StatusNotFound, err.Error())
+		}
 		return utils.HandleError(c, fiber.StatusInternalServerError, err.Error())
 	}
+	return c.Status(fiber.StatusOK).JSON(preview)
+}
+
+// GetHourLedger ดึงรายการ ledger ชั่วโมง (append-only) ของนิสิต / hour history
+// @Summary Get hour ledger entries
+// @Description ประวัติการเปลี่ยนแปลงชั่วโมงที่แก้ไขไม่ได้ — ทุกการแก้ไขเป็น reversal + entry ใหม่ พร้อมผู้บันทึกและเหตุผล
+// @Tags HourHistory
+// @Produce json
+// @Param query query models.PaginationParams true "Pagination parameters"
+// @Param studentId query string false "Student ID"
+// @Param historyId query string false "Hour history ID"
+// @Success 200 {object} models.HourLedgerPaginatedResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/ledger [get]
+func GetHourLedger(c *fiber.Ctx) error {
+	params := models.DefaultPagination()
+	if err := c.QueryParser(&params); err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid query parameters")
+	}
 
-	// Update student status based on new hours
-	if err := hourhistory.UpdateStudentStatus(ctx, studentID); err != nil {
-		return utils.HandleError(c, fiber.StatusInternalServerError, "Failed to update student status: "+err.Error())
+	studentID, err := optionalObjectIDQuery(c, "studentId")
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid studentId format")
+	}
+	historyID, err := optionalObjectIDQuery(c, "historyId")
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid historyId format")
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(history)
+	skip := (params.Page - 1) * params.Limit
+	entries, totalCount, err := hourhistory.GetLedgerEntries(c.Context(), studentID, historyID, params.Limit, skip)
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.HourLedgerPaginatedResponse{
+		Data: entries,
+		Meta: models.PaginationMeta{
+			Page:       params.Page,
+			Limit:      params.Limit,
+			Total:      totalCount,
+			TotalPages: int(math.Ceil(float64(totalCount) / float64(params.Limit))),
+		},
+	})
+}
+
+// VerifyHourLedger ตรวจสอบ ledger: hash chain (tamper), seq (gap) และความตรงกันกับ hour history (drift)
+// @Summary Verify hour ledger
+// @Description ไม่ส่ง studentId = ตรวจทั้งระบบ
+// @Tags HourHistory
+// @Produce json
+// @Param studentId query string false "Student ID"
+// @Success 200 {object} models.HourLedgerVerification
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/ledger/verify [get]
+func VerifyHourLedger(c *fiber.Ctx) error {
+	studentID, err := optionalObjectIDQuery(c, "studentId")
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid studentId format")
+	}
+
+	result, err := hourhistory.VerifyLedger(c.Context(), studentID)
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusInternalServerError, err.Error())
+	}
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// optionalObjectIDQuery อ่าน ObjectID จาก query (ว่าง = nil)
+func optionalObjectIDQuery(c *fiber.Ctx, key string) (*primitive.ObjectID, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := primitive.ObjectIDFromHex(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
+
+// ReconcileHourTotals เทียบยอดชั่วโมงที่ cache ใน Student กับ ledger (fix=true → แก้ให้ตรง)
+// @Summary Reconcile cached hour totals with the ledger
+// @Description รายงานนิสิตที่ softSkill / hardSkill ไม่ตรงกับ ledger — ส่ง fix=true เพื่อแก้และคำนวณสถานะใหม่
+// @Tags HourHistory
+// @Produce json
+// @Param fix query bool false "Fix mismatches"
+// @Success 200 {object} models.HourTotalsReconciliation
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/totals/reconcile [post]
+func ReconcileHourTotals(c *fiber.Ctx) error {
+	result, err := hourhistory.ReconcileHourTotals(c.Context(), c.QueryBool("fix"))
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusInternalServerError, err.Error())
+	}
+	return c.Status(fiber.StatusOK).JSON(result)
+}
+
+// CreateHourReverification สร้างงานประเมินชั่วโมงใหม่ของกิจกรรมที่เสร็จสิ้นแล้ว (คำนวณ diff ก่อน ยังไม่บันทึก)
+// @Summary Re-verify hours of completed programs
+// @Description ระบุ enrollmentId, programId หรือช่วงวันจัดกิจกรรม (from / to) อย่างใดอย่างหนึ่ง — worker คำนวณ diff แล้วรอยืนยันที่ /commit
+// @Tags HourHistory
+// @Accept json
+// @Produce json
+// @Param body body models.HourReverificationRequest true "Scope and reason"
+// @Success 202 {object} models.HourReverification
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/reverifications [post]
+func CreateHourReverification(c *fiber.Ctx) error {
+	var input models.HourReverificationRequest
+	if err := c.BodyParser(&input); err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	rev, err := hourhistory.CreateReverification(c.Context(), input, utils.ActorFromCtx(c))
+	if err != nil {
+		return hourReverificationError(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(rev)
+}
+
+// GetHourReverifications รายการงานประเมินชั่วโมงใหม่ (ล่าสุดก่อน)
+// @Summary List hour re-verifications
+// @Tags HourHistory
+// @Produce json
+// @Param query query models.PaginationParams true "Pagination parameters"
+// @Success 200 {object} models.HourReverificationPaginatedResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/reverifications [get]
+func GetHourReverifications(c *fiber.Ctx) error {
+	params := models.DefaultPagination()
+	if err := c.QueryParser(&params); err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid query parameters")
+	}
+
+	skip := (params.Page - 1) * params.Limit
+	revs, totalCount, err := hourhistory.ListReverifications(c.Context(), params.Limit, skip)
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.HourReverificationPaginatedResponse{
+		Data: revs,
+		Meta: models.PaginationMeta{
+			Page:       params.Page,
+			Limit:      params.Limit,
+			Total:      totalCount,
+			TotalPages: int(math.Ceil(float64(totalCount) / float64(params.Limit))),
+		},
+	})
+}
+
+// GetHourReverification ความคืบหน้าและ diff ของงานประเมินชั่วโมงใหม่
+// @Summary Get an hour re-verification with its diff report
+// @Tags HourHistory
+// @Produce json
+// @Param id path string true "Reverification ID"
+// @Success 200 {object} models.HourReverification
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/reverifications/{id} [get]
+func GetHourReverification(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid ID format")
+	}
+	rev, err := hourhistory.GetReverification(c.Context(), id)
+	if err != nil {
+		return hourReverificationError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(rev)
+}
+
+// CommitHourReverification ยืนยันบันทึก diff (สถานะต้องเป็น ready หรือ failed ระหว่างบันทึก)
+// @Summary Commit an hour re-verification
+// @Description บันทึกเฉพาะรายการที่ hour history ยังตรงกับค่าตอนคำนวณ diff (รายการที่ถูกแก้ระหว่างนั้น = stale) — งานที่ล้มเหลวระหว่างบันทึกสั่งซ้ำเพื่อบันทึกต่อได้
+// @Tags HourHistory
+// @Produce json
+// @Param id path string true "Reverification ID"
+// @Success 202 {object} models.HourReverification
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/reverifications/{id}/commit [post]
+func CommitHourReverification(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid ID format")
+	}
+	rev, err := hourhistory.CommitReverification(c.Context(), id, utils.ActorFromCtx(c))
+	if err != nil {
+		return hourReverificationError(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(rev)
+}
+
+// DiscardHourReverification ยกเลิก diff โดยไม่บันทึก (สถานะต้องเป็น ready)
+// @Summary Discard an hour re-verification
+// @Tags HourHistory
+// @Produce json
+// @Param id path string true "Reverification ID"
+// @Success 200 {object} models.HourReverification
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/reverifications/{id}/discard [post]
+func DiscardHourReverification(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid ID format")
+	}
+	rev, err := hourhistory.DiscardReverification(c.Context(), id, utils.ActorFromCtx(c))
+	if err != nil {
+		return hourReverificationError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(rev)
+}
+
+func hourReverificationError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, hourhistory.ErrInvalidReverification):
+		return utils.HandleError(c, fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, hourhistory.ErrReverificationNotFound),
+		errors.Is(err, hourhistory.ErrProgramNotFound):
+		return utils.HandleError(c, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, hourhistory.ErrReverificationStateInvalid):
+		return utils.HandleError(c, fiber.StatusConflict, err.Error())
+	}
+	return utils.HandleError(c, fiber.StatusInternalServerError, err.Error())
+}
+
+// PreviewHourAdjustments อัปโหลด CSV ปรับชั่วโมงแบบกลุ่ม → ตรวจทุกแถวและเก็บเป็น preview (ยังไม่บันทึก)
+// @Summary Upload a CSV of bulk hour adjustments
+// @Description คอลัมน์: studentCode, skillType, hourChange, title, remark — รายการที่ |hourChange| เกินเกณฑ์ (HOUR_ADJUSTMENT_APPROVAL_THRESHOLD) ต้องให้แอดมินอีกคนอนุมัติหลังบันทึก
+// @Tags HourHistory
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV file"
+// @Success 201 {object} models.HourAdjustmentBatch
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/adjustments [post]
+func PreviewHourAdjustments(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Failed to upload file: "+err.Error())
+	}
+	if !strings.EqualFold(filepath.Ext(fileHeader.Filename), ".csv") {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid file type. Only .csv files are allowed")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Failed to read file: "+err.Error())
+	}
+	defer file.Close()
+
+	batch, err := hourhistory.PreviewHourAdjustments(c.Context(), fileHeader.Filename, file, utils.ActorFromCtx(c))
+	if err != nil {
+		return hourAdjustmentError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(batch)
+}
+
+// GetHourAdjustmentBatches รายการชุดปรับชั่วโมง (ล่าสุดก่อน)
+// @Summary List bulk hour adjustment batches
+// @Tags HourHistory
+// @Produce json
+// @Param query query models.PaginationParams true "Pagination parameters"
+// @Param state query string false "preview | applying | applied | discarded | failed"
+// @Param awaitingApproval query bool false "Only batches with rows awaiting approval"
+// @Success 200 {object} models.HourAdjustmentBatchPaginatedResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/adjustments [get]
+func GetHourAdjustmentBatches(c *fiber.Ctx) error {
+	params := models.DefaultPagination()
+	if err := c.QueryParser(&params); err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid query parameters")
+	}
+
+	skip := (params.Page - 1) * params.Limit
+	batches, totalCount, err := hourhistory.ListHourAdjustmentBatches(c.Context(), c.Query("state"), c.QueryBool("awaitingApproval"), params.Limit, skip)
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.Status(fiber.StatusOK).JSON(models.HourAdjustmentBatchPaginatedResponse{
+		Data: batches,
+		Meta: models.PaginationMeta{
+			Page:       params.Page,
+			Limit:      params.Limit,
+			Total:      totalCount,
+			TotalPages: int(math.Ceil(float64(totalCount) / float64(params.Limit))),
+		},
+	})
+}
+
+// GetHourAdjustmentBatch ผลตรวจของทุกแถวในชุดปรับชั่วโมง
+// @Summary Get a bulk hour adjustment batch with its rows
+// @Tags HourHistory
+// @Produce json
+// @Param id path string true "Batch ID"
+// @Success 200 {object} models.HourAdjustmentBatch
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/adjustments/{id} [get]
+func GetHourAdjustmentBatch(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid ID format")
+	}
+	batch, err := hourhistory.GetHourAdjustmentBatch(c.Context(), id)
+	if err != nil {
+		return hourAdjustmentError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(batch)
+}
+
+// ApplyHourAdjustments บันทึกชุดปรับชั่วโมง (สถานะต้องเป็น preview และไม่มีแถวที่ผิด)
+// @Summary Apply a bulk hour adjustment batch
+// @Description สร้าง hour history ทุกแถว — แถวที่เกินเกณฑ์ได้สถานะ pending_approval และยังไม่นับรวมจนกว่าจะอนุมัติ
+// @Tags HourHistory
+// @Produce json
+// @Param id path string true "Batch ID"
+// @Success 200 {object} models.HourAdjustmentBatch
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/adjustments/{id}/apply [post]
+func ApplyHourAdjustments(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid ID format")
+	}
+	batch, err := hourhistory.ApplyHourAdjustments(c.Context(), id, utils.ActorFromCtx(c))
+	if err != nil {
+		return hourAdjustmentError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(batch)
+}
+
+// DiscardHourAdjustments ยกเลิกชุดปรับชั่วโมงที่ยังไม่บันทึก
+// @Summary Discard a bulk hour adjustment batch
+// @Tags HourHistory
+// @Produce json
+// @Param id path string true "Batch ID"
+// @Success 200 {object} models.HourAdjustmentBatch
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/adjustments/{id}/discard [post]
+func DiscardHourAdjustments(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid ID format")
+	}
+	batch, err := hourhistory.DiscardHourAdjustments(c.Context(), id, utils.ActorFromCtx(c))
+	if err != nil {
+		return hourAdjustmentError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(batch)
+}
+
+// ApproveHourAdjustments อนุมัติรายการที่รออนุมัติ (ต้องเป็นแอดมินคนละคนกับผู้อัปโหลด / ผู้บันทึก)
+// @Summary Approve bulk hour adjustments over the threshold
+// @Tags HourHistory
+// @Accept json
+// @Produce json
+// @Param id path string true "Batch ID"
+// @Param body body models.HourAdjustmentReviewRequest false "Lines to approve (empty = all pending)"
+// @Success 200 {object} models.HourAdjustmentBatch
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/adjustments/{id}/approve [post]
+func ApproveHourAdjustments(c *fiber.Ctx) error {
+	return reviewHourAdjustments(c, true)
+}
+
+// RejectHourAdjustments ปฏิเสธรายการที่รออนุมัติ (hour history เปลี่ยนเป็น rejected)
+// @Summary Reject bulk hour adjustments over the threshold
+// @Tags HourHistory
+// @Accept json
+// @Produce json
+// @Param id path string true "Batch ID"
+// @Param body body models.HourAdjustmentReviewRequest false "Lines to reject (empty = all pending)"
+// @Success 200 {object} models.HourAdjustmentBatch
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /hour-history/adjustments/{id}/reject [post]
+func RejectHourAdjustments(c *fiber.Ctx) error {
+	return reviewHourAdjustments(c, false)
+}
+
+func reviewHourAdjustments(c *fiber.Ctx, approve bool) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid ID format")
+	}
+	var input models.HourAdjustmentReviewRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return utils.HandleError(c, fiber.StatusBadRequest, "Invalid request body")
+		}
+	}
+	batch, err := hourhistory.ReviewHourAdjustments(c.Context(), id, input, approve, utils.ActorFromCtx(c))
+	if err != nil {
+		return hourAdjustmentError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(batch)
+}
+
+func hourAdjustmentError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, hourhistory.ErrInvalidHourAdjustment):
+		return utils.HandleError(c, fiber.StatusBadRequest, err.Error())
+	case errors.Is(err, hourhistory.ErrHourAdjustmentSelfApproval):
+		return utils.HandleError(c, fiber.StatusForbidden, err.Error())
+	case errors.Is(err, hourhistory.ErrHourAdjustmentNotFound):
+		return utils.HandleError(c, fiber.StatusNotFound, err.Error())
+	case errors.Is(err, hourhistory.ErrHourAdjustmentStateInvalid):
+		return utils.HandleError(c, fiber.StatusConflict, err.Error())
+	}
+	return utils.HandleError(c, fiber.StatusInternalServerError, err.Error())
 }
@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"Backend-Bluelock-007/src/models"
+	"Backend-Bluelock-007/src/services/programs"
+	"Backend-Bluelock-007/src/utils"
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetProgramApproval godoc
+// @Summary      Get program approval status
+// @Description  Current approval status (draft / submitted / changes_requested / approved) with review history
+// @Tags         programs
+// @Produce      json
+// @Param        id   path  string  true  "Program ID"
+// @Success      200  {object}  models.ProgramApproval
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Router       /programs/{id}/approval [get]
+func GetProgramApproval(c *fiber.Ctx) error {
+	programID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	approval, err := programs.GetProgramApproval(c.Context(), programID)
+	if err != nil {
+		return programApprovalError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(approval)
+}
+
+// SubmitProgramForApproval godoc
+// @Summary      Submit program for approval
+// @Description  draft / changes_requested → submitted (program must be in planning and complete enough to open)
+// @Tags         programs
+// @Accept       json
+// @Produce      json
+// @Param        id    path  string  true  "Program ID"
+// @Param        body  body  models.ProgramReviewInput  false  "Comment"
+// @Success      200  {object}  models.ProgramApproval
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Router       /programs/{id}/approval/submit [post]
+func SubmitProgramForApproval(c *fiber.Ctx) error {
+	return programApprovalAction(c, programs.SubmitProgramForApproval)
+}
+
+// ApproveProgram godoc
+// @Summary      Approve program
+// @Description  submitted → approved; records approver and time. The submitter cannot approve their own program
+// @Tags         programs
+// @Accept       json
+// @Produce      json
+// @Param        id    path  string  true  "Program ID"
+// @Param        body  body  models.ProgramReviewInput  false  "Comment"
+// @Success      200  {object}  models.ProgramApproval
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Router       /programs/{id}/approval/approve [post]
+func ApproveProgram(c *fiber.Ctx) error {
+	return programApprovalAction(c, programs.ApproveProgram)
+}
+
+// RequestProgramChanges godoc
+// @Summary      Request changes to program
+// @Description  submitted → changes_requested (comment required)
+// @Tags         programs
+// @Accept       json
+// @Produce      json
+// @Param        id    path  string  true  "Program ID"
+// @Param        body  body  models.ProgramReviewInput  true  "What to change"
+// @Success      200  {object}  models.ProgramApproval
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Router       /programs/{id}/approval/request-changes [post]
+func RequestProgramChanges(c *fiber.Ctx) error {
+	return programApprovalAction(c, programs.RequestProgramChanges)
+}
+
+// CommentOnProgram godoc
+// @Summary      Comment on program review
+// @Tags         programs
+// @Accept       json
+// @Produce      json
+// @Param        id    path  string  true  "Program ID"
+// @Param        body  body  models.ProgramReviewInput  true  "Comment"
+// @Success      200  {object}  models.ProgramApproval
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Router       /programs/{id}/approval/comments [post]
+func CommentOnProgram(c *fiber.Ctx) error {
+	return programApprovalAction(c, programs.CommentOnProgram)
+}
+
+func programApprovalAction(c *fiber.Ctx, action func(context.Context, primitive.ObjectID, string, string) (*models.ProgramApproval, error)) error {
+	programID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	var input models.ProgramReviewInput
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+		}
+	}
+	approval, err := action(c.Context(), programID, utils.ActorFromCtx(c), input.Comment)
+	if err != nil {
+		return programApprovalError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(approval)
+}
+
+func programApprovalError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, programs.ErrProgramNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, programs.ErrInvalidProgramApproval):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, programs.ErrProgramStateConflict):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
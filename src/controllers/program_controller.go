@@ -2,8 +2,12 @@ package controllers
 
 import (
 	"Backend-Bluelock-007/src/models"
+	"Backend-Bluelock-007/src/services/eligibility"
+	hourhistory "Backend-Bluelock-007/src/services/hour-history"
 	programs "Backend-Bluelock-007/src/services/programs"
+	"Backend-Bluelock-007/src/services/rooms"
 	"Backend-Bluelock-007/src/utils"
+	"errors"
 	"fmt"
 	"log"
 	"os"
@@ -38,9 +42,31 @@ func CreateProgram(c *fiber.Ctx) error {
 		return utils.HandleError(c, fiber.StatusBadRequest, "Invalid input: "+err.Error())
 	}
 
+	// ✅ ตรวจสอบ eligibilityRules และ hourPolicy ของ Program / ProgramItem
+	if err := hourhistory.ValidateHourPolicy(request.HourPolicy); err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, err.Error())
+	}
+	if err := hourhistory.ValidateEvaluation(request.EvaluationRequired, request.FormID, request.EvaluationDays); err != nil {
+		return utils.HandleError(c, fiber.StatusBadRequest, err.Error())
+	}
+	for _, item := range request.ProgramItems {
+		if err := eligibility.ValidateRules(item.EligibilityRules); err != nil {
+			return utils.HandleError(c, fiber.StatusBadRequest, err.Error())
+		}
+		if err := hourhistory.ValidateHourPolicy(item.HourPolicy); err != nil {
+			return utils.HandleError(c, fiber.StatusBadRequest, err.Error())
+		}
+	}
+
 	// บันทึก Program + Items
-	program, err := programs.CreateProgram(&request)
+	program, err := programs.CreateProgram(&request, utils.ActorFromCtx(c))
 	if err != nil {
+		switch {
+		case errors.Is(err, programs.ErrInvalidProgramTransition), errors.Is(err, rooms.ErrInvalidRoomBooking):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, rooms.ErrRoomConflict):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error(), "conflicts": roomConflicts(err)})
+		}
 		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -298,9 +324,37 @@ func UpdateProgram(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
 	}
 
+	// ✅ ตรวจสอบ eligibilityRules และ hourPolicy ของ Program / ProgramItem
+	if err := hourhistory.ValidateHourPolicy(request.HourPolicy); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := hourhistory.ValidateEvaluation(request.EvaluationRequired, request.FormID, request.EvaluationDays); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	for _, item := range request.ProgramItems {
+		if err := eligibility.ValidateRules(item.EligibilityRules); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err := hourhistory.ValidateHourPolicy(item.HourPolicy); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
 	// ✅ อัปเดต Program และ ProgramItems
-	updatedProgram, err := programs.UpdateProgram(programID, request)
+	updatedProgram, err := programs.UpdateProgram(programID, request, utils.ActorFromCtx(c))
 	if err != nil {
+		switch {
+		case errors.Is(err, rooms.ErrRoomConflict):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error(), "conflicts": roomConflicts(err)})
+		case errors.Is(err, rooms.ErrInvalidRoomBooking):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, programs.ErrProgramNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, programs.ErrInvalidProgramTransition):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, programs.ErrProgramStateConflict):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
@@ -309,10 +363,10 @@ func UpdateProgram(c *fiber.Ctx) error {
 	})
 }
 
-// DeleteProgram - ลบกิจกรรม พร้อม ProgramItems ที่เกี่ยวข้อง
+// DeleteProgram - ย้ายกิจกรรมไปถังขยะ (soft delete) ข้อมูลการลงทะเบียนและชั่วโมงยังอยู่ครบ
 // DeleteProgram - godoc
 // @Summary      Delete an program
-// @Description  Delete an program
+// @Description  Move a program to trash (soft delete). Use /trash to restore or purge
 // @Tags         programs
 // @Produce      json
 // @Param        id   path  string  true  "Program ID"
@@ -327,13 +381,16 @@ func DeleteProgram(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
 	}
 
-	// ลบ Program พร้อม ProgramItems ที่เกี่ยวข้อง
-	err = programs.DeleteProgram(programID)
+	// ย้าย Program ไปถังขยะ (ลบถาวรได้ที่ /trash)
+	err = programs.DeleteProgram(programID, utils.ActorFromCtx(c))
 	if err != nil {
+		if errors.Is(err, programs.ErrProgramNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Program and related ProgramItems were deleted "})
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Program was moved to trash"})
 }
 
 // GetAllProgramCalendar - ดึง Program และ ProgramItems ตามเดือนและปีที่ระบุ
@@ -358,3 +415,363 @@ func GetAllProgramCalendar(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusOK).JSON(calendar)
 }
+
+// ChangeProgramState - เปลี่ยนสถานะกิจกรรมผ่าน state machine
+// ChangeProgramState - godoc
+// @Summary      Change program state
+// @Description  Transition program state (planning/open/close/success). override ใช้ได้เฉพาะ Admin
+// @Tags         programs
+// @Accept       json
+// @Produce      json
+// @Param        id    path  string  true  "Program ID"
+// @Param        body  body  models.ProgramStateTransitionInput  true  "Target state"
+// @Success      200  {object}  models.ProgramStateHistory
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      403  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /programs/{id}/state [post]
+func ChangeProgramState(c *fiber.Ctx) error {
+	programID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var input models.ProgramStateTransitionInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if strings.TrimSpace(input.State) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "state is required"})
+	}
+	if input.Override && utils.RoleFromCtx(c) != "Admin" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "override requires Admin role"})
+	}
+
+	history, err := programs.TransitionProgramState(c.Context(), programID, input.State, utils.ActorFromCtx(c), input.Reason, input.Override)
+	if err != nil {
+		switch {
+		case errors.Is(err, programs.ErrProgramNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, programs.ErrInvalidProgramTransition):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, programs.ErrProgramStateConflict):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if history == nil {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Program is already in state " + programs.NormalizeProgramState(input.State)})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Program state changed successfully",
+		"data":    history,
+	})
+}
+
+// GetProgramStateHistory - ดึงประวัติการเปลี่ยนสถานะกิจกรรม
+// GetProgramStateHistory - godoc
+// @Summary      Get program state history
+// @Description  List all state transitions of a program (newest first)
+// @Tags         programs
+// @Produce      json
+// @Param        id   path  string  true  "Program ID"
+// @Success      200  {array}   models.ProgramStateHistory
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /programs/{id}/state-history [get]
+func GetProgramStateHistory(c *fiber.Ctx) error {
+	programID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	histories, err := programs.GetProgramStateHistory(programID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"data": histories})
+}
+
+// CancelProgram - ยกเลิกกิจกรรม (เก็บข้อมูลการลงทะเบียนไว้ และแจ้งนิสิตพร้อมเหตุผล)
+// CancelProgram - godoc
+// @Summary      Cancel a program
+// @Description  Move program to cancelled state, keep enrollments, mark pending hour histories as cancelled and notify enrolled students
+// @Tags         programs
+// @Accept       json
+// @Produce      json
+// @Param        id    path  string  true  "Program ID"
+// @Param        body  body  models.ProgramCancelInput  true  "Cancellation reason"
+// @Success      200  {object}  models.ProgramStateHistory
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /programs/{id}/cancel [post]
+func CancelProgram(c *fiber.Ctx) error {
+	programID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var input models.ProgramCancelInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	history, err := programs.CancelProgram(c.Context(), programID, utils.ActorFromCtx(c), input.Reason)
+	if err != nil {
+		switch {
+		case errors.Is(err, programs.ErrProgramNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, programs.ErrInvalidProgramTransition):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, programs.ErrProgramStateConflict):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if history == nil {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Program is already cancelled"})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Program cancelled successfully",
+		"data":    history,
+	})
+}
+
+// PostponeProgram - เลื่อนวันจัดกิจกรรม พร้อมตั้ง schedule ใหม่และแจ้งนิสิต
+// PostponeProgram - godoc
+// @Summary      Postpone a program
+// @Description  Move program item dates (and optionally endDateEnroll), reschedule jobs and notify enrolled students
+// @Tags         programs
+// @Accept       json
+// @Produce      json
+// @Param        id    path  string  true  "Program ID"
+// @Param        body  body  models.ProgramPostponeInput  true  "New schedule"
+// @Success      200  {object}  models.ProgramDto
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /programs/{id}/postpone [post]
+func PostponeProgram(c *fiber.Ctx) error {
+	programID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var input models.ProgramPostponeInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	program, err := programs.PostponeProgram(c.Context(), programID, input, utils.ActorFromCtx(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, rooms.ErrRoomConflict):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error(), "conflicts": roomConflicts(err)})
+		case errors.Is(err, rooms.ErrInvalidRoomBooking):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, programs.ErrProgramNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, programs.ErrInvalidProgramTransition), errors.Is(err, programs.ErrInvalidProgramSchedule):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Program postponed successfully",
+		"data":    program,
+	})
+}
+
+// DuplicateProgram - คัดลอกกิจกรรมพร้อมกิจกรรมย่อย ตัวเลือกอาหาร และฟอร์ม
+// DuplicateProgram - godoc
+// @Summary      Duplicate a program
+// @Description  Copy a program with all program items, food options and its form as a new planning program, optionally shifting every date
+// @Tags         programs
+// @Accept       json
+// @Produce      json
+// @Param        id    path  string  true  "Program ID"
+// @Param        body  body  models.DuplicateProgramInput  false  "Duplicate options"
+// @Success      201  {object}  models.ProgramDto
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /programs/{id}/duplicate [post]
+func DuplicateProgram(c *fiber.Ctx) error {
+	programID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	var input models.DuplicateProgramInput
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+		}
+	}
+
+	program, err := programs.DuplicateProgram(c.Context(), programID, input, utils.ActorFromCtx(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, rooms.ErrRoomConflict):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error(), "conflicts": roomConflicts(err)})
+		case errors.Is(err, rooms.ErrInvalidRoomBooking):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, programs.ErrProgramNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, programs.ErrInvalidProgramTransition):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Program duplicated successfully",
+		"data":    program,
+	})
+}
+
+// CreateProgramSeries - สร้างกิจกรรมจัดซ้ำจากกิจกรรมต้นแบบตาม recurrence rule
+// CreateProgramSeries - godoc
+// @Summary      Create a recurring program series
+// @Description  Generate one program per occurrence (weekly or custom dates) by cloning the source program and shifting its dates
+// @Tags         programs
+// @Accept       json
+// @Produce      json
+// @Param        body  body  models.CreateProgramSeriesInput  true  "Source program and recurrence rule"
+// @Success      201  {object}  models.ProgramSeriesDetail
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /programs/series [post]
+func CreateProgramSeries(c *fiber.Ctx) error {
+	var input models.CreateProgramSeriesInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	series, err := programs.CreateProgramSeries(c.Context(), input, utils.ActorFromCtx(c))
+	if err != nil {
+		return programSeriesError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Program series created successfully",
+		"data":    series,
+	})
+}
+
+// GetProgramSeries - ดึง series พร้อมกิจกรรมทุกครั้ง
+// GetProgramSeries - godoc
+// @Summary      Get a program series
+// @Description  Get a recurring program series with its programs ordered by occurrence
+// @Tags         programs
+// @Produce      json
+// @Param        seriesId  path  string  true  "Series ID"
+// @Success      200  {object}  models.ProgramSeriesDetail
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /programs/series/{seriesId} [get]
+func GetProgramSeries(c *fiber.Ctx) error {
+	seriesID, err := primitive.ObjectIDFromHex(c.Params("seriesId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	series, err := programs.GetProgramSeries(c.Context(), seriesID)
+	if err != nil {
+		return programSeriesError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(series)
+}
+
+// UpdateProgramInSeries - แก้ไขกิจกรรมใน series (scope=this แก้ครั้งนี้, scope=all แก้ทุกครั้งที่ยังไม่จบ)
+// UpdateProgramInSeries - godoc
+// @Summary      Update a program in a series
+// @Description  scope=this updates only this program; scope=all also applies non-date fields to every unfinished program in the series
+// @Tags         programs
+// @Accept       json
+// @Produce      json
+// @Param        id     path   string  true   "Program ID"
+// @Param        scope  query  string  false  "this | all (default this)"
+// @Param        program  body  models.ProgramDto  true  "Program object"
+// @Success      200  {array}   models.ProgramDto
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /programs/{id}/series [put]
+func UpdateProgramInSeries(c *fiber.Ctx) error {
+	programID, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+
+	scope := strings.ToLower(c.Query("scope", models.SeriesEditThis))
+	if scope != models.SeriesEditThis && scope != models.SeriesEditAll {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "scope must be 'this' or 'all'"})
+	}
+
+	var request models.ProgramDto
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := hourhistory.ValidateHourPolicy(request.HourPolicy); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := hourhistory.ValidateEvaluation(request.EvaluationRequired, request.FormID, request.EvaluationDays); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	for _, item := range request.ProgramItems {
+		if err := eligibility.ValidateRules(item.EligibilityRules); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err := hourhistory.ValidateHourPolicy(item.HourPolicy); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	updated, err := programs.UpdateProgramInSeries(c.Context(), programID, request, scope, utils.ActorFromCtx(c))
+	if err != nil {
+		return programSeriesError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"data": updated,
+	})
+}
+
+// programSeriesError แปลง error ของ series / program เป็น HTTP status
+func programSeriesError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, rooms.ErrRoomConflict):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error(), "conflicts": roomConflicts(err)})
+	case errors.Is(err, rooms.ErrInvalidRoomBooking):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, programs.ErrProgramNotFound), errors.Is(err, programs.ErrProgramSeriesNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, programs.ErrInvalidRecurrenceRule), errors.Is(err, programs.ErrInvalidProgramTransition):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, programs.ErrProgramStateConflict):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
+
+// roomConflicts รายการการจองห้องที่ชนกัน (จาก rooms.ConflictError) สำหรับแสดงฝั่ง client
+func roomConflicts(err error) []models.RoomConflict {
+	var conflictErr *rooms.ConflictError
+	if errors.As(err, &conflictErr) {
+		return conflictErr.Conflicts
+	}
+	return nil
+}
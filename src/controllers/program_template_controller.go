@@ -0,0 +1,264 @@
+package controllers
+
+import (
+	"Backend-Bluelock-007/src/models"
+	"Backend-Bluelock-007/src/services/eligibility"
+	hourhistory "Backend-Bluelock-007/src/services/hour-history"
+	programs "Backend-Bluelock-007/src/services/programs"
+	"Backend-Bluelock-007/src/services/rooms"
+	"Backend-Bluelock-007/src/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetProgramTemplates godoc
+// @Summary      List program templates
+// @Description  List the latest version of every program template
+// @Tags         program-templates
+// @Produce      json
+// @Success      200  {array}   models.ProgramTemplate
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /program-templates [get]
+func GetProgramTemplates(c *fiber.Ctx) error {
+	templates, err := programs.ListProgramTemplates(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(templates)
+}
+
+// GetProgramTemplate godoc
+// @Summary      Get a program template
+// @Description  Get the latest version of a program template
+// @Tags         program-templates
+// @Produce      json
+// @Param        id   path  string  true  "Template ID"
+// @Success      200  {object}  models.ProgramTemplate
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /program-templates/{id} [get]
+func GetProgramTemplate(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	template, err := programs.GetProgramTemplate(c.Context(), id)
+	if err != nil {
+		return programTemplateError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(template)
+}
+
+// GetProgramTemplateVersions godoc
+// @Summary      List program template versions
+// @Description  List every saved version of a program template (newest first)
+// @Tags         program-templates
+// @Produce      json
+// @Param        id   path  string  true  "Template ID"
+// @Success      200  {array}   models.ProgramTemplateVersion
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /program-templates/{id}/versions [get]
+func GetProgramTemplateVersions(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	versions, err := programs.GetProgramTemplateVersions(c.Context(), id)
+	if err != nil {
+		return programTemplateError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(versions)
+}
+
+// GetProgramTemplateVersion godoc
+// @Summary      Get a program template version
+// @Description  Get one saved version of a program template
+// @Tags         program-templates
+// @Produce      json
+// @Param        id       path  string  true  "Template ID"
+// @Param        version  path  int     true  "Version"
+// @Success      200  {object}  models.ProgramTemplateVersion
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /program-templates/{id}/versions/{version} [get]
+func GetProgramTemplateVersion(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	version, err := c.ParamsInt("version")
+	if err != nil || version <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid version"})
+	}
+	snapshot, err := programs.GetProgramTemplateVersion(c.Context(), id, version)
+	if err != nil {
+		return programTemplateError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(snapshot)
+}
+
+// CreateProgramTemplate godoc
+// @Summary      Create a program template
+// @Description  Save reusable program content (item structure without dates) as version 1
+// @Tags         program-templates
+// @Accept       json
+// @Produce      json
+// @Param        body  body  models.ProgramTemplateInput  true  "Template"
+// @Success      201  {object}  models.ProgramTemplate
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /program-templates [post]
+func CreateProgramTemplate(c *fiber.Ctx) error {
+	var input models.ProgramTemplateInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := hourhistory.ValidateHourPolicy(input.Content.HourPolicy); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := hourhistory.ValidateEvaluation(input.Content.EvaluationRequired, input.Content.FormID, input.Content.EvaluationDays); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	for _, item := range input.Content.Items {
+		if err := eligibility.ValidateRules(item.EligibilityRules); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err := hourhistory.ValidateHourPolicy(item.HourPolicy); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	template, err := programs.CreateProgramTemplate(c.Context(), input, utils.ActorFromCtx(c))
+	if err != nil {
+		return programTemplateError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Program template created successfully",
+		"data":    template,
+	})
+}
+
+// UpdateProgramTemplate godoc
+// @Summary      Update a program template
+// @Description  Save a new version of a program template (older versions stay available)
+// @Tags         program-templates
+// @Accept       json
+// @Produce      json
+// @Param        id    path  string  true  "Template ID"
+// @Param        body  body  models.ProgramTemplateInput  true  "Template"
+// @Success      200  {object}  models.ProgramTemplate
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /program-templates/{id} [put]
+func UpdateProgramTemplate(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	var input models.ProgramTemplateInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	if err := hourhistory.ValidateHourPolicy(input.Content.HourPolicy); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := hourhistory.ValidateEvaluation(input.Content.EvaluationRequired, input.Content.FormID, input.Content.EvaluationDays); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	for _, item := range input.Content.Items {
+		if err := eligibility.ValidateRules(item.EligibilityRules); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		if err := hourhistory.ValidateHourPolicy(item.HourPolicy); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	template, err := programs.UpdateProgramTemplate(c.Context(), id, input, utils.ActorFromCtx(c))
+	if err != nil {
+		return programTemplateError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Program template updated successfully",
+		"data":    template,
+	})
+}
+
+// DeleteProgramTemplate godoc
+// @Summary      Delete a program template
+// @Description  Hide a program template from the library (programs keep their template reference)
+// @Tags         program-templates
+// @Produce      json
+// @Param        id   path  string  true  "Template ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /program-templates/{id} [delete]
+func DeleteProgramTemplate(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	if err := programs.DeleteProgramTemplate(c.Context(), id); err != nil {
+		return programTemplateError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Program template deleted successfully"})
+}
+
+// CreateProgramFromTemplate godoc
+// @Summary      Create a program from a template
+// @Description  Create a program from a template version plus item dates; the program records templateId and templateVersion
+// @Tags         program-templates
+// @Accept       json
+// @Produce      json
+// @Param        id    path  string  true  "Template ID"
+// @Param        body  body  models.ProgramFromTemplateInput  true  "Dates and overrides"
+// @Success      201  {object}  models.ProgramDto
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /program-templates/{id}/programs [post]
+func CreateProgramFromTemplate(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	var input models.ProgramFromTemplateInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+
+	program, err := programs.CreateProgramFromTemplate(c.Context(), id, input, utils.ActorFromCtx(c))
+	if err != nil {
+		return programTemplateError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Program created from template successfully",
+		"data":    program,
+	})
+}
+
+// programTemplateError แปลง error ของเทมเพลตเป็น HTTP status
+func programTemplateError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, rooms.ErrRoomConflict):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error(), "conflicts": roomConflicts(err)})
+	case errors.Is(err, rooms.ErrInvalidRoomBooking):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, programs.ErrProgramTemplateNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, programs.ErrInvalidProgramTemplate),
+		errors.Is(err, programs.ErrInvalidProgramSchedule),
+		errors.Is(err, programs.ErrInvalidProgramTransition):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"Backend-Bluelock-007/src/models"
+	"Backend-Bluelock-007/src/services/rooms"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetRooms godoc
+// @Summary      List rooms and resources
+// @Description  List the rooms / resources catalog
+// @Tags         rooms
+// @Produce      json
+// @Success      200  {array}   models.Room
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /rooms [get]
+func GetRooms(c *fiber.Ctx) error {
+	list, err := rooms.ListRooms(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(list)
+}
+
+// GetRoomByID godoc
+// @Summary      Get a room
+// @Tags         rooms
+// @Produce      json
+// @Param        id   path  string  true  "Room ID"
+// @Success      200  {object}  models.Room
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Router       /rooms/{id} [get]
+func GetRoomByID(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	room, err := rooms.GetRoom(c.Context(), id)
+	if err != nil {
+		return roomError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(room)
+}
+
+// CreateRoom godoc
+// @Summary      Create a room
+// @Description  Add a room or resource with capacity to the catalog
+// @Tags         rooms
+// @Accept       json
+// @Produce      json
+// @Param        body  body  models.RoomInput  true  "Room"
+// @Success      201  {object}  models.Room
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /rooms [post]
+func CreateRoom(c *fiber.Ctx) error {
+	var input models.RoomInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	room, err := rooms.CreateRoom(c.Context(), input)
+	if err != nil {
+		return roomError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(room)
+}
+
+// UpdateRoom godoc
+// @Summary      Update a room
+// @Tags         rooms
+// @Accept       json
+// @Produce      json
+// @Param        id    path  string  true  "Room ID"
+// @Param        body  body  models.RoomInput  true  "Room"
+// @Success      200  {object}  models.Room
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /rooms/{id} [put]
+func UpdateRoom(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	var input models.RoomInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	room, err := rooms.UpdateRoom(c.Context(), id, input)
+	if err != nil {
+		return roomError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(room)
+}
+
+// DeleteRoom godoc
+// @Summary      Delete a room
+// @Description  Remove a room from the catalog (existing bookings keep their reference)
+// @Tags         rooms
+// @Produce      json
+// @Param        id   path  string  true  "Room ID"
+// @Success      200  {object}  map[string]string
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Router       /rooms/{id} [delete]
+func DeleteRoom(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	if err := rooms.DeleteRoom(c.Context(), id); err != nil {
+		return roomError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Room deleted successfully"})
+}
+
+// GetRoomsAvailability godoc
+// @Summary      Room availability calendar
+// @Description  Bookings of every room (or one room with roomId) between from and to
+// @Tags         rooms
+// @Produce      json
+// @Param        from    query  string  true   "YYYY-MM-DD"
+// @Param        to      query  string  true   "YYYY-MM-DD"
+// @Param        roomId  query  string  false  "Room ID"
+// @Success      200  {array}   models.RoomAvailability
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Router       /rooms/availability [get]
+func GetRoomsAvailability(c *fiber.Ctx) error {
+	var roomID *primitive.ObjectID
+	if raw := c.Query("roomId"); raw != "" {
+		id, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid roomId"})
+		}
+		roomID = &id
+	}
+	availability, err := rooms.GetAvailability(c.Context(), roomID, c.Query("from"), c.Query("to"))
+	if err != nil {
+		return roomError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(availability)
+}
+
+// CheckRoomConflicts godoc
+// @Summary      Check room double-booking
+// @Description  Resolve rooms of program items and list bookings that overlap (for warnings before saving)
+// @Tags         rooms
+// @Accept       json
+// @Produce      json
+// @Param        body  body  models.RoomConflictCheckInput  true  "Program items"
+// @Success      200  {object}  map[string]interface{}  "conflicts + programItems with resolved rooms"
+// @Failure      400  {object}  models.ErrorResponse
+// @Router       /rooms/check-conflicts [post]
+func CheckRoomConflicts(c *fiber.Ctx) error {
+	var input models.RoomConflictCheckInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	var programID primitive.ObjectID
+	if input.ProgramID != "" {
+		id, err := primitive.ObjectIDFromHex(input.ProgramID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid programId"})
+		}
+		programID = id
+	}
+
+	if err := rooms.NormalizeItemRooms(c.Context(), input.ProgramItems); err != nil {
+		return roomError(c, err)
+	}
+	conflicts, err := rooms.FindConflicts(c.Context(), programID, input.ProgramItems)
+	if err != nil {
+		return roomError(c, err)
+	}
+	if conflicts == nil {
+		conflicts = []models.RoomConflict{}
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"conflicts":    conflicts,
+		"programItems": input.ProgramItems,
+	})
+}
+
+// roomError แปลง error ของ rooms เป็น HTTP status
+func roomError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, rooms.ErrRoomNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, rooms.ErrInvalidRoom), errors.Is(err, rooms.ErrInvalidRoomBooking):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"Backend-Bluelock-007/src/services/search"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// SearchPrograms godoc
+// @Summary      Full-text search programs
+// @Description  Search program names and program item names / descriptions / operators, ranked by relevance
+// @Tags         programs
+// @Produce      json
+// @Param        q      query  string  true   "Search text"
+// @Param        limit  query  int     false  "Max results (default 20, max 100)"
+// @Success      200  {array}   models.ProgramSearchHit
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /programs/search [get]
+func SearchPrograms(c *fiber.Ctx) error {
+	hits, err := search.SearchPrograms(c.Context(), c.Query("q"), c.QueryInt("limit"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(hits)
+}
+
+// SearchStudents godoc
+// @Summary      Full-text search students
+// @Description  Search student codes and Thai / English names, ranked by relevance
+// @Tags         students
+// @Produce      json
+// @Param        q      query  string  true   "Search text"
+// @Param        limit  query  int     false  "Max results (default 20, max 100)"
+// @Success      200  {array}   models.StudentSearchHit
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /students/search [get]
+func SearchStudents(c *fiber.Ctx) error {
+	hits, err := search.SearchStudents(c.Context(), c.Query("q"), c.QueryInt("limit"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(hits)
+}
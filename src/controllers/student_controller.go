@@ -2,13 +2,17 @@ package controllers
 
 import (
 	"Backend-Bluelock-007/src/models"
+	hourhistory "Backend-Bluelock-007/src/services/hour-history"
 	"Backend-Bluelock-007/src/services/students"
+	"Backend-Bluelock-007/src/utils"
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // CreateStudent godoc
@@ -76,19 +80,26 @@ func CreateStudent(c *fiber.Ctx) error {
 	}
 
 	// ---------- เริ่มบันทึก (รองรับทั้ง create และ update) ----------
+	requirements, err := hourhistory.LoadGraduationRequirements(c.Context())
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
 	var failed []string
 	var created []string
 	var updated []string
 
 	for _, s := range req {
+		major := mapMajor(s.Major)
+		entryYear := models.EntryYearFromCode(s.Code)
 		stu := models.Student{
 			Code:      s.Code,
 			Name:      s.Name,
 			EngName:   cleanName(s.EngName),
-			Status:    calculateStatus(s.SoftSkill, s.HardSkill),
+			Status:    requirements.For(major, entryYear).StatusFor(s.SoftSkill, s.HardSkill),
 			SoftSkill: s.SoftSkill,
 			HardSkill: s.HardSkill,
-			Major:     mapMajor(s.Major),
+			Major:     major,
+			EntryYear: entryYear,
 		}
 		usr := models.User{
 			Email:    strings.ToLower(s.Code + "@go.buu.ac.th"),
@@ -263,7 +274,7 @@ func UpdateStudent(c *fiber.Ctx) error {
 
 // DeleteStudent godoc
 // @Summary Delete student
-// @Description Delete a student by ID
+// @Description Move a student to trash (soft delete) and deactivate the linked user. Use /trash to restore or purge
 // @Tags students
 // @Accept json
 // @Produce json
@@ -274,14 +285,20 @@ func UpdateStudent(c *fiber.Ctx) error {
 func DeleteStudent(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	if err := students.DeleteStudent(id); err != nil {
+	if err := students.DeleteStudent(id, utils.ActorFromCtx(c)); err != nil {
+		switch {
+		case errors.Is(err, students.ErrInvalidStudentID):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		case errors.Is(err, students.ErrStudentNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Error deleting student",
 		})
 	}
 
 	return c.JSON(fiber.Map{
-		"message": "Student deleted successfully",
+		"message": "Student was moved to trash",
 	})
 }
 
@@ -349,6 +366,42 @@ func GetSammaryAll(c *fiber.Ctx) error {
 	return c.JSON(summary)
 }
 
+// GetStudentDietaryProfile ดึงข้อมูลอาหาร/การแพ้อาหารของนิสิต
+func GetStudentDietaryProfile(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid student ID"})
+	}
+	student, err := students.GetStudentById(id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Student not found"})
+	}
+	if student.DietaryProfile == nil {
+		return c.JSON(models.DietaryProfile{Diets: []string{}, Allergies: []string{}})
+	}
+	return c.JSON(student.DietaryProfile)
+}
+
+// UpdateStudentDietaryProfile บันทึกข้อมูลอาหาร/การแพ้อาหารของนิสิต
+func UpdateStudentDietaryProfile(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid student ID"})
+	}
+	var req models.DietaryProfile
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+	}
+	profile, err := students.UpdateDietaryProfile(id, req)
+	if err != nil {
+		if err.Error() == "student not found" {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Student not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(profile)
+}
+
 func UpdateStudentStatus(c *fiber.Ctx) error {
 	id := c.Params("id")
 	err := students.UpdateStudentStatus(id)
@@ -357,18 +410,6 @@ func UpdateStudentStatus(c *fiber.Ctx) error {
 	}
 	return c.JSON(fiber.Map{"message": "Student status updated successfully"})
 }
-func calculateStatus(softSkill, hardSkill int) int {
-	total := softSkill + hardSkill
-
-	switch {
-	case softSkill >= 30 && hardSkill >= 12:
-		return 3 // ครบ
-	case total >= 20:
-		return 2 // น้อย
-	default:
-		return 1 // น้อยมาก
-	}
-}
 func mapMajor(fullName string) string {
 	switch fullName {
 	case "ปัญญาประดิษฐ์ประยุกต์และเทคโนโลยีอัจฉริยะ":
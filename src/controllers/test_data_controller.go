@@ -3,6 +3,8 @@ package controllers
 import (
 	"Backend-Bluelock-007/src/database"
 	"Backend-Bluelock-007/src/models"
+	hourhistory "Backend-Bluelock-007/src/services/hour-history"
+	"Backend-Bluelock-007/src/utils"
 	"context"
 	"fmt"
 	"log"
@@ -358,7 +360,7 @@ func UpdateCheckInOutRecords(c *fiber.Ctx) error {
 					SourceType:   "program",
 					SourceID:     &programItem.ProgramID,
 				}
-				_, err = database.HourChangeHistoryCollection.InsertOne(ctx, hourHistory)
+				err = hourhistory.InsertHistory(ctx, &hourHistory, utils.ActorFromCtx(c), "Test data")
 				if err != nil {
 					log.Printf("Error creating hour history: %v", err)
 				}
@@ -366,10 +368,10 @@ func UpdateCheckInOutRecords(c *fiber.Ctx) error {
 		}
 	} else {
 		// ถ้าไม่ได้ attended all days ให้ลบ hour history (ถ้ามี)
-		_, err = database.HourChangeHistoryCollection.DeleteMany(ctx, bson.M{
+		_, err = hourhistory.VoidHistories(ctx, bson.M{
 			"enrollmentId": enrollment.ID,
 			"sourceType":   "program",
-		})
+		}, utils.ActorFromCtx(c), "Test data")
 		if err != nil {
 			log.Printf("Error deleting hour history: %v", err)
 		}
@@ -423,7 +425,7 @@ func DeleteTestEnrollment(c *fiber.Ctx) error {
 	}
 
 	// 2. ลบ hour history ที่เกี่ยวข้อง
-	_, err = database.HourChangeHistoryCollection.DeleteMany(ctx, bson.M{"enrollmentId": enrollmentID})
+	_, err = hourhistory.VoidHistories(ctx, bson.M{"enrollmentId": enrollmentID}, utils.ActorFromCtx(c), "Test data")
 	if err != nil {
 		log.Printf("Error deleting hour histories: %v", err)
 	}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"Backend-Bluelock-007/src/services/transcripts"
+	"Backend-Bluelock-007/src/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetStudentTranscript godoc
+// @Summary      Student hour transcript (PDF)
+// @Description  Render the student's hour history with totals against graduation requirements and a verification QR code (Students may only get their own)
+// @Tags         transcripts
+// @Produce      application/pdf
+// @Security     BearerAuth
+// @Param        studentId  path  string  true  "Student ID"
+// @Success      200  {file}    file  "Transcript PDF"
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      403  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /transcripts/students/{studentId} [get]
+func GetStudentTranscript(c *fiber.Ctx) error {
+	studentID, err := primitive.ObjectIDFromHex(c.Params("studentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	if utils.RoleFromCtx(c) != "Admin" {
+		if userID, _ := c.Locals("userId").(string); userID != studentID.Hex() {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Only Admin can get another student's transcript"})
+		}
+	}
+
+	pdf, transcript, err := transcripts.Generate(c.Context(), studentID, utils.ActorFromCtx(c), c.BaseURL()+"/transcripts/verify")
+	if err != nil {
+		if errors.Is(err, transcripts.ErrStudentNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="transcript-`+transcript.Code+`.pdf"`)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(fiber.StatusOK).Send(pdf)
+}
+
+// VerifyTranscript godoc
+// @Summary      Verify an hour transcript
+// @Description  Public endpoint behind the transcript QR code; returns the issued snapshot and whether the student's hours changed since
+// @Tags         transcripts
+// @Produce      json
+// @Param        token  path  string  true  "Verification token"
+// @Success      200  {object}  models.HourTranscriptVerification
+// @Failure      404  {object}  models.HourTranscriptVerification
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /transcripts/verify/{token} [get]
+func VerifyTranscript(c *fiber.Ctx) error {
+	result, err := transcripts.Verify(c.Context(), c.Params("token"))
+	if err != nil {
+		if errors.Is(err, transcripts.ErrTranscriptNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"valid": false, "error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(result)
+}
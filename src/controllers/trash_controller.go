@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"Backend-Bluelock-007/src/models"
+	"Backend-Bluelock-007/src/services/rooms"
+	"Backend-Bluelock-007/src/services/trash"
+	"Backend-Bluelock-007/src/utils"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GetTrash godoc
+// @Summary      List trash
+// @Description  List soft-deleted programs, courses, forms and students (Admin only)
+// @Tags         trash
+// @Produce      json
+// @Param        type  query  string  false  "program | course | form | student"
+// @Success      200  {array}   models.TrashItem
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /trash [get]
+func GetTrash(c *fiber.Ctx) error {
+	items, err := trash.GetTrash(c.Query("type"))
+	if err != nil {
+		if errors.Is(err, trash.ErrUnknownTrashType) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"data": items})
+}
+
+// RestoreTrashItem godoc
+// @Summary      Restore from trash
+// @Description  Restore a soft-deleted item (Admin only)
+// @Tags         trash
+// @Produce      json
+// @Param        type  path  string  true  "program | course | form | student"
+// @Param        id    path  string  true  "Item ID"
+// @Success      200
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      409  {object}  models.ErrorResponse  "ห้องของกิจกรรมถูกจองไปแล้ว"
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /trash/{type}/{id}/restore [post]
+func RestoreTrashItem(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	if err := trash.Restore(c.Params("type"), id); err != nil {
+		return trashError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Item restored successfully"})
+}
+
+// PurgeTrashItem godoc
+// @Summary      Permanently delete from trash
+// @Description  Permanently delete a soft-deleted item and its related data (Admin only)
+// @Tags         trash
+// @Produce      json
+// @Param        type  path  string  true  "program | course | form | student"
+// @Param        id    path  string  true  "Item ID"
+// @Success      200
+// @Failure      400  {object}  models.ErrorResponse
+// @Failure      404  {object}  models.ErrorResponse
+// @Failure      500  {object}  models.ErrorResponse
+// @Router       /trash/{type}/{id} [delete]
+func PurgeTrashItem(c *fiber.Ctx) error {
+	id, err := primitive.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid ID format"})
+	}
+	if err := trash.Purge(c.Params("type"), id, utils.ActorFromCtx(c)); err != nil {
+		return trashError(c, err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Item permanently deleted"})
+}
+
+func trashError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, trash.ErrUnknownTrashType):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, models.ErrNotInTrash):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, rooms.ErrRoomConflict):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error(), "conflicts": roomConflicts(err)})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+}
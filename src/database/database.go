@@ -40,6 +40,18 @@ var (
 	UploadCertificateCollection        *mongo.Collection
 	HourChangeHistoryCollection        *mongo.Collection
 	SummaryCheckInOutReportsCollection *mongo.Collection
+	ProgramStateHistoryCollection      *mongo.Collection
+	ProgramSeriesCollection            *mongo.Collection
+	ProgramTemplateCollection          *mongo.Collection
+	ProgramTemplateVersionCollection   *mongo.Collection
+	RoomCollection                     *mongo.Collection
+	ProgramReviewCollection            *mongo.Collection
+	HourLedgerCollection               *mongo.Collection
+	GraduationRequirementCollection    *mongo.Collection
+	HourReverificationCollection       *mongo.Collection
+	HourTranscriptCollection           *mongo.Collection
+	HourAdjustmentBatchCollection      *mongo.Collection
+	HourAppealCollection               *mongo.Collection
 )
 
 // ConnectMongoDB เชื่อมต่อกับ MongoDB แค่ครั้งเดียว
@@ -45,3 +45,57 @@ func NewCloseEnrollTaskWithName(programID, programName string) (*asynq.Task, err
 func NewCloseEnrollTask(programID string) (*asynq.Task, error) {
 	return NewCloseEnrollTaskWithName(programID, "")
 }
+
+// TypeEvaluationDeadline ปิดรายการที่รอแบบประเมินหลังเลยกำหนดส่ง (ไม่ได้รับชั่วโมง)
+const TypeEvaluationDeadline = "program:evaluation-deadline"
+
+// NewEvaluationDeadlineTaskWithName creates an evaluation-deadline task with id and optional name.
+func NewEvaluationDeadlineTaskWithName(programID, programName string) (*asynq.Task, error) {
+	payload, err := json.Marshal(ProgramPayload{ProgramID: programID, ProgramName: programName})
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(TypeEvaluationDeadline, payload), nil
+}
+
+// TypeReconcileHourTotals แก้ยอดชั่วโมงที่ cache ไว้ใน Student ให้ตรงกับ ledger (งานตามรอบ)
+const TypeReconcileHourTotals = "hours:reconcile-totals"
+
+// NewReconcileHourTotalsTask creates a reconcile-hour-totals task (no payload).
+func NewReconcileHourTotalsTask() *asynq.Task {
+	return asynq.NewTask(TypeReconcileHourTotals, nil)
+}
+
+// TypeReverifyHours ประเมินชั่วโมงใหม่ของกิจกรรมที่เสร็จสิ้นแล้ว (phase "diff" = คำนวณ, "commit" = บันทึก)
+const TypeReverifyHours = "hours:reverify"
+
+type ReverifyHoursPayload struct {
+	ReverificationID string `json:"reverification_id"`
+	Phase            string `json:"phase"`
+}
+
+// NewReverifyHoursTask creates a reverify-hours task for one phase of a reverification.
+func NewReverifyHoursTask(reverificationID, phase string) (*asynq.Task, error) {
+	payload, err := json.Marshal(ReverifyHoursPayload{ReverificationID: reverificationID, Phase: phase})
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(TypeReverifyHours, payload), nil
+}
+
+// TypeNotifyAppealDecision แจ้งผลพิจารณาคำร้องขอทบทวนชั่วโมงให้นิสิตทางอีเมล
+// (ประกาศไว้ที่ jobs ให้ hour-history ส่งงานได้โดยไม่ต้องพึ่ง programs/email — handler อยู่ใน email)
+const TypeNotifyAppealDecision = "email:notify-appeal-decision"
+
+type AppealDecisionPayload struct {
+	AppealID string `json:"appeal_id"`
+}
+
+// NewNotifyAppealDecisionTask creates a notify-appeal-decision task for one decided appeal.
+func NewNotifyAppealDecisionTask(appealID string) (*asynq.Task, error) {
+	payload, err := json.Marshal(AppealDecisionPayload{AppealID: appealID})
+	if err != nil {
+		return nil, err
+	}
+	return asynq.NewTask(TypeNotifyAppealDecision, payload), nil
+}
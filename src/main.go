@@ -7,6 +7,8 @@ import (
 	"Backend-Bluelock-007/src/routes"
 	"Backend-Bluelock-007/src/services"
 	"Backend-Bluelock-007/src/services/programs" // 👈 ผูก email handlers ที่นี่
+	"Backend-Bluelock-007/src/services/students"
+	"context"
 	"fmt"
 	"log"
 	"net/url"
@@ -56,6 +58,15 @@ func main() {
 		}
 	}
 
+	// ---- Migrations ----
+	migrateCtx, cancelMigrate := context.WithTimeout(context.Background(), 60*time.Second)
+	if n, err := students.BackfillStudentEntryYears(migrateCtx); err != nil {
+		log.Printf("⚠️ Warning: Failed to backfill student entryYear: %v", err)
+	} else if n > 0 {
+		log.Printf("✅ Backfilled entryYear for %d students", n)
+	}
+	cancelMigrate()
+
 	// ---- Redis & Asynq ----
 	database.InitRedis() // sets database.RedisURI and database.RedisClient (if ok)
 	database.InitAsynq() // sets database.AsynqClient (if Redis ok)
@@ -77,12 +88,9 @@ func main() {
 
 			mux := asynq.NewServeMux()
 
-			// ✅ งานเปลี่ยนสถานะโปรแกรม (ของเดิม)
-			mux.HandleFunc(jobs.TypeCompleteProgram, jobs.HandleCompleteProgramTask)
-			mux.HandleFunc(jobs.TypeCloseEnroll, jobs.HandleCloseEnrollTask)
-
-			// ✅ งานอีเมล: เปิดลงทะเบียน / แจ้งเตือนก่อนเริ่ม 3 วัน
-			// (ภายในจะผูก handler: programs/email.TypeNotifyOpenProgram, programs/email.TypeNotifyProgramReminder)
+			// ✅ งานเปลี่ยนสถานะโปรแกรม (state machine) + งานอีเมล: เปิดลงทะเบียน / แจ้งเตือนก่อนเริ่ม 3 วัน
+			// (ภายในจะผูก handler: jobs.TypeCompleteProgram, jobs.TypeCloseEnroll,
+			//  programs/email.TypeNotifyOpenProgram, programs/email.TypeNotifyProgramReminder)
 
 			if err := programs.RegisterProgramHandlers(mux); err != nil {
 				log.Println("⚠️ RegisterProgramHandlers error:", err)
@@ -92,6 +100,19 @@ func main() {
 				log.Println("⚠️ Asynq worker stopped:", err)
 			}
 		}()
+
+		// ---- Periodic jobs ----
+		// 🧮 ตรวจ / แก้ยอดชั่วโมงที่ cache ใน Student ให้ตรงกับ ledger ทุกวันเวลา 03:00
+		scheduler := asynq.NewScheduler(
+			asynq.RedisClientOpt{Addr: database.RedisURI},
+			&asynq.SchedulerOpts{Location: time.Local},
+		)
+		if _, err := scheduler.Register("0 3 * * *", jobs.NewReconcileHourTotalsTask()); err != nil {
+			log.Println("⚠️ Failed to register hour totals reconciliation:", err)
+		}
+		if err := scheduler.Start(); err != nil {
+			log.Println("⚠️ Asynq scheduler error:", err)
+		}
 	} else {
 		log.Println("⚠️ Asynq worker will not start. Background jobs disabled (no Redis).")
 	}
@@ -114,7 +135,10 @@ func main() {
 	// Swagger
 	app.Get("/swagger/*", swagger.HandlerDefault)
 
-	// Static uploads
+	// Static uploads (ยกเว้นหลักฐานคำร้องขอทบทวนชั่วโมง — ดาวน์โหลดผ่าน /hour-history/appeals/:id/evidence/:file)
+	app.Use("/uploads/appeals", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNotFound)
+	})
 	app.Static("/uploads", "./uploads")
 
 	// ---- Start HTTP Server ----
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AcademicTerm ภาคการศึกษา — ปีการศึกษา (พ.ศ.) + ภาค (1, 2, 3 = ฤดูร้อน)
+type AcademicTerm struct {
+	AcademicYear int `json:"academicYear" bson:"academicYear" example:"2567"`
+	Term         int `json:"term" bson:"term" example:"1"`
+}
+
+var ErrInvalidAcademicTerm = errors.New("academic term must be YYYY/T (e.g. 2567/1)")
+
+// ParseAcademicTerm แปลง "2567/1" เป็น AcademicTerm
+func ParseAcademicTerm(s string) (AcademicTerm, error) {
+	year, term, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return AcademicTerm{}, ErrInvalidAcademicTerm
+	}
+	y, err := strconv.Atoi(year)
+	if err != nil || y < 2500 || y > 3000 {
+		return AcademicTerm{}, ErrInvalidAcademicTerm
+	}
+	t, err := strconv.Atoi(term)
+	if err != nil || t < 1 {
+		return AcademicTerm{}, ErrInvalidAcademicTerm
+	}
+	return AcademicTerm{AcademicYear: y, Term: t}, nil
+}
+
+func (t AcademicTerm) String() string {
+	return fmt.Sprintf("%d/%d", t.AcademicYear, t.Term)
+}
+
+// IsZero ไม่ทราบภาค (entry ใน ledger ที่บันทึกก่อนมีการแบ่งภาค)
+func (t AcademicTerm) IsZero() bool {
+	return t.AcademicYear == 0
+}
+
+// Before ภาคนี้อยู่ก่อนภาค o
+func (t AcademicTerm) Before(o AcademicTerm) bool {
+	if t.AcademicYear != o.AcademicYear {
+		return t.AcademicYear < o.AcademicYear
+	}
+	return t.Term < o.Term
+}
+
+// AcademicTermRange ช่วงภาคการศึกษา (รวมทั้งสองฝั่ง, nil = ไม่จำกัด)
+type AcademicTermRange struct {
+	From *AcademicTerm
+	To   *AcademicTerm
+}
+
+// HourTermSummary ชั่วโมงของนิสิตในหนึ่งภาคการศึกษา
+type HourTermSummary struct {
+	AcademicTerm
+	Label       string    `json:"label" example:"2567/1"`
+	StartDate   time.Time `json:"startDate"`
+	EndDate     time.Time `json:"endDate"` // วันเริ่มภาคถัดไป (ไม่รวม)
+	Current     bool      `json:"current"`
+	SoftHours   int       `json:"softHours"` // ชั่วโมงที่ได้ในภาค
+	HardHours   int       `json:"hardHours"`
+	SoftCounted int       `json:"softCounted"` // ชั่วโมงที่นับรวม (หลังจำกัดเพดานต่อภาค)
+	HardCounted int       `json:"hardCounted"`
+	// ต่ำกว่าขั้นต่ำต่อภาค (ตรวจเฉพาะภาคปกติ)
+	BelowSoftMinimum bool `json:"belowSoftMinimum"`
+	BelowHardMinimum bool `json:"belowHardMinimum"`
+}
+
+// HourTermReport ชั่วโมงของนิสิตแยกตามภาคการศึกษา เทียบกับขั้นต่ำ / เพดานต่อภาคของเกณฑ์
+type HourTermReport struct {
+	StudentID     primitive.ObjectID `json:"studentId"`
+	TermSoftMin   int                `json:"termSoftMin"`
+	TermHardMin   int                `json:"termHardMin"`
+	TermSoftCap   int                `json:"termSoftCap"`
+	TermHardCap   int                `json:"termHardCap"`
+	SoftTotal     int                `json:"softTotal"` // ยอดที่นับรวม (เท่ากับ CalculateNetHours)
+	HardTotal     int                `json:"hardTotal"`
+	SoftCarryOver int                `json:"softCarryOver"` // ชั่วโมงยกมาจากระบบเก่า (รวมใน softTotal ไม่จำกัดเพดานต่อภาค)
+	HardCarryOver int                `json:"hardCarryOver"`
+	Terms         []HourTermSummary  `json:"terms"`
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -17,6 +19,8 @@ type Course struct {
 	IsActive          bool               `json:"isActive" bson:"isActive" example:"true"`
 	ImagePath        *string            `json:"imagePath,omitempty" bson:"imagePath,omitempty" example:"upload/image.jpg"` // Image file URL for course
 	VideoURL          *string            `json:"videoUrl,omitempty" bson:"videoUrl,omitempty" example:"https://www.youtube.com/watch?v=example"` // Tutorial video URL for certificate claiming
+	DeletedAt         *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`                                                 // ลบแบบ soft delete (อยู่ในถังขยะ)
+	DeletedBy         string             `json:"deletedBy,omitempty" bson:"deletedBy,omitempty"`
 }
 
 // CourseFilters ใช้เก็บค่าการกรองสำหรับคอร์ส
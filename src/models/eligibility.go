@@ -0,0 +1,34 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EligibilityRule เงื่อนไขการลงทะเบียนของ ProgramItem (ประเมินเพิ่มจาก majors / studentYears)
+type EligibilityRule struct {
+	Type         string              `json:"type" bson:"type" example:"minHours"`                                 // EligibilityRule* constants
+	SkillType    string              `json:"skillType,omitempty" bson:"skillType,omitempty" example:"soft"`       // "soft" | "hard" | "" (รวมทั้งสอง) สำหรับ minHours / maxHours
+	Hours        *int                `json:"hours,omitempty" bson:"hours,omitempty" example:"10"`                 // จำนวนชั่วโมงสำหรับ minHours / maxHours
+	Statuses     []int               `json:"statuses,omitempty" bson:"statuses,omitempty" example:"1,2"`          // สถานะนิสิตที่อนุญาต (Student.Status)
+	ProgramID    *primitive.ObjectID `json:"programId,omitempty" bson:"programId,omitempty"`                      // กิจกรรมที่ต้องเคยเข้าร่วม (prerequisite)
+	StudentCodes []string            `json:"studentCodes,omitempty" bson:"studentCodes,omitempty" example:"6516"` // รหัสนิสิตสำหรับ allowCodes / denyCodes
+	Message      string              `json:"message,omitempty" bson:"message,omitempty"`                          // ข้อความปฏิเสธที่กำหนดเอง (ถ้าว่างใช้ข้อความมาตรฐาน)
+}
+
+// enum Type ของ EligibilityRule
+const (
+	EligibilityRuleMinHours      = "minHours"      // ชั่วโมงปัจจุบันต้องไม่น้อยกว่า Hours
+	EligibilityRuleMaxHours      = "maxHours"      // ชั่วโมงปัจจุบันต้องไม่เกิน Hours
+	EligibilityRuleStudentStatus = "studentStatus" // Student.Status ต้องอยู่ใน Statuses
+	EligibilityRulePrerequisite  = "prerequisite"  // ต้องเคยเข้าร่วม (attended) กิจกรรม ProgramID
+	EligibilityRuleAllowCodes    = "allowCodes"    // อนุญาตเฉพาะรหัสนิสิตใน StudentCodes
+	EligibilityRuleDenyCodes     = "denyCodes"     // ไม่อนุญาตรหัสนิสิตใน StudentCodes
+)
+
+// EligibilityResult ผลการตรวจสอบสิทธิ์ลงทะเบียนของนิสิตใน ProgramItem
+type EligibilityResult struct {
+	ProgramItemID primitive.ObjectID `json:"programItemId"`
+	StudentID     primitive.ObjectID `json:"studentId"`
+	Eligible      bool               `json:"eligible"`
+	Reasons       []string           `json:"reasons"`
+}
@@ -13,7 +13,8 @@ type Enrollment struct {
 	ProgramID        primitive.ObjectID  `json:"programId" bson:"programId"`
 	ProgramItemID    primitive.ObjectID  `json:"programItemId" bson:"programItemId"`
 	StudentID        primitive.ObjectID  `json:"studentId" bson:"studentId"`
-	Food             *string             `json:"food" bson:"food"`
+	FoodID           *primitive.ObjectID `json:"foodId,omitempty" bson:"foodId,omitempty"` // ตัวเลือกอาหาร (FoodVote.FoodID ของ Program)
+	Food             *string             `json:"food" bson:"food"`                         // ชื่ออาหาร ณ ตอนเลือก (สำหรับแสดงผล)
 	CheckinoutRecord *[]CheckinoutRecord `json:"checkinoutRecord" bson:"checkinoutRecord"`
 	SubmissionID     *primitive.ObjectID `json:"submissionId,omitempty" bson:"submissionId,omitempty"`
 	AttendedAllDays  *bool               `json:"attendedAllDays,omitempty" bson:"attendedAllDays,omitempty"`
@@ -27,6 +28,7 @@ type SuccessResponse struct {
 
 type BulkEnrollItem struct {
 	StudentCode string  `json:"studentCode"`
+	FoodID      *string `json:"foodId"`
 	Food        *string `json:"food"`
 }
 
@@ -51,3 +53,44 @@ type BulkEnrollFailedItem struct {
 	StudentCode string `json:"studentCode"`
 	Reason      string `json:"reason"`
 }
+
+// StudentTimelineItem รายการกิจกรรมใน timeline ของนิสิต (1 รายการ = 1 enrollment)
+type StudentTimelineItem struct {
+	EnrollmentID     primitive.ObjectID  `json:"enrollmentId" bson:"enrollmentId"`
+	RegistrationDate time.Time           `json:"registrationDate" bson:"registrationDate"`
+	FoodID           *primitive.ObjectID `json:"foodId,omitempty" bson:"foodId,omitempty"`
+	Food             *string             `json:"food" bson:"food"`
+	Program          Program             `json:"program" bson:"program"`
+	ProgramItem      ProgramItem         `json:"programItem" bson:"programItem"`
+	CheckinoutRecord []CheckinoutRecord  `json:"checkinoutRecord" bson:"checkinoutRecord"`
+	HourStatus       string              `json:"hourStatus" bson:"hourStatus"`                           // HCStatus* ล่าสุดจาก Hour_Change_Histories
+	HourChange       int                 `json:"hourChange" bson:"hourChange"`                           // ชั่วโมงที่ได้/ถูกหักจาก enrollment นี้
+	HourChangedAt    *time.Time          `json:"hourChangedAt,omitempty" bson:"hourChangedAt,omitempty"` // เวลาที่สถานะชั่วโมงเปลี่ยนล่าสุด
+	StartDate        string              `json:"startDate" bson:"startDate"`                             // วันแรกของ programItem (YYYY-MM-DD)
+	EndDate          string              `json:"endDate" bson:"endDate"`                                 // วันสุดท้ายของ programItem (YYYY-MM-DD)
+	Period           string              `json:"period" bson:"period"`                                   // TimelinePeriod* constants
+}
+
+// enum Period ของ StudentTimelineItem (เทียบกับวันที่ปัจจุบันตามเวลาไทย)
+const (
+	TimelinePeriodPast     = "past"     // กิจกรรมจบไปแล้ว
+	TimelinePeriodCurrent  = "current"  // อยู่ระหว่างช่วงวันจัดกิจกรรม
+	TimelinePeriodUpcoming = "upcoming" // ยังไม่ถึงวันจัดกิจกรรม
+)
+
+// StudentTimelineFilters ใช้เก็บค่าการกรองสำหรับ timeline ของนิสิต (ค่าแบบ comma-separated)
+type StudentTimelineFilters struct {
+	ProgramID  string `json:"programId" query:"programId"`   // Program ObjectID (optional)
+	Skills     string `json:"skills" query:"skills"`         // "soft,hard"
+	States     string `json:"states" query:"states"`         // programState เช่น "open,close,success"
+	HourStatus string `json:"hourStatus" query:"hourStatus"` // HCStatus* เช่น "upcoming,attended"
+	Period     string `json:"period" query:"period"`         // "past,current,upcoming"
+	From       string `json:"from" query:"from"`             // YYYY-MM-DD (กิจกรรมที่จบตั้งแต่วันนี้)
+	To         string `json:"to" query:"to"`                 // YYYY-MM-DD (กิจกรรมที่เริ่มไม่เกินวันนี้)
+}
+
+// StudentTimelinePaginatedResponse is a concrete type for paginated student timeline responses
+type StudentTimelinePaginatedResponse struct {
+	Data []StudentTimelineItem `json:"data"`
+	Meta PaginationMeta        `json:"meta"`
+}
@@ -1,14 +1,137 @@
 package models
 
 import (
+	"strings"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // Food อาหาร
 type Food struct {
-	ID   primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	Name string             `json:"name" bson:"name"`
+	ID        primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	Name      string             `json:"name" bson:"name"`
+	Tags      []string           `json:"tags" bson:"tags" example:"vegetarian,halal"` // คุณสมบัติอาหาร (FoodTag* constants)
+	Allergens []string           `json:"allergens" bson:"allergens" example:"peanut"` // สารก่อภูมิแพ้ที่มีในอาหาร
 }
 type CreateFoodInput struct {
-	Name string `json:"name" bson:"name"`
-}
\ No newline at end of file
+	Name      string   `json:"name" bson:"name"`
+	Tags      []string `json:"tags" bson:"tags"`
+	Allergens []string `json:"allergens" bson:"allergens"`
+}
+
+// enum Tags ของ Food / Diets ของ DietaryProfile
+const (
+	FoodTagVegetarian = "vegetarian" // มังสวิรัติ
+	FoodTagVegan      = "vegan"      // วีแกน (ถือว่าเป็นมังสวิรัติด้วย)
+	FoodTagHalal      = "halal"      // ฮาลาล
+)
+
+// DietaryProfile ข้อมูลอาหารของนิสิต ใช้กรองตัวเลือกอาหารตอนลงทะเบียน และแจ้งผู้จัดเลี้ยง
+type DietaryProfile struct {
+	Diets     []string `json:"diets" bson:"diets" example:"halal"`          // ข้อจำกัดด้านอาหาร (อาหารต้องมี Tags ครบทุกตัว)
+	Allergies []string `json:"allergies" bson:"allergies" example:"peanut"` // สารที่แพ้ (อาหารต้องไม่มีใน Allergens)
+	Note      string   `json:"note" bson:"note"`                            // หมายเหตุเพิ่มเติมถึงผู้จัดเลี้ยง
+}
+
+// CompatibleWith ตรวจว่าอาหารนี้เหมาะกับ profile ของนิสิตหรือไม่ (nil profile = ทานได้ทุกอย่าง)
+func (f Food) CompatibleWith(p *DietaryProfile) bool {
+	if p == nil {
+		return true
+	}
+	for _, diet := range p.Diets {
+		if !containsTag(f.Tags, diet) && !(diet == FoodTagVegetarian && containsTag(f.Tags, FoodTagVegan)) {
+			return false
+		}
+	}
+	for _, allergy := range p.Allergies {
+		if containsTag(f.Allergens, allergy) {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeFoodTags ตัดช่องว่าง แปลงเป็นตัวพิมพ์เล็ก และตัดค่าซ้ำ (ใช้กับ Tags / Allergens / Diets / Allergies)
+func NormalizeFoodTags(tags []string) []string {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// FoodOption ตัวเลือกอาหารของ Program พร้อมคุณสมบัติ (สำหรับหน้าลงทะเบียน)
+type FoodOption struct {
+	FoodID    *primitive.ObjectID `json:"foodId,omitempty"`
+	FoodName  string              `json:"foodName"`
+	Tags      []string            `json:"tags"`
+	Allergens []string            `json:"allergens"`
+}
+
+// FoodSelectionInput ใช้เปลี่ยนตัวเลือกอาหารของ Enrollment (ส่ง foodId; food ชื่ออาหารรองรับ client เดิม, ว่างทั้งคู่ = ไม่รับอาหาร)
+type FoodSelectionInput struct {
+	FoodID *string `json:"foodId"`
+	Food   *string `json:"food"`
+}
+
+// CatererFoodCount จำนวนที่ต้องเตรียมของอาหารแต่ละรายการ
+type CatererFoodCount struct {
+	FoodID   *primitive.ObjectID `json:"foodId,omitempty" bson:"foodId,omitempty"`
+	FoodName string              `json:"foodName" bson:"foodName"`
+	Count    int                 `json:"count" bson:"count"`
+}
+
+// CatererStudentRow รายชื่อนิสิตพร้อมอาหารที่เลือก (สำหรับแจกอาหารหน้างาน)
+type CatererStudentRow struct {
+	StudentCode    string              `json:"studentCode" bson:"studentCode"`
+	StudentName    string              `json:"studentName" bson:"studentName"`
+	Major          string              `json:"major" bson:"major"`
+	FoodID         *primitive.ObjectID `json:"foodId,omitempty" bson:"foodId,omitempty"`
+	FoodName       string              `json:"foodName" bson:"foodName"`
+	DietaryProfile *DietaryProfile     `json:"dietaryProfile,omitempty" bson:"dietaryProfile,omitempty"`
+}
+
+// CatererDietCount จำนวนนิสิตตามข้อจำกัดด้านอาหาร เช่น halal / vegetarian
+type CatererDietCount struct {
+	Diet  string `json:"diet"`
+	Count int    `json:"count"`
+}
+
+// CatererAllergyNote รายการแพ้อาหาร/หมายเหตุของนิสิต สำหรับผู้จัดงาน
+type CatererAllergyNote struct {
+	StudentCode string   `json:"studentCode"`
+	StudentName string   `json:"studentName"`
+	FoodName    string   `json:"foodName"`
+	Allergies   []string `json:"allergies"`
+	Note        string   `json:"note"`
+}
+
+// CatererExport สรุปอาหารสำหรับผู้จัดเลี้ยง ต่อ programItem และวันที่
+type CatererExport struct {
+	ProgramID       primitive.ObjectID   `json:"programId"`
+	ProgramItemID   primitive.ObjectID   `json:"programItemId"`
+	ProgramName     string               `json:"programName"`
+	ProgramItemName string               `json:"programItemName"`
+	Date            string               `json:"date"`
+	Total           int                  `json:"total"`       // จำนวนผู้ลงทะเบียนทั้งหมด
+	NoSelection     int                  `json:"noSelection"` // ไม่ได้เลือกอาหาร
+	Foods           []CatererFoodCount   `json:"foods"`
+	Diets           []CatererDietCount   `json:"diets"`
+	AllergyNotes    []CatererAllergyNote `json:"allergyNotes"`
+	Students        []CatererStudentRow  `json:"students"`
+}
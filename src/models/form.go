@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -13,6 +15,9 @@ type Form struct {
 	// Category   string             `bson:"category" json:"category"`
 
 	Blocks []Block `bson:"blocks,omitempty" json:"blocks,omitempty"`
+
+	DeletedAt *time.Time `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"` // ลบแบบ soft delete (อยู่ในถังขยะ)
+	DeletedBy string     `bson:"deletedBy,omitempty" json:"deletedBy,omitempty"`
 }
 
 // --- Block ---
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GraduationRequirement เกณฑ์ชั่วโมงกิจกรรมที่ต้องผ่านก่อนจบ แยกตามสาขาและรุ่น (ปีที่เข้าศึกษา)
+// Major = "" → ทุกสาขา, EntryYear = 0 → ทุกรุ่น; ใช้เกณฑ์ที่เจาะจงที่สุดที่ตรงกับนิสิต
+type GraduationRequirement struct {
+	ID                 primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Major              string             `json:"major" bson:"major" example:"SE"`
+	EntryYear          int                `json:"entryYear" bson:"entryYear" example:"2567"`
+	SoftSkillHours     int                `json:"softSkillHours" bson:"softSkillHours" example:"30"`         // ชั่วโมง soft skill ที่ต้องได้ (สถานะ 3)
+	HardSkillHours     int                `json:"hardSkillHours" bson:"hardSkillHours" example:"12"`         // ชั่วโมง hard skill ที่ต้องได้ (สถานะ 3)
+	SoftCertificateCap int                `json:"softCertificateCap" bson:"softCertificateCap" example:"15"` // ชั่วโมง soft skill สูงสุดจากใบเซอร์อบรม
+	HardCertificateCap int                `json:"hardCertificateCap" bson:"hardCertificateCap" example:"9"`  // ชั่วโมง hard skill สูงสุดจากใบเซอร์อบรม
+	LowStatusHours     int                `json:"lowStatusHours" bson:"lowStatusHours" example:"20"`         // ชั่วโมงรวมขั้นต่ำของสถานะ 2 (ต่ำกว่านี้ = สถานะ 1)
+	TermSoftMin        int                `json:"termSoftMin" bson:"termSoftMin" example:"0"`                // ชั่วโมง soft skill ขั้นต่ำต่อภาคปกติ (0 = ไม่กำหนด)
+	TermHardMin        int                `json:"termHardMin" bson:"termHardMin" example:"0"`                // ชั่วโมง hard skill ขั้นต่ำต่อภาคปกติ (0 = ไม่กำหนด)
+	TermSoftCap        int                `json:"termSoftCap" bson:"termSoftCap" example:"0"`                // ชั่วโมง soft skill สูงสุดที่นับรวมต่อภาค (0 = ไม่จำกัด)
+	TermHardCap        int                `json:"termHardCap" bson:"termHardCap" example:"0"`                // ชั่วโมง hard skill สูงสุดที่นับรวมต่อภาค (0 = ไม่จำกัด)
+	Note               string             `json:"note,omitempty" bson:"note,omitempty"`
+	CreatedBy          string             `json:"createdBy,omitempty" bson:"createdBy,omitempty"`
+	CreatedAt          time.Time          `json:"createdAt" bson:"createdAt"`
+	UpdatedBy          string             `json:"updatedBy,omitempty" bson:"updatedBy,omitempty"`
+	UpdatedAt          time.Time          `json:"updatedAt" bson:"updatedAt"`
+}
+
+// GraduationRequirementInput ข้อมูลสำหรับสร้าง / แก้ไขเกณฑ์
+type GraduationRequirementInput struct {
+	Major              string `json:"major" example:"SE"`
+	EntryYear          int    `json:"entryYear" example:"2567"`
+	SoftSkillHours     int    `json:"softSkillHours" example:"30"`
+	HardSkillHours     int    `json:"hardSkillHours" example:"12"`
+	SoftCertificateCap int    `json:"softCertificateCap" example:"15"`
+	HardCertificateCap int    `json:"hardCertificateCap" example:"9"`
+	LowStatusHours     int    `json:"lowStatusHours" example:"20"`
+	TermSoftMin        int    `json:"termSoftMin" example:"0"`
+	TermHardMin        int    `json:"termHardMin" example:"0"`
+	TermSoftCap        int    `json:"termSoftCap" example:"0"`
+	TermHardCap        int    `json:"termHardCap" example:"0"`
+	Note               string `json:"note"`
+}
+
+// DefaultGraduationRequirement เกณฑ์เดิมของระบบ (ใช้เมื่อยังไม่มีเกณฑ์ที่ตรงกับนิสิตในฐานข้อมูล)
+// soft 30 / hard 12, อบรม soft ไม่เกิน 15, hard ไม่เกิน 9 (SE, AAI) หรือ 6 (สาขาอื่น), สถานะ 2 เมื่อรวม ≥ 20
+func DefaultGraduationRequirement(major string) GraduationRequirement {
+	hardCap := 6
+	switch strings.ToUpper(strings.TrimSpace(major)) {
+	case "SE", "AAI":
+		hardCap = 9
+	}
+	return GraduationRequirement{
+		SoftSkillHours:     30,
+		HardSkillHours:     12,
+		SoftCertificateCap: 15,
+		HardCertificateCap: hardCap,
+		LowStatusHours:     20,
+	}
+}
+
+// StatusFor สถานะนิสิตจากชั่วโมงตามเกณฑ์นี้
+// Return: 1 = น้อยมาก, 2 = น้อย, 3 = ครบ
+func (r GraduationRequirement) StatusFor(softSkill, hardSkill int) int {
+	switch {
+	case softSkill >= r.SoftSkillHours && hardSkill >= r.HardSkillHours:
+		return 3 // ครบ
+	case softSkill+hardSkill >= r.LowStatusHours:
+		return 2 // น้อย
+	default:
+		return 1 // น้อยมาก
+	}
+}
+
+// HasTermCaps มีเพดานชั่วโมงต่อภาค (ยอดรวมต้องคำนวณแยกภาค)
+func (r GraduationRequirement) HasTermCaps() bool {
+	return r.TermSoftCap > 0 || r.TermHardCap > 0
+}
+
+// CapTermHours ชั่วโมงที่นับรวมของหนึ่งภาคตามเพดานต่อภาค (ชั่วโมงติดลบจากการหักไม่ถูกจำกัด)
+func (r GraduationRequirement) CapTermHours(soft, hard int) (int, int) {
+	if r.TermSoftCap > 0 && soft > r.TermSoftCap {
+		soft = r.TermSoftCap
+	}
+	if r.TermHardCap > 0 && hard > r.TermHardCap {
+		hard = r.TermHardCap
+	}
+	return soft, hard
+}
+
+// CertificateCap ชั่วโมงอบรมสูงสุดจากใบเซอร์ตามประเภท skill
+func (r GraduationRequirement) CertificateCap(skillType string) int {
+	if strings.ToLower(skillType) == "soft" {
+		return r.SoftCertificateCap
+	}
+	return r.HardCertificateCap
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// enum State ของ HourAdjustmentBatch
+// preview → applying → applied (หรือ discarded / failed)
+const (
+	HourAdjustmentStatePreview   = "preview"   // ตรวจไฟล์แล้ว รอยืนยันบันทึก
+	HourAdjustmentStateApplying  = "applying"  // กำลังสร้าง hour history
+	HourAdjustmentStateApplied   = "applied"   // บันทึกแล้ว (บางรายการอาจรออนุมัติ)
+	HourAdjustmentStateDiscarded = "discarded" // ยกเลิกโดยไม่บันทึก
+	HourAdjustmentStateFailed    = "failed"
+)
+
+// enum Approval ของ HourAdjustmentRow (เฉพาะรายการที่ชั่วโมงเกินเกณฑ์)
+const (
+	HourAdjustmentApprovalPending  = "pending"
+	HourAdjustmentApprovalApproved = "approved"
+	HourAdjustmentApprovalRejected = "rejected"
+)
+
+// HourAdjustmentRow หนึ่งแถวใน CSV ปรับชั่วโมง พร้อมผลตรวจสอบ
+type HourAdjustmentRow struct {
+	Line             int                 `json:"line" bson:"line"` // บรรทัดในไฟล์ (header = 1)
+	StudentCode      string              `json:"studentCode" bson:"studentCode"`
+	StudentID        *primitive.ObjectID `json:"studentId,omitempty" bson:"studentId,omitempty"`
+	StudentName      string              `json:"studentName,omitempty" bson:"studentName,omitempty"`
+	SkillType        string              `json:"skillType" bson:"skillType"`
+	HourChange       int                 `json:"hourChange" bson:"hourChange"`
+	Title            string              `json:"title" bson:"title"`
+	Remark           string              `json:"remark,omitempty" bson:"remark,omitempty"`
+	Errors           []string            `json:"errors,omitempty" bson:"errors,omitempty"`
+	RequiresApproval bool                `json:"requiresApproval" bson:"requiresApproval"` // |hourChange| เกินเกณฑ์ → ต้องให้แอดมินอีกคนอนุมัติ
+	HistoryID        *primitive.ObjectID `json:"historyId,omitempty" bson:"historyId,omitempty"`
+	Approval         string              `json:"approval,omitempty" bson:"approval,omitempty"` // HourAdjustmentApproval* constants
+	ReviewedBy       string              `json:"reviewedBy,omitempty" bson:"reviewedBy,omitempty"`
+	ReviewedAt       *time.Time          `json:"reviewedAt,omitempty" bson:"reviewedAt,omitempty"`
+}
+
+// HourAdjustmentBatch ชุดการปรับชั่วโมงแบบกลุ่มจาก CSV (ตรวจ → preview → บันทึก → อนุมัติรายการที่เกินเกณฑ์)
+type HourAdjustmentBatch struct {
+	ID              primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	FileName        string              `json:"fileName" bson:"fileName"`
+	State           string              `json:"state" bson:"state"`         // HourAdjustmentState* constants
+	Threshold       int                 `json:"threshold" bson:"threshold"` // เกณฑ์ชั่วโมงต่อรายการ ณ ตอนตรวจไฟล์
+	Total           int                 `json:"total" bson:"total"`
+	ValidRows       int                 `json:"validRows" bson:"validRows"`
+	InvalidRows     int                 `json:"invalidRows" bson:"invalidRows"`
+	PendingApproval int                 `json:"pendingApproval" bson:"pendingApproval"`
+	Rows            []HourAdjustmentRow `json:"rows" bson:"rows"`
+	Error           string              `json:"error,omitempty" bson:"error,omitempty"`
+	CreatedBy       string              `json:"createdBy" bson:"createdBy"`
+	AppliedBy       string              `json:"appliedBy,omitempty" bson:"appliedBy,omitempty"`
+	CreatedAt       time.Time           `json:"createdAt" bson:"createdAt"`
+	UpdatedAt       time.Time           `json:"updatedAt" bson:"updatedAt"`
+	AppliedAt       *time.Time          `json:"appliedAt,omitempty" bson:"appliedAt,omitempty"`
+}
+
+// HourAdjustmentReviewRequest อนุมัติ / ปฏิเสธรายการที่รออนุมัติ (lines ว่าง = ทุกรายการที่รออยู่)
+type HourAdjustmentReviewRequest struct {
+	Lines  []int  `json:"lines,omitempty"`
+	Remark string `json:"remark,omitempty" example:"ตรวจสอบกับเอกสารแล้ว"`
+}
+
+// HourAdjustmentBatchPaginatedResponse รายการชุดปรับชั่วโมงแบบแบ่งหน้า (ไม่รวมรายละเอียดแต่ละแถว)
+type HourAdjustmentBatchPaginatedResponse struct {
+	Data []HourAdjustmentBatch `json:"data"`
+	Meta PaginationMeta        `json:"meta"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// enum State ของ HourAppeal
+// pending → approved | rejected (หรือ withdrawn เมื่อนิสิตถอนคำร้อง)
+const (
+	HourAppealStatePending   = "pending"   // รอแอดมินพิจารณา
+	HourAppealStateApproved  = "approved"  // อนุมัติ — ปรับ hour history แล้ว
+	HourAppealStateRejected  = "rejected"  // ไม่อนุมัติ — hour history คงเดิม
+	HourAppealStateWithdrawn = "withdrawn" // นิสิตถอนคำร้อง
+)
+
+// HourAppeal คำร้องขอทบทวนผลชั่วโมงของนิสิต (สถานะ late / incomplete / absent ของกิจกรรม หรือใบรับรองที่ถูกปฏิเสธ)
+type HourAppeal struct {
+	ID                 primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	HistoryID          primitive.ObjectID  `json:"historyId" bson:"historyId"`
+	StudentID          primitive.ObjectID  `json:"studentId" bson:"studentId"`
+	SourceType         string              `json:"sourceType" bson:"sourceType"` // "program" | "certificate"
+	SourceID           *primitive.ObjectID `json:"sourceId,omitempty" bson:"sourceId,omitempty"`
+	SkillType          string              `json:"skillType" bson:"skillType"`
+	Title              string              `json:"title" bson:"title"`
+	OriginalStatus     string              `json:"originalStatus" bson:"originalStatus"` // สถานะ hour history ตอนยื่นคำร้อง
+	OriginalHourChange int                 `json:"originalHourChange" bson:"originalHourChange"`
+	OriginalRemark     string              `json:"originalRemark,omitempty" bson:"originalRemark,omitempty"`
+	ExpectedHours      int                 `json:"expectedHours" bson:"expectedHours"` // ชั่วโมงเต็มของกิจกรรม / คอร์ส (ค่าเริ่มต้นเมื่ออนุมัติ)
+	Reason             string              `json:"reason" bson:"reason"`
+	Evidence           []string            `json:"evidence" bson:"evidence"` // ชื่อไฟล์หลักฐาน (ดาวน์โหลดผ่าน /hour-history/appeals/:id/evidence/:file)
+	State              string              `json:"state" bson:"state"`       // HourAppealState* constants
+	GrantedStatus      string              `json:"grantedStatus,omitempty" bson:"grantedStatus,omitempty"`
+	GrantedHourChange  *int                `json:"grantedHourChange,omitempty" bson:"grantedHourChange,omitempty"`
+	DecisionNote       string              `json:"decisionNote,omitempty" bson:"decisionNote,omitempty"`
+	DecidedBy          string              `json:"decidedBy,omitempty" bson:"decidedBy,omitempty"`
+	DecidedAt          *time.Time          `json:"decidedAt,omitempty" bson:"decidedAt,omitempty"`
+	CreatedAt          time.Time           `json:"createdAt" bson:"createdAt"`
+	UpdatedAt          time.Time           `json:"updatedAt" bson:"updatedAt"`
+}
+
+// HourAppealDecisionRequest ผลพิจารณาคำร้อง (hourChange ใช้เฉพาะตอนอนุมัติ — ไม่ส่ง = expectedHours)
+type HourAppealDecisionRequest struct {
+	HourChange *int   `json:"hourChange,omitempty" example:"3"`
+	Note       string `json:"note" example:"ตรวจสอบหลักฐานแล้ว เช็คอินไม่ได้เพราะระบบขัดข้อง"`
+}
+
+// HourAppealFilters ใช้กรองรายการคำร้อง
+type HourAppealFilters struct {
+	StudentID string `json:"studentId" query:"studentId"`
+	State     string `json:"state" query:"state"`         // HourAppealState* constants
+	HistoryID string `json:"historyId" query:"historyId"` // คำร้องของ hour history นี้
+}
+
+// HourAppealPaginatedResponse รายการคำร้องแบบแบ่งหน้า
+type HourAppealPaginatedResponse struct {
+	Data []HourAppeal   `json:"data"`
+	Meta PaginationMeta `json:"meta"`
+}
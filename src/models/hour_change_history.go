@@ -21,6 +21,8 @@ type HourChangeHistory struct {
 	SourceType    string              `bson:"sourceType" json:"sourceType"`                           // "program" | "certificate"
 	SourceID      *primitive.ObjectID `bson:"sourceId" json:"sourceId"`                               // ID ของ program/certificate ที่เป็นต้นเหตุ
 
+	EvaluationDeadline *time.Time `bson:"evaluationDeadline,omitempty" json:"evaluationDeadline,omitempty"` // กำหนดส่งแบบประเมิน (status pending_evaluation)
+
 	// Fields สำหรับ populate/map objects (ไม่บันทึกลง database)
 	Program     *Program           `bson:"-" json:"program,omitempty"`
 	ProgramItem *ProgramItem       `bson:"-" json:"programItem,omitempty"`
@@ -39,13 +41,19 @@ const (
 	HCStatusIncomplete    = "incomplete"    // มาไม่ครบ (ไม่มีการเช็คชื่อเพียงครั้งเดียว ไม่ได้ชั่วโมง)
 	HCStatusAbsent        = "absent"        // ไม่มาเข้าร่วม (ไม่ได้ checkin เลย → จะถูกลบชั่วโมง)
 
+	HCStatusPendingEvaluation = "pending_evaluation" // เข้าร่วมผ่านเกณฑ์แล้ว รอส่งแบบประเมิน (hourChange = ชั่วโมงที่จะได้รับ ยังไม่นับรวม)
+
 	// Certificate statuses
 	HCStatusPending  = "pending"  // รออนุมัติ (certificate)
 	HCStatusApproved = "approved" // อนุมัติแล้ว (certificate)
 	HCStatusRejected = "rejected" // ปฏิเสธแล้ว (certificate)
 
 	// Direct/Manual entry status
-	HCStatusManual = "manual" // เพิ่มชั่วโมงโดยตรงจาก Admin
+	HCStatusManual          = "manual"           // เพิ่มชั่วโมงโดยตรงจาก Admin
+	HCStatusPendingApproval = "pending_approval" // ปรับชั่วโมงเกินเกณฑ์ รอแอดมินอีกคนอนุมัติ (ยังไม่นับรวม)
+
+	// Program cancelled
+	HCStatusCancelled = "cancelled" // กิจกรรมถูกยกเลิก (ไม่ได้ชั่วโมง)
 )
 
 // HourHistoryFilters ใช้เก็บค่าการกรองสำหรับ hour history
@@ -54,6 +62,8 @@ type HourHistoryFilters struct {
 	SourceType string `json:"sourceType" query:"sourceType"` // "program" | "certificate"
 	Status     string `json:"status" query:"status"`         // Comma-separated statuses
 	Search     string `json:"search" query:"search"`         // Search by title
+	FromTerm   string `json:"fromTerm" query:"fromTerm"`     // ภาคเริ่มต้น "2567/1" (ตาม changeAt)
+	ToTerm     string `json:"toTerm" query:"toTerm"`         // ภาคสุดท้าย "2567/2" (รวมภาคนี้)
 }
 
 // HourHistoryPaginatedResponse is a concrete type for paginated hour history responses
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// enum Kind ของ HourLedgerEntry
+const (
+	HourLedgerKindEntry    = "entry"    // สถานะใหม่ของ hour history (ชั่วโมงที่มีผล)
+	HourLedgerKindReversal = "reversal" // กลับรายการ entry ก่อนหน้า (แก้ไข / ลบ hour history)
+)
+
+// HourLedgerActorSystem ผู้บันทึกเมื่อรายการเกิดจากระบบ (job, worker, การนำเข้า)
+const HourLedgerActorSystem = "system"
+
+// enum Type ของ HourLedgerIssue
+const (
+	HourLedgerIssueTamper = "tamper" // hash / prevHash ไม่ตรง — ข้อมูลใน ledger ถูกแก้ไข
+	HourLedgerIssueGap    = "gap"    // seq ไม่ต่อเนื่อง — มีรายการหายไป
+	HourLedgerIssueDrift  = "drift"  // hour history ปัจจุบันไม่ตรงกับ ledger
+)
+
+// HourLedgerEntry รายการใน ledger ชั่วโมงแบบ append-only (ห้ามแก้ไข / ลบ)
+// ทุกการเปลี่ยนแปลงของ HourChangeHistory = reversal ของ entry เดิม + entry ใหม่
+// entry ของนิสิตแต่ละคนเชื่อมกันด้วย seq และ prevHash → hash (sha256)
+type HourLedgerEntry struct {
+	ID         primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	StudentID  primitive.ObjectID  `json:"studentId" bson:"studentId"`
+	Seq        int64               `json:"seq" bson:"seq"`   // ลำดับต่อนิสิต เริ่มที่ 1
+	Kind       string              `json:"kind" bson:"kind"` // HourLedgerKind* constants
+	HistoryID  primitive.ObjectID  `json:"historyId" bson:"historyId"`
+	ReversesID *primitive.ObjectID `json:"reversesId,omitempty" bson:"reversesId,omitempty"` // entry ที่ถูกกลับรายการ (kind = reversal)
+	SkillType  string              `json:"skillType" bson:"skillType"`
+	Status     string              `json:"status" bson:"status"`         // สถานะของ hour history ณ entry นี้
+	HourChange int                 `json:"hourChange" bson:"hourChange"` // hourChange ของ hour history ณ entry นี้
+	Hours      int                 `json:"hours" bson:"hours"`           // ชั่วโมงที่มีผลต่อยอดรวม (reversal = ค่าลบของ entry เดิม)
+	Remark     string              `json:"remark,omitempty" bson:"remark,omitempty"`
+	SourceType string              `json:"sourceType" bson:"sourceType"`
+	SourceID   *primitive.ObjectID `json:"sourceId,omitempty" bson:"sourceId,omitempty"`
+	// ภาคการศึกษาจาก changeAt ของ hour history (reversal ใช้ภาคเดียวกับ entry ที่กลับรายการ) — อยู่ใน hash
+	// entry ที่บันทึกก่อนมีการแบ่งภาคไม่มีค่านี้ (ภาคคำนวณตอนอ่าน ดู aggregateLedgerTerms)
+	AcademicTerm `bson:",inline"`
+	Actor        string    `json:"actor" bson:"actor"`   // email ของแอดมิน / "student:<id>" / "system"
+	Reason       string    `json:"reason" bson:"reason"` // เหตุผลของการเปลี่ยนแปลง
+	CreatedAt    time.Time `json:"createdAt" bson:"createdAt"`
+	PrevHash     string    `json:"prevHash" bson:"prevHash"`
+	Hash         string    `json:"hash" bson:"hash"`
+}
+
+// HourLedgerIssue ปัญหาที่พบจากการตรวจสอบ ledger
+type HourLedgerIssue struct {
+	Type      string              `json:"type"` // HourLedgerIssue* constants
+	StudentID primitive.ObjectID  `json:"studentId"`
+	HistoryID *primitive.ObjectID `json:"historyId,omitempty"`
+	EntryID   *primitive.ObjectID `json:"entryId,omitempty"`
+	Seq       int64               `json:"seq,omitempty"`
+	Message   string              `json:"message"`
+	Expected  string              `json:"expected,omitempty"`
+	Actual    string              `json:"actual,omitempty"`
+}
+
+// HourLedgerVerification ผลการตรวจสอบ ledger (hash chain + เทียบกับ hour history)
+type HourLedgerVerification struct {
+	OK               bool              `json:"ok"`
+	CheckedStudents  int               `json:"checkedStudents"`
+	CheckedEntries   int               `json:"checkedEntries"`
+	CheckedHistories int               `json:"checkedHistories"`
+	Issues           []HourLedgerIssue `json:"issues"`
+}
+
+// HourLedgerPaginatedResponse รายการ ledger แบบแบ่งหน้า
+type HourLedgerPaginatedResponse struct {
+	Data []HourLedgerEntry `json:"data"`
+	Meta PaginationMeta    `json:"meta"`
+}
+
+// HourTotalsMismatch ยอดชั่วโมงที่ cache ไว้ใน Student ไม่ตรงกับ ledger
+type HourTotalsMismatch struct {
+	StudentID  primitive.ObjectID `json:"studentId"`
+	Code       string             `json:"code"`
+	CachedSoft int                `json:"cachedSoft"`
+	CachedHard int                `json:"cachedHard"`
+	LedgerSoft int                `json:"ledgerSoft"`
+	LedgerHard int                `json:"ledgerHard"`
+	CachedSeq  int64              `json:"cachedSeq"` // seq ล่าสุดของ ledger ที่ cache นับรวมแล้ว
+	LedgerSeq  int64              `json:"ledgerSeq"`
+	Fixed      bool               `json:"fixed"`
+}
+
+// HourTotalsReconciliation ผลการเทียบ / แก้ยอดชั่วโมงที่ cache ไว้กับ ledger
+type HourTotalsReconciliation struct {
+	CheckedStudents int                  `json:"checkedStudents"`
+	Mismatches      int                  `json:"mismatches"`
+	Fixed           int                  `json:"fixed"`
+	Items           []HourTotalsMismatch `json:"items"`
+}
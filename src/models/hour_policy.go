@@ -0,0 +1,53 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// enum Mode ของ HourPolicy
+const (
+	HourPolicyAllOrNothing = "allOrNothing" // ได้ชั่วโมงเต็มเมื่อเข้าร่วมตรงเวลาครบทุกวัน (ค่าเดิม)
+	HourPolicyProRata      = "proRata"      // ได้ชั่วโมงตามสัดส่วนวันที่เข้าร่วม (ปัดลง)
+)
+
+// HourPolicy เกณฑ์การให้ชั่วโมงเมื่อกิจกรรมเสร็จสิ้น
+// กำหนดได้ที่ Program (ใช้กับทุกกิจกรรมย่อย) หรือ ProgramItem (ทับค่าของ Program) — nil = เกณฑ์เดิม
+type HourPolicy struct {
+	Mode                 string `json:"mode,omitempty" bson:"mode,omitempty" example:"proRata"`                            // HourPolicy* constants (ว่าง = allOrNothing)
+	LateGraceDays        int    `json:"lateGraceDays,omitempty" bson:"lateGraceDays,omitempty" example:"1"`                // จำนวนวันที่มาสายแต่ยังนับเป็นวันเข้าร่วม
+	CheckinWindowMinutes int    `json:"checkinWindowMinutes,omitempty" bson:"checkinWindowMinutes,omitempty" example:"30"` // ช่วงเช็คอินก่อน/หลังเวลาเริ่ม (0 = 30 นาที)
+	RequireForm          bool   `json:"requireForm,omitempty" bson:"requireForm,omitempty"`                                // ต้องส่งแบบฟอร์มของกิจกรรมก่อนได้ชั่วโมง (ชั่วโมงรอจนกว่าจะส่ง / เลยกำหนด)
+	AbsencePenalty       *bool  `json:"absencePenalty,omitempty" bson:"absencePenalty,omitempty"`                          // หักชั่วโมงเมื่อไม่มาเลย (nil = หัก)
+}
+
+// HourGrade ผลการประเมินชั่วโมงของการลงทะเบียนหนึ่งรายการตาม HourPolicy
+type HourGrade struct {
+	Status         string `json:"status"` // HCStatus* constants
+	HourChange     int    `json:"hourChange"`
+	Remark         string `json:"remark"`
+	TotalDays      int    `json:"totalDays"`
+	DaysOnTime     int    `json:"daysOnTime"`
+	DaysLate       int    `json:"daysLate"`
+	DaysIncomplete int    `json:"daysIncomplete"`
+	DaysAbsent     int    `json:"daysAbsent"`
+	CountedDays    int    `json:"countedDays"`   // วันที่นับเป็นวันเข้าร่วม (ตรงเวลา + สายภายใน lateGraceDays)
+	FormSubmitted  bool   `json:"formSubmitted"` // ส่งแบบฟอร์มของกิจกรรมแล้ว
+}
+
+// HourGradePreview ผลประเมินชั่วโมงของนิสิตหนึ่งคน (ไม่บันทึก) เทียบกับค่าปัจจุบัน
+type HourGradePreview struct {
+	EnrollmentID      primitive.ObjectID `json:"enrollmentId"`
+	ProgramItemID     primitive.ObjectID `json:"programItemId"`
+	StudentID         primitive.ObjectID `json:"studentId"`
+	StudentCode       string             `json:"studentCode"`
+	StudentName       string             `json:"studentName"`
+	CurrentStatus     string             `json:"currentStatus"`
+	CurrentHourChange int                `json:"currentHourChange"`
+	Grade             HourGrade          `json:"grade"`
+}
+
+// HourPolicyPreview ผลลัพธ์ "what-if" ของเกณฑ์การให้ชั่วโมงทั้งกิจกรรม
+type HourPolicyPreview struct {
+	ProgramID   primitive.ObjectID `json:"programId"`
+	Policy      *HourPolicy        `json:"policy,omitempty"` // เกณฑ์ที่ใช้ทดลอง (nil = ใช้เกณฑ์ที่บันทึกไว้)
+	TotalHours  int                `json:"totalHours"`       // ผลรวม hourChange ตามเกณฑ์
+	Enrollments []HourGradePreview `json:"enrollments"`
+}
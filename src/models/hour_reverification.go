@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// enum State ของ HourReverification
+// pending → running → ready → committing → committed (หรือ discarded / failed)
+const (
+	HourReverificationStatePending    = "pending"    // รอ worker คำนวณ diff
+	HourReverificationStateRunning    = "running"    // กำลังประเมินชั่วโมงใหม่ (ยังไม่บันทึก)
+	HourReverificationStateReady      = "ready"      // diff พร้อมให้ตรวจ — รอยืนยันหรือยกเลิก
+	HourReverificationStateCommitting = "committing" // กำลังบันทึกผลที่เปลี่ยน
+	HourReverificationStateCommitted  = "committed"  // บันทึกแล้ว
+	HourReverificationStateDiscarded  = "discarded"  // ยกเลิกโดยไม่บันทึก
+	HourReverificationStateFailed     = "failed"
+)
+
+// HourReverificationScope ขอบเขตการประเมินชั่วโมงใหม่ (ระบุอย่างใดอย่างหนึ่ง)
+type HourReverificationScope struct {
+	EnrollmentID *primitive.ObjectID `json:"enrollmentId,omitempty" bson:"enrollmentId,omitempty"`
+	ProgramID    *primitive.ObjectID `json:"programId,omitempty" bson:"programId,omitempty"`
+	From         string              `json:"from,omitempty" bson:"from,omitempty" example:"2025-03-01"` // วันจัดกิจกรรม (YYYY-MM-DD) — ทุกกิจกรรมที่เสร็จสิ้นในช่วงนี้
+	To           string              `json:"to,omitempty" bson:"to,omitempty" example:"2025-03-31"`
+}
+
+// HourReverificationRequest คำขอประเมินชั่วโมงใหม่
+type HourReverificationRequest struct {
+	HourReverificationScope
+	Reason string `json:"reason" example:"แก้ไขเวลาเช็คอินย้อนหลัง"`
+}
+
+// HourReverificationChange hour history ที่ผลประเมินใหม่ต่างจากปัจจุบัน
+type HourReverificationChange struct {
+	HistoryID             primitive.ObjectID `json:"historyId" bson:"historyId"`
+	EnrollmentID          primitive.ObjectID `json:"enrollmentId" bson:"enrollmentId"`
+	ProgramID             primitive.ObjectID `json:"programId" bson:"programId"`
+	ProgramName           string             `json:"programName" bson:"programName"`
+	StudentID             primitive.ObjectID `json:"studentId" bson:"studentId"`
+	StudentCode           string             `json:"studentCode" bson:"studentCode"`
+	StudentName           string             `json:"studentName" bson:"studentName"`
+	SkillType             string             `json:"skillType" bson:"skillType"`
+	CurrentStatus         string             `json:"currentStatus" bson:"currentStatus"`
+	CurrentHourChange     int                `json:"currentHourChange" bson:"currentHourChange"`
+	NewStatus             string             `json:"newStatus" bson:"newStatus"`
+	NewHourChange         int                `json:"newHourChange" bson:"newHourChange"`
+	NewRemark             string             `json:"newRemark" bson:"newRemark"`
+	NewEvaluationDeadline *time.Time         `json:"newEvaluationDeadline,omitempty" bson:"newEvaluationDeadline,omitempty"`
+	HoursDelta            int                `json:"hoursDelta" bson:"hoursDelta"` // ผลต่างของชั่วโมงที่มีผลต่อยอดรวม
+	Applied               bool               `json:"applied" bson:"applied"`
+	Stale                 bool               `json:"stale,omitempty" bson:"stale,omitempty"` // hour history เปลี่ยนหลังคำนวณ diff → ไม่บันทึก
+}
+
+// HourReverificationStudent ยอดชั่วโมง / สถานะของนิสิตก่อนและหลังบันทึก diff
+type HourReverificationStudent struct {
+	StudentID     primitive.ObjectID `json:"studentId" bson:"studentId"`
+	Code          string             `json:"code" bson:"code"`
+	Name          string             `json:"name" bson:"name"`
+	CurrentSoft   int                `json:"currentSoft" bson:"currentSoft"`
+	CurrentHard   int                `json:"currentHard" bson:"currentHard"`
+	NewSoft       int                `json:"newSoft" bson:"newSoft"`
+	NewHard       int                `json:"newHard" bson:"newHard"`
+	CurrentStatus int                `json:"currentStatus" bson:"currentStatus"`
+	NewStatus     int                `json:"newStatus" bson:"newStatus"`
+}
+
+// HourReverification งานประเมินชั่วโมงใหม่ของกิจกรรมที่เสร็จสิ้นแล้ว (คำนวณ diff ก่อน แล้วจึงยืนยันบันทึก)
+type HourReverification struct {
+	ID          primitive.ObjectID          `json:"id" bson:"_id,omitempty"`
+	Scope       HourReverificationScope     `json:"scope" bson:"scope"`
+	Reason      string                      `json:"reason" bson:"reason"`
+	State       string                      `json:"state" bson:"state"` // HourReverificationState* constants
+	Total       int                         `json:"total" bson:"total"` // จำนวน enrollment (ตอนคำนวณ) / จำนวนรายการที่ต้องบันทึก (ตอนยืนยัน)
+	Processed   int                         `json:"processed" bson:"processed"`
+	Unchanged   int                         `json:"unchanged" bson:"unchanged"`
+	Skipped     int                         `json:"skipped" bson:"skipped"` // ไม่มี hour history ที่สรุปผลแล้ว / กิจกรรมย่อยไม่มีวันจัด
+	Stale       int                         `json:"stale" bson:"stale"`
+	Changes     []HourReverificationChange  `json:"changes" bson:"changes"`
+	Students    []HourReverificationStudent `json:"students" bson:"students"`
+	Error       string                      `json:"error,omitempty" bson:"error,omitempty"`
+	RequestedBy string                      `json:"requestedBy" bson:"requestedBy"`
+	CommittedBy string                      `json:"committedBy,omitempty" bson:"committedBy,omitempty"`
+	CreatedAt   time.Time                   `json:"createdAt" bson:"createdAt"`
+	UpdatedAt   time.Time                   `json:"updatedAt" bson:"updatedAt"`
+	CommittedAt *time.Time                  `json:"committedAt,omitempty" bson:"committedAt,omitempty"`
+}
+
+// HourReverificationPaginatedResponse รายการงานประเมินใหม่แบบแบ่งหน้า (ไม่รวมรายละเอียด diff)
+type HourReverificationPaginatedResponse struct {
+	Data []HourReverification `json:"data"`
+	Meta PaginationMeta       `json:"meta"`
+}
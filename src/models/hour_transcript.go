@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HourTranscript ใบรับรองชั่วโมงกิจกรรม / อบรมที่ออกให้นิสิต (เก็บ snapshot ไว้ตรวจสอบผ่าน QR / URL)
+type HourTranscript struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	StudentID    primitive.ObjectID `json:"studentId" bson:"studentId"`
+	Code         string             `json:"code" bson:"code"`
+	Name         string             `json:"name" bson:"name"`
+	EngName      string             `json:"engName" bson:"engName"`
+	Major        string             `json:"major" bson:"major"`
+	EntryYear    int                `json:"entryYear" bson:"entryYear"`
+	SoftSkill    int                `json:"softSkill" bson:"softSkill"`
+	HardSkill    int                `json:"hardSkill" bson:"hardSkill"`
+	SoftRequired int                `json:"softRequired" bson:"softRequired"`
+	HardRequired int                `json:"hardRequired" bson:"hardRequired"`
+	Status       int                `json:"status" bson:"status"` // สถานะนิสิต ณ วันที่ออก (ดู Student.Status)
+	Entries      int                `json:"entries" bson:"entries"`
+	ContentHash  string             `json:"contentHash" bson:"contentHash"` // sha256 ของรายการและยอดชั่วโมงที่พิมพ์ในเอกสาร
+	VerifyToken  string             `json:"-" bson:"verifyToken"`           // token สุ่ม 128 บิตใน QR (ไม่ใช้ _id เพราะ ObjectID เดาลำดับได้)
+	IssuedBy     string             `json:"issuedBy" bson:"issuedBy"`
+	IssuedAt     time.Time          `json:"issuedAt" bson:"issuedAt"`
+}
+
+// HourTranscriptVerification ผลการตรวจสอบใบรับรองชั่วโมง (endpoint สาธารณะ)
+type HourTranscriptVerification struct {
+	Valid      bool            `json:"valid"`
+	Transcript *HourTranscript `json:"transcript,omitempty"`
+	// ยอดชั่วโมงปัจจุบันต่างจากตอนออกเอกสาร (เอกสารยังถูกต้อง ณ วันที่ออก)
+	Outdated bool `json:"outdated"`
+}
@@ -8,59 +8,99 @@ import (
 
 // Program กิจกรรมหลัก
 type Program struct {
-	ID            primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	FormID        primitive.ObjectID `json:"formId,omitempty" bson:"formId,omitempty"`
-	Name          *string            `json:"name" bson:"name" example:"Football Tournament"`
-	Type          string             `json:"type" bson:"type" example:"one"`
-	ProgramState  string             `json:"programState" bson:"programState" example:"planning"`
-	Skill         string             `json:"skill" bson:"skill" example:"hard"`
-	EndDateEnroll string             `json:"endDateEnroll" bson:"endDateEnroll"`
-	File          string             `json:"file" bson:"file"  example:"image.jpg"`
-	FoodVotes     []FoodVote         `json:"foodVotes" bson:"foodVotes"`
+	ID                 primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	FormID             primitive.ObjectID  `json:"formId,omitempty" bson:"formId,omitempty"`
+	Name               *string             `json:"name" bson:"name" example:"Football Tournament"`
+	Type               string              `json:"type" bson:"type" example:"one"`
+	ProgramState       string              `json:"programState" bson:"programState" example:"planning"`
+	Skill              string              `json:"skill" bson:"skill" example:"hard"`
+	EndDateEnroll      string              `json:"endDateEnroll" bson:"endDateEnroll"`
+	File               string              `json:"file" bson:"file"  example:"image.jpg"`
+	FoodVotes          []FoodVote          `json:"foodVotes" bson:"foodVotes"`
+	FoodDeadline       string              `json:"foodDeadline,omitempty" bson:"foodDeadline,omitempty" example:"2025-03-10"` // วันสุดท้ายที่เปลี่ยนอาหารได้ (ว่าง = ใช้ endDateEnroll)
+	DeletedAt          *time.Time          `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`                            // ลบแบบ soft delete (อยู่ในถังขยะ)
+	DeletedBy          string              `json:"deletedBy,omitempty" bson:"deletedBy,omitempty"`
+	SeriesID           *primitive.ObjectID `json:"seriesId,omitempty" bson:"seriesId,omitempty"`               // series ที่กิจกรรมนี้สังกัด (กิจกรรมจัดซ้ำ)
+	SeriesIndex        int                 `json:"seriesIndex,omitempty" bson:"seriesIndex,omitempty"`         // ลำดับครั้งใน series (เริ่มที่ 1)
+	TemplateID         *primitive.ObjectID `json:"templateId,omitempty" bson:"templateId,omitempty"`           // เทมเพลตที่ใช้สร้างกิจกรรมนี้
+	TemplateVersion    int                 `json:"templateVersion,omitempty" bson:"templateVersion,omitempty"` // เวอร์ชันของเทมเพลตที่ใช้
+	ApprovalStatus     string              `json:"approvalStatus,omitempty" bson:"approvalStatus,omitempty"`   // ProgramApproval* constants (ว่าง = กิจกรรมก่อนมีขั้นตอนอนุมัติ)
+	SubmittedBy        string              `json:"submittedBy,omitempty" bson:"submittedBy,omitempty"`         // ผู้ส่งขออนุมัติล่าสุด
+	SubmittedAt        *time.Time          `json:"submittedAt,omitempty" bson:"submittedAt,omitempty"`
+	ApprovedBy         string              `json:"approvedBy,omitempty" bson:"approvedBy,omitempty"` // ผู้อนุมัติ
+	ApprovedAt         *time.Time          `json:"approvedAt,omitempty" bson:"approvedAt,omitempty"`
+	ApprovalHash       string              `json:"-" bson:"approvalHash,omitempty"`                                      // hash ของเนื้อหาที่ส่งอนุมัติ (แก้ไขหลังส่ง = ต้องส่งใหม่)
+	HourPolicy         *HourPolicy         `json:"hourPolicy,omitempty" bson:"hourPolicy,omitempty"`                     // เกณฑ์การให้ชั่วโมง (nil = เข้าร่วมตรงเวลาครบทุกวันเท่านั้น)
+	EvaluationRequired bool                `json:"evaluationRequired,omitempty" bson:"evaluationRequired,omitempty"`     // ต้องส่งแบบประเมิน (FormID) ก่อนได้ชั่วโมง
+	EvaluationDays     int                 `json:"evaluationDays,omitempty" bson:"evaluationDays,omitempty" example:"7"` // จำนวนวันหลังกิจกรรมเสร็จสิ้นที่ส่งแบบประเมินได้ (0 = 7 วัน)
+	Revision           int                 `json:"revision,omitempty" bson:"revision,omitempty"`                         // เพิ่มทุกครั้งที่แก้ไขกิจกรรม (ใช้คำนวณ SEQUENCE ของปฏิทิน)
 }
 
 type ProgramDto struct {
-	ID            primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	FormID        primitive.ObjectID `json:"formId,omitempty" bson:"formId,omitempty"`
-	Name          *string            `json:"name" bson:"name" example:"Football Tournament"`
-	Type          string             `json:"type" bson:"type" example:"one"`
-	ProgramState  string             `json:"programState" bson:"programState" example:"planning"`
-	Skill         string             `json:"skill" bson:"skill" example:"hard"`
-	EndDateEnroll string             `json:"endDateEnroll" bson:"endDateEnroll"`
-	File          string             `json:"file" bson:"file"  example:"image.jpg"`
-	FoodVotes     []FoodVote         `json:"foodVotes" bson:"foodVotes"`
-	ProgramItems  []ProgramItemDto   `json:"programItems" bson:"programItems"`
+	ID                 primitive.ObjectID  `json:"id,omitempty" bson:"_id,omitempty"`
+	FormID             primitive.ObjectID  `json:"formId,omitempty" bson:"formId,omitempty"`
+	Name               *string             `json:"name" bson:"name" example:"Football Tournament"`
+	Type               string              `json:"type" bson:"type" example:"one"`
+	ProgramState       string              `json:"programState" bson:"programState" example:"planning"`
+	Skill              string              `json:"skill" bson:"skill" example:"hard"`
+	EndDateEnroll      string              `json:"endDateEnroll" bson:"endDateEnroll"`
+	File               string              `json:"file" bson:"file"  example:"image.jpg"`
+	FoodVotes          []FoodVote          `json:"foodVotes" bson:"foodVotes"`
+	FoodDeadline       string              `json:"foodDeadline,omitempty" bson:"foodDeadline,omitempty" example:"2025-03-10"`
+	DeletedAt          *time.Time          `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+	DeletedBy          string              `json:"deletedBy,omitempty" bson:"deletedBy,omitempty"`
+	SeriesID           *primitive.ObjectID `json:"seriesId,omitempty" bson:"seriesId,omitempty"`
+	SeriesIndex        int                 `json:"seriesIndex,omitempty" bson:"seriesIndex,omitempty"`
+	TemplateID         *primitive.ObjectID `json:"templateId,omitempty" bson:"templateId,omitempty"`
+	TemplateVersion    int                 `json:"templateVersion,omitempty" bson:"templateVersion,omitempty"`
+	ApprovalStatus     string              `json:"approvalStatus,omitempty" bson:"approvalStatus,omitempty"`
+	SubmittedBy        string              `json:"submittedBy,omitempty" bson:"submittedBy,omitempty"`
+	SubmittedAt        *time.Time          `json:"submittedAt,omitempty" bson:"submittedAt,omitempty"`
+	ApprovedBy         string              `json:"approvedBy,omitempty" bson:"approvedBy,omitempty"`
+	ApprovedAt         *time.Time          `json:"approvedAt,omitempty" bson:"approvedAt,omitempty"`
+	ApprovalHash       string              `json:"-" bson:"approvalHash,omitempty"`
+	HourPolicy         *HourPolicy         `json:"hourPolicy,omitempty" bson:"hourPolicy,omitempty"`
+	EvaluationRequired bool                `json:"evaluationRequired,omitempty" bson:"evaluationRequired,omitempty"`
+	EvaluationDays     int                 `json:"evaluationDays,omitempty" bson:"evaluationDays,omitempty" example:"7"`
+	Revision           int                 `json:"revision,omitempty" bson:"revision,omitempty"`
+	ProgramItems       []ProgramItemDto    `json:"programItems" bson:"programItems"`
 }
 
 // ProgramItem รายละเอียดกิจกรรมย่อย
 type ProgramItem struct {
-	ID              primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	ProgramID       primitive.ObjectID `json:"programId,omitempty" bson:"programId,omitempty"`
-	Name            *string            `json:"name" bson:"name" example:"Quarter Final"`
-	Description     *string            `json:"description" bson:"description" example:"Quarter Final"`
-	StudentYears    []int              `json:"studentYears" bson:"studentYears" example:"1,2,3,4"`
-	MaxParticipants *int               `json:"maxParticipants" bson:"maxParticipants" example:"22"`
-	Majors          []string           `json:"majors" bson:"majors" example:"CS,SE,ITDI,AAI"`
-	Rooms           *[]string          `json:"rooms" bson:"rooms" example:"Room 1,Room 2"`
-	Operator        *string            `json:"operator" bson:"operator" example:"Operator 1"`
-	Dates           []Dates            `json:"dates" bson:"dates" `
-	Hour            *int               `json:"hour" bson:"hour"  example:"4"`
-	EnrollmentCount int                `json:"enrollmentCount"  `
+	ID               primitive.ObjectID   `json:"id,omitempty" bson:"_id,omitempty"`
+	ProgramID        primitive.ObjectID   `json:"programId,omitempty" bson:"programId,omitempty"`
+	Name             *string              `json:"name" bson:"name" example:"Quarter Final"`
+	Description      *string              `json:"description" bson:"description" example:"Quarter Final"`
+	StudentYears     []int                `json:"studentYears" bson:"studentYears" example:"1,2,3,4"`
+	MaxParticipants  *int                 `json:"maxParticipants" bson:"maxParticipants" example:"22"`
+	Majors           []string             `json:"majors" bson:"majors" example:"CS,SE,ITDI,AAI"`
+	Rooms            *[]string            `json:"rooms" bson:"rooms" example:"Room 1,Room 2"`
+	Operator         *string              `json:"operator" bson:"operator" example:"Operator 1"`
+	Dates            []Dates              `json:"dates" bson:"dates" `
+	Hour             *int                 `json:"hour" bson:"hour"  example:"4"`
+	EnrollmentCount  int                  `json:"enrollmentCount"  `
+	EligibilityRules []EligibilityRule    `json:"eligibilityRules,omitempty" bson:"eligibilityRules,omitempty"`
+	RoomIDs          []primitive.ObjectID `json:"roomIds,omitempty" bson:"roomIds,omitempty"`       // ห้อง/ทรัพยากรจาก Rooms (Rooms เก็บชื่อสำหรับแสดงผล)
+	HourPolicy       *HourPolicy          `json:"hourPolicy,omitempty" bson:"hourPolicy,omitempty"` // ทับ hourPolicy ของ Program (nil = ใช้ของ Program)
 }
 
 type ProgramItemDto struct {
-	ID              primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	ProgramID       primitive.ObjectID `json:"programId,omitempty" bson:"programId,omitempty"`
-	Name            *string            `json:"name" bson:"name" example:"Quarter Final"`
-	Description     *string            `json:"description" bson:"description" example:"Quarter Final"`
-	StudentYears    []int              `json:"studentYears" bson:"studentYears" example:"1,2,3,4"`
-	MaxParticipants *int               `json:"maxParticipants" bson:"maxParticipants" example:"22"`
-	Majors          []string           `json:"majors" bson:"majors" example:"CS,SE,ITDI,AAI"`
-	Rooms           *[]string          `json:"rooms" bson:"rooms" example:"Room 1,Room 2"`
-	Operator        *string            `json:"operator" bson:"operator" example:"Operator 1"`
-	Dates           []Dates            `json:"dates" bson:"dates" `
-	Hour            *int               `json:"hour" bson:"hour"  example:"4"`
-	EnrollmentCount int                `json:"enrollmentCount"  `
+	ID               primitive.ObjectID   `json:"id,omitempty" bson:"_id,omitempty"`
+	ProgramID        primitive.ObjectID   `json:"programId,omitempty" bson:"programId,omitempty"`
+	Name             *string              `json:"name" bson:"name" example:"Quarter Final"`
+	Description      *string              `json:"description" bson:"description" example:"Quarter Final"`
+	StudentYears     []int                `json:"studentYears" bson:"studentYears" example:"1,2,3,4"`
+	MaxParticipants  *int                 `json:"maxParticipants" bson:"maxParticipants" example:"22"`
+	Majors           []string             `json:"majors" bson:"majors" example:"CS,SE,ITDI,AAI"`
+	Rooms            *[]string            `json:"rooms" bson:"rooms" example:"Room 1,Room 2"`
+	Operator         *string              `json:"operator" bson:"operator" example:"Operator 1"`
+	Dates            []Dates              `json:"dates" bson:"dates" `
+	Hour             *int                 `json:"hour" bson:"hour"  example:"4"`
+	EnrollmentCount  int                  `json:"enrollmentCount"  `
+	EligibilityRules []EligibilityRule    `json:"eligibilityRules,omitempty" bson:"eligibilityRules,omitempty"`
+	RoomIDs          []primitive.ObjectID `json:"roomIds,omitempty" bson:"roomIds,omitempty"`
+	HourPolicy       *HourPolicy          `json:"hourPolicy,omitempty" bson:"hourPolicy,omitempty"`
 }
 
 type ProgramDtoWithCheckinoutRecord struct {
@@ -100,9 +140,17 @@ type Dates struct {
 	Etime string `json:"etime" bson:"etime" example:"12:00"`
 }
 
+// Overlaps วันเดียวกันและช่วงเวลาทับซ้อนกัน (เวลาอยู่ในรูปแบบ HH:mm จึงเทียบเป็น string ได้)
+// ตัวอย่าง: 09:00-10:30 กับ 10:00-12:00 -> true, 09:00-10:00 กับ 10:00-12:00 -> false
+func (d Dates) Overlaps(o Dates) bool {
+	return d.Date == o.Date && !(d.Etime <= o.Stime || o.Etime <= d.Stime)
+}
+
+// FoodVote ตัวเลือกอาหารของ Program (อ้างอิง Foods ด้วย FoodID) — Vote คำนวณจาก Enrollments เสมอ
 type FoodVote struct {
-	Vote     int    `json:"vote" bson:"vote"`
-	FoodName string `json:"foodName" bson:"foodName" example:"Pizza"`
+	FoodID   *primitive.ObjectID `json:"foodId,omitempty" bson:"foodId,omitempty"`
+	Vote     int                 `json:"vote" bson:"vote"`
+	FoodName string              `json:"foodName" bson:"foodName" example:"Pizza"`
 }
 
 type EnrollmentSummary struct {
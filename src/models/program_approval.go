@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// enum ApprovalStatus ของ Program (ขั้นตอนก่อนเปิดลงทะเบียน)
+// draft → submitted → approved → (programState open) / submitted → changes_requested → submitted
+const (
+	ProgramApprovalDraft            = "draft"             // ร่าง (ยังไม่ส่งอนุมัติ)
+	ProgramApprovalSubmitted        = "submitted"         // ส่งอนุมัติแล้ว รอผู้ตรวจ
+	ProgramApprovalChangesRequested = "changes_requested" // ผู้ตรวจขอให้แก้ไข
+	ProgramApprovalApproved         = "approved"          // อนุมัติแล้ว เปิดลงทะเบียนได้
+)
+
+// enum Action ของ ProgramReview
+const (
+	ProgramReviewSubmit         = "submit"
+	ProgramReviewComment        = "comment"
+	ProgramReviewRequestChanges = "request_changes"
+	ProgramReviewApprove        = "approve"
+	ProgramReviewReset          = "reset" // การอนุมัติถูกยกเลิกอัตโนมัติ (แก้ไขเนื้อหา / กลับไปวางแผน)
+)
+
+// ProgramReview บันทึกการส่งอนุมัติ / ความเห็น / การอนุมัติของกิจกรรม
+type ProgramReview struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ProgramID  primitive.ObjectID `json:"programId" bson:"programId"`
+	Action     string             `json:"action" bson:"action"` // ProgramReview* constants
+	FromStatus string             `json:"fromStatus" bson:"fromStatus"`
+	ToStatus   string             `json:"toStatus" bson:"toStatus"`
+	Actor      string             `json:"actor" bson:"actor"`
+	Comment    string             `json:"comment,omitempty" bson:"comment,omitempty"`
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// ProgramReviewInput ความเห็นประกอบการส่ง / อนุมัติ / ขอแก้ไข
+type ProgramReviewInput struct {
+	Comment string `json:"comment" example:"ตรวจสอบวันจัดกิจกรรมย่อยที่ 2 อีกครั้ง"`
+}
+
+// ProgramApproval สถานะการอนุมัติปัจจุบันพร้อมประวัติ (ล่าสุดก่อน)
+type ProgramApproval struct {
+	ProgramID      primitive.ObjectID `json:"programId"`
+	ApprovalStatus string             `json:"approvalStatus"`
+	SubmittedBy    string             `json:"submittedBy,omitempty"`
+	SubmittedAt    *time.Time         `json:"submittedAt,omitempty"`
+	ApprovedBy     string             `json:"approvedBy,omitempty"`
+	ApprovedAt     *time.Time         `json:"approvedAt,omitempty"`
+	Reviews        []ProgramReview    `json:"reviews"`
+}
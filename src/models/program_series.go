@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ความถี่ของรอบกิจกรรม (RecurrenceRule.Frequency)
+const (
+	RecurrenceWeekly = "weekly" // ทุก N สัปดาห์
+	RecurrenceCustom = "custom" // กำหนดวันเอง
+)
+
+// ขอบเขตการแก้ไขกิจกรรมที่อยู่ใน series
+const (
+	SeriesEditThis = "this" // แก้เฉพาะกิจกรรมนี้
+	SeriesEditAll  = "all"  // แก้ทุกกิจกรรมใน series ที่ยังไม่จบ
+)
+
+// RecurrenceRule กฎการสร้างวันจัดของกิจกรรมที่จัดซ้ำ
+type RecurrenceRule struct {
+	Frequency string   `json:"frequency" bson:"frequency" example:"weekly"`                            // weekly | custom
+	Interval  int      `json:"interval,omitempty" bson:"interval,omitempty" example:"1"`               // ทุกกี่สัปดาห์ (weekly, ค่าเริ่มต้น 1)
+	StartDate string   `json:"startDate,omitempty" bson:"startDate,omitempty" example:"2025-06-02"`    // วันแรกของรอบ (ว่าง = วันแรกของกิจกรรมต้นแบบ)
+	Count     int      `json:"count,omitempty" bson:"count,omitempty" example:"8"`                     // จำนวนครั้ง (weekly)
+	Until     string   `json:"until,omitempty" bson:"until,omitempty" example:"2025-09-30"`            // วันสุดท้ายของรอบ (weekly)
+	Dates     []string `json:"dates,omitempty" bson:"dates,omitempty" example:"2025-06-02,2025-11-03"` // วันเริ่มของแต่ละครั้ง (custom)
+}
+
+// ProgramSeries กลุ่มกิจกรรมที่จัดซ้ำตาม RecurrenceRule จากกิจกรรมต้นแบบ
+type ProgramSeries struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name            string             `json:"name" bson:"name"`
+	SourceProgramID primitive.ObjectID `json:"sourceProgramId" bson:"sourceProgramId"`
+	Rule            RecurrenceRule     `json:"rule" bson:"rule"`
+	CreatedBy       string             `json:"createdBy" bson:"createdBy"`
+	CreatedAt       time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// ProgramSeriesDetail series พร้อมกิจกรรมทั้งหมด (เรียงตาม seriesIndex)
+type ProgramSeriesDetail struct {
+	ProgramSeries `bson:",inline"`
+	Programs      []ProgramDto `json:"programs"`
+}
+
+// DuplicateProgramInput ข้อมูลสำหรับคัดลอกกิจกรรม
+type DuplicateProgramInput struct {
+	Name      *string `json:"name,omitempty" example:"Football Tournament 2/2568"` // ว่าง = ใช้ชื่อเดิม
+	ShiftDays int     `json:"shiftDays" example:"182"`                             // เลื่อนวันทั้งหมดกี่วัน (ติดลบได้)
+}
+
+// CreateProgramSeriesInput ข้อมูลสำหรับสร้าง series จากกิจกรรมต้นแบบ
+type CreateProgramSeriesInput struct {
+	SourceProgramID string         `json:"sourceProgramId" example:"67bc0f0c4d3f1c2a9e0b1234"`
+	Name            string         `json:"name,omitempty" example:"Weekly Coding Club"`
+	Rule            RecurrenceRule `json:"rule"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// enum ProgramState ของ Program
+const (
+	ProgramStatePlanning  = "planning"  // กำลังวางแผน (ยังไม่เปิดให้ลงทะเบียน)
+	ProgramStateOpen      = "open"      // เปิดลงทะเบียน
+	ProgramStateClose     = "close"     // ปิดลงทะเบียน (รอจัดกิจกรรม)
+	ProgramStateSuccess   = "success"   // กิจกรรมเสร็จสิ้น (ให้ชั่วโมงแล้ว)
+	ProgramStateCancelled = "cancelled" // ยกเลิกกิจกรรม (เก็บข้อมูลการลงทะเบียนไว้)
+)
+
+// ProgramStateHistory บันทึกการเปลี่ยนสถานะของ Program ทุกครั้ง
+type ProgramStateHistory struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ProgramID primitive.ObjectID `json:"programId" bson:"programId"`
+	FromState string             `json:"fromState" bson:"fromState"`
+	ToState   string             `json:"toState" bson:"toState"`
+	Actor     string             `json:"actor" bson:"actor"`       // ผู้เปลี่ยน (email ของแอดมิน หรือ "system" สำหรับ job อัตโนมัติ)
+	Reason    string             `json:"reason" bson:"reason"`     // เหตุผลการเปลี่ยนสถานะ
+	Override  bool               `json:"override" bson:"override"` // ข้ามเงื่อนไขของ state machine (เช่น reopen หลัง success)
+	ChangedAt time.Time          `json:"changedAt" bson:"changedAt"`
+}
+
+// ProgramStateTransitionInput ใช้เปลี่ยนสถานะ Program ผ่าน API
+type ProgramStateTransitionInput struct {
+	State    string `json:"state" example:"open"`
+	Reason   string `json:"reason" example:"เปิดรับสมัครรอบสอง"`
+	Override bool   `json:"override"`
+}
+
+// ProgramCancelInput ใช้ยกเลิกกิจกรรม (reason จะถูกส่งถึงนิสิตที่ลงทะเบียน)
+type ProgramCancelInput struct {
+	Reason string `json:"reason" example:"วิทยากรติดภารกิจ"`
+}
+
+// ProgramItemReschedule วันจัดใหม่ของกิจกรรมย่อย
+type ProgramItemReschedule struct {
+	ProgramItemID string  `json:"programItemId"`
+	Dates         []Dates `json:"dates"`
+}
+
+// ProgramPostponeInput ใช้เลื่อนวันจัดกิจกรรม (ส่งเฉพาะกิจกรรมย่อยที่เลื่อน)
+type ProgramPostponeInput struct {
+	Items         []ProgramItemReschedule `json:"items"`
+	EndDateEnroll string                  `json:"endDateEnroll,omitempty" example:"2025-03-20"` // ว่าง = ใช้วันปิดรับสมัครเดิม
+	Reason        string                  `json:"reason" example:"ห้องจัดกิจกรรมไม่ว่าง"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProgramTemplateItem โครงกิจกรรมย่อยในเทมเพลต (ไม่มีวันจัด — กำหนดตอนสร้างกิจกรรม)
+type ProgramTemplateItem struct {
+	Name             *string              `json:"name" bson:"name" example:"Workshop"`
+	Description      *string              `json:"description" bson:"description" example:"Hands-on session"`
+	StudentYears     []int                `json:"studentYears" bson:"studentYears" example:"1,2,3,4"`
+	MaxParticipants  *int                 `json:"maxParticipants" bson:"maxParticipants" example:"40"`
+	Majors           []string             `json:"majors" bson:"majors" example:"CS,SE,ITDI,AAI"`
+	Rooms            *[]string            `json:"rooms" bson:"rooms" example:"Room 1,Room 2"`
+	Operator         *string              `json:"operator" bson:"operator" example:"Operator 1"`
+	Hour             *int                 `json:"hour" bson:"hour" example:"3"`
+	EligibilityRules []EligibilityRule    `json:"eligibilityRules,omitempty" bson:"eligibilityRules,omitempty"`
+	RoomIDs          []primitive.ObjectID `json:"roomIds,omitempty" bson:"roomIds,omitempty"`
+	HourPolicy       *HourPolicy          `json:"hourPolicy,omitempty" bson:"hourPolicy,omitempty"`
+}
+
+// ProgramTemplateContent ส่วนของ ProgramDto ที่เก็บในเทมเพลต (ไม่มีวันจัด / วันปิดรับสมัคร / สถานะ)
+type ProgramTemplateContent struct {
+	ProgramName        *string               `json:"programName" bson:"programName" example:"Football Tournament"`
+	Type               string                `json:"type" bson:"type" example:"one"`
+	Skill              string                `json:"skill" bson:"skill" example:"hard"`
+	FormID             primitive.ObjectID    `json:"formId,omitempty" bson:"formId,omitempty"` // ฟอร์มต้นแบบ (คัดลอกใหม่ทุกครั้งที่สร้างกิจกรรม)
+	FoodVotes          []FoodVote            `json:"foodVotes" bson:"foodVotes"`
+	HourPolicy         *HourPolicy           `json:"hourPolicy,omitempty" bson:"hourPolicy,omitempty"`
+	EvaluationRequired bool                  `json:"evaluationRequired,omitempty" bson:"evaluationRequired,omitempty"` // ต้องส่งแบบประเมิน (ฟอร์มที่คัดลอกจาก FormID)
+	EvaluationDays     int                   `json:"evaluationDays,omitempty" bson:"evaluationDays,omitempty"`
+	Items              []ProgramTemplateItem `json:"items" bson:"items"`
+}
+
+// ProgramTemplate เทมเพลตกิจกรรม (เก็บเวอร์ชันล่าสุด) — ทุกการแก้ไขเพิ่มเวอร์ชันใหม่
+type ProgramTemplate struct {
+	ID          primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	Name        string                 `json:"name" bson:"name" example:"Coding Bootcamp"`
+	Description string                 `json:"description" bson:"description"`
+	Version     int                    `json:"version" bson:"version" example:"1"`
+	Content     ProgramTemplateContent `json:"content" bson:"content"`
+	CreatedBy   string                 `json:"createdBy" bson:"createdBy"`
+	CreatedAt   time.Time              `json:"createdAt" bson:"createdAt"`
+	UpdatedBy   string                 `json:"updatedBy" bson:"updatedBy"`
+	UpdatedAt   time.Time              `json:"updatedAt" bson:"updatedAt"`
+	DeletedAt   *time.Time             `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+}
+
+// ProgramTemplateVersion snapshot ของเทมเพลตแต่ละเวอร์ชัน (ไม่ถูกแก้ไขภายหลัง)
+type ProgramTemplateVersion struct {
+	ID          primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	TemplateID  primitive.ObjectID     `json:"templateId" bson:"templateId"`
+	Version     int                    `json:"version" bson:"version"`
+	Name        string                 `json:"name" bson:"name"`
+	Description string                 `json:"description" bson:"description"`
+	Content     ProgramTemplateContent `json:"content" bson:"content"`
+	CreatedBy   string                 `json:"createdBy" bson:"createdBy"`
+	CreatedAt   time.Time              `json:"createdAt" bson:"createdAt"`
+}
+
+// ProgramTemplateInput ข้อมูลสร้าง/แก้ไขเทมเพลต
+type ProgramTemplateInput struct {
+	Name        string                 `json:"name" example:"Coding Bootcamp"`
+	Description string                 `json:"description"`
+	Content     ProgramTemplateContent `json:"content" bson:"content"`
+}
+
+// ProgramTemplateItemSchedule วันจัดของกิจกรรมย่อยลำดับที่ตรงกับ items ในเทมเพลต
+type ProgramTemplateItemSchedule struct {
+	Dates []Dates `json:"dates"`
+}
+
+// ProgramFromTemplateInput ข้อมูลสร้างกิจกรรมจากเทมเพลต
+type ProgramFromTemplateInput struct {
+	Version       int                           `json:"version,omitempty" example:"2"` // 0 = เวอร์ชันล่าสุด
+	Name          *string                       `json:"name,omitempty"`                // ว่าง = ใช้ programName ของเทมเพลต
+	ProgramState  string                        `json:"programState,omitempty" example:"planning"`
+	EndDateEnroll string                        `json:"endDateEnroll" example:"2025-03-01"`
+	FoodDeadline  string                        `json:"foodDeadline,omitempty" example:"2025-03-05"`
+	File          string                        `json:"file,omitempty"`
+	Items         []ProgramTemplateItemSchedule `json:"items"` // เรียงตาม items ของเทมเพลต
+}
@@ -0,0 +1,20 @@
+package models
+
+// ProgramRecommendations กิจกรรมแนะนำสำหรับนิสิต (เรียงตามความเหมาะสมแล้ว)
+type ProgramRecommendations struct {
+	SoftSkill     int                     `json:"softSkill"`     // ชั่วโมง soft skill สุทธิปัจจุบัน
+	HardSkill     int                     `json:"hardSkill"`     // ชั่วโมง hard skill สุทธิปัจจุบัน
+	SoftRemaining int                     `json:"softRemaining"` // ชั่วโมง soft skill ที่ยังขาด
+	HardRemaining int                     `json:"hardRemaining"` // ชั่วโมง hard skill ที่ยังขาด
+	PrioritySkill string                  `json:"prioritySkill"` // "soft" | "hard" | "" (ครบทั้งสองแล้ว)
+	Programs      []ProgramRecommendation `json:"programs"`
+}
+
+// ProgramRecommendation กิจกรรมที่แนะนำ — ProgramItems มีเฉพาะกิจกรรมย่อยที่นิสิตลงทะเบียนได้
+type ProgramRecommendation struct {
+	Program   ProgramDto `json:"program"`
+	NextDate  string     `json:"nextDate"`         // วันจัดที่ใกล้ที่สุดของกิจกรรมย่อยที่ลงได้ (YYYY-MM-DD)
+	SeatsLeft *int       `json:"seatsLeft"`        // ที่นั่งคงเหลือมากที่สุด (nil = ไม่จำกัด)
+	Priority  bool       `json:"priority"`         // เป็นทักษะที่นิสิตขาดมากที่สุด
+	Reason    string     `json:"reason,omitempty"` // เหตุผลที่แนะนำ (สำหรับแสดงผล)
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ประเภทของ Room (ห้องหรือทรัพยากรที่จองได้)
+const (
+	RoomTypeRoom      = "room"      // ห้อง / สถานที่
+	RoomTypeEquipment = "equipment" // อุปกรณ์ เช่น โปรเจกเตอร์ ชุดเครื่องเสียง
+)
+
+// Room ห้องหรือทรัพยากรที่กิจกรรมย่อยจองได้ (ProgramItem.RoomIDs)
+type Room struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Name      string             `json:"name" bson:"name" example:"IF-3C01"`
+	Type      string             `json:"type" bson:"type" example:"room"`              // room | equipment
+	Building  string             `json:"building,omitempty" bson:"building,omitempty"` // อาคาร / ที่ตั้ง
+	Capacity  int                `json:"capacity" bson:"capacity" example:"60"`        // จำนวนคนที่รองรับ (0 = ไม่จำกัด / ไม่ใช่ห้อง)
+	Note      string             `json:"note,omitempty" bson:"note,omitempty"`
+	DeletedAt *time.Time         `json:"deletedAt,omitempty" bson:"deletedAt,omitempty"`
+}
+
+// RoomInput ข้อมูลสร้าง/แก้ไข Room
+type RoomInput struct {
+	Name     string `json:"name" example:"IF-3C01"`
+	Type     string `json:"type" example:"room"`
+	Building string `json:"building,omitempty"`
+	Capacity int    `json:"capacity" example:"60"`
+	Note     string `json:"note,omitempty"`
+}
+
+// RoomBooking การใช้ห้องของกิจกรรมย่อยในวัน/เวลาหนึ่ง
+type RoomBooking struct {
+	RoomID          primitive.ObjectID `json:"roomId"`
+	RoomName        string             `json:"roomName"`
+	ProgramID       primitive.ObjectID `json:"programId"`
+	ProgramName     string             `json:"programName"`
+	ProgramItemID   primitive.ObjectID `json:"programItemId"`
+	ProgramItemName string             `json:"programItemName"`
+	ProgramState    string             `json:"programState"`
+	Date            string             `json:"date"`
+	Stime           string             `json:"stime"`
+	Etime           string             `json:"etime"`
+}
+
+// RoomConflict กิจกรรมย่อยที่ขอจองชนกับการจองที่มีอยู่
+type RoomConflict struct {
+	ItemName string      `json:"itemName"` // กิจกรรมย่อยที่กำลังบันทึก
+	Date     Dates       `json:"date"`
+	Booking  RoomBooking `json:"booking"` // การจองที่ชนกัน
+}
+
+// RoomAvailability การจองของห้องในช่วงวันที่ขอดู
+type RoomAvailability struct {
+	Room     Room          `json:"room"`
+	Bookings []RoomBooking `json:"bookings"`
+}
+
+// RoomConflictCheckInput ตรวจการจองซ้อนก่อนบันทึก (ใช้แสดงคำเตือนฝั่ง client)
+type RoomConflictCheckInput struct {
+	ProgramID    string           `json:"programId,omitempty"` // กิจกรรมที่กำลังแก้ไข (ไม่นับการจองของตัวเอง)
+	ProgramItems []ProgramItemDto `json:"programItems"`
+}
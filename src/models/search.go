@@ -0,0 +1,31 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// ProgramSearchHit ผลค้นหากิจกรรม เรียงตาม Score (มาก = ตรงกว่า)
+type ProgramSearchHit struct {
+	ID           primitive.ObjectID     `json:"id"`
+	Name         *string                `json:"name"`
+	Skill        string                 `json:"skill"`
+	ProgramState string                 `json:"programState"`
+	File         string                 `json:"file,omitempty"`
+	Score        float64                `json:"score"`
+	MatchedItems []ProgramItemSearchHit `json:"matchedItems,omitempty"` // กิจกรรมย่อยที่ตรงกับคำค้น
+}
+
+// ProgramItemSearchHit กิจกรรมย่อยที่ตรงกับคำค้น
+type ProgramItemSearchHit struct {
+	ID    primitive.ObjectID `json:"id"`
+	Name  *string            `json:"name"`
+	Score float64            `json:"score"`
+}
+
+// StudentSearchHit ผลค้นหานิสิต เรียงตาม Score
+type StudentSearchHit struct {
+	ID      primitive.ObjectID `json:"id"`
+	Code    string             `json:"code"`
+	Name    string             `json:"name"`
+	EngName string             `json:"engName"`
+	Major   string             `json:"major"`
+	Score   float64            `json:"score"`
+}
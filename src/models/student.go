@@ -1,18 +1,88 @@
 package models
 
 import (
+	"encoding/json"
+	"strconv"
+	"time"
+
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // Student นิสิต
 type Student struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Code      string             `bson:"code" json:"code"`
-	Name      string             `bson:"name" json:"name"`
-	EngName   string             `bson:"engName" json:"engName"`
-	Status    int                `bson:"status" json:"status"` // 0พ้นสภาพ 1ชั่วโมงน้อยมาก 2ชั่วโมงน้อย 3ชั่วโมงครบแล้ว 4ออกผึกแล้ว
-	SoftSkill int                `bson:"softSkill" json:"softSkill"`
-	HardSkill int                `bson:"hardSkill" json:"hardSkill"`
-	Major     string             `bson:"major" json:"major"`
-	Year      string             `bson:"year" json:"year"` // ปีการศึกษา เช่น "2567"
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Code           string             `bson:"code" json:"code"`
+	Name           string             `bson:"name" json:"name"`
+	EngName        string             `bson:"engName" json:"engName"`
+	Status         int                `bson:"status" json:"status"`              // 0พ้นสภาพ 1ชั่วโมงน้อยมาก 2ชั่วโมงน้อย 3ชั่วโมงครบแล้ว 4ออกผึกแล้ว
+	SoftSkill      int                `bson:"softSkill" json:"softSkill"`        // ชั่วโมงรวม soft skill (cache จาก ledger — อัปเดตโดย hourhistory เท่านั้น)
+	HardSkill      int                `bson:"hardSkill" json:"hardSkill"`        // ชั่วโมงรวม hard skill (cache จาก ledger — อัปเดตโดย hourhistory เท่านั้น)
+	HoursLedgerSeq int64              `bson:"hoursLedgerSeq,omitempty" json:"-"` // seq ล่าสุดของ ledger ที่นับรวมใน SoftSkill / HardSkill แล้ว
+	Major          string             `bson:"major" json:"major"`
+	Year           string             `bson:"year" json:"year"`                                         // (legacy) ข้อความปีการศึกษา เช่น "2567" — ใช้ EntryYear แทน
+	EntryYear      int                `bson:"entryYear,omitempty" json:"entryYear"`                     // ปีการศึกษาที่เข้าศึกษา (พ.ศ.) เช่น 2565
+	DietaryProfile *DietaryProfile    `bson:"dietaryProfile,omitempty" json:"dietaryProfile,omitempty"` // ข้อมูลอาหาร/การแพ้อาหาร
+	DeletedAt      *time.Time         `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"`           // ลบแบบ soft delete (อยู่ในถังขยะ)
+	DeletedBy      string             `bson:"deletedBy,omitempty" json:"deletedBy,omitempty"`
+}
+
+// CurrentYear ชั้นปีปัจจุบันของนิสิต คำนวณจาก EntryYear (0 = ไม่ทราบ)
+func (s Student) CurrentYear() int {
+	return StudentYearFromEntryYear(s.EntryYear)
+}
+
+// MarshalJSON แนบ studentYear (ชั้นปีปัจจุบันที่คำนวณจาก EntryYear) ไปกับ JSON ทุกครั้ง
+func (s Student) MarshalJSON() ([]byte, error) {
+	type student Student
+	return json.Marshal(struct {
+		student
+		StudentYear int `json:"studentYear"`
+	}{student(s), s.CurrentYear()})
+}
+
+// CurrentAcademicYear ปีการศึกษาปัจจุบัน (พ.ศ.) — ปีการศึกษาใหม่เริ่มเดือนกรกฎาคม
+func CurrentAcademicYear() int {
+	now := time.Now()
+	year := now.Year() + 543 // แปลง ค.ศ. เป็น พ.ศ.
+	if now.Month() < time.July {
+		year -= 1
+	}
+	return year
+}
+
+// StudentYearFromEntryYear แปลงปีที่เข้าศึกษา (พ.ศ.) เป็นชั้นปีปัจจุบัน (0 = ไม่ทราบ)
+func StudentYearFromEntryYear(entryYear int) int {
+	if entryYear <= 0 {
+		return 0
+	}
+	year := CurrentAcademicYear() - entryYear + 1
+	if year < 1 {
+		return 0
+	}
+	return year
+}
+
+// EntryYearsForStudentYears แปลงชั้นปี (1, 2, 3, ...) เป็นปีที่เข้าศึกษา (พ.ศ.) สำหรับใช้กรองใน query
+func EntryYearsForStudentYears(studentYears []int) []int {
+	current := CurrentAcademicYear()
+	entryYears := make([]int, 0, len(studentYears))
+	for _, y := range studentYears {
+		if y >= 1 {
+			entryYears = append(entryYears, current-(y-1))
+		}
+	}
+	return entryYears
+}
+
+// EntryYearFromCode ประมาณปีที่เข้าศึกษาจาก 2 หลักแรกของรหัสนิสิต (เช่น 65160309 → 2565)
+// ใช้สำหรับ backfill ข้อมูลเดิมและตอนสร้างนิสิตใหม่ที่ไม่ได้ระบุ EntryYear เท่านั้น
+func EntryYearFromCode(code string) int {
+	if len(code) < 2 {
+		return 0
+	}
+	prefix, err := strconv.Atoi(code[:2])
+	if err != nil {
+		return 0
+	}
+	return 2500 + prefix
 }
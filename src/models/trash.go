@@ -0,0 +1,28 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ประเภทข้อมูลที่ลบแบบ soft delete ได้ (ใช้ใน path /trash/:type/:id)
+const (
+	TrashTypeProgram = "program"
+	TrashTypeCourse  = "course"
+	TrashTypeForm    = "form"
+	TrashTypeStudent = "student"
+)
+
+// ErrNotInTrash ไม่พบรายการในถังขยะ (ไม่มีอยู่ หรือยังไม่ถูกลบ)
+var ErrNotInTrash = errors.New("item not found in trash")
+
+// TrashItem รายการในถังขยะของแอดมิน
+type TrashItem struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Type      string             `json:"type" bson:"type"` // program | course | form | student
+	Name      string             `json:"name" bson:"name"`
+	DeletedAt time.Time          `json:"deletedAt" bson:"deletedAt"`
+	DeletedBy string             `json:"deletedBy" bson:"deletedBy"`
+}
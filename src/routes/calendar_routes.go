@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"Backend-Bluelock-007/src/controllers"
+	"Backend-Bluelock-007/src/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// calendarRoutes iCalendar (ICS) feed สำหรับ subscribe จากแอปปฏิทิน
+func calendarRoutes(router fiber.Router) {
+	calendarRoutes := router.Group("/calendar")
+	calendarRoutes.Get("/programs.ics", controllers.GetPublicProgramsICS)                        // feed สาธารณะ
+	calendarRoutes.Get("/students/:studentId/schedule.ics", controllers.GetStudentScheduleICS)   // feed ส่วนตัว (ตรวจ token)
+	calendarRoutes.Get("/subscription", middleware.AuthJWT, controllers.GetCalendarSubscription) // ขอ URL subscribe
+}
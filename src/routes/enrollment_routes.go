@@ -14,18 +14,19 @@ func enrollmentRoutes(router fiber.Router) {
 	enrollmentRoutes.Post("/", controllers.RegisterStudent)                // ✅ ลงทะเบียน
 	enrollmentRoutes.Post("/by-admin", controllers.RegisterStudentByAdmin) // ✅ ลงทะเบียน
 	// enrollmentRoutes.Post("/many", controllers.RegisterStudentsByCodes)       // ✅ ลงทะเบียนหลายคน                                              // ✅ ลงทะเบียนหลายคน
-	enrollmentRoutes.Get("/student/:studentId", controllers.GetEnrollmentsByStudent) // ✅ ดูกิจกรรมที่ Student ลงทะเบียนไว้
+	enrollmentRoutes.Get("/student/:studentId", controllers.GetEnrollmentsByStudent)                   // ✅ ดูกิจกรรมที่ Student ลงทะเบียนไว้
+	enrollmentRoutes.Get("/student/:studentId/timeline", controllers.GetStudentTimeline)               // ✅ Timeline อดีต/ปัจจุบัน/กำลังจะมาถึง + สถานะชั่วโมง + check-in/out
+	enrollmentRoutes.Get("/student/:studentId/recommendations", controllers.GetProgramRecommendations) // ✅ กิจกรรมแนะนำที่ลงทะเบียนได้ เรียงตามทักษะที่ยังขาดชั่วโมง
 	enrollmentRoutes.Get("/:enrollmentId", controllers.GetEnrollmentById)
 	enrollmentRoutes.Patch("/:enrollmentId/checkinout", controllers.UpdateEnrollmentCheckinout)
-	enrollmentRoutes.Delete("/:enrollmentId", controllers.UnregisterStudent) // ✅ ยกเลิกลงทะเบียน
-	// enrollmentRoutes.Get("/program/:programId", controllers.GetStudentsByProgram)                                        // ✅ Admin ดูนักศึกษาที่ลงทะเบียน
-	enrollmentRoutes.Get("/student/:studentId/program/:programId/check", controllers.CheckEnrollmentByStudentAndProgram) // ✅ ตรวจสอบว่านักศึกษาลงทะเบียนในกิจกรรมหรือไม่
+	enrollmentRoutes.Patch("/:enrollmentId/food", controllers.UpdateEnrollmentFood)                                            // ✅ เปลี่ยนอาหารที่เลือก (ก่อน foodDeadline)
+	enrollmentRoutes.Delete("/:enrollmentId", controllers.UnregisterStudent)                                                   // ✅ ยกเลิกลงทะเบียน
+	enrollmentRoutes.Get("/student/:studentId/program/:programId/check", controllers.CheckEnrollmentByStudentAndProgram)       // ✅ ตรวจสอบว่านักศึกษาลงทะเบียนในกิจกรรมหรือไม่
+	enrollmentRoutes.Get("/student/:studentId/programItem/:programItemId/eligibility", controllers.CheckEnrollmentEligibility) // ✅ ตรวจสอบสิทธิ์ลงทะเบียน (เหตุผลที่ไม่ผ่านทุกข้อ)
+	enrollmentRoutes.Get("/student/:studentId/program/:programId/foods", controllers.GetFoodOptionsForStudent)                 // ✅ ตัวเลือกอาหารที่เหมาะกับ dietaryProfile ของนิสิต
 
 	// ดูนิสิตที่ลงทะเบียน
 	enrollmentRoutes.Get("/programItems/:id/enrollments", controllers.GetEnrollmentByProgramItemID) //programItems enrollments
+	enrollmentRoutes.Get("/programItems/:id/food-export", controllers.ExportCatererFood)            // สรุปอาหารสำหรับผู้จัดเลี้ยง (?date=&format=csv)
 	enrollmentRoutes.Get("/:id/enrollments", controllers.GetEnrollmentsByProgramID)                 //program enrollments
-
-	// enrollmentRoutes.Get("/student/:studentId/program/:programId", controllers.GetStudentEnrollmentInProgram)            // ✅ ดึงข้อมูล Enrollment ของ Student ใน Program
-	// enrollmentRoutes.Get("/history/student/:studentId", controllers.GetRegistrationHistoryStatus)          // ✅ ประวัติการลงทะเบียน แบ่งสถานะจาก Hour_Change_Histories
-	// enrollmentRoutes.Get("/history-status/student/:studentId", controllers.GetEnrollmentsHistoryByStudent) // ✅ ประวัติการอบรมของ Student (กิจกรรมทั้งหมดที่เคยลง)
 }
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"Backend-Bluelock-007/src/controllers"
+	"Backend-Bluelock-007/src/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// graduationRequirementRoutes เกณฑ์ชั่วโมงตามสาขา / รุ่น (แก้ไขได้เฉพาะ Admin)
+func graduationRequirementRoutes(router fiber.Router) {
+	requirementRoutes := router.Group("/graduation-requirements", middleware.AuthJWT)
+	requirementRoutes.Get("/", controllers.GetGraduationRequirements)
+	requirementRoutes.Get("/resolve", controllers.ResolveGraduationRequirement) // ?major=SE&entryYear=2567
+	requirementRoutes.Post("/", middleware.RequireRole("Admin"), controllers.CreateGraduationRequirement)
+	requirementRoutes.Put("/:id", middleware.RequireRole("Admin"), controllers.UpdateGraduationRequirement)
+	requirementRoutes.Delete("/:id", middleware.RequireRole("Admin"), controllers.DeleteGraduationRequirement)
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"Backend-Bluelock-007/src/controllers"
+	"Backend-Bluelock-007/src/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -12,14 +13,103 @@ func hourHistoryRoutes(router fiber.Router) {
 	// hourHistoryGroup.Use(middleware.AuthJWT)
 
 	// GET /hour-history/details - ดึงข้อมูล hour history พร้อม ProgramItem และ Certificate details
-	// Query params: studentId, sourceType, status (comma-separated), search, limit, page
+	// Query params: studentId, sourceType, status (comma-separated), search, fromTerm, toTerm (เช่น 2567/1), limit, page
 	hourHistoryGroup.Get("/details", controllers.GetHourHistoryWithDetails)
 
 	// GET /hour-history/student-hours-summary - ดึงชั่วโมงรวมของนิสิตจาก hour history
 	// Query params: studentId (required)
 	hourHistoryGroup.Get("/student-hours-summary", controllers.GetStudentHoursSummary)
 
-	// POST /hour-history/direct - สร้างการเปลี่ยนแปลงชั่วโมงโดยตรงโดย Admin
+	// GET /hour-history/terms - ชั่วโมงของนิสิตแยกตามภาคการศึกษา (ขั้นต่ำ / เพดานต่อภาคตามเกณฑ์)
+	// Query params: studentId (required)
+	hourHistoryGroup.Get("/terms", controllers.GetStudentTermHours)
+
+	// POST /hour-history/direct - สร้างการเปลี่ยนแปลงชั่วโมงโดยตรงโดย Admin (เกินเกณฑ์ = รอแอดมินอีกคนอนุมัติใน /adjustments)
 	// Body: CreateDirectHourChangeRequest
-	hourHistoryGroup.Post("/direct", controllers.CreateDirectHourChange)
+	hourHistoryGroup.Post("/direct", middleware.AuthJWT, middleware.RequireRole("Admin"), controllers.CreateDirectHourChange)
+
+	// POST /hour-history/programs/:programId/preview - ทดลองประเมินชั่วโมงตาม hourPolicy (ไม่บันทึก)
+	// Body: HourPolicy (optional — ไม่ส่ง = ใช้เกณฑ์ที่บันทึกไว้)
+	hourHistoryGroup.Post("/programs/:programId/preview", controllers.PreviewProgramHours)
+
+	// 📒 ledger ชั่วโมง (append-only) — Admin เท่านั้น
+	ledgerGroup := hourHistoryGroup.Group("/ledger", middleware.AuthJWT, middleware.RequireRole("Admin"))
+
+	// GET /hour-history/ledger - รายการ ledger พร้อมผู้บันทึกและเหตุผล
+	// Query params: studentId, historyId, limit, page
+	ledgerGroup.Get("/", controllers.GetHourLedger)
+
+	// GET /hour-history/ledger/verify - ตรวจ hash chain / seq / ความตรงกันกับ hour history
+	// Query params: studentId (optional — ไม่ส่ง = ทั้งระบบ)
+	ledgerGroup.Get("/verify", controllers.VerifyHourLedger)
+
+	// POST /hour-history/totals/reconcile - เทียบยอดชั่วโมงที่ cache ใน Student กับ ledger (Admin เท่านั้น)
+	// Query params: fix (true = แก้ยอดที่ไม่ตรง)
+	hourHistoryGroup.Post("/totals/reconcile", middleware.AuthJWT, middleware.RequireRole("Admin"), controllers.ReconcileHourTotals)
+
+	// 🔁 ประเมินชั่วโมงใหม่ของกิจกรรมที่เสร็จสิ้นแล้ว (diff ก่อน → ยืนยัน) — Admin เท่านั้น
+	reverifyGroup := hourHistoryGroup.Group("/reverifications", middleware.AuthJWT, middleware.RequireRole("Admin"))
+
+	// POST /hour-history/reverifications - สร้างงาน (Body: enrollmentId | programId | from + to, reason)
+	reverifyGroup.Post("/", controllers.CreateHourReverification)
+
+	// GET /hour-history/reverifications - รายการงาน พร้อมความคืบหน้า
+	reverifyGroup.Get("/", controllers.GetHourReverifications)
+
+	// GET /hour-history/reverifications/:id - ความคืบหน้า + diff ของชั่วโมง / สถานะนิสิต
+	reverifyGroup.Get("/:id", controllers.GetHourReverification)
+
+	// POST /hour-history/reverifications/:id/commit - บันทึก diff
+	reverifyGroup.Post("/:id/commit", controllers.CommitHourReverification)
+
+	// POST /hour-history/reverifications/:id/discard - ยกเลิก diff
+	reverifyGroup.Post("/:id/discard", controllers.DiscardHourReverification)
+
+	// 📥 ปรับชั่วโมงแบบกลุ่มจาก CSV (ตรวจ → บันทึก → อนุมัติรายการที่เกินเกณฑ์) — Admin เท่านั้น
+	adjustmentGroup := hourHistoryGroup.Group("/adjustments", middleware.AuthJWT, middleware.RequireRole("Admin"))
+
+	// POST /hour-history/adjustments - อัปโหลด CSV (form field: file) → preview ผลตรวจแต่ละแถว
+	adjustmentGroup.Post("/", controllers.PreviewHourAdjustments)
+
+	// GET /hour-history/adjustments - รายการชุดปรับชั่วโมง
+	// Query params: state, awaitingApproval, limit, page
+	adjustmentGroup.Get("/", controllers.GetHourAdjustmentBatches)
+
+	// GET /hour-history/adjustments/:id - ผลตรวจ / สถานะอนุมัติของทุกแถว
+	adjustmentGroup.Get("/:id", controllers.GetHourAdjustmentBatch)
+
+	// POST /hour-history/adjustments/:id/apply - บันทึก (แถวที่เกินเกณฑ์ = pending_approval)
+	adjustmentGroup.Post("/:id/apply", controllers.ApplyHourAdjustments)
+
+	// POST /hour-history/adjustments/:id/discard - ยกเลิก preview
+	adjustmentGroup.Post("/:id/discard", controllers.DiscardHourAdjustments)
+
+	// POST /hour-history/adjustments/:id/approve | reject - แอดมินอีกคนตรวจรายการที่รออนุมัติ
+	// Body: HourAdjustmentReviewRequest (lines ว่าง = ทุกรายการที่รออยู่)
+	adjustmentGroup.Post("/:id/approve", controllers.ApproveHourAdjustments)
+	adjustmentGroup.Post("/:id/reject", controllers.RejectHourAdjustments)
+
+	// 📨 คำร้องขอทบทวนผลชั่วโมง (late / incomplete / absent / ใบรับรองที่ถูกปฏิเสธ)
+	appealGroup := hourHistoryGroup.Group("/appeals", middleware.AuthJWT)
+
+	// POST /hour-history/appeals - นิสิตยื่นคำร้อง (form fields: historyId, reason, files)
+	appealGroup.Post("/", controllers.CreateHourAppeal)
+
+	// GET /hour-history/appeals - รายการคำร้อง (นิสิตเห็นเฉพาะของตัวเอง)
+	// Query params: studentId, state, historyId, limit, page
+	appealGroup.Get("/", controllers.GetHourAppeals)
+
+	// GET /hour-history/appeals/:id - รายละเอียดคำร้อง
+	appealGroup.Get("/:id", controllers.GetHourAppeal)
+
+	// GET /hour-history/appeals/:id/evidence/:file - ดาวน์โหลดไฟล์หลักฐาน
+	appealGroup.Get("/:id/evidence/:file", controllers.GetHourAppealEvidence)
+
+	// POST /hour-history/appeals/:id/withdraw - นิสิตถอนคำร้องที่ยังรอพิจารณา
+	appealGroup.Post("/:id/withdraw", controllers.WithdrawHourAppeal)
+
+	// POST /hour-history/appeals/:id/approve | reject - Admin พิจารณา (อนุมัติ = ปรับ hour history ลง ledger) แล้วแจ้งผลทางอีเมล
+	// Body: HourAppealDecisionRequest
+	appealGroup.Post("/:id/approve", middleware.RequireRole("Admin"), controllers.ApproveHourAppeal)
+	appealGroup.Post("/:id/reject", middleware.RequireRole("Admin"), controllers.RejectHourAppeal)
 }
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"Backend-Bluelock-007/src/controllers"
+	"Backend-Bluelock-007/src/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -10,14 +11,31 @@ import (
 func programRoutes(router fiber.Router) {
 	programRoutes := router.Group("/programs")
 	// programRoutes.Use(middleware.AuthJWT)
-	programRoutes.Get("/", controllers.GetAllPrograms) // ดึงผู้ใช้ทั้งหมด
-	programRoutes.Post("/", controllers.CreateProgram) // สร้างผู้ใช้ใหม่
+	programRoutes.Get("/", controllers.GetAllPrograms)       // ดึงผู้ใช้ทั้งหมด
+	programRoutes.Post("/", controllers.CreateProgram)       // สร้างผู้ใช้ใหม่
+	programRoutes.Get("/search", controllers.SearchPrograms) // ค้นหาแบบ full-text เรียงตามความเกี่ยวข้อง
+	programRoutes.Post("/series", controllers.CreateProgramSeries)
+	programRoutes.Get("/series/:seriesId", controllers.GetProgramSeries)
 	programRoutes.Post(":id/image", controllers.UploadProgramImage)
 	programRoutes.Delete(":id/image", controllers.DeleteProgramImage)
 	programRoutes.Get("/:id", controllers.GetProgramByID)   // ดึงข้อมูลผู้ใช้ตาม ID
 	programRoutes.Put("/:id", controllers.UpdateProgram)    // อัปเดตข้อมูลผู้ใช้
 	programRoutes.Delete("/:id", controllers.DeleteProgram) // ลบผู้ใช้
 	programRoutes.Get("/:id/enrollment-summary", controllers.GetEnrollmentSummaryByProgramID)
+	programRoutes.Post("/:id/state", controllers.ChangeProgramState)
+	programRoutes.Get("/:id/state-history", controllers.GetProgramStateHistory)
+	programRoutes.Post("/:id/cancel", controllers.CancelProgram)
+	programRoutes.Post("/:id/postpone", controllers.PostponeProgram)
+	programRoutes.Post("/:id/duplicate", controllers.DuplicateProgram)
+	programRoutes.Put("/:id/series", controllers.UpdateProgramInSeries)
+
+	// ขั้นตอนอนุมัติก่อนเปิดลงทะเบียน (draft → submitted → approved)
+	approvalRoutes := programRoutes.Group("/:id/approval", middleware.AuthJWT, middleware.RequireRole("Admin"))
+	approvalRoutes.Get("/", controllers.GetProgramApproval)
+	approvalRoutes.Post("/submit", controllers.SubmitProgramForApproval)
+	approvalRoutes.Post("/approve", controllers.ApproveProgram)
+	approvalRoutes.Post("/request-changes", controllers.RequestProgramChanges)
+	approvalRoutes.Post("/comments", controllers.CommentOnProgram)
 
 	programRoutes.Get("/calendar/:month/:year", controllers.GetAllProgramCalendar)
 	// Testing endpoints to trigger job handlers
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"Backend-Bluelock-007/src/controllers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// programTemplateRoutes คลังเทมเพลตกิจกรรม (มีเวอร์ชัน) และการสร้างกิจกรรมจากเทมเพลต
+func programTemplateRoutes(router fiber.Router) {
+	templateRoutes := router.Group("/program-templates")
+	templateRoutes.Get("/", controllers.GetProgramTemplates)
+	templateRoutes.Post("/", controllers.CreateProgramTemplate)
+	templateRoutes.Get("/:id", controllers.GetProgramTemplate)
+	templateRoutes.Put("/:id", controllers.UpdateProgramTemplate) // บันทึกเป็นเวอร์ชันใหม่
+	templateRoutes.Delete("/:id", controllers.DeleteProgramTemplate)
+	templateRoutes.Get("/:id/versions", controllers.GetProgramTemplateVersions)
+	templateRoutes.Get("/:id/versions/:version", controllers.GetProgramTemplateVersion)
+	templateRoutes.Post("/:id/programs", controllers.CreateProgramFromTemplate)
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"Backend-Bluelock-007/src/controllers"
+	"Backend-Bluelock-007/src/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// roomRoutes แคตตาล็อกห้อง / ทรัพยากร และปฏิทินการใช้ห้อง
+func roomRoutes(router fiber.Router) {
+	roomRoutes := router.Group("/rooms")
+	roomRoutes.Use(middleware.AuthJWT)
+	roomRoutes.Get("/", controllers.GetRooms)
+	roomRoutes.Post("/", controllers.CreateRoom)
+	roomRoutes.Get("/availability", controllers.GetRoomsAvailability)
+	roomRoutes.Post("/check-conflicts", controllers.CheckRoomConflicts)
+	roomRoutes.Get("/:id", controllers.GetRoomByID)
+	roomRoutes.Put("/:id", controllers.UpdateRoom)
+	roomRoutes.Delete("/:id", controllers.DeleteRoom)
+}
@@ -19,10 +19,13 @@ func InitRoutes(app fiber.Router) {
 	// เรียกใช้ฟังก์ชัน InitUserRoutes และ InitOrderRoutes
 	authRoutes(app)
 	programRoutes(app)
+	programTemplateRoutes(app)
+	calendarRoutes(app)
 	adminRoutes(app)
 	checkInOutRoutes(app)
 	enrollmentRoutes(app)
 	foodRoutes(app)
+	roomRoutes(app)
 	formRoutes(app) //
 	studentRoutes(app)
 	certificateRoutes(app)
@@ -30,6 +33,9 @@ func InitRoutes(app fiber.Router) {
 	SubmissionRoutes(app, db)
 	SetupSummaryReportsRoutes(app)
 	hourHistoryRoutes(app)
+	graduationRequirementRoutes(app)
+	transcriptRoutes(app)
+	trashRoutes(app)
 	TestDataRoutes(app) // เพิ่ม route สำหรับสร้างข้อมูลทดสอบ
 
 	// Route เช็คว่า API ทำงานอยู่
@@ -10,8 +10,9 @@ import (
 func studentRoutes(router fiber.Router) {
 	studentGroup := router.Group("/students")
 	// studentGroup.Use(middleware.AuthJWT)
-	studentGroup.Get("/", controllers.GetStudents)    // ดึงผู้ใช้ทั้งหมด
-	studentGroup.Post("/", controllers.CreateStudent) // สร้างผู้ใช้ใหม่
+	studentGroup.Get("/", controllers.GetStudents)          // ดึงผู้ใช้ทั้งหมด
+	studentGroup.Post("/", controllers.CreateStudent)       // สร้างผู้ใช้ใหม่
+	studentGroup.Get("/search", controllers.SearchStudents) // ค้นหาแบบ full-text เรียงตามความเกี่ยวข้อง
 	// studentGroup.Get("/:code", controllers.GetStudentByCode)                                   // ดึงข้อมูลผู้ใช้ตาม ID
 	studentGroup.Put("/:id", controllers.UpdateStudent)                                        // อัปเดตข้อมูลผู้ใช้
 	studentGroup.Delete("/:id", controllers.DeleteStudent)                                     // ลบผู้ใช้
@@ -20,4 +21,6 @@ func studentRoutes(router fiber.Router) {
 	studentGroup.Get("/sammary-with-hours/:code", controllers.GetSammaryByCodeWithHourHistory) // ดึงข้อมูลสรุปพร้อมชั่วโมงจาก hour history
 	studentGroup.Post("/update-status-by-ids", controllers.UpdateStudentStatusByIDs)           // เพิ่ม route ใหม่
 	studentGroup.Put("/update-status/:id", controllers.UpdateStudentStatus)                    // อัปเดตสถานะนักเรียน
+	studentGroup.Get("/:id/dietary-profile", controllers.GetStudentDietaryProfile)             // ข้อมูลอาหาร/การแพ้อาหาร
+	studentGroup.Put("/:id/dietary-profile", controllers.UpdateStudentDietaryProfile)          // บันทึกข้อมูลอาหาร/การแพ้อาหาร
 }
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"Backend-Bluelock-007/src/controllers"
+	"Backend-Bluelock-007/src/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// transcriptRoutes ใบรับรองชั่วโมง (PDF) และ endpoint สาธารณะสำหรับตรวจสอบผ่าน QR
+func transcriptRoutes(router fiber.Router) {
+	transcriptRoutes := router.Group("/transcripts")
+	transcriptRoutes.Get("/students/:studentId", middleware.AuthJWT, controllers.GetStudentTranscript)
+	transcriptRoutes.Get("/verify/:token", controllers.VerifyTranscript) // สาธารณะ
+}
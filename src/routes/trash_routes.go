@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"Backend-Bluelock-007/src/controllers"
+	"Backend-Bluelock-007/src/middleware"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// trashRoutes ถังขยะของแอดมิน: ดูรายการ / กู้คืน / ลบถาวร
+func trashRoutes(router fiber.Router) {
+	trashRoutes := router.Group("/trash")
+	trashRoutes.Use(middleware.AuthJWT, middleware.RequireRole("Admin"))
+	trashRoutes.Get("/", controllers.GetTrash)
+	trashRoutes.Post("/:type/:id/restore", controllers.RestoreTrashItem)
+	trashRoutes.Delete("/:type/:id", controllers.PurgeTrashItem) // ลบถาวร
+}
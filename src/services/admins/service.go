@@ -3,6 +3,7 @@ package admins
 import (
 	DB "Backend-Bluelock-007/src/database"
 	"Backend-Bluelock-007/src/models"
+	"Backend-Bluelock-007/src/services/search"
 
 	"context"
 	"errors"
@@ -53,7 +54,7 @@ func GetAllAdmins(params models.PaginationParams) ([]bson.M, int64, int, error)
 
 	// ค้นหา: name (ใน Admin) + user.email (ใน Users)
 	if s := strings.TrimSpace(params.Search); s != "" {
-		reg := bson.M{"$regex": s, "$options": "i"}
+		reg := bson.M{"$regex": search.Contains(s)}
 		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{
 			"$or": bson.A{
 				bson.M{"name": reg},
@@ -7,7 +7,6 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -128,31 +127,6 @@ func GetRemainingCooldownTime(email string) time.Duration {
 	return 0
 }
 
-// extractStudentYearFromCode ดึงชั้นปีจากรหัสนิสิต
-func extractStudentYearFromCode(code string) int {
-	if len(code) < 2 {
-		return 0
-	}
-
-	// ดึง 2 ตัวแรกของรหัสนิสิต (เช่น 67 จาก 6712345678)
-	yearPrefix := code[:2]
-	year, err := strconv.Atoi(yearPrefix)
-	if err != nil {
-		return 0
-	}
-
-	// คำนวณปีการศึกษาปัจจุบัน (ปี พ.ศ. - 2500)
-	currentYear := time.Now().Year() - 2500 + 543
-	studentYear := currentYear - year + 1
-
-	// ตรวจสอบว่าอยู่ในช่วง 1-4 ปี
-	if studentYear >= 1 && studentYear <= 4 {
-		return studentYear
-	}
-
-	return 0
-}
-
 // enrichUserProfile ดึงข้อมูลเพิ่มเติมจาก Student/Admin collection
 // ✅ ฟังก์ชันกลางที่ใช้ร่วมกันในทุก auth flow
 func enrichUserProfile(ctx context.Context, user *models.User) error {
@@ -167,7 +141,7 @@ func enrichUserProfile(ctx context.Context, user *models.User) error {
 		user.Name = student.Name
 		user.Code = student.Code
 		user.Major = student.Major
-		user.StudentYear = extractStudentYearFromCode(student.Code)
+		user.StudentYear = student.CurrentYear()
 
 	case "Admin":
 		var admin models.Admin
@@ -0,0 +1,237 @@
+package calendar
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// publicFeedLookback feed สาธารณะแสดงกิจกรรมย้อนหลังไม่เกินกี่วัน
+const publicFeedLookback = 30 * 24 * time.Hour
+
+// ProgramEvents แปลงกิจกรรมเป็น Event ต่อกิจกรรมย่อยต่อวัน (items = nil คือทุกกิจกรรมย่อย)
+// UID ผูกกับกิจกรรมย่อย + ลำดับวัน เพื่อให้การเลื่อนวันเป็นการอัปเดตนัดเดิม ไม่ใช่นัดใหม่
+func ProgramEvents(program *models.ProgramDto, items map[primitive.ObjectID]bool, sequence int) []Event {
+	loc := bangkok()
+	programName := ""
+	if program.Name != nil {
+		programName = *program.Name
+	}
+	cancelled := program.ProgramState == models.ProgramStateCancelled
+
+	var events []Event
+	for _, item := range program.ProgramItems {
+		if items != nil && !items[item.ID] {
+			continue
+		}
+		summary := programName
+		if item.Name != nil && *item.Name != "" && *item.Name != programName {
+			summary = programName + " - " + *item.Name
+		}
+		if cancelled {
+			summary = "[ยกเลิก] " + summary
+		}
+
+		var desc []string
+		if item.Description != nil && *item.Description != "" {
+			desc = append(desc, *item.Description)
+		}
+		if item.Hour != nil {
+			desc = append(desc, fmt.Sprintf("ชั่วโมงกิจกรรม: %d ชั่วโมง", *item.Hour))
+		}
+		if item.Operator != nil && *item.Operator != "" {
+			desc = append(desc, "ผู้ดูแล: "+*item.Operator)
+		}
+		location := ""
+		if item.Rooms != nil {
+			location = strings.Join(*item.Rooms, ", ")
+		}
+
+		for i, d := range item.Dates {
+			e := Event{
+				UID:         fmt.Sprintf("%s-%d@%s", item.ID.Hex(), i, uidDomain),
+				Sequence:    sequence,
+				Summary:     summary,
+				Description: strings.Join(desc, "\n"),
+				Location:    location,
+				URL:         ProgramDetailURL(program.ID.Hex()),
+				Cancelled:   cancelled,
+			}
+			start, err := time.ParseInLocation("2006-01-02 15:04", d.Date+" "+d.Stime, loc)
+			if err != nil {
+				day, err := time.ParseInLocation("2006-01-02", d.Date, loc)
+				if err != nil {
+					continue
+				}
+				e.AllDay, e.Start, e.End = true, day, day.AddDate(0, 0, 1)
+			} else {
+				end, err := time.ParseInLocation("2006-01-02 15:04", d.Date+" "+d.Etime, loc)
+				if err != nil || !end.After(start) {
+					end = start.Add(time.Hour)
+				}
+				e.Start, e.End = start, end
+			}
+			events = append(events, e)
+		}
+	}
+	return events
+}
+
+// ProgramDetailURL ลิงก์หน้ารายละเอียดกิจกรรมฝั่งนิสิต
+func ProgramDetailURL(programID string) string {
+	base := strings.TrimRight(os.Getenv("FRONTEND_URL"), "/")
+	if base == "" {
+		base = "http://localhost:9000"
+	}
+	return base + "/Student/Program/MyProgramDetail/" + programID
+}
+
+// ProgramInvite ไฟล์ .ics สำหรับแนบอีเมล
+// method: PUBLISH = แจ้งกำหนดการ, REQUEST = นัด/อัปเดตนัด, CANCEL = ยกเลิกนัด
+func ProgramInvite(ctx context.Context, program *models.ProgramDto, items map[primitive.ObjectID]bool, method string) []byte {
+	sequences := programSequences(ctx, []models.ProgramDto{*program})
+	name := ""
+	if program.Name != nil {
+		name = *program.Name
+	}
+	return Build(name, method, ProgramEvents(program, items, sequences[program.ID]))
+}
+
+// PublicFeed feed สาธารณะของกิจกรรมที่เปิด/ปิดรับสมัครแล้ว (กิจกรรมที่ถูกยกเลิกแสดงเป็น STATUS:CANCELLED)
+func PublicFeed(ctx context.Context) ([]byte, error) {
+	programs, err := loadPrograms(ctx, bson.M{
+		"deletedAt": nil,
+		"programState": bson.M{"$in": []string{
+			models.ProgramStateOpen,
+			models.ProgramStateClose,
+			models.ProgramStateCancelled,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-publicFeedLookback).Format("2006-01-02")
+	sequences := programSequences(ctx, programs)
+
+	var events []Event
+	for i := range programs {
+		for _, e := range ProgramEvents(&programs[i], nil, sequences[programs[i].ID]) {
+			if e.Start.Format("2006-01-02") >= cutoff {
+				events = append(events, e)
+			}
+		}
+	}
+	return Build("กิจกรรมทั้งหมด", MethodPublish, events), nil
+}
+
+// StudentFeed feed ส่วนตัวของนิสิต: เฉพาะกิจกรรมย่อยที่ลงทะเบียนไว้
+func StudentFeed(ctx context.Context, studentID primitive.ObjectID) ([]byte, error) {
+	cur, err := DB.EnrollmentCollection.Find(ctx, bson.M{"studentId": studentID})
+	if err != nil {
+		return nil, err
+	}
+	var enrollments []models.Enrollment
+	if err := cur.All(ctx, &enrollments); err != nil {
+		return nil, err
+	}
+
+	items := map[primitive.ObjectID]bool{}
+	programSet := map[primitive.ObjectID]bool{}
+	var programIDs []primitive.ObjectID
+	for _, en := range enrollments {
+		items[en.ProgramItemID] = true
+		if !programSet[en.ProgramID] {
+			programSet[en.ProgramID] = true
+			programIDs = append(programIDs, en.ProgramID)
+		}
+	}
+
+	var events []Event
+	if len(programIDs) > 0 {
+		programs, err := loadPrograms(ctx, bson.M{"_id": bson.M{"$in": programIDs}, "deletedAt": nil})
+		if err != nil {
+			return nil, err
+		}
+		sequences := programSequences(ctx, programs)
+		for i := range programs {
+			events = append(events, ProgramEvents(&programs[i], items, sequences[programs[i].ID])...)
+		}
+	}
+	return Build("กิจกรรมของฉัน", MethodPublish, events), nil
+}
+
+// loadPrograms ดึง Program พร้อม ProgramItems
+func loadPrograms(ctx context.Context, filter bson.M) ([]models.ProgramDto, error) {
+	cur, err := DB.ProgramCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var programs []models.ProgramDto
+	if err := cur.All(ctx, &programs); err != nil {
+		return nil, err
+	}
+	if len(programs) == 0 {
+		return programs, nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(programs))
+	index := make(map[primitive.ObjectID]int, len(programs))
+	for i, p := range programs {
+		ids = append(ids, p.ID)
+		index[p.ID] = i
+	}
+	itemCur, err := DB.ProgramItemCollection.Find(ctx, bson.M{"programId": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	var items []models.ProgramItem
+	if err := itemCur.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if i, ok := index[item.ProgramID]; ok {
+			programs[i].ProgramItems = append(programs[i].ProgramItems, models.ProgramItemDto(item))
+		}
+	}
+	return programs, nil
+}
+
+// programSequences SEQUENCE ของแต่ละกิจกรรม = จำนวนครั้งที่สถานะ/กำหนดการเปลี่ยน (ประวัติใน Program_State_Histories)
+// + revision ที่เพิ่มทุกครั้งที่แก้ไขกิจกรรม (แก้วัน/เวลาผ่าน UpdateProgram ไม่มีประวัติสถานะ)
+func programSequences(ctx context.Context, programs []models.ProgramDto) map[primitive.ObjectID]int {
+	sequences := map[primitive.ObjectID]int{}
+	if len(programs) == 0 {
+		return sequences
+	}
+	programIDs := make([]primitive.ObjectID, 0, len(programs))
+	for _, p := range programs {
+		programIDs = append(programIDs, p.ID)
+		sequences[p.ID] = p.Revision
+	}
+	cur, err := DB.ProgramStateHistoryCollection.Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"programId": bson.M{"$in": programIDs}}},
+		{"$group": bson.M{"_id": "$programId", "count": bson.M{"$sum": 1}}},
+	})
+	if err != nil {
+		return sequences
+	}
+	var rows []struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Count int                `bson:"count"`
+	}
+	if err := cur.All(ctx, &rows); err != nil {
+		return sequences
+	}
+	for _, r := range rows {
+		sequences[r.ID] += r.Count
+	}
+	return sequences
+}
@@ -0,0 +1,143 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ========================================
+// iCalendar (RFC 5545) - สร้างไฟล์ .ics สำหรับ feed และไฟล์แนบในอีเมล
+// ========================================
+
+// METHOD ของ VCALENDAR
+const (
+	MethodPublish = "PUBLISH" // feed สำหรับ subscribe
+	MethodRequest = "REQUEST" // เชิญ / อัปเดตนัดหมาย (ไฟล์แนบอีเมล)
+	MethodCancel  = "CANCEL"  // ยกเลิกนัดหมาย (ไฟล์แนบอีเมล)
+)
+
+const (
+	tzBangkok = "Asia/Bangkok"
+	prodID    = "-//Bluelock 007//Programs//TH"
+	uidDomain = "bluelock007"
+)
+
+// Event หนึ่งนัดหมาย (VEVENT) = กิจกรรมย่อย 1 รายการใน 1 วัน
+type Event struct {
+	UID         string
+	Sequence    int // เพิ่มขึ้นทุกครั้งที่กิจกรรมเปลี่ยน เพื่อให้ปฏิทินของผู้ subscribe อัปเดตตาม
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Start       time.Time
+	End         time.Time
+	AllDay      bool
+	Cancelled   bool
+}
+
+// bangkok เขตเวลาที่ใช้กับวัน/เวลาของกิจกรรมทั้งหมด
+func bangkok() *time.Location {
+	if loc, err := time.LoadLocation(tzBangkok); err == nil {
+		return loc
+	}
+	return time.FixedZone("ICT", 7*60*60)
+}
+
+// Build สร้าง VCALENDAR จากรายการ Event (บรรทัดคั่นด้วย CRLF ตาม RFC 5545)
+func Build(name, method string, events []Event) []byte {
+	var b strings.Builder
+	now := time.Now().UTC().Format("20060102T150405Z")
+
+	writeLine(&b, "BEGIN:VCALENDAR")
+	writeLine(&b, "VERSION:2.0")
+	writeLine(&b, "PRODID:"+prodID)
+	writeLine(&b, "CALSCALE:GREGORIAN")
+	writeLine(&b, "METHOD:"+method)
+	if name != "" {
+		writeLine(&b, "X-WR-CALNAME:"+escapeText(name))
+	}
+	writeLine(&b, "X-WR-TIMEZONE:"+tzBangkok)
+
+	// ประเทศไทยไม่มี daylight saving → offset +07:00 ตลอด
+	writeLine(&b, "BEGIN:VTIMEZONE")
+	writeLine(&b, "TZID:"+tzBangkok)
+	writeLine(&b, "BEGIN:STANDARD")
+	writeLine(&b, "DTSTART:19700101T000000")
+	writeLine(&b, "TZOFFSETFROM:+0700")
+	writeLine(&b, "TZOFFSETTO:+0700")
+	writeLine(&b, "TZNAME:ICT")
+	writeLine(&b, "END:STANDARD")
+	writeLine(&b, "END:VTIMEZONE")
+
+	for _, e := range events {
+		writeLine(&b, "BEGIN:VEVENT")
+		writeLine(&b, "UID:"+e.UID)
+		writeLine(&b, "DTSTAMP:"+now)
+		writeLine(&b, fmt.Sprintf("SEQUENCE:%d", e.Sequence))
+		if e.AllDay {
+			writeLine(&b, "DTSTART;VALUE=DATE:"+e.Start.Format("20060102"))
+			writeLine(&b, "DTEND;VALUE=DATE:"+e.End.Format("20060102"))
+		} else {
+			writeLine(&b, "DTSTART;TZID="+tzBangkok+":"+e.Start.Format("20060102T150405"))
+			writeLine(&b, "DTEND;TZID="+tzBangkok+":"+e.End.Format("20060102T150405"))
+		}
+		writeLine(&b, "SUMMARY:"+escapeText(e.Summary))
+		if e.Description != "" {
+			writeLine(&b, "DESCRIPTION:"+escapeText(e.Description))
+		}
+		if e.Location != "" {
+			writeLine(&b, "LOCATION:"+escapeText(e.Location))
+		}
+		if e.URL != "" {
+			writeLine(&b, "URL:"+e.URL)
+		}
+		if e.Cancelled {
+			writeLine(&b, "STATUS:CANCELLED")
+		} else {
+			writeLine(&b, "STATUS:CONFIRMED")
+		}
+		writeLine(&b, "END:VEVENT")
+	}
+
+	writeLine(&b, "END:VCALENDAR")
+	return []byte(b.String())
+}
+
+// writeLine เขียน content line พร้อมพับบรรทัดที่ยาวเกิน 75 octets (ไม่ตัดกลางตัวอักษร UTF-8)
+func writeLine(b *strings.Builder, line string) {
+	const limit = 75
+	first := true
+	for len(line) > 0 {
+		max := limit
+		if !first {
+			max = limit - 1 // บรรทัดต่อเนื่องขึ้นต้นด้วยช่องว่าง 1 octet
+		}
+		cut := len(line)
+		if cut > max {
+			cut = max
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+		}
+		if !first {
+			b.WriteString(" ")
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n")
+		line = line[cut:]
+		first = false
+	}
+}
+
+// escapeText escape ค่า TEXT ตาม RFC 5545 (\ ; , และขึ้นบรรทัดใหม่)
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, ";", `\;`)
+	s = strings.ReplaceAll(s, ",", `\,`)
+	s = strings.ReplaceAll(s, "\r\n", `\n`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return s
+}
@@ -11,7 +11,6 @@ import (
 	"io"
 	"net/http"
 	"os"
-	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -19,75 +18,9 @@ import (
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
-// calculateCurrentCertificateHours คำนวณชั่วโมงรวมจาก certificate ที่ approved แล้ว (sourceType = "certificate")
-func calculateCurrentCertificateHours(ctx context.Context, studentID primitive.ObjectID, skillType string) (int, error) {
-	// Query: หา HourChangeHistory ที่เป็น certificate และ approved
-	filter := bson.M{
-		"studentId":  studentID,
-		"sourceType": "certificate",
-		"status":     models.HCStatusApproved,
-		"skillType":  skillType,
-	}
-
-	cursor, err := DB.HourChangeHistoryCollection.Find(ctx, filter)
-	if err != nil {
-		return 0, fmt.Errorf("failed to query hour change history: %v", err)
-	}
-	defer cursor.Close(ctx)
-
-	totalHours := 0
-	for cursor.Next(ctx) {
-		var record models.HourChangeHistory
-		if err := cursor.Decode(&record); err != nil {
-			continue
-		}
-		totalHours += record.HourChange
-	}
-
-	return totalHours, nil
-}
-
-// getMaxTrainingHours คำนวณชั่วโมงอบรมสูงสุดตามประเภท skill และสาขา
-func getMaxTrainingHours(skillType string, major string) int {
-	if skillType == "soft" {
-		return 15 // soft skill: อบรมไม่เกิน 15 ชั่วโมง (ทุกสาขา)
-	}
-
-	// hard skill: ขึ้นอยู่กับสาขา
-	majorUpper := strings.ToUpper(major)
-	if majorUpper == "SE" || majorUpper == "AAI" {
-		return 9 // SE และ AAI: อบรมไม่เกิน 9 ชั่วโมง
-	}
-
-	// ITDI, CS และสาขาอื่นๆ
-	return 6 // อบรมไม่เกิน 6 ชั่วโมง
-}
-
-// calculateHoursToAdd คำนวณชั่วโมงที่สามารถเพิ่มได้จริง (ไม่เกิน max)
-func calculateHoursToAdd(courseHour, currentHours, maxHours int, studentCode, skillType string) int {
-	hoursToAdd := courseHour
-
-	if currentHours+hoursToAdd > maxHours {
-		hoursToAdd = maxHours - currentHours
-		if hoursToAdd < 0 {
-			hoursToAdd = 0
-		}
-
-		if hoursToAdd > 0 {
-			fmt.Printf("⚠️ Certificate hours capped: Student %s already has %d/%d %s training hours, adding only %d (original: %d)\n",
-				studentCode, currentHours, maxHours, skillType, hoursToAdd, courseHour)
-		} else {
-			fmt.Printf("⚠️ Student %s has reached max %s training hours (%d/%d), no hours added\n",
-				studentCode, skillType, currentHours, maxHours)
-		}
-	}
-
-	return hoursToAdd
-}
-
 // saveOrUpdateHourHistory บันทึกหรืออัพเดท hour history record และบันทึก hourHistoryId กลับไปที่ certificate
 // ต้องมี hour history record อยู่แล้ว ไม่งั้น error
-func saveOrUpdateHourHistory(ctx context.Context, certificate *models.UploadCertificate, course models.Course, skillType string, hoursToAdd int, status string) error {
+func saveOrUpdateHourHistory(ctx context.Context, certificate *models.UploadCertificate, course models.Course, skillType string, hoursToAdd int, status string, actor string) error {
 	now := time.Now()
 
 	// ตรวจสอบว่า certificate มี hourHistoryId หรือไม่
@@ -119,13 +52,13 @@ func saveOrUpdateHourHistory(ctx context.Context, certificate *models.UploadCert
 		},
 	}
 
-	updateResult, err := DB.HourChangeHistoryCollection.UpdateOne(ctx, histFilter, histUpdate)
+	matched, err := hourhistory.UpdateHistories(ctx, histFilter, histUpdate, actor, remark)
 	if err != nil {
 		return fmt.Errorf("failed to update hour history: %v", err)
 	}
 
 	// ถ้าไม่เจอ record ให้ error
-	if updateResult.MatchedCount == 0 {
+	if matched == 0 {
 		return fmt.Errorf("hour history record not found for certificate %s (hourHistoryId: %s)",
 			certificate.ID.Hex(), certificate.HourHistoryId.Hex())
 	}
@@ -138,7 +71,7 @@ func saveOrUpdateHourHistory(ctx context.Context, certificate *models.UploadCert
 
 // updateCertificateHoursRejected อัพเดท student hours และ hour history เมื่อ certificate ถูกปฏิเสธหรือยกเลิก
 // ต้องมี hour history record อยู่แล้ว ไม่งั้น error
-func updateCertificateHoursRejected(ctx context.Context, certificate *models.UploadCertificate) error {
+func updateCertificateHoursRejected(ctx context.Context, certificate *models.UploadCertificate, actor string) error {
 	// Validation: ตรวจสอบว่า certificate ไม่ซ้ำ
 	if certificate.IsDuplicate {
 		fmt.Printf("Skipping hours removal for duplicate certificate %s\n", certificate.ID.Hex())
@@ -224,12 +157,12 @@ func updateCertificateHoursRejected(ctx context.Context, certificate *models.Upl
 		},
 	}
 
-	result, err := DB.HourChangeHistoryCollection.UpdateOne(ctx, histFilter, histUpdate)
+	matched, err := hourhistory.UpdateHistories(ctx, histFilter, histUpdate, actor, remark)
 	if err != nil {
 		return fmt.Errorf("failed to update hour history: %v", err)
 	}
 
-	if result.MatchedCount == 0 {
+	if matched == 0 {
 		return fmt.Errorf("failed to update hour history - record not found (ID: %s)", certificate.HourHistoryId.Hex())
 	}
 
@@ -251,7 +184,7 @@ func updateCertificateHoursRejected(ctx context.Context, certificate *models.Upl
 // recordCertificateRejection อัพเดท hour history เมื่อ certificate ถูกปฏิเสธจาก pending
 // ไม่มีการเปลี่ยนแปลงชั่วโมงจริง (hourChange = 0) แต่บันทึกเป็นประวัติ
 // ต้องมี hour history record อยู่แล้ว ไม่งั้น error
-func recordCertificateRejection(ctx context.Context, certificate *models.UploadCertificate, adminRemark string) error {
+func recordCertificateRejection(ctx context.Context, certificate *models.UploadCertificate, adminRemark string, actor string) error {
 	// ตรวจสอบว่ามี hourHistoryId
 	if certificate.HourHistoryId == nil {
 		return fmt.Errorf("certificate %s does not have hourHistoryId", certificate.ID.Hex())
@@ -292,13 +225,13 @@ func recordCertificateRejection(ctx context.Context, certificate *models.UploadC
 		},
 	}
 
-	result, err := DB.HourChangeHistoryCollection.UpdateOne(ctx, histFilter, histUpdate)
+	matched, err := hourhistory.UpdateHistories(ctx, histFilter, histUpdate, actor, remark)
 	if err != nil {
 		return fmt.Errorf("failed to update hour history: %v", err)
 	}
 
 	// ถ้าไม่เจอ record ให้ error
-	if result.MatchedCount == 0 {
+	if matched == 0 {
 		return fmt.Errorf("hour history record not found for certificate %s (hourHistoryId: %s)",
 			certificate.ID.Hex(), certificate.HourHistoryId.Hex())
 	}
@@ -312,7 +245,7 @@ func recordCertificateRejection(ctx context.Context, certificate *models.UploadC
 // recordCertificatePending อัพเดท hour history เมื่อ certificate กลับไปสถานะ pending
 // ไม่มีการเปลี่ยนแปลงชั่วโมงจริง (hourChange = 0) แต่บันทึกเป็นประวัติ
 // ต้องมี hour history record อยู่แล้ว ไม่งั้น error
-func recordCertificatePending(ctx context.Context, certificate *models.UploadCertificate, adminRemark string) error {
+func recordCertificatePending(ctx context.Context, certificate *models.UploadCertificate, adminRemark string, actor string) error {
 	// ไม่ต้องบันทึกถ้าเป็น duplicate
 	if certificate.IsDuplicate {
 		return nil
@@ -358,13 +291,13 @@ func recordCertificatePending(ctx context.Context, certificate *models.UploadCer
 		},
 	}
 
-	result, err := DB.HourChangeHistoryCollection.UpdateOne(ctx, histFilter, histUpdate)
+	matched, err := hourhistory.UpdateHistories(ctx, histFilter, histUpdate, actor, remark)
 	if err != nil {
 		return fmt.Errorf("failed to update hour history: %v", err)
 	}
 
 	// ถ้าไม่เจอ record ให้ error
-	if result.MatchedCount == 0 {
+	if matched == 0 {
 		return fmt.Errorf("hour history record not found for certificate %s (hourHistoryId: %s)",
 			certificate.ID.Hex(), certificate.HourHistoryId.Hex())
 	}
@@ -387,13 +320,13 @@ func RecordUploadPending(certificate *models.UploadCertificate, remark string) e
 		return nil
 	}
 
-	return recordCertificatePending(context.Background(), certificate, remark)
+	return recordCertificatePending(context.Background(), certificate, remark, models.HourLedgerActorSystem)
 }
 
 // finalizePendingHistoryApproved applies hours to the student (if applicable)
 // and updates the pending HourChangeHistory for the given upload to approved.
 // ต้องมี hour history record อยู่แล้ว ไม่งั้น error
-func finalizePendingHistoryApproved(ctx context.Context, upload *models.UploadCertificate, course models.Course) error {
+func finalizePendingHistoryApproved(ctx context.Context, upload *models.UploadCertificate, course models.Course, actor string) error {
 	// ตรวจสอบว่ามี hourHistoryId
 	if upload.HourHistoryId == nil {
 		return fmt.Errorf("upload certificate %s does not have hourHistoryId", upload.ID.Hex())
@@ -412,13 +345,16 @@ func finalizePendingHistoryApproved(ctx context.Context, upload *models.UploadCe
 	}
 
 	// คำนวณชั่วโมงที่สามารถเพิ่มได้
-	currentCertHours, err := calculateCurrentCertificateHours(ctx, upload.StudentId, skillType)
+	currentCertHours, err := hourhistory.CurrentCertificateHours(ctx, upload.StudentId, skillType)
 	if err != nil {
 		return fmt.Errorf("failed to calculate current certificate hours: %v", err)
 	}
 
-	maxTrainingHours := getMaxTrainingHours(skillType, student.Major)
-	hoursToAdd := calculateHoursToAdd(course.Hour, currentCertHours, maxTrainingHours, student.Code, skillType)
+	maxTrainingHours, err := hourhistory.MaxCertificateHours(ctx, skillType, student.Major, student.EntryYear)
+	if err != nil {
+		return fmt.Errorf("failed to resolve graduation requirement: %v", err)
+	}
+	hoursToAdd := hourhistory.CertificateHoursToAdd(course.Hour, currentCertHours, maxTrainingHours, student.Code, skillType)
 
 	// Log hours information (ไม่อัพเดท softSkill/hardSkill โดยตรงอีกต่อไป - ใช้ hour history เป็นแหล่งข้อมูลหลัก)
 	if !upload.IsDuplicate && course.IsActive {
@@ -449,13 +385,13 @@ func finalizePendingHistoryApproved(ctx context.Context, upload *models.UploadCe
 		"skillType":  skillType,
 	}}
 
-	result, err := DB.HourChangeHistoryCollection.UpdateOne(ctx, histFilter, histUpdate)
+	matched, err := hourhistory.UpdateHistories(ctx, histFilter, histUpdate, actor, "อนุมัติใบรับรอง")
 	if err != nil {
 		return fmt.Errorf("failed to update hour history: %v", err)
 	}
 
 	// ถ้าไม่เจอ record ให้ error
-	if result.MatchedCount == 0 {
+	if matched == 0 {
 		return fmt.Errorf("hour history record not found for certificate %s (hourHistoryId: %s)",
 			upload.ID.Hex(), upload.HourHistoryId.Hex())
 	}
@@ -468,7 +404,7 @@ func finalizePendingHistoryApproved(ctx context.Context, upload *models.UploadCe
 
 // finalizePendingHistoryRejected updates the pending HourChangeHistory to rejected.
 // ต้องมี hour history record อยู่แล้ว ไม่งั้น error
-func finalizePendingHistoryRejected(ctx context.Context, upload *models.UploadCertificate, course models.Course, remark string) error {
+func finalizePendingHistoryRejected(ctx context.Context, upload *models.UploadCertificate, course models.Course, remark string, actor string) error {
 	// ตรวจสอบว่ามี hourHistoryId
 	if upload.HourHistoryId == nil {
 		return fmt.Errorf("upload certificate %s does not have hourHistoryId", upload.ID.Hex())
@@ -497,13 +433,13 @@ func finalizePendingHistoryRejected(ctx context.Context, upload *models.UploadCe
 		"skillType":  skillType,
 	}}
 
-	result, err := DB.HourChangeHistoryCollection.UpdateOne(ctx, histFilter, histUpdate)
+	matched, err := hourhistory.UpdateHistories(ctx, histFilter, histUpdate, actor, remark)
 	if err != nil {
 		return fmt.Errorf("failed to update hour history: %v", err)
 	}
 
 	// ถ้าไม่เจอ record ให้ error
-	if result.MatchedCount == 0 {
+	if matched == 0 {
 		return fmt.Errorf("hour history record not found for certificate %s (hourHistoryId: %s)",
 			upload.ID.Hex(), upload.HourHistoryId.Hex())
 	}
@@ -516,7 +452,7 @@ func finalizePendingHistoryRejected(ctx context.Context, upload *models.UploadCe
 
 // updateCertificateHoursApproved applies approval logic (wraps finalizePendingHistoryApproved)
 // This function is used by admin flow to add hours when a certificate is approved.
-func updateCertificateHoursApproved(ctx context.Context, certificate *models.UploadCertificate) error {
+func updateCertificateHoursApproved(ctx context.Context, certificate *models.UploadCertificate, actor string) error {
 	// Load course
 	course, err := courses.GetCourseByID(certificate.CourseId)
 	if err != nil {
@@ -524,7 +460,7 @@ func updateCertificateHoursApproved(ctx context.Context, certificate *models.Upl
 	}
 
 	// Finalize the pending history to approved (this will compute hourChange and update HourChangeHistory)
-	if err := finalizePendingHistoryApproved(ctx, certificate, *course); err != nil {
+	if err := finalizePendingHistoryApproved(ctx, certificate, *course, actor); err != nil {
 		return fmt.Errorf("failed to finalize pending history approved: %v", err)
 	}
 
@@ -4,6 +4,8 @@ import (
 	DB "Backend-Bluelock-007/src/database"
 	"Backend-Bluelock-007/src/models"
 	"Backend-Bluelock-007/src/services/courses"
+	hourhistory "Backend-Bluelock-007/src/services/hour-history"
+	"Backend-Bluelock-007/src/services/search"
 	"context"
 	"errors"
 	"fmt"
@@ -86,7 +88,7 @@ func CreateUploadCertificate(uploadCertificate *models.UploadCertificate) (*mode
 		EnrollmentID: nil,
 	}
 
-	_, err = DB.HourChangeHistoryCollection.InsertOne(ctx, hourHistory)
+	err = hourhistory.InsertHistory(ctx, &hourHistory, "student:"+uploadCertificate.StudentId.Hex(), hourHistory.Remark)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create hour history: %v", err)
 	}
@@ -97,7 +99,7 @@ func CreateUploadCertificate(uploadCertificate *models.UploadCertificate) (*mode
 	result, err := DB.UploadCertificateCollection.InsertOne(ctx, uploadCertificate)
 	if err != nil {
 		// ถ้าสร้าง certificate ไม่สำเร็จ ลบ hour history ที่สร้างไว้
-		DB.HourChangeHistoryCollection.DeleteOne(ctx, bson.M{"_id": hourHistoryId})
+		hourhistory.VoidHistories(ctx, bson.M{"_id": hourHistoryId}, models.HourLedgerActorSystem, "สร้าง certificate ไม่สำเร็จ")
 		return nil, err
 	}
 
@@ -132,7 +134,7 @@ func UpdateUploadCertificate(id string, uploadCertificate *models.UploadCertific
 
 // UpdateUploadCertificateStatus อัพเดทสถานะของ certificate และจัดการชั่วโมงอัตโนมัติ
 // ใช้โดย Admin เพื่อ approve/reject certificate
-func UpdateUploadCertificateStatus(id string, newStatus models.StatusType, remark string) (*models.UploadCertificate, error) {
+func UpdateUploadCertificateStatus(id string, newStatus models.StatusType, remark string, actor string) (*models.UploadCertificate, error) {
 	ctx := context.Background()
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -197,7 +199,7 @@ func UpdateUploadCertificateStatus(id string, newStatus models.StatusType, remar
 
 		certForHours.Remark = "อนุมัติโดยเจ้าหน้าที่"
 
-		if err := updateCertificateHoursApproved(ctx, &certForHours); err != nil {
+		if err := updateCertificateHoursApproved(ctx, &certForHours, actor); err != nil {
 			return nil, fmt.Errorf("failed to add hours: %v", err)
 		}
 	}
@@ -216,7 +218,7 @@ func UpdateUploadCertificateStatus(id string, newStatus models.StatusType, remar
 		fmt.Printf("▶️ Old Remark: %s\n", oldCert.Remark)
 		fmt.Printf("▶️ Remark for hours removal: %s\n", certForHours.Remark)
 
-		if err := updateCertificateHoursRejected(ctx, &certForHours); err != nil {
+		if err := updateCertificateHoursRejected(ctx, &certForHours, actor); err != nil {
 			return nil, fmt.Errorf("failed to remove hours: %v", err)
 		}
 	}
@@ -227,7 +229,7 @@ func UpdateUploadCertificateStatus(id string, newStatus models.StatusType, remar
 
 		certForHours.Remark = "อนุมัติโดยเจ้าหน้าที่"
 
-		if err := updateCertificateHoursApproved(ctx, &certForHours); err != nil {
+		if err := updateCertificateHoursApproved(ctx, &certForHours, actor); err != nil {
 			return nil, fmt.Errorf("failed to add hours: %v", err)
 		}
 	}
@@ -242,12 +244,12 @@ func UpdateUploadCertificateStatus(id string, newStatus models.StatusType, remar
 		}
 
 		// ลบชั่วโมงที่เคยได้รับการอนุมัติ
-		if err := updateCertificateHoursRejected(ctx, &certForHours); err != nil {
+		if err := updateCertificateHoursRejected(ctx, &certForHours, actor); err != nil {
 			return nil, fmt.Errorf("failed to remove hours: %v", err)
 		}
 
 		// บันทึก history record ด้วยสถานะ pending
-		if err := recordCertificatePending(ctx, &certForHours, certForHours.Remark); err != nil {
+		if err := recordCertificatePending(ctx, &certForHours, certForHours.Remark, actor); err != nil {
 			fmt.Printf("Warning: Failed to record certificate pending status: %v\n", err)
 		}
 	}
@@ -263,7 +265,7 @@ func UpdateUploadCertificateStatus(id string, newStatus models.StatusType, remar
 			certForHours.Remark = remark
 		}
 
-		if err := recordCertificateRejection(ctx, &certForHours, remark); err != nil {
+		if err := recordCertificateRejection(ctx, &certForHours, remark, actor); err != nil {
 			fmt.Printf("Warning: Failed to record certificate rejection: %v\n", err)
 		}
 	}
@@ -279,7 +281,7 @@ func UpdateUploadCertificateStatus(id string, newStatus models.StatusType, remar
 			certForHours.Remark = remark
 		}
 
-		if err := recordCertificatePending(ctx, &certForHours, remark); err != nil {
+		if err := recordCertificatePending(ctx, &certForHours, remark, actor); err != nil {
 			fmt.Printf("Warning: Failed to record certificate pending status: %v\n", err)
 		}
 	}
@@ -401,12 +403,12 @@ func GetUploadCertificates(params models.UploadCertificateQuery, pagination mode
 					"$or": []bson.M{
 						{
 							"student.name": bson.M{
-								"$regex": primitive.Regex{Pattern: pagination.Search, Options: "i"},
+								"$regex": search.Contains(pagination.Search),
 							},
 						},
 						{
 							"student.code": bson.M{
-								"$regex": primitive.Regex{Pattern: pagination.Search, Options: "i"},
+								"$regex": search.Contains(pagination.Search),
 							},
 						},
 					},
@@ -420,7 +422,7 @@ func GetUploadCertificates(params models.UploadCertificateQuery, pagination mode
 			if len(majors) == 1 {
 				pipeline = append(pipeline,
 					bson.D{{Key: "$match", Value: bson.M{
-						"student.major": bson.M{"$regex": primitive.Regex{Pattern: strings.TrimSpace(majors[0]), Options: "i"}},
+						"student.major": bson.M{"$regex": search.Contains(majors[0])},
 					}}},
 				)
 			} else {
@@ -431,7 +433,7 @@ func GetUploadCertificates(params models.UploadCertificateQuery, pagination mode
 					if m == "" {
 						continue
 					}
-					regexes = append(regexes, primitive.Regex{Pattern: m, Options: "i"})
+					regexes = append(regexes, search.Contains(m))
 				}
 				if len(regexes) > 0 {
 					pipeline = append(pipeline,
@@ -451,7 +453,7 @@ func GetUploadCertificates(params models.UploadCertificateQuery, pagination mode
 				yearPrefix := strings.TrimSpace(years[0])
 				pipeline = append(pipeline,
 					bson.D{{Key: "$match", Value: bson.M{
-						"student.code": bson.M{"$regex": primitive.Regex{Pattern: "^" + yearPrefix, Options: "i"}},
+						"student.code": bson.M{"$regex": search.Prefix(yearPrefix)},
 					}}},
 				)
 			} else {
@@ -463,7 +465,7 @@ func GetUploadCertificates(params models.UploadCertificateQuery, pagination mode
 						continue
 					}
 					orConditions = append(orConditions, bson.M{
-						"student.code": bson.M{"$regex": primitive.Regex{Pattern: "^" + y, Options: "i"}},
+						"student.code": bson.M{"$regex": search.Prefix(y)},
 					})
 				}
 				if len(orConditions) > 0 {
@@ -571,10 +573,10 @@ func ProcessPendingUpload(uploadIDHex string) error {
 			return fmt.Errorf("failed to mark duplicate upload: %v", err)
 		}
 		// Finalize pending history as rejected (reuse helper)
-		if err := finalizePendingHistoryRejected(context.Background(), &uc, *course, duplicateRemark); err != nil {
+		if err := finalizePendingHistoryRejected(context.Background(), &uc, *course, duplicateRemark, models.HourLedgerActorSystem); err != nil {
 			// fallback: still attempt to record rejection
 			fmt.Printf("Warning: failed to finalize pending history for duplicate %s: %v\n", uploadIDHex, err)
-			if rerr := recordCertificateRejection(context.Background(), &uc, duplicateRemark); rerr != nil {
+			if rerr := recordCertificateRejection(context.Background(), &uc, duplicateRemark, models.HourLedgerActorSystem); rerr != nil {
 				fmt.Printf("Warning: failed to record rejection history for %s: %v\n", uploadIDHex, rerr)
 			}
 		}
@@ -609,10 +611,10 @@ func ProcessPendingUpload(uploadIDHex string) error {
 			return fmt.Errorf("failed to update upload after error: %v (update err: %v)", err, uerr)
 		}
 		// finalize pending history as rejected (update existing pending record if any)
-		if ferr := finalizePendingHistoryRejected(context.Background(), &uc, *course, remark); ferr != nil {
+		if ferr := finalizePendingHistoryRejected(context.Background(), &uc, *course, remark, models.HourLedgerActorSystem); ferr != nil {
 			fmt.Printf("Warning: failed to finalize pending rejection history for %s: %v\n", uploadIDHex, ferr)
 			// fallback: insert rejection history
-			if rerr := recordCertificateRejection(context.Background(), &uc, remark); rerr != nil {
+			if rerr := recordCertificateRejection(context.Background(), &uc, remark, models.HourLedgerActorSystem); rerr != nil {
 				fmt.Printf("Warning: failed to record rejection history for %s: %v\n", uploadIDHex, rerr)
 			}
 		}
@@ -684,15 +686,15 @@ func ProcessPendingUpload(uploadIDHex string) error {
 	// finalize history depending on chosenStatus
 	switch chosenStatus {
 	case models.StatusApproved:
-		if err := finalizePendingHistoryApproved(context.Background(), &uc, *course); err != nil {
+		if err := finalizePendingHistoryApproved(context.Background(), &uc, *course, models.HourLedgerActorSystem); err != nil {
 			fmt.Printf("Warning: failed to finalize pending history approved for %s: %v\n", uploadIDHex, err)
 		}
 	case models.StatusRejected:
-		if err := finalizePendingHistoryRejected(context.Background(), &uc, *course, remark); err != nil {
+		if err := finalizePendingHistoryRejected(context.Background(), &uc, *course, remark, models.HourLedgerActorSystem); err != nil {
 			fmt.Printf("Warning: failed to finalize pending history rejected for %s: %v\n", uploadIDHex, err)
 		}
 	case models.StatusPending:
-		if err := recordCertificatePending(context.Background(), &uc, remark); err != nil {
+		if err := recordCertificatePending(context.Background(), &uc, remark, models.HourLedgerActorSystem); err != nil {
 			fmt.Printf("Warning: failed to record pending history for %s: %v\n", uploadIDHex, err)
 		}
 	}
@@ -3,7 +3,9 @@ package courses
 import (
 	DB "Backend-Bluelock-007/src/database"
 	"Backend-Bluelock-007/src/models"
+	"Backend-Bluelock-007/src/services/search"
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -12,6 +14,9 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrCourseNotFound ไม่พบคอร์ส (ไม่มีอยู่ หรืออยู่ในถังขยะแล้ว)
+var ErrCourseNotFound = errors.New("course not found")
+
 // CreateCourse - สร้างคอร์สใหม่
 func CreateCourse(course *models.Course) (*models.Course, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -29,13 +34,13 @@ func GetAllCourses(params models.PaginationParams, filters models.CourseFilters)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	query := bson.M{}
+	query := bson.M{"deletedAt": nil} // ไม่รวมคอร์สที่อยู่ในถังขยะ
 
 	// ค้นหา
 	if params.Search != "" {
 		query["$or"] = []bson.M{
-			{"name": bson.M{"$regex": primitive.Regex{Pattern: params.Search, Options: "i"}}},
-			{"description": bson.M{"$regex": primitive.Regex{Pattern: params.Search, Options: "i"}}},
+			{"name": bson.M{"$regex": search.Contains(params.Search)}},
+			{"description": bson.M{"$regex": search.Contains(params.Search)}},
 		}
 	}
 
@@ -123,12 +128,52 @@ func UpdateCourse(id primitive.ObjectID, update models.Course) (*models.Course,
 	return GetCourseByID(id)
 }
 
-// DeleteCourse - ลบคอร์ส
-func DeleteCourse(id primitive.ObjectID) error {
+// DeleteCourse - ย้ายคอร์สไปถังขยะ (soft delete)
+func DeleteCourse(id primitive.ObjectID, actor string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := DB.CourseCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "deletedAt": nil},
+		bson.M{"$set": bson.M{"deletedAt": time.Now(), "deletedBy": actor}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCourseNotFound
+	}
+	return nil
+}
+
+// RestoreCourse - กู้คืนคอร์สจากถังขยะ
+func RestoreCourse(id primitive.ObjectID) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	_, err := DB.CourseCollection.DeleteOne(ctx, bson.M{"_id": id})
-	return err
+	result, err := DB.CourseCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}},
+		bson.M{"$unset": bson.M{"deletedAt": "", "deletedBy": ""}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return models.ErrNotInTrash
+	}
+	return nil
+}
+
+// PurgeCourse - ลบคอร์สถาวร (เฉพาะที่อยู่ในถังขยะแล้ว)
+func PurgeCourse(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	result, err := DB.CourseCollection.DeleteOne(ctx, bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return models.ErrNotInTrash
+	}
+	return nil
 }
 
 // UploadCourseImage - อัปเดตชื่อไฟล์รูปภาพของคอร์ส
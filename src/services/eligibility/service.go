@@ -0,0 +1,260 @@
+package eligibility
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	hourhistory "Backend-Bluelock-007/src/services/hour-history"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ========================================
+// Evaluator - ใช้ร่วมกันทั้ง student / admin / bulk enrollment และ email แจ้งเปิดลงทะเบียน
+// ========================================
+
+// Evaluate ตรวจสอบว่านิสิตมีสิทธิ์ลงทะเบียน programItem หรือไม่
+// คืน error ที่อธิบายเหตุผลของเงื่อนไขแรกที่ไม่ผ่าน, nil ถ้าผ่านทุกเงื่อนไข
+func Evaluate(ctx context.Context, item *models.ProgramItem, student *models.Student) error {
+	reasons, err := evaluate(ctx, item, student, true)
+	if err != nil {
+		return err
+	}
+	if len(reasons) > 0 {
+		return errors.New(reasons[0])
+	}
+	return nil
+}
+
+// Check ตรวจสอบทุกเงื่อนไขและรวบรวมเหตุผลที่ไม่ผ่านทั้งหมด (ใช้แสดงผลให้นิสิต/แอดมินเห็น)
+func Check(ctx context.Context, item *models.ProgramItem, student *models.Student) (*models.EligibilityResult, error) {
+	reasons, err := evaluate(ctx, item, student, false)
+	if err != nil {
+		return nil, err
+	}
+	return &models.EligibilityResult{
+		ProgramItemID: item.ID,
+		StudentID:     student.ID,
+		Eligible:      len(reasons) == 0,
+		Reasons:       reasons,
+	}, nil
+}
+
+// evaluate ประเมิน majors → studentYears → eligibilityRules ตามลำดับ
+// stopOnFirst = true จะหยุดทันทีที่เจอเงื่อนไขแรกที่ไม่ผ่าน (ไม่ต้อง query เงื่อนไขที่เหลือ)
+func evaluate(ctx context.Context, item *models.ProgramItem, student *models.Student, stopOnFirst bool) ([]string, error) {
+	reasons := []string{}
+	reject := func(msg string) bool {
+		reasons = append(reasons, "ไม่สามารถลงทะเบียนได้: "+msg)
+		return stopOnFirst
+	}
+
+	// ✅ เช็คสาขา: กิจกรรมอนุญาตเฉพาะบาง major
+	if len(item.Majors) > 0 && !containsFold(item.Majors, student.Major) {
+		if reject("สาขาไม่ตรงกับเงื่อนไขของกิจกรรม") {
+			return reasons, nil
+		}
+	}
+
+	// ✅ เช็คชั้นปี: กิจกรรมอนุญาตเฉพาะบางชั้นปี (ชั้นปีปัจจุบันคำนวณจาก entryYear)
+	if len(item.StudentYears) > 0 && !containsInt(item.StudentYears, student.CurrentYear()) {
+		if reject("ชั้นปีไม่ตรงกับเงื่อนไขของกิจกรรม") {
+			return reasons, nil
+		}
+	}
+
+	// ✅ เงื่อนไขเพิ่มเติมแบบ declarative
+	hours := &hoursLoader{student: student}
+	for _, rule := range item.EligibilityRules {
+		msg, err := evaluateRule(ctx, rule, student, hours)
+		if err != nil {
+			return nil, err
+		}
+		if msg == "" {
+			continue
+		}
+		if rule.Message != "" {
+			msg = rule.Message
+		}
+		if reject(msg) {
+			return reasons, nil
+		}
+	}
+
+	return reasons, nil
+}
+
+// evaluateRule คืนข้อความปฏิเสธ ("" = ผ่าน)
+func evaluateRule(ctx context.Context, rule models.EligibilityRule, student *models.Student, hours *hoursLoader) (string, error) {
+	switch rule.Type {
+	case models.EligibilityRuleMinHours, models.EligibilityRuleMaxHours:
+		if rule.Hours == nil {
+			return "", nil
+		}
+		current, err := hours.get(ctx, rule.SkillType)
+		if err != nil {
+			return "", err
+		}
+		label := skillLabel(rule.SkillType)
+		if rule.Type == models.EligibilityRuleMinHours && current < *rule.Hours {
+			return fmt.Sprintf("ต้องมีชั่วโมง%sอย่างน้อย %d ชั่วโมง (ปัจจุบัน %d ชั่วโมง)", label, *rule.Hours, current), nil
+		}
+		if rule.Type == models.EligibilityRuleMaxHours && current > *rule.Hours {
+			return fmt.Sprintf("กิจกรรมนี้สำหรับนิสิตที่มีชั่วโมง%sไม่เกิน %d ชั่วโมง (ปัจจุบัน %d ชั่วโมง)", label, *rule.Hours, current), nil
+		}
+
+	case models.EligibilityRuleStudentStatus:
+		if len(rule.Statuses) == 0 {
+			return "", nil
+		}
+		for _, s := range rule.Statuses {
+			if s == student.Status {
+				return "", nil
+			}
+		}
+		return "สถานะนิสิตไม่ตรงกับเงื่อนไขของกิจกรรม", nil
+
+	case models.EligibilityRulePrerequisite:
+		if rule.ProgramID == nil {
+			return "", nil
+		}
+		count, err := DB.HourChangeHistoryCollection.CountDocuments(ctx, bson.M{
+			"studentId":  student.ID,
+			"sourceType": "program",
+			"sourceId":   *rule.ProgramID,
+			"status":     models.HCStatusAttended,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to check prerequisite program: %w", err)
+		}
+		if count == 0 {
+			return "ต้องเคยเข้าร่วมกิจกรรมที่เป็นเงื่อนไขก่อน (" + prerequisiteName(ctx, rule) + ")", nil
+		}
+
+	case models.EligibilityRuleAllowCodes:
+		if len(rule.StudentCodes) > 0 && !containsTrimmed(rule.StudentCodes, student.Code) {
+			return "รหัสนิสิตไม่อยู่ในรายชื่อที่อนุญาตให้ลงทะเบียน", nil
+		}
+
+	case models.EligibilityRuleDenyCodes:
+		if containsTrimmed(rule.StudentCodes, student.Code) {
+			return "รหัสนิสิตอยู่ในรายชื่อที่ไม่อนุญาตให้ลงทะเบียน", nil
+		}
+	}
+
+	return "", nil
+}
+
+// ========================================
+// Validation - ใช้ตอนสร้าง/แก้ไข Program
+// ========================================
+
+// ValidateRules ตรวจสอบความถูกต้องของ eligibilityRules ก่อนบันทึก
+func ValidateRules(rules []models.EligibilityRule) error {
+	for i, rule := range rules {
+		switch rule.Type {
+		case models.EligibilityRuleMinHours, models.EligibilityRuleMaxHours:
+			if rule.Hours == nil || *rule.Hours < 0 {
+				return fmt.Errorf("eligibilityRules[%d]: hours must be a non-negative number", i)
+			}
+			if rule.SkillType != "" && rule.SkillType != "soft" && rule.SkillType != "hard" {
+				return fmt.Errorf("eligibilityRules[%d]: skillType must be soft, hard or empty", i)
+			}
+		case models.EligibilityRuleStudentStatus:
+			if len(rule.Statuses) == 0 {
+				return fmt.Errorf("eligibilityRules[%d]: statuses is required", i)
+			}
+		case models.EligibilityRulePrerequisite:
+			if rule.ProgramID == nil || rule.ProgramID.IsZero() {
+				return fmt.Errorf("eligibilityRules[%d]: programId is required", i)
+			}
+		case models.EligibilityRuleAllowCodes, models.EligibilityRuleDenyCodes:
+			if len(rule.StudentCodes) == 0 {
+				return fmt.Errorf("eligibilityRules[%d]: studentCodes is required", i)
+			}
+		default:
+			return fmt.Errorf("eligibilityRules[%d]: unknown rule type %q", i, rule.Type)
+		}
+	}
+	return nil
+}
+
+// ========================================
+// Helpers
+// ========================================
+
+// hoursLoader โหลดชั่วโมงสุทธิจาก HourChangeHistory เพียงครั้งเดียวต่อการประเมิน
+type hoursLoader struct {
+	student *models.Student
+	loaded  bool
+	soft    int
+	hard    int
+}
+
+func (h *hoursLoader) get(ctx context.Context, skillType string) (int, error) {
+	if !h.loaded {
+		soft, hard, err := hourhistory.CalculateNetHours(ctx, h.student.ID)
+		if err != nil {
+			return 0, err
+		}
+		h.soft, h.hard, h.loaded = soft, hard, true
+	}
+	switch skillType {
+	case "soft":
+		return h.soft, nil
+	case "hard":
+		return h.hard, nil
+	default:
+		return h.soft + h.hard, nil
+	}
+}
+
+func skillLabel(skillType string) string {
+	switch skillType {
+	case "soft":
+		return " soft skill "
+	case "hard":
+		return " hard skill "
+	default:
+		return "รวม"
+	}
+}
+
+func prerequisiteName(ctx context.Context, rule models.EligibilityRule) string {
+	var program models.Program
+	if err := DB.ProgramCollection.FindOne(ctx, bson.M{"_id": *rule.ProgramID}).Decode(&program); err == nil && program.Name != nil {
+		return *program.Name
+	}
+	return rule.ProgramID.Hex()
+}
+
+func containsFold(list []string, val string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, val) { // ปลอดภัยต่อเคสตัวพิมพ์เล็ก/ใหญ่
+			return true
+		}
+	}
+	return false
+}
+
+func containsTrimmed(list []string, val string) bool {
+	val = strings.TrimSpace(val)
+	for _, v := range list {
+		if strings.TrimSpace(v) == val {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(list []int, val int) bool {
+	for _, v := range list {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
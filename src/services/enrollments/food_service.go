@@ -0,0 +1,342 @@
+package enrollments
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"Backend-Bluelock-007/src/services/programs"
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ParseFoodID แปลง foodId (hex) จาก request → nil ถ้าไม่ได้ส่งมา
+func ParseFoodID(raw *string) (*primitive.ObjectID, error) {
+	if raw == nil || *raw == "" {
+		return nil, nil
+	}
+	id, err := primitive.ObjectIDFromHex(*raw)
+	if err != nil {
+		return nil, errors.New("invalid foodId")
+	}
+	return &id, nil
+}
+
+// applyFoodOption บันทึกตัวเลือกอาหารลง enrollment (foodId + ชื่ออาหาร ณ ตอนเลือก)
+func applyFoodOption(enrollment *models.Enrollment, opt *models.FoodVote) {
+	if opt == nil {
+		enrollment.FoodID = nil
+		enrollment.Food = nil
+		return
+	}
+	name := opt.FoodName
+	enrollment.FoodID = opt.FoodID
+	enrollment.Food = &name
+}
+
+// checkFoodCompatibility ตรวจว่าอาหารที่เลือกเหมาะกับ dietaryProfile ของนิสิต
+// ตัวเลือกเดิมที่ไม่มี foodId (ไม่ทราบคุณสมบัติ) จะไม่ถูกตรวจ
+func checkFoodCompatibility(ctx context.Context, opt *models.FoodVote, student *models.Student) error {
+	if opt == nil || opt.FoodID == nil || student.DietaryProfile == nil {
+		return nil
+	}
+	var food models.Food
+	if err := DB.FoodCollection.FindOne(ctx, bson.M{"_id": *opt.FoodID}).Decode(&food); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		return err
+	}
+	if !food.CompatibleWith(student.DietaryProfile) {
+		return errors.New("อาหารที่เลือกไม่เหมาะกับข้อจำกัดด้านอาหาร/การแพ้อาหารของนิสิต")
+	}
+	return nil
+}
+
+// GetFoodOptionsForStudent คืนเฉพาะตัวเลือกอาหารของ Program ที่เหมาะกับ dietaryProfile ของนิสิต
+func GetFoodOptionsForStudent(programID, studentID primitive.ObjectID) ([]models.FoodOption, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var program models.Program
+	if err := DB.ProgramCollection.FindOne(ctx, bson.M{"_id": programID}).Decode(&program); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("program not found")
+		}
+		return nil, err
+	}
+	var student models.Student
+	if err := DB.StudentCollection.FindOne(ctx, bson.M{"_id": studentID}).Decode(&student); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("student not found")
+		}
+		return nil, err
+	}
+
+	ids := []primitive.ObjectID{}
+	for _, opt := range program.FoodVotes {
+		if opt.FoodID != nil {
+			ids = append(ids, *opt.FoodID)
+		}
+	}
+	foods := map[primitive.ObjectID]models.Food{}
+	if len(ids) > 0 {
+		cur, err := DB.FoodCollection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+		if err != nil {
+			return nil, err
+		}
+		var list []models.Food
+		if err := cur.All(ctx, &list); err != nil {
+			return nil, err
+		}
+		for _, f := range list {
+			foods[f.ID] = f
+		}
+	}
+
+	options := []models.FoodOption{}
+	for _, opt := range program.FoodVotes {
+		food := models.Food{Name: opt.FoodName}
+		if opt.FoodID != nil {
+			if f, ok := foods[*opt.FoodID]; ok {
+				food = f
+			}
+		}
+		if opt.FoodID != nil && !food.CompatibleWith(student.DietaryProfile) {
+			continue
+		}
+		options = append(options, models.FoodOption{
+			FoodID:    opt.FoodID,
+			FoodName:  opt.FoodName,
+			Tags:      models.NormalizeFoodTags(food.Tags),
+			Allergens: models.NormalizeFoodTags(food.Allergens),
+		})
+	}
+	return options, nil
+}
+
+// UpdateEnrollmentFood เปลี่ยนอาหารที่เลือกของ enrollment (ส่ง foodId/food ว่าง = ไม่รับอาหาร)
+// enforceDeadline = true สำหรับนิสิต: เปลี่ยนได้ถึง foodDeadline (หรือ endDateEnroll) เท่านั้น
+func UpdateEnrollmentFood(enrollmentID primitive.ObjectID, foodID *primitive.ObjectID, food *string, enforceDeadline bool) (*models.Enrollment, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var enrollment models.Enrollment
+	if err := DB.EnrollmentCollection.FindOne(ctx, bson.M{"_id": enrollmentID}).Decode(&enrollment); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("enrollment not found")
+		}
+		return nil, err
+	}
+
+	var program models.Program
+	if err := DB.ProgramCollection.FindOne(ctx, bson.M{"_id": enrollment.ProgramID}).Decode(&program); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("program not found")
+		}
+		return nil, err
+	}
+
+	if enforceDeadline && !programs.IsFoodSelectionOpen(&program, time.Now()) {
+		return nil, errors.New("เลยกำหนดเวลาเปลี่ยนอาหารแล้ว (" + programs.FoodDeadline(&program) + ")")
+	}
+
+	opt, err := programs.FindFoodOption(&program, foodID, food)
+	if err != nil {
+		return nil, err
+	}
+	var student models.Student
+	if err := DB.StudentCollection.FindOne(ctx, bson.M{"_id": enrollment.StudentID}).Decode(&student); err != nil {
+		return nil, err
+	}
+	if err := checkFoodCompatibility(ctx, opt, &student); err != nil {
+		return nil, err
+	}
+	applyFoodOption(&enrollment, opt)
+
+	if _, err := DB.EnrollmentCollection.UpdateOne(ctx,
+		bson.M{"_id": enrollmentID},
+		bson.M{"$set": bson.M{"foodId": enrollment.FoodID, "food": enrollment.Food}},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := programs.RecalculateFoodVotes(ctx, program.ID); err != nil {
+		log.Printf("⚠️ Warning: Failed to recalculate food votes: %v", err)
+	}
+
+	return &enrollment, nil
+}
+
+// GetCatererExport สรุปจำนวนอาหารและรายชื่อนิสิตของ programItem สำหรับวันที่ระบุ
+// นับเฉพาะนิสิตที่ลงทะเบียนภายในวันนั้น (เวลาไทย) — date ว่างได้เมื่อ programItem มีวันจัดเพียงวันเดียว
+func GetCatererExport(programItemID primitive.ObjectID, date string) (*models.CatererExport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var programItem models.ProgramItem
+	if err := DB.ProgramItemCollection.FindOne(ctx, bson.M{"_id": programItemID}).Decode(&programItem); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("program item not found")
+		}
+		return nil, err
+	}
+
+	if date == "" {
+		if len(programItem.Dates) != 1 {
+			return nil, errors.New("date is required for multi-day program item")
+		}
+		date = programItem.Dates[0].Date
+	} else if !dateExistsInItem(&programItem, date) {
+		return nil, errors.New("date is not in program item schedule")
+	}
+
+	loc, err := time.LoadLocation("Asia/Bangkok")
+	if err != nil {
+		loc = time.FixedZone("UTC+7", 7*60*60)
+	}
+	day, err := time.ParseInLocation("2006-01-02", date, loc)
+	if err != nil {
+		return nil, errors.New("invalid date format")
+	}
+	endOfDay := day.AddDate(0, 0, 1)
+
+	var program models.Program
+	if err := DB.ProgramCollection.FindOne(ctx, bson.M{"_id": programItem.ProgramID}).Decode(&program); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("program not found")
+		}
+		return nil, err
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"programItemId":    programItemID,
+			"registrationDate": bson.M{"$lt": endOfDay},
+		}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "Students",
+			"localField":   "studentId",
+			"foreignField": "_id",
+			"as":           "student",
+		}}},
+		{{Key: "$unwind", Value: "$student"}},
+		{{Key: "$project", Value: bson.M{
+			"_id":            0,
+			"studentCode":    "$student.code",
+			"studentName":    "$student.name",
+			"major":          "$student.major",
+			"foodId":         "$foodId",
+			"foodName":       bson.M{"$ifNull": bson.A{"$food", ""}},
+			"dietaryProfile": "$student.dietaryProfile",
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "foodName", Value: 1}, {Key: "studentCode", Value: 1}}}},
+	}
+	cur, err := DB.EnrollmentCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	rows := []models.CatererStudentRow{}
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	// นับตาม foodId ตามตัวเลือกของ Program (ให้ทุกตัวเลือกแสดงแม้เป็น 0) + อาหารเดิมที่ไม่มีในตัวเลือกแล้ว
+	// ชื่ออาหารใช้แสดงผลเท่านั้น — enrollment เดิมที่ไม่มี foodId จับคู่ตัวเลือกด้วยชื่อ
+	counts := map[string]int{}
+	names := map[string]string{}
+	optionIDs := map[string]*primitive.ObjectID{}
+	for _, opt := range program.FoodVotes {
+		if opt.FoodID != nil {
+			optionIDs[opt.FoodName] = opt.FoodID
+		}
+	}
+	dietCounts := map[string]int{}
+	allergyNotes := []models.CatererAllergyNote{}
+	noSelection := 0
+	for _, r := range rows {
+		if p := r.DietaryProfile; p != nil {
+			for _, d := range p.Diets {
+				dietCounts[d]++
+			}
+			if len(p.Allergies) > 0 || p.Note != "" {
+				allergyNotes = append(allergyNotes, models.CatererAllergyNote{
+					StudentCode: r.StudentCode,
+					StudentName: r.StudentName,
+					FoodName:    r.FoodName,
+					Allergies:   p.Allergies,
+					Note:        p.Note,
+				})
+			}
+		}
+		if r.FoodName == "" {
+			noSelection++
+			continue
+		}
+		foodID := r.FoodID
+		if foodID == nil {
+			foodID = optionIDs[r.FoodName]
+		}
+		key := catererFoodKey(foodID, r.FoodName)
+		counts[key]++
+		if _, ok := names[key]; !ok {
+			names[key] = r.FoodName
+		}
+	}
+	foods := []models.CatererFoodCount{}
+	for _, opt := range program.FoodVotes {
+		key := catererFoodKey(opt.FoodID, opt.FoodName)
+		foods = append(foods, models.CatererFoodCount{FoodID: opt.FoodID, FoodName: opt.FoodName, Count: counts[key]})
+		delete(counts, key)
+	}
+	extra := make([]string, 0, len(counts))
+	for key := range counts {
+		extra = append(extra, key)
+	}
+	sort.Slice(extra, func(i, j int) bool { return names[extra[i]] < names[extra[j]] })
+	for _, key := range extra {
+		food := models.CatererFoodCount{FoodName: names[key], Count: counts[key]}
+		if id, err := primitive.ObjectIDFromHex(key); err == nil {
+			food.FoodID = &id
+		}
+		foods = append(foods, food)
+	}
+
+	diets := []models.CatererDietCount{}
+	for diet, n := range dietCounts {
+		diets = append(diets, models.CatererDietCount{Diet: diet, Count: n})
+	}
+	sort.Slice(diets, func(i, j int) bool { return diets[i].Diet < diets[j].Diet })
+
+	result := &models.CatererExport{
+		ProgramID:     program.ID,
+		ProgramItemID: programItem.ID,
+		Date:          date,
+		Total:         len(rows),
+		NoSelection:   noSelection,
+		Foods:         foods,
+		Diets:         diets,
+		AllergyNotes:  allergyNotes,
+		Students:      rows,
+	}
+	if program.Name != nil {
+		result.ProgramName = *program.Name
+	}
+	if programItem.Name != nil {
+		result.ProgramItemName = *programItem.Name
+	}
+	return result, nil
+}
+
+// catererFoodKey key สำหรับนับอาหาร: foodId (hex) หรือชื่ออาหารสำหรับตัวเลือกเดิมที่ไม่มี foodId
+func catererFoodKey(foodID *primitive.ObjectID, name string) string {
+	if foodID != nil {
+		return foodID.Hex()
+	}
+	return "name:" + name
+}
@@ -145,11 +145,6 @@ func FindEnrolledProgramItem(studentID string, programId string) (string, bool)
 	return "", false
 }
 
-func isTimeOverlap(start1, end1, start2, end2 string) bool {
-	// ตัวอย่าง: 09:00 < 10:00 -> true (มีเวลาทับซ้อน)
-	return !(end1 <= start2 || end2 <= start1)
-}
-
 func bangkok() *time.Location {
 	loc, _ := time.LoadLocation(tzBangkok)
 	return loc
@@ -0,0 +1,225 @@
+package enrollments
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"Backend-Bluelock-007/src/services/eligibility"
+	hourhistory "Backend-Bluelock-007/src/services/hour-history"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ========================================
+// Recommendations - แนะนำกิจกรรมที่เปิดรับสมัครและนิสิตลงทะเบียนได้
+// ========================================
+
+var ErrStudentNotFound = errors.New("student not found")
+
+// GetProgramRecommendations กิจกรรมที่เปิดรับสมัครซึ่งนิสิตลงทะเบียนได้ (สาขา / ชั้นปี / เงื่อนไข / เวลาไม่ทับ / ยังมีที่ว่าง)
+// เรียง: ทักษะที่ขาดจากเกณฑ์มากที่สุดก่อน → วันจัดใกล้ที่สุด → ที่นั่งว่างมากกว่า
+func GetProgramRecommendations(ctx context.Context, studentID primitive.ObjectID, limit int) (*models.ProgramRecommendations, error) {
+	var student models.Student
+	if err := DB.StudentCollection.FindOne(ctx, bson.M{"_id": studentID, "deletedAt": nil}).Decode(&student); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrStudentNotFound
+		}
+		return nil, err
+	}
+
+	soft, hard, err := hourhistory.CalculateNetHours(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+	requirement, err := hourhistory.ResolveGraduationRequirement(ctx, student.Major, student.EntryYear)
+	if err != nil {
+		return nil, err
+	}
+	result := &models.ProgramRecommendations{
+		SoftSkill:     soft,
+		HardSkill:     hard,
+		SoftRemaining: max(0, requirement.SoftSkillHours-soft),
+		HardRemaining: max(0, requirement.HardSkillHours-hard),
+		Programs:      []models.ProgramRecommendation{},
+	}
+	// วัดจากสัดส่วนที่ยังขาด เพราะเกณฑ์สองทักษะไม่เท่ากัน (เช่น 30 / 12)
+	softGap := skillGap(result.SoftRemaining, requirement.SoftSkillHours)
+	hardGap := skillGap(result.HardRemaining, requirement.HardSkillHours)
+	switch {
+	case softGap == 0 && hardGap == 0:
+		result.PrioritySkill = ""
+	case softGap >= hardGap:
+		result.PrioritySkill = "soft"
+	default:
+		result.PrioritySkill = "hard"
+	}
+
+	// กิจกรรมที่ลงทะเบียนไปแล้ว (กิจกรรมย่อยใดก็ได้) ไม่ต้องแนะนำซ้ำ
+	enrolledPrograms := map[primitive.ObjectID]bool{}
+	cur, err := DB.EnrollmentCollection.Find(ctx, bson.M{"studentId": studentID})
+	if err != nil {
+		return nil, err
+	}
+	var enrolled []models.Enrollment
+	if err := cur.All(ctx, &enrolled); err != nil {
+		return nil, err
+	}
+	for _, e := range enrolled {
+		enrolledPrograms[e.ProgramID] = true
+	}
+
+	cur, err = DB.ProgramCollection.Find(ctx, bson.M{"programState": models.ProgramStateOpen, "deletedAt": nil})
+	if err != nil {
+		return nil, err
+	}
+	var openPrograms []models.Program
+	if err := cur.All(ctx, &openPrograms); err != nil {
+		return nil, err
+	}
+
+	today := time.Now().In(bangkok()).Format(fmtDay)
+	programIndex := map[primitive.ObjectID]int{}
+	var programIDs []primitive.ObjectID
+	for i, p := range openPrograms {
+		if enrolledPrograms[p.ID] || (p.EndDateEnroll != "" && p.EndDateEnroll < today) {
+			continue
+		}
+		programIndex[p.ID] = i
+		programIDs = append(programIDs, p.ID)
+	}
+	if len(programIDs) == 0 {
+		return result, nil
+	}
+
+	cur, err = DB.ProgramItemCollection.Find(ctx, bson.M{"programId": bson.M{"$in": programIDs}})
+	if err != nil {
+		return nil, err
+	}
+	var items []models.ProgramItem
+	if err := cur.All(ctx, &items); err != nil {
+		return nil, err
+	}
+
+	byProgram := map[primitive.ObjectID]*models.ProgramRecommendation{}
+	for i := range items {
+		item := &items[i]
+		nextDate := firstUpcomingDate(item.Dates, today)
+		if nextDate == "" {
+			continue
+		}
+		if item.MaxParticipants != nil && item.EnrollmentCount >= *item.MaxParticipants {
+			continue
+		}
+		if err := eligibility.Evaluate(ctx, item, &student); err != nil {
+			continue
+		}
+		if err := checkTimeOverlapWithActiveEnrollments(ctx, studentID, item.Dates); err != nil {
+			continue
+		}
+
+		rec, ok := byProgram[item.ProgramID]
+		if !ok {
+			program := openPrograms[programIndex[item.ProgramID]]
+			rec = &models.ProgramRecommendation{
+				Program: models.ProgramDto{
+					ID:            program.ID,
+					FormID:        program.FormID,
+					Name:          program.Name,
+					Type:          program.Type,
+					ProgramState:  program.ProgramState,
+					Skill:         program.Skill,
+					EndDateEnroll: program.EndDateEnroll,
+					FoodDeadline:  program.FoodDeadline,
+					File:          program.File,
+					FoodVotes:     program.FoodVotes,
+				},
+				Priority: result.PrioritySkill != "" && program.Skill == result.PrioritySkill,
+			}
+			byProgram[item.ProgramID] = rec
+		}
+		rec.Program.ProgramItems = append(rec.Program.ProgramItems, models.ProgramItemDto(*item))
+		if rec.NextDate == "" || nextDate < rec.NextDate {
+			rec.NextDate = nextDate
+		}
+	}
+
+	for _, rec := range byProgram {
+		rec.SeatsLeft = maxSeatsLeft(rec.Program.ProgramItems)
+		rec.Reason = recommendationReason(rec, result)
+		result.Programs = append(result.Programs, *rec)
+	}
+	sort.SliceStable(result.Programs, func(i, j int) bool {
+		a, b := result.Programs[i], result.Programs[j]
+		if a.Priority != b.Priority {
+			return a.Priority
+		}
+		if a.NextDate != b.NextDate {
+			return a.NextDate < b.NextDate
+		}
+		return seatsRank(a.SeatsLeft) > seatsRank(b.SeatsLeft)
+	})
+	if limit > 0 && len(result.Programs) > limit {
+		result.Programs = result.Programs[:limit]
+	}
+	return result, nil
+}
+
+// firstUpcomingDate วันจัดแรกที่ยังไม่ผ่าน ("" ถ้าจัดไปหมดแล้ว)
+func firstUpcomingDate(dates []models.Dates, today string) string {
+	first := ""
+	for _, d := range dates {
+		if d.Date >= today && (first == "" || d.Date < first) {
+			first = d.Date
+		}
+	}
+	return first
+}
+
+// maxSeatsLeft ที่นั่งคงเหลือมากที่สุดในกิจกรรมย่อย (nil = มีกิจกรรมย่อยที่ไม่จำกัดจำนวน)
+func maxSeatsLeft(items []models.ProgramItemDto) *int {
+	var best *int
+	for _, item := range items {
+		if item.MaxParticipants == nil {
+			return nil
+		}
+		left := *item.MaxParticipants - item.EnrollmentCount
+		if best == nil || left > *best {
+			best = &left
+		}
+	}
+	return best
+}
+
+// seatsRank ใช้เทียบที่นั่งคงเหลือ (ไม่จำกัด = มากที่สุด)
+func seatsRank(seats *int) int {
+	if seats == nil {
+		return math.MaxInt
+	}
+	return *seats
+}
+
+func recommendationReason(rec *models.ProgramRecommendation, result *models.ProgramRecommendations) string {
+	switch {
+	case rec.Priority && rec.Program.Skill == "soft":
+		return fmt.Sprintf("ยังขาดชั่วโมง soft skill อีก %d ชั่วโมง", result.SoftRemaining)
+	case rec.Priority && rec.Program.Skill == "hard":
+		return fmt.Sprintf("ยังขาดชั่วโมง hard skill อีก %d ชั่วโมง", result.HardRemaining)
+	default:
+		return "เปิดรับสมัครและคุณมีสิทธิ์ลงทะเบียน"
+	}
+}
+
+// skillGap สัดส่วนชั่วโมงที่ยังขาดจากเกณฑ์ (เกณฑ์ 0 ชั่วโมง = ไม่ขาด)
+func skillGap(remaining, required int) float64 {
+	if required <= 0 {
+		return 0
+	}
+	return float64(remaining) / float64(required)
+}
@@ -76,7 +76,15 @@ func RegisterStudentsByCodes(ctx context.Context, programItemID primitive.Object
 		}
 
 		// เรียก service เดิมให้ตรวจทุกกฎ (กันชนเวลา/สาขา/เต็มโควต้า/ลงซ้ำ/เพิ่ม foodVotes/เพิ่ม enrollmentcount)
-		if err := RegisterStudent(programItemID, stu.ID, it.Food); err != nil {
+		foodID, err := ParseFoodID(it.FoodID)
+		if err != nil {
+			res.Failed = append(res.Failed, models.BulkEnrollFailedItem{
+				StudentCode: code,
+				Reason:      err.Error(),
+			})
+			continue
+		}
+		if err := RegisterStudent(programItemID, stu.ID, foodID, it.Food); err != nil {
 			res.Failed = append(res.Failed, models.BulkEnrollFailedItem{
 				StudentCode: code,
 				Reason:      err.Error(),
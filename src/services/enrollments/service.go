@@ -3,8 +3,10 @@ package enrollments
 import (
 	DB "Backend-Bluelock-007/src/database"
 	"Backend-Bluelock-007/src/models"
+	"Backend-Bluelock-007/src/services/eligibility"
 	hourhistory "Backend-Bluelock-007/src/services/hour-history"
 	"Backend-Bluelock-007/src/services/programs"
+	"Backend-Bluelock-007/src/services/search"
 	"Backend-Bluelock-007/src/services/summary_reports"
 	"context"
 	"errors"
@@ -59,9 +61,9 @@ func GetEnrollmentsByStudent(studentID primitive.ObjectID, params models.Paginat
 		sort[0].Value = -1
 	}
 
-	filter := bson.M{"_id": bson.M{"$in": programIDs}}
+	filter := bson.M{"_id": bson.M{"$in": programIDs}, "deletedAt": nil}
 	if params.Search != "" {
-		filter["name"] = bson.M{"$regex": params.Search, "$options": "i"}
+		filter["name"] = bson.M{"$regex": search.Contains(params.Search)}
 	}
 	if len(skillFilter) > 0 && skillFilter[0] != "" {
 		filter["skill"] = bson.M{"$in": skillFilter}
@@ -197,9 +199,10 @@ func checkTimeOverlapWithActiveEnrollments(ctx context.Context, studentID primit
 		}}},
 		bson.D{{Key: "$unwind", Value: "$program"}},
 
-		// 4. กรองเฉพาะ program ที่ status เป็น open หรือ close
+		// 4. กรองเฉพาะ program ที่ status เป็น open หรือ close (ไม่รวมกิจกรรมที่อยู่ในถังขยะ)
 		bson.D{{Key: "$match", Value: bson.M{
 			"program.programState": bson.M{"$in": []string{"open", "close"}},
+			"program.deletedAt":    nil,
 		}}},
 
 		// 5. เลือกเฉพาะ field ที่ต้องใช้
@@ -231,7 +234,7 @@ func checkTimeOverlapWithActiveEnrollments(ctx context.Context, studentID primit
 		for _, dOld := range existing.Dates {
 			for _, dNew := range newDates {
 				if dOld.Date == dNew.Date { // วันเดียวกัน
-					if isTimeOverlap(dOld.Stime, dOld.Etime, dNew.Stime, dNew.Etime) {
+					if dOld.Overlaps(dNew) {
 						existingName := "ไม่ระบุชื่อ"
 						if existing.ProgramItemName != nil {
 							existingName = *existing.ProgramItemName
@@ -248,7 +251,7 @@ func checkTimeOverlapWithActiveEnrollments(ctx context.Context, studentID primit
 }
 
 // Student ลงทะเบียนกิจกรรม (ลงซ้ำไม่ได้ + เช็ค major + กันเวลาทับซ้อน)
-func RegisterStudent(programItemID, studentID primitive.ObjectID, food *string) error {
+func RegisterStudent(programItemID, studentID primitive.ObjectID, foodID *primitive.ObjectID, food *string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -261,27 +264,24 @@ func RegisterStudent(programItemID, studentID primitive.ObjectID, food *string)
 		return err
 	}
 
-	// 2) ถ้ามีการเลือกอาหาร: +1 vote ให้ foodName ที่ตรงกันใน Program
-	if food != nil {
-		programID := programItem.ProgramID
-
-		filter := bson.M{"_id": programID}
-		update := bson.M{
-			"$inc": bson.M{"foodVotes.$[elem].vote": 1},
-		}
-		arrayFilter := options.Update().SetArrayFilters(options.ArrayFilters{
-			Filters: []any{
-				bson.M{"elem.foodName": *food},
-			},
-		})
-
-		if _, err := DB.ProgramCollection.UpdateOne(ctx, filter, update, arrayFilter); err != nil {
-			return fmt.Errorf("update food vote failed: %w", err)
+	// 2) โหลด Program และตรวจว่าอาหารที่เลือกอยู่ในตัวเลือกของ Program
+	var program models.Program
+	// กิจกรรมที่อยู่ในถังขยะลงทะเบียนไม่ได้
+	if err := DB.ProgramCollection.FindOne(ctx, bson.M{"_id": programItem.ProgramID, "deletedAt": nil}).Decode(&program); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return programs.ErrProgramNotFound
 		}
-		// fmt.Println("Updated food vote for:", *food)
+		return err
+	}
+	if program.ProgramState == models.ProgramStateCancelled {
+		return errors.New("กิจกรรมนี้ถูกยกเลิกแล้ว ไม่สามารถลงทะเบียนได้")
+	}
+	foodOpt, err := programs.FindFoodOption(&program, foodID, food)
+	if err != nil {
+		return err
 	}
 
-	// 3) โหลด student และเช็ค major ให้ตรงกับ programItem.Majors (ถ้ามีจำกัด)
+	// 3) โหลด student เพื่อตรวจสอบสิทธิ์ลงทะเบียน
 	var student models.Student
 	if err := DB.StudentCollection.FindOne(ctx, bson.M{"_id": studentID}).Decode(&student); err != nil {
 		if err == mongo.ErrNoDocuments {
@@ -290,39 +290,14 @@ func RegisterStudent(programItemID, studentID primitive.ObjectID, food *string)
 		return err
 	}
 
-	// ✅ เช็คสาขา: กิจกรรมอนุญาตเฉพาะบาง major
-	if len(programItem.Majors) > 0 {
-		allowed := false
-		for _, m := range programItem.Majors {
-			log.Println(programItem.Majors)
-			log.Println(student.Major)
-			if strings.EqualFold(m, student.Major) { // ปลอดภัยต่อเคสตัวพิมพ์เล็ก/ใหญ่
-				allowed = true
-				break
-			}
-		}
-		if !allowed {
-			return errors.New("ไม่สามารถลงทะเบียนได้: สาขาไม่ตรงกับเงื่อนไขของกิจกรรม")
-		}
+	// ✅ เช็คสิทธิ์ลงทะเบียน: สาขา / ชั้นปี / eligibilityRules ของ programItem
+	if err := eligibility.Evaluate(ctx, &programItem, &student); err != nil {
+		return err
 	}
 
-	// ✅ เช็คชั้นปี: กิจกรรมอนุญาตเฉพาะบางชั้นปี
-	if len(programItem.StudentYears) > 0 {
-		// สร้าง prefix ชั้นปีที่อนุญาต (เช่น 67, 66, 65, 64)
-		allowedPrefixes := programs.GenerateStudentCodeFilter(programItem.StudentYears)
-
-		// ตรวจสอบว่ารหัสนิสิตขึ้นต้นด้วย prefix ที่อนุญาตหรือไม่
-		allowed := false
-		for _, prefix := range allowedPrefixes {
-			if strings.HasPrefix(student.Code, prefix) {
-				allowed = true
-				break
-			}
-		}
-
-		if !allowed {
-			return errors.New("ไม่สามารถลงทะเบียนได้: ชั้นปีไม่ตรงกับเงื่อนไขของกิจกรรม")
-		}
+	// ✅ อาหารที่เลือกต้องเหมาะกับ dietaryProfile ของนิสิต
+	if err := checkFoodCompatibility(ctx, foodOpt, &student); err != nil {
+		return err
 	}
 
 	// 4) กันเวลาทับซ้อนกับ enrollment ที่เคยลงไว้แล้ว (เฉพาะ program ที่ status เป็น open หรือ close)
@@ -330,8 +305,6 @@ func RegisterStudent(programItemID, studentID primitive.ObjectID, food *string)
 		return err
 	}
 
-	// (ถ้าต้องการเช็คชั้นปีด้วย ให้เพิ่มเงื่อนไขจาก programItem.StudentYears ที่นี่ได้)
-
 	// 5) กันเต็มโควต้า
 	if programItem.MaxParticipants != nil && programItem.EnrollmentCount >= *programItem.MaxParticipants {
 		return errors.New("ไม่สามารถลงทะเบียนได้ เนื่องจากจำนวนผู้เข้าร่วมเต็มแล้ว")
@@ -356,8 +329,8 @@ func RegisterStudent(programItemID, studentID primitive.ObjectID, food *string)
 		ProgramID:        programItem.ProgramID,
 		ProgramItemID:    programItemID,
 		RegistrationDate: time.Now(),
-		Food:             food,
 	}
+	applyFoodOption(&newEnrollment, foodOpt)
 	if _, err := DB.EnrollmentCollection.InsertOne(ctx, newEnrollment); err != nil {
 		return err
 	}
@@ -370,6 +343,11 @@ func RegisterStudent(programItemID, studentID primitive.ObjectID, food *string)
 	); err != nil {
 		return fmt.Errorf("เพิ่ม enrollmentcount ไม่สำเร็จ: %w", err)
 	}
+	if foodOpt != nil {
+		if err := programs.RecalculateFoodVotes(ctx, programItem.ProgramID); err != nil {
+			log.Printf("⚠️ Warning: Failed to recalculate food votes: %v", err)
+		}
+	}
 
 	// 9) ✅ อัปเดต Summary Report - เพิ่ม Registered count สำหรับแต่ละ date ของ programItem
 	for _, date := range programItem.Dates {
@@ -384,8 +362,7 @@ func RegisterStudent(programItemID, studentID primitive.ObjectID, food *string)
 	fmt.Println("Before recording hour change history.................")
 
 	// 10) 📝 บันทึก HourChangeHistory สำหรับ Enrollment
-	var program models.Program
-	if err := DB.ProgramCollection.FindOne(ctx, bson.M{"_id": programItem.ProgramID}).Decode(&program); err == nil {
+	{
 		programName := "Unknown Program"
 		if program.Name != nil {
 			programName = *program.Name
@@ -401,21 +378,20 @@ func RegisterStudent(programItemID, studentID primitive.ObjectID, food *string)
 			0,                       // hourChange (0 ตอน enroll)
 			programName,             // title
 			"ลงทะเบียนกิจกรรม (กำลังมาถึง)", // remark
-			&newEnrollment.ID, // enrollmentID
-			&programItem.ID,   // programItemID
+			&newEnrollment.ID,          // enrollmentID
+			&programItem.ID,            // programItemID
+			"student:"+studentID.Hex(), // actor
 		)
 		if err != nil {
 			log.Printf("⚠️ Warning: Failed to record enrollment hour change: %v", err)
 			// Don't return error - we don't want to fail enrollment if hour history fails
 		}
-	} else {
-		log.Printf("⚠️ Warning: Failed to get program info for hour history: %v", err)
 	}
 
 	return nil
 }
 
-func RegisterStudentByAdmin(programItemID, studentID primitive.ObjectID, food *string) error {
+func RegisterStudentByAdmin(programItemID, studentID primitive.ObjectID, foodID *primitive.ObjectID, food *string, actor string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -428,24 +404,21 @@ func RegisterStudentByAdmin(programItemID, studentID primitive.ObjectID, food *s
 		return err
 	}
 
-	// 2) ถ้ามีการเลือกอาหาร: +1 vote ให้ foodName ที่ตรงกันใน Program
-	if food != nil {
-		programID := programItem.ProgramID
-
-		filter := bson.M{"_id": programID}
-		update := bson.M{
-			"$inc": bson.M{"foodVotes.$[elem].vote": 1},
-		}
-		arrayFilter := options.Update().SetArrayFilters(options.ArrayFilters{
-			Filters: []any{
-				bson.M{"elem.foodName": *food},
-			},
-		})
-
-		if _, err := DB.ProgramCollection.UpdateOne(ctx, filter, update, arrayFilter); err != nil {
-			return fmt.Errorf("update food vote failed: %w", err)
+	// 2) โหลด Program และตรวจว่าอาหารที่เลือกอยู่ในตัวเลือกของ Program
+	var program models.Program
+	// กิจกรรมที่อยู่ในถังขยะลงทะเบียนไม่ได้
+	if err := DB.ProgramCollection.FindOne(ctx, bson.M{"_id": programItem.ProgramID, "deletedAt": nil}).Decode(&program); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return programs.ErrProgramNotFound
 		}
-		// fmt.Println("Updated food vote for:", *food)
+		return err
+	}
+	if program.ProgramState == models.ProgramStateCancelled {
+		return errors.New("กิจกรรมนี้ถูกยกเลิกแล้ว ไม่สามารถลงทะเบียนได้")
+	}
+	foodOpt, err := programs.FindFoodOption(&program, foodID, food)
+	if err != nil {
+		return err
 	}
 
 	// 3) กันเวลาทับซ้อนกับ enrollment ที่เคยลงไว้แล้ว (เฉพาะ program ที่ status เป็น open หรือ close)
@@ -453,49 +426,22 @@ func RegisterStudentByAdmin(programItemID, studentID primitive.ObjectID, food *s
 		return err
 	}
 
-	// 4) โหลด student และเช็ค major + ชั้นปี ให้ตรงกับ programItem (ถ้ามีจำกัด)
-	// var student models.Student
-	// if err := DB.StudentCollection.FindOne(ctx, bson.M{"_id": studentID}).Decode(&student); err != nil {
-	// 	if err == mongo.ErrNoDocuments {
-	// 		return errors.New("student not found")
-	// 	}
-	// 	return err
-	// }
-
-	// // ✅ เช็คสาขา: กิจกรรมอนุญาตเฉพาะบาง major
-	// if len(programItem.Majors) > 0 {
-	// 	allowed := false
-	// 	for _, m := range programItem.Majors {
-	// 		log.Println(programItem.Majors)
-	// 		log.Println(student.Major)
-	// 		if strings.EqualFold(m, student.Major) { // ปลอดภัยต่อเคสตัวพิมพ์เล็ก/ใหญ่
-	// 			allowed = true
-	// 			break
-	// 		}
-	// 	}
-	// 	if !allowed {
-	// 		return errors.New("ไม่สามารถลงทะเบียนได้: สาขาไม่ตรงกับเงื่อนไขของกิจกรรม")
-	// 	}
-	// }
-
-	// // ✅ เช็คชั้นปี: กิจกรรมอนุญาตเฉพาะบางชั้นปี
-	// if len(programItem.StudentYears) > 0 {
-	// 	// สร้าง prefix ชั้นปีที่อนุญาต (เช่น 67, 66, 65, 64)
-	// 	allowedPrefixes := programs.GenerateStudentCodeFilter(programItem.StudentYears)
-
-	// 	// ตรวจสอบว่ารหัสนิสิตขึ้นต้นด้วย prefix ที่อนุญาตหรือไม่
-	// 	allowed := false
-	// 	for _, prefix := range allowedPrefixes {
-	// 		if strings.HasPrefix(student.Code, prefix) {
-	// 			allowed = true
-	// 			break
-	// 		}
-	// 	}
+	// 4) โหลด student และเช็คสิทธิ์ลงทะเบียน (สาขา / ชั้นปี / eligibilityRules) ด้วย evaluator เดียวกับ RegisterStudent
+	var student models.Student
+	if err := DB.StudentCollection.FindOne(ctx, bson.M{"_id": studentID}).Decode(&student); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.New("student not found")
+		}
+		return err
+	}
+	if err := eligibility.Evaluate(ctx, &programItem, &student); err != nil {
+		return err
+	}
 
-	// 	if !allowed {
-	// 		return errors.New("ไม่สามารถลงทะเบียนได้: ชั้นปีไม่ตรงกับเงื่อนไขของกิจกรรม")
-	// 	}
-	// }
+	// ✅ อาหารที่เลือกต้องเหมาะกับ dietaryProfile ของนิสิต
+	if err := checkFoodCompatibility(ctx, foodOpt, &student); err != nil {
+		return err
+	}
 
 	// // 5) กันเต็มโควต้า
 	// if programItem.MaxParticipants != nil && programItem.EnrollmentCount >= *programItem.MaxParticipants {
@@ -521,8 +467,8 @@ func RegisterStudentByAdmin(programItemID, studentID primitive.ObjectID, food *s
 		ProgramID:        programItem.ProgramID,
 		ProgramItemID:    programItemID,
 		RegistrationDate: time.Now(),
-		Food:             food,
 	}
+	applyFoodOption(&newEnrollment, foodOpt)
 	if _, err := DB.EnrollmentCollection.InsertOne(ctx, newEnrollment); err != nil {
 		return err
 	}
@@ -535,6 +481,11 @@ func RegisterStudentByAdmin(programItemID, studentID primitive.ObjectID, food *s
 	); err != nil {
 		return fmt.Errorf("เพิ่ม enrollmentcount ไม่สำเร็จ: %w", err)
 	}
+	if foodOpt != nil {
+		if err := programs.RecalculateFoodVotes(ctx, programItem.ProgramID); err != nil {
+			log.Printf("⚠️ Warning: Failed to recalculate food votes: %v", err)
+		}
+	}
 
 	// 9) ✅ อัปเดต Summary Report - เพิ่ม Registered count สำหรับแต่ละ date ของ programItem
 	for _, date := range programItem.Dates {
@@ -549,8 +500,7 @@ func RegisterStudentByAdmin(programItemID, studentID primitive.ObjectID, food *s
 	fmt.Println("Before recording hour change history.................")
 
 	// 10) 📝 บันทึก HourChangeHistory สำหรับ Enrollment
-	var program models.Program
-	if err := DB.ProgramCollection.FindOne(ctx, bson.M{"_id": programItem.ProgramID}).Decode(&program); err == nil {
+	{
 		programName := "Unknown Program"
 		if program.Name != nil {
 			programName = *program.Name
@@ -568,18 +518,41 @@ func RegisterStudentByAdmin(programItemID, studentID primitive.ObjectID, food *s
 			"ลงทะเบียนกิจกรรม (กำลังมาถึง)", // remark
 			&newEnrollment.ID, // enrollmentID
 			&programItem.ID,   // programItemID
+			actor,             // actor
 		)
 		if err != nil {
 			log.Printf("⚠️ Warning: Failed to record enrollment hour change: %v", err)
 			// Don't return error - we don't want to fail enrollment if hour history fails
 		}
-	} else {
-		log.Printf("⚠️ Warning: Failed to get program info for hour history: %v", err)
 	}
 
 	return nil
 }
 
+// CheckEligibility ตรวจสอบสิทธิ์ลงทะเบียนของนิสิตใน programItem และคืนเหตุผลที่ไม่ผ่านทั้งหมด
+func CheckEligibility(programItemID, studentID primitive.ObjectID) (*models.EligibilityResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var programItem models.ProgramItem
+	if err := DB.ProgramItemCollection.FindOne(ctx, bson.M{"_id": programItemID}).Decode(&programItem); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("program item not found")
+		}
+		return nil, err
+	}
+
+	var student models.Student
+	if err := DB.StudentCollection.FindOne(ctx, bson.M{"_id": studentID}).Decode(&student); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("student not found")
+		}
+		return nil, err
+	}
+
+	return eligibility.Check(ctx, &programItem, &student)
+}
+
 func GetEnrollmentById(enrollmentID primitive.ObjectID) (*models.Enrollment, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -828,7 +801,7 @@ func UpdateEnrollmentCheckinoutByRecordID(
 }
 
 // ยกเลิกการลงทะเบียน
-func UnregisterStudent(enrollmentID primitive.ObjectID) error {
+func UnregisterStudent(enrollmentID primitive.ObjectID, actor string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -849,29 +822,6 @@ func UnregisterStudent(enrollmentID primitive.ObjectID) error {
 		return err
 	}
 
-	if enrollment.Food != nil {
-		programID := programItem.ProgramID
-
-		// ✅ Update -1 vote ของ foodName ที่ตรงกับชื่ออาหาร
-		filter := bson.M{"_id": programID}
-		update := bson.M{
-			"$inc": bson.M{"foodVotes.$[elem].vote": -1},
-		}
-		arrayFilter := options.Update().SetArrayFilters(options.ArrayFilters{
-			Filters: []any{
-				bson.M{"elem.foodName": *enrollment.Food},
-			},
-		})
-
-		// ✅ Run update
-		_, err := DB.ProgramCollection.UpdateOne(ctx, filter, update, arrayFilter)
-		if err != nil {
-			return err
-		}
-
-		fmt.Println("Updated food vote for:", *enrollment.Food)
-	}
-
 	res, err := DB.EnrollmentCollection.DeleteOne(ctx, filter)
 	if err != nil {
 		return err
@@ -881,6 +831,13 @@ func UnregisterStudent(enrollmentID primitive.ObjectID) error {
 		return errors.New("no enrollment found to delete")
 	}
 
+	// ✅ นับ foodVotes ใหม่จาก Enrollments ที่เหลือ
+	if enrollment.Food != nil || enrollment.FoodID != nil {
+		if err := programs.RecalculateFoodVotes(ctx, programItem.ProgramID); err != nil {
+			log.Printf("⚠️ Warning: Failed to recalculate food votes: %v", err)
+		}
+	}
+
 	// ✅ ลบ enrollmentcount -1 จาก programItem
 	_, err = DB.ProgramItemCollection.UpdateOne(ctx,
 		bson.M{"_id": enrollment.ProgramItemID},
@@ -900,8 +857,8 @@ func UnregisterStudent(enrollmentID primitive.ObjectID) error {
 		}
 	}
 
-	// ✅ ลบประวัติการเปลี่ยนแปลงชั่วโมงที่เกี่ยวข้องกับ enrollment นี้
-	_, err = DB.HourChangeHistoryCollection.DeleteMany(ctx, bson.M{"enrollmentId": enrollmentID})
+	// ✅ ลบประวัติการเปลี่ยนแปลงชั่วโมงที่เกี่ยวข้องกับ enrollment นี้ (กลับรายการใน ledger)
+	_, err = hourhistory.VoidHistories(ctx, bson.M{"enrollmentId": enrollmentID}, actor, "ยกเลิกการลงทะเบียน")
 	if err != nil {
 		log.Printf("⚠️ Warning: Failed to delete hour change histories for enrollmentId %s: %v", enrollmentID.Hex(), err)
 		// Don't return error - we don't want to fail unenrollment if history deletion fails
@@ -1221,14 +1178,10 @@ func GetEnrollmentByProgramItemID(
 		filter = append(filter, bson.E{Key: "student.status", Value: bson.M{"$in": status}})
 	}
 	if len(studentYears) > 0 {
-		var ors []bson.M
-		for _, y := range programs.GenerateStudentCodeFilter(studentYears) {
-			ors = append(ors, bson.M{"student.code": bson.M{"$regex": "^" + y, "$options": "i"}})
-		}
-		filter = append(filter, bson.E{Key: "$or", Value: ors})
+		filter = append(filter, bson.E{Key: "student.entryYear", Value: bson.M{"$in": models.EntryYearsForStudentYears(studentYears)}})
 	}
 	if s := strings.TrimSpace(pagination.Search); s != "" {
-		re := bson.M{"$regex": s, "$options": "i"}
+		re := bson.M{"$regex": search.Contains(s)}
 		filter = append(filter, bson.E{Key: "$or", Value: bson.A{
 			bson.M{"student.code": re},
 			bson.M{"student.name": re},
@@ -1448,6 +1401,7 @@ func GetEnrollmentsByProgramID(
 			"softSkill":        "$student.softSkill",
 			"hardSkill":        "$student.hardSkill",
 			"major":            "$student.major",
+			"entryYear":        "$student.entryYear",
 			"enrollmentId":     "$_id",
 			"food":             "$food",
 			"registrationDate": "$registrationDate",
@@ -1475,14 +1429,10 @@ func GetEnrollmentsByProgramID(
 		filter = append(filter, bson.E{Key: "status", Value: bson.M{"$in": status}})
 	}
 	if len(studentYears) > 0 {
-		var ors []bson.M
-		for _, y := range programs.GenerateStudentCodeFilter(studentYears) {
-			ors = append(ors, bson.M{"code": bson.M{"$regex": "^" + y, "$options": "i"}})
-		}
-		filter = append(filter, bson.E{Key: "$or", Value: ors})
+		filter = append(filter, bson.E{Key: "entryYear", Value: bson.M{"$in": models.EntryYearsForStudentYears(studentYears)}})
 	}
 	if s := strings.TrimSpace(pagination.Search); s != "" {
-		re := bson.M{"$regex": s, "$options": "i"}
+		re := bson.M{"$regex": search.Contains(s)}
 		filter = append(filter, bson.E{Key: "$or", Value: bson.A{
 			bson.M{"code": re},
 			bson.M{"name": re},
@@ -1504,6 +1454,7 @@ func GetEnrollmentsByProgramID(
 			"softSkill":        bson.M{"$first": "$softSkill"},
 			"hardSkill":        bson.M{"$first": "$hardSkill"},
 			"major":            bson.M{"$first": "$major"},
+			"entryYear":        bson.M{"$first": "$entryYear"},
 			"food":             bson.M{"$first": "$food"},
 			"registrationDate": bson.M{"$min": "$registrationDate"},
 			"enrollmentId":     bson.M{"$first": "$enrollmentId"},
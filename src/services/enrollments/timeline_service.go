@@ -0,0 +1,173 @@
+package enrollments
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// GetStudentTimeline ดึง timeline กิจกรรมของนิสิต (อดีต / ปัจจุบัน / กำลังจะมาถึง) ในคำขอเดียว
+// รวม Enrollment + ProgramItem + Program + check-in/out + สถานะล่าสุดจาก Hour_Change_Histories
+func GetStudentTimeline(studentID primitive.ObjectID, params models.PaginationParams, filters models.StudentTimelineFilters) ([]models.StudentTimelineItem, models.PaginationMeta, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	today := time.Now().In(bangkok()).Format(fmtDay)
+
+	match := bson.M{"studentId": studentID}
+	if filters.ProgramID != "" {
+		programID, err := primitive.ObjectIDFromHex(filters.ProgramID)
+		if err != nil {
+			return nil, models.PaginationMeta{}, fmt.Errorf("invalid programId")
+		}
+		match["programId"] = programID
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+
+		// 🔗 ProgramItem + Program
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "Program_Items",
+			"localField":   "programItemId",
+			"foreignField": "_id",
+			"as":           "programItem",
+		}}},
+		{{Key: "$unwind", Value: "$programItem"}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "Programs",
+			"localField":   "programItem.programId",
+			"foreignField": "_id",
+			"as":           "program",
+		}}},
+		{{Key: "$unwind", Value: "$program"}},
+
+		// 🔗 สถานะชั่วโมงล่าสุดของ enrollment นี้
+		{{Key: "$lookup", Value: bson.M{
+			"from": "Hour_Change_Histories",
+			"let":  bson.M{"enrollmentId": "$_id"},
+			"pipeline": mongo.Pipeline{
+				{{Key: "$match", Value: bson.M{
+					"sourceType": "program",
+					"$expr":      bson.M{"$eq": bson.A{"$enrollmentId", "$$enrollmentId"}},
+				}}},
+				{{Key: "$sort", Value: bson.D{{Key: "changeAt", Value: -1}}}},
+				{{Key: "$limit", Value: 1}},
+				{{Key: "$project", Value: bson.M{"status": 1, "hourChange": 1, "changeAt": 1}}},
+			},
+			"as": "hourHistory",
+		}}},
+
+		// 📅 ช่วงวันจัดกิจกรรม + สถานะชั่วโมง
+		{{Key: "$addFields", Value: bson.M{
+			"startDate":     bson.M{"$ifNull": bson.A{bson.M{"$min": "$programItem.dates.date"}, ""}},
+			"endDate":       bson.M{"$ifNull": bson.A{bson.M{"$max": "$programItem.dates.date"}, ""}},
+			"hourStatus":    bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$hourHistory.status", 0}}, ""}},
+			"hourChange":    bson.M{"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$hourHistory.hourChange", 0}}, 0}},
+			"hourChangedAt": bson.M{"$arrayElemAt": bson.A{"$hourHistory.changeAt", 0}},
+		}}},
+		{{Key: "$addFields", Value: bson.M{
+			"period": bson.M{"$switch": bson.M{
+				"branches": bson.A{
+					bson.M{"case": bson.M{"$gt": bson.A{"$startDate", today}}, "then": models.TimelinePeriodUpcoming},
+					bson.M{"case": bson.M{"$lt": bson.A{"$endDate", today}}, "then": models.TimelinePeriodPast},
+				},
+				"default": models.TimelinePeriodCurrent,
+			}},
+		}}},
+	}
+
+	// 🔍 filters
+	if cond := timelineFilterConditions(params.Search, filters); len(cond) > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: cond}})
+	}
+
+	// ↕️ sort (startDate | registrationDate)
+	sortField := "startDate"
+	if params.SortBy == "registrationDate" {
+		sortField = "registrationDate"
+	}
+	order := -1
+	if strings.ToLower(params.Order) == "asc" {
+		order = 1
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.D{{Key: sortField, Value: order}, {Key: "_id", Value: order}}}},
+		bson.D{{Key: "$project", Value: bson.M{
+			"_id":              0,
+			"enrollmentId":     "$_id",
+			"registrationDate": 1,
+			"foodId":           1,
+			"food":             1,
+			"program":          1,
+			"programItem":      1,
+			"checkinoutRecord": bson.M{"$ifNull": bson.A{"$checkinoutRecord", bson.A{}}},
+			"hourStatus":       1,
+			"hourChange":       1,
+			"hourChangedAt":    1,
+			"startDate":        1,
+			"endDate":          1,
+			"period":           1,
+		}}},
+	)
+
+	items, meta, err := models.AggregatePaginateGlobal[models.StudentTimelineItem](ctx, DB.EnrollmentCollection, pipeline, params.Page, params.Limit)
+	if err != nil {
+		return nil, models.PaginationMeta{}, fmt.Errorf("error fetching student timeline: %v", err)
+	}
+	return items, meta, nil
+}
+
+// timelineFilterConditions แปลง filters ของ timeline เป็นเงื่อนไข $match
+func timelineFilterConditions(search string, filters models.StudentTimelineFilters) bson.M {
+	cond := bson.M{}
+
+	if skills := splitCSV(filters.Skills); len(skills) > 0 {
+		cond["program.skill"] = bson.M{"$in": skills}
+	}
+	if states := splitCSV(filters.States); len(states) > 0 {
+		cond["program.programState"] = bson.M{"$in": states}
+	}
+	if statuses := splitCSV(filters.HourStatus); len(statuses) > 0 {
+		cond["hourStatus"] = bson.M{"$in": statuses}
+	}
+	if periods := splitCSV(filters.Period); len(periods) > 0 {
+		cond["period"] = bson.M{"$in": periods}
+	}
+
+	// ช่วงวันที่: เอากิจกรรมที่ช่วงวันจัดซ้อนทับกับ [from, to]
+	if filters.From != "" {
+		cond["endDate"] = bson.M{"$gte": filters.From}
+	}
+	if filters.To != "" {
+		cond["startDate"] = bson.M{"$lte": filters.To}
+	}
+
+	if s := strings.TrimSpace(search); s != "" {
+		regex := primitive.Regex{Pattern: regexp.QuoteMeta(s), Options: "i"}
+		cond["$or"] = bson.A{
+			bson.M{"program.name": regex},
+			bson.M{"programItem.name": regex},
+		}
+	}
+
+	return cond
+}
+
+func splitCSV(s string) []string {
+	out := []string{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
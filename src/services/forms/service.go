@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"time"
 
 	"Backend-Bluelock-007/src/database" // สมมติว่าเชื่อมต่อ DB ไว้ที่นี่
 	"Backend-Bluelock-007/src/models"
@@ -21,7 +22,7 @@ func InsetForm(ctx context.Context, form *models.Form) (*mongo.InsertOneResult,
 
 // ดึงฟอร์มทั้งหมด
 func GetAllForms(ctx context.Context) ([]models.Form, error) {
-	cursor, err := database.FormCollection.Find(ctx, bson.D{})
+	cursor, err := database.FormCollection.Find(ctx, bson.M{"deletedAt": nil}) // ไม่รวมฟอร์มที่อยู่ในถังขยะ
 	if err != nil {
 		return nil, err
 	}
@@ -34,14 +35,41 @@ func GetAllForms(ctx context.Context) ([]models.Form, error) {
 	return forms, nil
 }
 
-// ลบฟอร์มตาม ObjectID
-func DeleteFormByID(ctx context.Context, id string) (*mongo.DeleteResult, error) {
+// ย้ายฟอร์มไปถังขยะตาม ObjectID (soft delete)
+func DeleteFormByID(ctx context.Context, id string, actor string) (*mongo.UpdateResult, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
 	}
-	filter := bson.M{"_id": objID}
-	return database.FormCollection.DeleteOne(ctx, filter)
+	filter := bson.M{"_id": objID, "deletedAt": nil}
+	update := bson.M{"$set": bson.M{"deletedAt": time.Now(), "deletedBy": actor}}
+	return database.FormCollection.UpdateOne(ctx, filter, update)
+}
+
+// กู้คืนฟอร์มจากถังขยะ
+func RestoreFormByID(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}}
+	update := bson.M{"$unset": bson.M{"deletedAt": "", "deletedBy": ""}}
+	result, err := database.FormCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return models.ErrNotInTrash
+	}
+	return nil
+}
+
+// ลบฟอร์มถาวร (เฉพาะที่อยู่ในถังขยะแล้ว)
+func PurgeFormByID(ctx context.Context, id primitive.ObjectID) error {
+	result, err := database.FormCollection.DeleteOne(ctx, bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return models.ErrNotInTrash
+	}
+	return nil
 }
 
 // ดึงฟอร์มตาม ObjectID
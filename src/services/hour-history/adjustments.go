@@ -0,0 +1,551 @@
+package hourhistory
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ========================================
+// Adjustments - ปรับชั่วโมงแบบกลุ่มจาก CSV (ตรวจ → preview → บันทึก → อนุมัติ)
+// ========================================
+//
+// CSV: studentCode, skillType, hourChange, title, remark (มี header, สลับลำดับคอลัมน์ได้)
+// บันทึกเป็น hour history sourceType = "manual" (sourceId = batch) — รายการที่ |hourChange| ไม่เกินเกณฑ์ได้สถานะ manual ทันที
+// รายการที่เกินเกณฑ์ได้สถานะ pending_approval (ไม่นับรวมใน CalculateNetHours) จนกว่าแอดมินอีกคนจะอนุมัติ
+
+var (
+	ErrHourAdjustmentNotFound     = errors.New("hour adjustment batch not found")
+	ErrInvalidHourAdjustment      = errors.New("invalid hour adjustment")
+	ErrHourAdjustmentStateInvalid = errors.New("hour adjustment batch is not in a valid state for this action")
+	ErrHourAdjustmentSelfApproval = errors.New("hour adjustments must be approved by another admin")
+)
+
+// HOUR_ADJUSTMENT_APPROVAL_THRESHOLD ชั่วโมงสูงสุดต่อรายการที่บันทึกได้ทันที (เกินนี้ต้องอนุมัติ)
+var HOUR_ADJUSTMENT_APPROVAL_THRESHOLD = 10
+
+const maxHourAdjustmentRows = 2000
+
+func init() {
+	if v := os.Getenv("HOUR_ADJUSTMENT_APPROVAL_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			HOUR_ADJUSTMENT_APPROVAL_THRESHOLD = n
+			log.Printf("ℹ️ HOUR_ADJUSTMENT_APPROVAL_THRESHOLD loaded from env: %d hours", n)
+		} else {
+			log.Printf("⚠️ Failed to parse HOUR_ADJUSTMENT_APPROVAL_THRESHOLD=%s: %v", v, err)
+		}
+	}
+}
+
+// hourAdjustmentColumns ชื่อคอลัมน์ที่รองรับ (ไม่สนตัวพิมพ์เล็ก/ใหญ่)
+var hourAdjustmentColumns = map[string]string{
+	"studentcode": "code",
+	"code":        "code",
+	"skilltype":   "skill",
+	"skill":       "skill",
+	"hourchange":  "hours",
+	"hours":       "hours",
+	"title":       "title",
+	"remark":      "remark",
+}
+
+// PreviewHourAdjustments อ่านและตรวจ CSV แล้วเก็บเป็น batch สถานะ preview (ยังไม่สร้าง hour history)
+func PreviewHourAdjustments(ctx context.Context, fileName string, r io.Reader, actor string) (*models.HourAdjustmentBatch, error) {
+	rows, err := parseHourAdjustmentCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateHourAdjustmentRows(ctx, rows); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	batch := models.HourAdjustmentBatch{
+		FileName:  fileName,
+		State:     models.HourAdjustmentStatePreview,
+		Threshold: HOUR_ADJUSTMENT_APPROVAL_THRESHOLD,
+		Rows:      rows,
+		CreatedBy: actor,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for i := range batch.Rows {
+		row := &batch.Rows[i]
+		if len(row.Errors) > 0 {
+			batch.InvalidRows++
+			continue
+		}
+		batch.ValidRows++
+		row.RequiresApproval = absInt(row.HourChange) > batch.Threshold
+		if row.RequiresApproval {
+			batch.PendingApproval++
+		}
+	}
+	batch.Total = len(batch.Rows)
+
+	res, err := DB.HourAdjustmentBatchCollection.InsertOne(ctx, batch)
+	if err != nil {
+		return nil, err
+	}
+	batch.ID = res.InsertedID.(primitive.ObjectID)
+	return &batch, nil
+}
+
+func parseHourAdjustmentCSV(r io.Reader) ([]models.HourAdjustmentRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: file is empty", ErrInvalidHourAdjustment)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidHourAdjustment, err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if key, ok := hourAdjustmentColumns[name]; ok {
+			columns[key] = i
+		}
+	}
+	for _, key := range []string{"code", "skill", "hours", "title"} {
+		if _, ok := columns[key]; !ok {
+			return nil, fmt.Errorf("%w: header must contain studentCode, skillType, hourChange, title (remark optional)", ErrInvalidHourAdjustment)
+		}
+	}
+	field := func(record []string, key string) string {
+		i, ok := columns[key]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	rows := []models.HourAdjustmentRow{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidHourAdjustment, err)
+		}
+		line, _ := reader.FieldPos(0)
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		if len(rows) == maxHourAdjustmentRows {
+			return nil, fmt.Errorf("%w: at most %d rows per file", ErrInvalidHourAdjustment, maxHourAdjustmentRows)
+		}
+
+		row := models.HourAdjustmentRow{
+			Line:        line,
+			StudentCode: field(record, "code"),
+			SkillType:   strings.ToLower(field(record, "skill")),
+			Title:       field(record, "title"),
+			Remark:      field(record, "remark"),
+		}
+		if row.StudentCode == "" {
+			row.Errors = append(row.Errors, "studentCode is required")
+		}
+		if row.SkillType != "soft" && row.SkillType != "hard" {
+			row.Errors = append(row.Errors, "skillType must be 'soft' or 'hard'")
+		}
+		if hours, err := strconv.Atoi(field(record, "hours")); err != nil {
+			row.Errors = append(row.Errors, "hourChange must be an integer")
+		} else if hours == 0 {
+			row.Errors = append(row.Errors, "hourChange cannot be zero")
+		} else {
+			row.HourChange = hours
+		}
+		if row.Title == "" {
+			row.Errors = append(row.Errors, "title is required")
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: file has no rows", ErrInvalidHourAdjustment)
+	}
+	return rows, nil
+}
+
+// validateHourAdjustmentRows ตรวจรหัสนิสิตและแถวซ้ำ (รหัส + ทักษะ + ชั่วโมง + หัวข้อเดียวกัน)
+func validateHourAdjustmentRows(ctx context.Context, rows []models.HourAdjustmentRow) error {
+	codes := []string{}
+	for _, row := range rows {
+		if row.StudentCode != "" {
+			codes = append(codes, row.StudentCode)
+		}
+	}
+	cur, err := DB.StudentCollection.Find(ctx,
+		bson.M{"code": bson.M{"$in": codes}, "deletedAt": nil},
+		options.Find().SetProjection(bson.M{"code": 1, "name": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	var students []models.Student
+	if err := cur.All(ctx, &students); err != nil {
+		return err
+	}
+	byCode := make(map[string]models.Student, len(students))
+	for _, s := range students {
+		byCode[s.Code] = s
+	}
+
+	seen := map[string]int{}
+	for i := range rows {
+		row := &rows[i]
+		if row.StudentCode != "" {
+			if s, ok := byCode[row.StudentCode]; ok {
+				id := s.ID
+				row.StudentID = &id
+				row.StudentName = s.Name
+			} else {
+				row.Errors = append(row.Errors, "student not found")
+			}
+		}
+		key := fmt.Sprintf("%s|%s|%d|%s", row.StudentCode, row.SkillType, row.HourChange, row.Title)
+		if first, ok := seen[key]; ok {
+			row.Errors = append(row.Errors, fmt.Sprintf("duplicate of line %d", first))
+		} else {
+			seen[key] = row.Line
+		}
+	}
+	return nil
+}
+
+// ApplyHourAdjustments สร้าง hour history ของทุกแถว (ไฟล์ต้องไม่มีแถวที่ผิด) แล้วคำนวณสถานะนิสิตใหม่
+func ApplyHourAdjustments(ctx context.Context, id primitive.ObjectID, actor string) (*models.HourAdjustmentBatch, error) {
+	batch, err := GetHourAdjustmentBatch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if batch.InvalidRows > 0 {
+		return nil, fmt.Errorf("%w: %d rows have errors — fix the file and upload again", ErrInvalidHourAdjustment, batch.InvalidRows)
+	}
+	batch, err = transitionHourAdjustment(ctx, id, models.HourAdjustmentStatePreview, models.HourAdjustmentStateApplying,
+		bson.M{"appliedBy": actor})
+	if err != nil {
+		return nil, err
+	}
+
+	affected := map[primitive.ObjectID]bool{}
+	for i := range batch.Rows {
+		row := &batch.Rows[i]
+		status := models.HCStatusManual
+		if row.RequiresApproval {
+			status = models.HCStatusPendingApproval
+			row.Approval = models.HourAdjustmentApprovalPending
+		}
+		batchID := batch.ID
+		history := models.HourChangeHistory{
+			StudentID:  *row.StudentID,
+			SourceType: "manual",
+			SourceID:   &batchID,
+			SkillType:  row.SkillType,
+			Status:     status,
+			HourChange: row.HourChange,
+			Title:      row.Title,
+			Remark:     row.Remark,
+			ChangeAt:   time.Now(),
+		}
+		if err := InsertHistory(ctx, &history, actor, fmt.Sprintf("ปรับชั่วโมงจากไฟล์ %s (บรรทัด %d)", batch.FileName, row.Line)); err != nil {
+			// เก็บ historyId ที่สร้างไปแล้วไว้ เพื่อให้ตามแก้ได้
+			failHourAdjustment(ctx, batch, fmt.Errorf("line %d: %v", row.Line, err))
+			return nil, err
+		}
+		row.HistoryID = &history.ID
+		if status == models.HCStatusManual {
+			affected[history.StudentID] = true
+		}
+	}
+
+	now := time.Now()
+	if _, err := DB.HourAdjustmentBatchCollection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"state":     models.HourAdjustmentStateApplied,
+		"rows":      batch.Rows,
+		"appliedAt": now,
+		"updatedAt": now,
+	}}); err != nil {
+		return nil, err
+	}
+	batch.State = models.HourAdjustmentStateApplied
+	batch.AppliedAt = &now
+	batch.UpdatedAt = now
+
+	updateAdjustedStudents(ctx, affected)
+	log.Printf("✅ Hour adjustments %s applied by %s (%d rows, %d awaiting approval)", id.Hex(), actor, batch.Total, batch.PendingApproval)
+	return batch, nil
+}
+
+// ReviewHourAdjustments อนุมัติ / ปฏิเสธรายการที่รออนุมัติ — ผู้ตรวจต้องไม่ใช่ผู้อัปโหลดหรือผู้บันทึก batch
+func ReviewHourAdjustments(ctx context.Context, id primitive.ObjectID, input models.HourAdjustmentReviewRequest, approve bool, actor string) (*models.HourAdjustmentBatch, error) {
+	batch, err := GetHourAdjustmentBatch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if batch.State != models.HourAdjustmentStateApplied {
+		return nil, fmt.Errorf("%w: expected state %s", ErrHourAdjustmentStateInvalid, models.HourAdjustmentStateApplied)
+	}
+	if actor == batch.CreatedBy || actor == batch.AppliedBy {
+		return nil, ErrHourAdjustmentSelfApproval
+	}
+
+	lines := map[int]bool{}
+	for _, line := range input.Lines {
+		lines[line] = true
+	}
+	newStatus, approval, verb := models.HCStatusRejected, models.HourAdjustmentApprovalRejected, "ปฏิเสธ"
+	if approve {
+		newStatus, approval, verb = models.HCStatusManual, models.HourAdjustmentApprovalApproved, "อนุมัติ"
+	}
+	reason := verb + "การปรับชั่วโมง"
+	if input.Remark != "" {
+		reason += ": " + input.Remark
+	}
+
+	affected := map[primitive.ObjectID]bool{}
+	reviewed := 0
+	for i := range batch.Rows {
+		row := &batch.Rows[i]
+		if row.Approval != models.HourAdjustmentApprovalPending || row.HistoryID == nil {
+			continue
+		}
+		if len(lines) > 0 && !lines[row.Line] {
+			continue
+		}
+		// guard ด้วยสถานะเดิม กันอนุมัติซ้ำเมื่อมีผู้ตรวจพร้อมกัน
+		matched, err := UpdateHistories(ctx,
+			bson.M{"_id": *row.HistoryID, "status": models.HCStatusPendingApproval},
+			bson.M{"$set": bson.M{"status": newStatus}},
+			actor, reason,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if matched == 0 {
+			continue
+		}
+		// แก้เฉพาะแถวที่ตรวจ (ไม่เขียนทับทั้ง rows) → ผู้ตรวจพร้อมกันคนละแถวไม่ทับผลกัน
+		now := time.Now()
+		prefix := "rows." + strconv.Itoa(i) + "."
+		if _, err := DB.HourAdjustmentBatchCollection.UpdateOne(ctx,
+			bson.M{"_id": id, prefix + "line": row.Line, prefix + "approval": models.HourAdjustmentApprovalPending},
+			bson.M{
+				"$set": bson.M{
+					prefix + "approval":   approval,
+					prefix + "reviewedBy": actor,
+					prefix + "reviewedAt": now,
+					"updatedAt":           now,
+				},
+				"$inc": bson.M{"pendingApproval": -1},
+			},
+		); err != nil {
+			return nil, err
+		}
+		reviewed++
+		if approve {
+			affected[*row.StudentID] = true
+		}
+	}
+	if len(lines) > 0 && reviewed == 0 {
+		return nil, fmt.Errorf("%w: no pending rows match the given lines", ErrInvalidHourAdjustment)
+	}
+
+	updateAdjustedStudents(ctx, affected)
+	log.Printf("✅ Hour adjustments %s: %d rows %s by %s", id.Hex(), reviewed, approval, actor)
+	return GetHourAdjustmentBatch(ctx, id)
+}
+
+// CreateDirectHourAdjustment ปรับชั่วโมงรายคนโดยแอดมิน (POST /hour-history/direct)
+// |hourChange| เกิน HOUR_ADJUSTMENT_APPROVAL_THRESHOLD → pending_approval ใน batch 1 แถว
+// ให้แอดมินอีกคนตรวจผ่าน ReviewHourAdjustments เหมือนแถวใน CSV
+func CreateDirectHourAdjustment(ctx context.Context, studentID primitive.ObjectID, sourceType, skillType string, hourChange int, title, remark, actor string) (*models.HourChangeHistory, error) {
+	var student models.Student
+	err := DB.StudentCollection.FindOne(ctx, bson.M{"_id": studentID, "deletedAt": nil},
+		options.FindOne().SetProjection(bson.M{"code": 1, "name": 1})).Decode(&student)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrStudentNotFound
+		}
+		return nil, err
+	}
+
+	threshold := HOUR_ADJUSTMENT_APPROVAL_THRESHOLD
+	if absInt(hourChange) <= threshold {
+		history, err := CreateHourChangeHistory(ctx, studentID, sourceType, nil, skillType, models.HCStatusManual,
+			hourChange, title, remark, nil, nil, actor)
+		if err != nil {
+			return nil, err
+		}
+		updateAdjustedStudents(ctx, map[primitive.ObjectID]bool{studentID: true})
+		return history, nil
+	}
+
+	history, err := CreateHourChangeHistory(ctx, studentID, sourceType, nil, skillType, models.HCStatusPendingApproval,
+		hourChange, title, remark, nil, nil, actor)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	batch := models.HourAdjustmentBatch{
+		FileName:        "direct",
+		State:           models.HourAdjustmentStateApplied,
+		Threshold:       threshold,
+		Total:           1,
+		ValidRows:       1,
+		PendingApproval: 1,
+		Rows: []models.HourAdjustmentRow{{
+			Line:             1,
+			StudentCode:      student.Code,
+			StudentID:        &studentID,
+			StudentName:      student.Name,
+			SkillType:        skillType,
+			HourChange:       hourChange,
+			Title:            title,
+			Remark:           remark,
+			RequiresApproval: true,
+			HistoryID:        &history.ID,
+			Approval:         models.HourAdjustmentApprovalPending,
+		}},
+		CreatedBy: actor,
+		AppliedBy: actor,
+		CreatedAt: now,
+		UpdatedAt: now,
+		AppliedAt: &now,
+	}
+	res, err := DB.HourAdjustmentBatchCollection.InsertOne(ctx, batch)
+	if err != nil {
+		// ไม่มี batch ให้ตรวจ → ปิดรายการที่รออนุมัติไว้ ไม่ให้ค้าง
+		if _, rerr := UpdateHistories(ctx,
+			bson.M{"_id": history.ID, "status": models.HCStatusPendingApproval},
+			bson.M{"$set": bson.M{"status": models.HCStatusRejected}},
+			actor, "สร้างรายการรออนุมัติไม่สำเร็จ",
+		); rerr != nil {
+			log.Printf("⚠️ Warning: Failed to reject pending direct hour change %s: %v", history.ID.Hex(), rerr)
+		}
+		return nil, err
+	}
+	log.Printf("⏳ Direct hour change %s (%d hours) awaiting approval in batch %s", history.ID.Hex(), hourChange, res.InsertedID.(primitive.ObjectID).Hex())
+	return history, nil
+}
+
+// DiscardHourAdjustments ยกเลิก batch ที่ยังไม่บันทึก
+func DiscardHourAdjustments(ctx context.Context, id primitive.ObjectID, actor string) (*models.HourAdjustmentBatch, error) {
+	batch, err := transitionHourAdjustment(ctx, id, models.HourAdjustmentStatePreview, models.HourAdjustmentStateDiscarded, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("🗑️ Hour adjustments %s discarded by %s", id.Hex(), actor)
+	return batch, nil
+}
+
+// GetHourAdjustmentBatch batch พร้อมผลตรวจของทุกแถว
+func GetHourAdjustmentBatch(ctx context.Context, id primitive.ObjectID) (*models.HourAdjustmentBatch, error) {
+	var batch models.HourAdjustmentBatch
+	if err := DB.HourAdjustmentBatchCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&batch); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrHourAdjustmentNotFound
+		}
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// ListHourAdjustmentBatches batch ล่าสุดก่อน (ไม่รวมแถว) — awaitingApproval = เฉพาะ batch ที่ยังมีรายการรออนุมัติ
+func ListHourAdjustmentBatches(ctx context.Context, state string, awaitingApproval bool, limit, skip int) ([]models.HourAdjustmentBatch, int64, error) {
+	filter := bson.M{}
+	if state != "" {
+		filter["state"] = state
+	}
+	if awaitingApproval {
+		filter["state"] = models.HourAdjustmentStateApplied
+		filter["pendingApproval"] = bson.M{"$gt": 0}
+	}
+	total, err := DB.HourAdjustmentBatchCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetProjection(bson.M{"rows": 0}).
+		SetSkip(int64(skip))
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cur, err := DB.HourAdjustmentBatchCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	batches := []models.HourAdjustmentBatch{}
+	if err := cur.All(ctx, &batches); err != nil {
+		return nil, 0, err
+	}
+	return batches, total, nil
+}
+
+func transitionHourAdjustment(ctx context.Context, id primitive.ObjectID, from, to string, set bson.M) (*models.HourAdjustmentBatch, error) {
+	set["state"] = to
+	set["updatedAt"] = time.Now()
+	var batch models.HourAdjustmentBatch
+	err := DB.HourAdjustmentBatchCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "state": from},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&batch)
+	if err == mongo.ErrNoDocuments {
+		if _, err := GetHourAdjustmentBatch(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: expected state %s", ErrHourAdjustmentStateInvalid, from)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+func failHourAdjustment(ctx context.Context, batch *models.HourAdjustmentBatch, cause error) {
+	log.Printf("❌ Hour adjustments %s failed: %v", batch.ID.Hex(), cause)
+	if _, err := DB.HourAdjustmentBatchCollection.UpdateOne(ctx, bson.M{"_id": batch.ID}, bson.M{"$set": bson.M{
+		"state":     models.HourAdjustmentStateFailed,
+		"rows":      batch.Rows,
+		"error":     cause.Error(),
+		"updatedAt": time.Now(),
+	}}); err != nil {
+		log.Printf("⚠️ Failed to mark hour adjustments %s as failed: %v", batch.ID.Hex(), err)
+	}
+}
+
+// updateAdjustedStudents คำนวณสถานะนิสิตที่ชั่วโมงเปลี่ยน (error ของแต่ละคนไม่ทำให้ทั้ง batch ล้ม)
+func updateAdjustedStudents(ctx context.Context, students map[primitive.ObjectID]bool) {
+	for studentID := range students {
+		if err := UpdateStudentStatus(ctx, studentID); err != nil {
+			log.Printf("⚠️ Failed to update student status %s: %v", studentID.Hex(), err)
+		}
+	}
+}
+
+func absInt(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
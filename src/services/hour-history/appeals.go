@@ -0,0 +1,464 @@
+package hourhistory
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/jobs"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hibiken/asynq"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ========================================
+// Appeals - คำร้องขอทบทวนผลชั่วโมง (นิสิตยื่น + หลักฐาน → แอดมินพิจารณา → แจ้งผลทางอีเมล)
+// ========================================
+//
+// ยื่นได้เฉพาะ hour history ของตัวเองที่สถานะ late / incomplete / absent (กิจกรรม) หรือ rejected (ใบรับรอง)
+// ภายใน HOUR_APPEAL_WINDOW_DAYS วันหลังบันทึกผล — อนุมัติแล้วแก้ hour history ผ่าน UpdateHistories (ลง ledger)
+// เป็น attended / approved ตามชั่วโมงที่แอดมินให้ ส่วนไม่อนุมัติ hour history คงเดิม
+
+var (
+	ErrHourAppealNotFound     = errors.New("hour appeal not found")
+	ErrInvalidHourAppeal      = errors.New("invalid hour appeal")
+	ErrHourAppealStateInvalid = errors.New("hour appeal is not in a valid state for this action")
+	ErrHourAppealNotAllowed   = errors.New("this hour history cannot be appealed")
+	ErrHourAppealForbidden    = errors.New("hour appeal belongs to another student")
+	ErrHourHistoryNotFound    = errors.New("hour history not found")
+)
+
+// HOUR_APPEAL_WINDOW_DAYS จำนวนวันหลังบันทึกผลชั่วโมงที่ยังยื่นคำร้องได้
+var HOUR_APPEAL_WINDOW_DAYS = 30
+
+const (
+	MaxHourAppealEvidence = 5
+	maxHourAppealReason   = 2000
+)
+
+func init() {
+	if v := os.Getenv("HOUR_APPEAL_WINDOW_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			HOUR_APPEAL_WINDOW_DAYS = n
+			log.Printf("ℹ️ HOUR_APPEAL_WINDOW_DAYS loaded from env: %d days", n)
+		} else {
+			log.Printf("⚠️ Failed to parse HOUR_APPEAL_WINDOW_DAYS=%s: %v", v, err)
+		}
+	}
+}
+
+// appealableStatuses สถานะ hour history ที่ยื่นคำร้องได้ แยกตาม sourceType
+var appealableStatuses = map[string][]string{
+	"program":     {models.HCStatusLate, models.HCStatusIncomplete, models.HCStatusAbsent},
+	"certificate": {models.HCStatusRejected},
+}
+
+// grantedAppealStatus สถานะของ hour history หลังอนุมัติคำร้อง
+var grantedAppealStatus = map[string]string{
+	"program":     models.HCStatusAttended,
+	"certificate": models.HCStatusApproved,
+}
+
+// EnsureHourAppealIndexes คำร้องที่รอพิจารณาได้ทีละ 1 รายการต่อ hour history
+func EnsureHourAppealIndexes(ctx context.Context) error {
+	_, err := DB.HourAppealCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "historyId", Value: 1}},
+			Options: options.Index().SetName("historyId_pending").SetUnique(true).
+				SetPartialFilterExpression(bson.M{"state": models.HourAppealStatePending}),
+		},
+		{
+			Keys:    bson.D{{Key: "studentId", Value: 1}, {Key: "createdAt", Value: -1}},
+			Options: options.Index().SetName("studentId_createdAt"),
+		},
+	})
+	return err
+}
+
+// CreateHourAppeal ยื่นคำร้องของนิสิต (evidence = ชื่อไฟล์หลักฐานที่บันทึกไว้แล้ว)
+func CreateHourAppeal(ctx context.Context, studentID, historyID primitive.ObjectID, reason string, evidence []string) (*models.HourAppeal, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalidHourAppeal)
+	}
+	if utf8.RuneCountInString(reason) > maxHourAppealReason {
+		return nil, fmt.Errorf("%w: reason must be at most %d characters", ErrInvalidHourAppeal, maxHourAppealReason)
+	}
+	if len(evidence) > MaxHourAppealEvidence {
+		return nil, fmt.Errorf("%w: at most %d evidence files", ErrInvalidHourAppeal, MaxHourAppealEvidence)
+	}
+
+	var history models.HourChangeHistory
+	if err := DB.HourChangeHistoryCollection.FindOne(ctx, bson.M{"_id": historyID}).Decode(&history); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrHourHistoryNotFound
+		}
+		return nil, err
+	}
+	if history.StudentID != studentID {
+		return nil, ErrHourAppealForbidden
+	}
+	if !slices.Contains(appealableStatuses[history.SourceType], history.Status) {
+		return nil, fmt.Errorf("%w: status %q of %s cannot be appealed", ErrHourAppealNotAllowed, history.Status, history.SourceType)
+	}
+	deadline := history.ChangeAt.AddDate(0, 0, HOUR_APPEAL_WINDOW_DAYS)
+	if time.Now().After(deadline) {
+		return nil, fmt.Errorf("%w: appeals must be filed within %d days", ErrHourAppealNotAllowed, HOUR_APPEAL_WINDOW_DAYS)
+	}
+
+	// ผลเดิมยื่นได้ครั้งเดียว (ถอนคำร้องแล้วยื่นใหม่ได้)
+	filed, err := DB.HourAppealCollection.CountDocuments(ctx, bson.M{
+		"historyId":          historyID,
+		"originalStatus":     history.Status,
+		"originalHourChange": history.HourChange,
+		"state":              bson.M{"$ne": models.HourAppealStateWithdrawn},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if filed > 0 {
+		return nil, fmt.Errorf("%w: this result has already been appealed", ErrHourAppealNotAllowed)
+	}
+
+	expected, err := appealExpectedHours(ctx, &history)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if evidence == nil {
+		evidence = []string{}
+	}
+	appeal := models.HourAppeal{
+		HistoryID:          history.ID,
+		StudentID:          history.StudentID,
+		SourceType:         history.SourceType,
+		SourceID:           history.SourceID,
+		SkillType:          history.SkillType,
+		Title:              history.Title,
+		OriginalStatus:     history.Status,
+		OriginalHourChange: history.HourChange,
+		OriginalRemark:     history.Remark,
+		ExpectedHours:      expected,
+		Reason:             reason,
+		Evidence:           evidence,
+		State:              models.HourAppealStatePending,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	res, err := DB.HourAppealCollection.InsertOne(ctx, appeal)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("%w: an appeal for this hour history is already pending", ErrHourAppealNotAllowed)
+		}
+		return nil, err
+	}
+	appeal.ID = res.InsertedID.(primitive.ObjectID)
+	log.Printf("📨 Hour appeal %s filed for history %s (%s)", appeal.ID.Hex(), historyID.Hex(), history.Status)
+	return &appeal, nil
+}
+
+// appealExpectedHours ชั่วโมงเต็มของรายการ — กิจกรรม = hour ของ program item, ใบรับรอง = hour ของคอร์ส
+func appealExpectedHours(ctx context.Context, history *models.HourChangeHistory) (int, error) {
+	switch history.SourceType {
+	case "program":
+		if history.ProgramItemID != nil {
+			var item models.ProgramItem
+			err := DB.ProgramItemCollection.FindOne(ctx, bson.M{"_id": *history.ProgramItemID}).Decode(&item)
+			if err != nil && err != mongo.ErrNoDocuments {
+				return 0, err
+			}
+			if err == nil && item.Hour != nil {
+				return *item.Hour, nil
+			}
+		}
+	case "certificate":
+		if history.SourceID != nil {
+			var certificate models.UploadCertificate
+			err := DB.UploadCertificateCollection.FindOne(ctx, bson.M{"_id": *history.SourceID}).Decode(&certificate)
+			if err != nil && err != mongo.ErrNoDocuments {
+				return 0, err
+			}
+			if err == nil {
+				var course models.Course
+				err := DB.CourseCollection.FindOne(ctx, bson.M{"_id": certificate.CourseId}).Decode(&course)
+				if err != nil && err != mongo.ErrNoDocuments {
+					return 0, err
+				}
+				if err == nil {
+					return course.Hour, nil
+				}
+			}
+		}
+	}
+	// absent ที่หักชั่วโมงไว้ = ชั่วโมงเต็มของกิจกรรม
+	return absInt(history.HourChange), nil
+}
+
+// DecideHourAppeal อนุมัติ / ไม่อนุมัติคำร้อง แล้วส่งอีเมลแจ้งผลให้นิสิต
+// อนุมัติ = แก้ hour history (guard ด้วยผลเดิม) เป็น attended / approved ตามชั่วโมงที่ให้ แล้วคำนวณสถานะนิสิตใหม่
+func DecideHourAppeal(ctx context.Context, id primitive.ObjectID, input models.HourAppealDecisionRequest, approve bool, actor string) (*models.HourAppeal, error) {
+	appeal, err := GetHourAppeal(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if appeal.State != models.HourAppealStatePending {
+		return nil, fmt.Errorf("%w: expected state %s", ErrHourAppealStateInvalid, models.HourAppealStatePending)
+	}
+	note := strings.TrimSpace(input.Note)
+
+	now := time.Now()
+	set := bson.M{"decisionNote": note, "decidedBy": actor, "decidedAt": now}
+	state := models.HourAppealStateRejected
+	hours := appeal.ExpectedHours
+	if approve {
+		state = models.HourAppealStateApproved
+		if input.HourChange != nil {
+			hours = *input.HourChange
+		}
+		if hours < 0 || (appeal.ExpectedHours > 0 && hours > appeal.ExpectedHours) {
+			return nil, fmt.Errorf("%w: hourChange must be between 0 and %d", ErrInvalidHourAppeal, appeal.ExpectedHours)
+		}
+		if appeal.SourceType == "certificate" {
+			// ใบรับรองต้องไม่เกินเพดานใบเซอร์ตามเกณฑ์ เหมือนการอนุมัติใบรับรองปกติ
+			if hours, err = cappedCertificateAppealHours(ctx, appeal, hours); err != nil {
+				return nil, err
+			}
+		}
+		set["grantedStatus"] = grantedAppealStatus[appeal.SourceType]
+		set["grantedHourChange"] = hours
+	} else if note == "" {
+		return nil, fmt.Errorf("%w: note is required when rejecting", ErrInvalidHourAppeal)
+	}
+
+	// จองคำร้องก่อน (กันแอดมินสองคนตัดสินพร้อมกัน) — แก้ hour history ไม่สำเร็จจะคืนเป็น pending
+	appeal, err = transitionHourAppeal(ctx, id, models.HourAppealStatePending, state, set)
+	if err != nil {
+		return nil, err
+	}
+
+	if approve {
+		if err := applyHourAppeal(ctx, appeal, hours, note, actor); err != nil {
+			reopenHourAppeal(ctx, appeal)
+			return nil, err
+		}
+		if err := UpdateStudentStatus(ctx, appeal.StudentID); err != nil {
+			log.Printf("⚠️ Failed to update student status %s: %v", appeal.StudentID.Hex(), err)
+		}
+	}
+
+	notifyHourAppealDecision(appeal.ID)
+	log.Printf("✅ Hour appeal %s %s by %s", id.Hex(), state, actor)
+	return appeal, nil
+}
+
+// cappedCertificateAppealHours ชั่วโมงใบรับรองที่ให้ได้จริง (ใช้ CurrentCertificateHours / MaxCertificateHours / CertificateHoursToAdd
+// ชุดเดียวกับ finalizePendingHistoryApproved — hour history ที่ยื่นคำร้องยังเป็น rejected จึงไม่ถูกนับในยอดปัจจุบัน)
+func cappedCertificateAppealHours(ctx context.Context, appeal *models.HourAppeal, hours int) (int, error) {
+	var student models.Student
+	if err := DB.StudentCollection.FindOne(ctx, bson.M{"_id": appeal.StudentID}).Decode(&student); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return 0, ErrStudentNotFound
+		}
+		return 0, err
+	}
+	current, err := CurrentCertificateHours(ctx, appeal.StudentID, appeal.SkillType)
+	if err != nil {
+		return 0, err
+	}
+	max, err := MaxCertificateHours(ctx, appeal.SkillType, student.Major, student.EntryYear)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve graduation requirement: %v", err)
+	}
+	return CertificateHoursToAdd(hours, current, max, student.Code, appeal.SkillType), nil
+}
+
+// applyHourAppeal แก้ hour history ตามคำร้องที่อนุมัติ (คง changeAt เดิม ชั่วโมงจึงอยู่ในภาคเดิมของกิจกรรม)
+func applyHourAppeal(ctx context.Context, appeal *models.HourAppeal, hours int, note, actor string) error {
+	remark := fmt.Sprintf("✅ อนุมัติคำร้องขอทบทวน - ได้รับ %d ชั่วโมง", hours)
+	reason := "อนุมัติคำร้องขอทบทวนชั่วโมง"
+	if note != "" {
+		remark += " (" + note + ")"
+		reason += ": " + note
+	}
+	matched, err := UpdateHistories(ctx,
+		bson.M{"_id": appeal.HistoryID, "status": appeal.OriginalStatus, "hourChange": appeal.OriginalHourChange},
+		bson.M{"$set": bson.M{
+			"status":     appeal.GrantedStatus,
+			"hourChange": hours,
+			"remark":     remark,
+		}},
+		actor, reason,
+	)
+	if err != nil {
+		return err
+	}
+	if matched == 0 {
+		return fmt.Errorf("%w: hour history has changed since the appeal was filed", ErrHourAppealStateInvalid)
+	}
+
+	// ใบรับรองที่ถูกปฏิเสธ → อนุมัติตามคำร้อง
+	if appeal.SourceType == "certificate" && appeal.SourceID != nil {
+		if _, err := DB.UploadCertificateCollection.UpdateOne(ctx, bson.M{"_id": *appeal.SourceID}, bson.M{"$set": bson.M{
+			"status":          models.StatusApproved,
+			"remark":          remark,
+			"changedStatusAt": time.Now(),
+		}}); err != nil {
+			log.Printf("⚠️ Failed to approve certificate %s for appeal %s: %v", appeal.SourceID.Hex(), appeal.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// WithdrawHourAppeal นิสิตถอนคำร้องที่ยังรอพิจารณา
+func WithdrawHourAppeal(ctx context.Context, id, studentID primitive.ObjectID) (*models.HourAppeal, error) {
+	appeal, err := GetHourAppeal(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if appeal.StudentID != studentID {
+		return nil, ErrHourAppealForbidden
+	}
+	appeal, err = transitionHourAppeal(ctx, id, models.HourAppealStatePending, models.HourAppealStateWithdrawn, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("↩️ Hour appeal %s withdrawn", id.Hex())
+	return appeal, nil
+}
+
+// GetHourAppeal คำร้องตาม ID
+func GetHourAppeal(ctx context.Context, id primitive.ObjectID) (*models.HourAppeal, error) {
+	var appeal models.HourAppeal
+	if err := DB.HourAppealCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&appeal); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrHourAppealNotFound
+		}
+		return nil, err
+	}
+	return &appeal, nil
+}
+
+// ListHourAppeals คำร้องล่าสุดก่อน
+func ListHourAppeals(ctx context.Context, filters models.HourAppealFilters, limit, skip int) ([]models.HourAppeal, int64, error) {
+	filter := bson.M{}
+	if filters.StudentID != "" {
+		studentID, err := primitive.ObjectIDFromHex(filters.StudentID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: invalid studentId", ErrInvalidHourAppeal)
+		}
+		filter["studentId"] = studentID
+	}
+	if filters.HistoryID != "" {
+		historyID, err := primitive.ObjectIDFromHex(filters.HistoryID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%w: invalid historyId", ErrInvalidHourAppeal)
+		}
+		filter["historyId"] = historyID
+	}
+	if filters.State != "" {
+		filter["state"] = filters.State
+	}
+
+	total, err := DB.HourAppealCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetSkip(int64(skip))
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cur, err := DB.HourAppealCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	appeals := []models.HourAppeal{}
+	if err := cur.All(ctx, &appeals); err != nil {
+		return nil, 0, err
+	}
+	return appeals, total, nil
+}
+
+// approvedAppealHistories hour history ที่ได้ผลจากคำร้องที่อนุมัติแล้ว (ประเมินใหม่จากการเช็คชื่อจะทับผลคำร้อง)
+func approvedAppealHistories(ctx context.Context, historyIDs []primitive.ObjectID) (map[primitive.ObjectID]bool, error) {
+	appealed := map[primitive.ObjectID]bool{}
+	if len(historyIDs) == 0 {
+		return appealed, nil
+	}
+	ids, err := DB.HourAppealCollection.Distinct(ctx, "historyId", bson.M{
+		"historyId": bson.M{"$in": historyIDs},
+		"state":     models.HourAppealStateApproved,
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if oid, ok := id.(primitive.ObjectID); ok {
+			appealed[oid] = true
+		}
+	}
+	return appealed, nil
+}
+
+func transitionHourAppeal(ctx context.Context, id primitive.ObjectID, from, to string, set bson.M) (*models.HourAppeal, error) {
+	set["state"] = to
+	set["updatedAt"] = time.Now()
+	var appeal models.HourAppeal
+	err := DB.HourAppealCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "state": from},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&appeal)
+	if err == mongo.ErrNoDocuments {
+		if _, err := GetHourAppeal(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: expected state %s", ErrHourAppealStateInvalid, from)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &appeal, nil
+}
+
+// reopenHourAppeal คืนคำร้องเป็น pending เมื่อบันทึกผลอนุมัติไม่สำเร็จ
+func reopenHourAppeal(ctx context.Context, appeal *models.HourAppeal) {
+	if _, err := DB.HourAppealCollection.UpdateOne(ctx,
+		bson.M{"_id": appeal.ID, "state": appeal.State},
+		bson.M{
+			"$set":   bson.M{"state": models.HourAppealStatePending, "updatedAt": time.Now()},
+			"$unset": bson.M{"grantedStatus": "", "grantedHourChange": "", "decisionNote": "", "decidedBy": "", "decidedAt": ""},
+		},
+	); err != nil {
+		log.Printf("⚠️ Failed to reopen hour appeal %s: %v", appeal.ID.Hex(), err)
+	}
+}
+
+// notifyHourAppealDecision ส่งงานแจ้งผลทางอีเมล — ไม่มี Redis = ข้าม (ผลดูได้จากระบบ)
+func notifyHourAppealDecision(id primitive.ObjectID) {
+	if DB.AsynqClient == nil {
+		log.Println("⚠️ Redis/Asynq not available → skip hour appeal decision email")
+		return
+	}
+	task, err := jobs.NewNotifyAppealDecisionTask(id.Hex())
+	if err != nil {
+		log.Println("❌ build notify-appeal-decision task:", err)
+		return
+	}
+	if _, err := DB.AsynqClient.Enqueue(task, asynq.TaskID("notify-appeal-"+id.Hex()), asynq.MaxRetry(3)); err != nil {
+		log.Println("❌ enqueue notify-appeal-decision task:", err)
+	}
+}
@@ -0,0 +1,165 @@
+package hourhistory
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ========================================
+// Evaluation - แบบประเมินหลังกิจกรรม (ชั่วโมงรอจนกว่านิสิตจะส่งฟอร์มของกิจกรรม)
+// ========================================
+
+var ErrInvalidEvaluation = errors.New("invalid evaluation settings")
+
+const (
+	DefaultEvaluationDays = 7
+	maxEvaluationDays     = 60
+)
+
+// ValidateEvaluation ตรวจสอบการตั้งค่าแบบประเมินของกิจกรรมก่อนบันทึก
+func ValidateEvaluation(required bool, formID primitive.ObjectID, days int) error {
+	if days < 0 || days > maxEvaluationDays {
+		return fmt.Errorf("%w: evaluationDays must be between 0 and %d", ErrInvalidEvaluation, maxEvaluationDays)
+	}
+	if required && formID.IsZero() {
+		return fmt.Errorf("%w: evaluationRequired needs a formId", ErrInvalidEvaluation)
+	}
+	return nil
+}
+
+// EvaluationDeadline กำหนดส่งแบบประเมิน = สิ้นวัน (เวลาไทย) ของวันที่ completedAt + evaluationDays
+func EvaluationDeadline(evaluationDays int, completedAt time.Time) time.Time {
+	if evaluationDays <= 0 {
+		evaluationDays = DefaultEvaluationDays
+	}
+	loc, _ := time.LoadLocation("Asia/Bangkok")
+	t := completedAt.In(loc).AddDate(0, 0, evaluationDays)
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, 0, loc)
+}
+
+// ReleaseEvaluationHours ให้ชั่วโมงที่รอแบบประเมินเมื่อนิสิตส่งฟอร์มของกิจกรรม (เรียกหลังบันทึก submission)
+// userID ของ submission อาจเป็น Student ID หรือ User ID ของนิสิต — คืนจำนวนรายการที่ได้รับชั่วโมง
+func ReleaseEvaluationHours(ctx context.Context, submission *models.Submission) (int, error) {
+	studentID, err := resolveSubmissionStudent(ctx, submission.UserID)
+	if err != nil || studentID.IsZero() {
+		return 0, err
+	}
+
+	// กิจกรรมที่ใช้ฟอร์มนี้
+	cur, err := DB.ProgramCollection.Find(ctx, bson.M{"formId": submission.FormID})
+	if err != nil {
+		return 0, err
+	}
+	var programs []models.Program
+	if err := cur.All(ctx, &programs); err != nil {
+		return 0, err
+	}
+	if len(programs) == 0 {
+		return 0, nil
+	}
+	programIDs := make([]primitive.ObjectID, 0, len(programs))
+	for _, p := range programs {
+		programIDs = append(programIDs, p.ID)
+	}
+
+	// ผูก submission กับ enrollment (ใช้ตอนประเมินชั่วโมง / preview)
+	if _, err := DB.EnrollmentCollection.UpdateMany(ctx, bson.M{
+		"programId":    bson.M{"$in": programIDs},
+		"studentId":    studentID,
+		"submissionId": nil,
+	}, bson.M{"$set": bson.M{"submissionId": submission.ID}}); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	cur, err = DB.HourChangeHistoryCollection.Find(ctx, bson.M{
+		"sourceType": "program",
+		"sourceId":   bson.M{"$in": programIDs},
+		"studentId":  studentID,
+		"status":     models.HCStatusPendingEvaluation,
+	})
+	if err != nil {
+		return 0, err
+	}
+	var records []models.HourChangeHistory
+	if err := cur.All(ctx, &records); err != nil {
+		return 0, err
+	}
+
+	released := 0
+	for _, r := range records {
+		// เลยกำหนดแล้ว → ปล่อยให้ ForfeitExpiredEvaluations ปิดรายการ
+		if r.EvaluationDeadline != nil && now.After(*r.EvaluationDeadline) {
+			continue
+		}
+		matched, err := UpdateHistories(ctx,
+			bson.M{"_id": r.ID, "status": models.HCStatusPendingEvaluation},
+			bson.M{"$set": bson.M{
+				"status":   models.HCStatusAttended,
+				"remark":   fmt.Sprintf("✅ ส่งแบบประเมินแล้ว - ได้รับ %d ชั่วโมง", r.HourChange),
+				"changeAt": now,
+			}},
+			models.HourLedgerActorSystem, "ส่งแบบประเมินหลังกิจกรรม",
+		)
+		if err != nil {
+			return released, err
+		}
+		released += int(matched)
+	}
+
+	if released > 0 {
+		if err := UpdateStudentStatus(ctx, studentID); err != nil {
+			log.Printf("⚠️ Warning: Failed to update student status for %s: %v", studentID.Hex(), err)
+		}
+		log.Printf("📝 Released %d pending evaluation hour records for student %s", released, studentID.Hex())
+	}
+	return released, nil
+}
+
+// resolveSubmissionStudent หา Student ID จาก userId ของ submission (Student ID ตรง ๆ หรือ User.refId)
+func resolveSubmissionStudent(ctx context.Context, userID primitive.ObjectID) (primitive.ObjectID, error) {
+	count, err := DB.StudentCollection.CountDocuments(ctx, bson.M{"_id": userID})
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	if count > 0 {
+		return userID, nil
+	}
+	var user models.User
+	if err := DB.UserCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return primitive.NilObjectID, nil
+		}
+		return primitive.NilObjectID, err
+	}
+	return user.RefID, nil
+}
+
+// ForfeitExpiredEvaluations ปิดรายการที่รอแบบประเมินและเลยกำหนดแล้วของกิจกรรม → incomplete + 0 ชั่วโมง
+func ForfeitExpiredEvaluations(ctx context.Context, programID primitive.ObjectID) (int64, error) {
+	now := time.Now()
+	return UpdateHistories(ctx,
+		bson.M{
+			"sourceType":         "program",
+			"sourceId":           programID,
+			"status":             models.HCStatusPendingEvaluation,
+			"evaluationDeadline": bson.M{"$lt": now},
+		},
+		bson.M{"$set": bson.M{
+			"status":     models.HCStatusIncomplete,
+			"hourChange": 0,
+			"remark":     "⚠️ ไม่ได้ส่งแบบประเมินภายในกำหนด - ไม่ได้รับชั่วโมง",
+			"changeAt":   now,
+		}},
+		models.HourLedgerActorSystem, "ไม่ได้ส่งแบบประเมินภายในกำหนด",
+	)
+}
@@ -0,0 +1,567 @@
+package hourhistory
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ========================================
+// Ledger - บันทึกการเปลี่ยนแปลงชั่วโมงแบบ append-only
+// ========================================
+//
+// Hour_Change_Histories คือ "สถานะปัจจุบัน" ของแต่ละรายการ (ถูกแก้ไขได้)
+// Hour_Ledger คือประวัติที่แก้ไม่ได้: ทุกครั้งที่ hour history เปลี่ยน จะ append reversal ของ entry เดิม + entry ใหม่
+// ยอดชั่วโมงรวมคำนวณจาก ledger (sum ของ hours) และตรวจสอบความถูกต้องได้ด้วย VerifyLedger
+
+const (
+	ledgerAppendRetries = 5
+	maxLedgerIssues     = 500
+)
+
+// EnsureLedgerIndexes สร้าง index ของ Hour_Ledger (seq ต่อนิสิตห้ามซ้ำ → กัน append ชนกัน)
+func EnsureLedgerIndexes(ctx context.Context) error {
+	_, err := DB.HourLedgerCollection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "studentId", Value: 1}, {Key: "seq", Value: 1}},
+			Options: options.Index().SetName("student_seq").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "historyId", Value: 1}, {Key: "seq", Value: 1}},
+			Options: options.Index().SetName("history_seq"),
+		},
+	})
+	return err
+}
+
+// EffectiveHours ชั่วโมงที่มีผลต่อยอดรวมของ hour history หนึ่งรายการ
+// attended / approved / manual = +hourChange, absent = หักชั่วโมงเสมอ (-|hourChange|), สถานะอื่น = 0
+func EffectiveHours(status string, hourChange int) int {
+	switch status {
+	case models.HCStatusAttended, models.HCStatusApproved, models.HCStatusManual:
+		return hourChange
+	case models.HCStatusAbsent:
+		if hourChange > 0 {
+			return -hourChange
+		}
+		return hourChange
+	default:
+		return 0
+	}
+}
+
+// ledgerHash คำนวณ hash ของ entry (รวม prevHash → แก้ entry ใดก็ทำให้ chain หลังจากนั้นไม่ตรง)
+func ledgerHash(e *models.HourLedgerEntry) string {
+	reverses, source := "", ""
+	if e.ReversesID != nil {
+		reverses = e.ReversesID.Hex()
+	}
+	if e.SourceID != nil {
+		source = e.SourceID.Hex()
+	}
+	payload := strings.Join([]string{
+		e.StudentID.Hex(),
+		strconv.FormatInt(e.Seq, 10),
+		e.Kind,
+		e.HistoryID.Hex(),
+		reverses,
+		e.SkillType,
+		e.Status,
+		strconv.Itoa(e.HourChange),
+		strconv.Itoa(e.Hours),
+		e.Remark,
+		e.SourceType,
+		source,
+		e.Actor,
+		e.Reason,
+		strconv.FormatInt(e.CreatedAt.UnixMilli(), 10),
+		e.PrevHash,
+	}, "|")
+	// entry ที่บันทึกก่อนมีการแบ่งภาคไม่มีภาค → hash เดิมยังตรวจผ่าน
+	if !e.AcademicTerm.IsZero() {
+		payload += "|" + e.AcademicTerm.String()
+	}
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// appendLedgerEntry ต่อท้าย entry ใน chain ของนิสิต (กำหนด seq / prevHash / hash ให้)
+func appendLedgerEntry(ctx context.Context, entry *models.HourLedgerEntry) error {
+	if entry.Actor == "" {
+		entry.Actor = models.HourLedgerActorSystem
+	}
+	for attempt := 0; attempt < ledgerAppendRetries; attempt++ {
+		var last models.HourLedgerEntry
+		err := DB.HourLedgerCollection.FindOne(ctx,
+			bson.M{"studentId": entry.StudentID},
+			options.FindOne().SetSort(bson.D{{Key: "seq", Value: -1}}),
+		).Decode(&last)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return fmt.Errorf("failed to read hour ledger: %v", err)
+		}
+
+		entry.ID = primitive.NewObjectID()
+		entry.Seq = last.Seq + 1
+		entry.PrevHash = last.Hash
+		// Mongo เก็บเวลาละเอียดระดับ millisecond → ตัดก่อน hash ให้ตรงกับที่อ่านกลับมา
+		entry.CreatedAt = time.Now().UTC().Truncate(time.Millisecond)
+		entry.Hash = ledgerHash(entry)
+
+		if _, err := DB.HourLedgerCollection.InsertOne(ctx, entry); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				continue // มีคนต่อท้าย seq เดียวกันไปก่อน → อ่าน chain ใหม่
+			}
+			return fmt.Errorf("failed to append hour ledger: %v", err)
+		}
+		// 🧮 cache ยอดชั่วโมงใน Student (ไม่สำเร็จ → ReconcileHourTotals แก้ภายหลัง)
+		if err := refreshStudentTotals(ctx, entry.StudentID); err != nil {
+			log.Printf("⚠️ Warning: Failed to refresh hour totals for student %s: %v", entry.StudentID.Hex(), err)
+		}
+		return nil
+	}
+	return fmt.Errorf("failed to append hour ledger: too many concurrent writes for student %s", entry.StudentID.Hex())
+}
+
+// activeLedgerEntry entry ล่าสุดของ hour history ที่ยังไม่ถูกกลับรายการ (nil = ไม่มี / ถูกกลับรายการแล้ว)
+func activeLedgerEntry(ctx context.Context, historyID primitive.ObjectID) (*models.HourLedgerEntry, error) {
+	cur, err := DB.HourLedgerCollection.Find(ctx,
+		bson.M{"historyId": historyID},
+		options.Find().SetSort(bson.D{{Key: "seq", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hour ledger: %v", err)
+	}
+	var entries []models.HourLedgerEntry
+	if err := cur.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("failed to read hour ledger: %v", err)
+	}
+
+	var active *models.HourLedgerEntry
+	for i := range entries {
+		e := &entries[i]
+		switch e.Kind {
+		case models.HourLedgerKindEntry:
+			active = e
+		case models.HourLedgerKindReversal:
+			if active != nil && e.ReversesID != nil && *e.ReversesID == active.ID {
+				active = nil
+			}
+		}
+	}
+	return active, nil
+}
+
+// reverseLedgerEntry append reversal ของ entry (หักล้างชั่วโมงที่ entry นั้นเคยให้)
+func reverseLedgerEntry(ctx context.Context, active *models.HourLedgerEntry, actor, reason string) error {
+	return appendLedgerEntry(ctx, &models.HourLedgerEntry{
+		StudentID:    active.StudentID,
+		Kind:         models.HourLedgerKindReversal,
+		HistoryID:    active.HistoryID,
+		ReversesID:   &active.ID,
+		SkillType:    active.SkillType,
+		Status:       active.Status,
+		HourChange:   active.HourChange,
+		Hours:        -active.Hours,
+		Remark:       active.Remark,
+		SourceType:   active.SourceType,
+		SourceID:     active.SourceID,
+		AcademicTerm: active.AcademicTerm,
+		Actor:        actor,
+		Reason:       reason,
+	})
+}
+
+// recordHistoryChange บันทึกสถานะปัจจุบันของ hour history ลง ledger
+// ถ้ามี entry เดิม → reversal ก่อนแล้วจึง entry ใหม่, สถานะไม่เปลี่ยน → ไม่บันทึก
+func recordHistoryChange(ctx context.Context, history *models.HourChangeHistory, actor, reason string) error {
+	active, err := activeLedgerEntry(ctx, history.ID)
+	if err != nil {
+		return err
+	}
+	if active != nil &&
+		active.Status == history.Status &&
+		active.HourChange == history.HourChange &&
+		active.SkillType == history.SkillType &&
+		active.Remark == history.Remark {
+		return nil
+	}
+
+	if active != nil {
+		if err := reverseLedgerEntry(ctx, active, actor, reason); err != nil {
+			return err
+		}
+	}
+	return appendLedgerEntry(ctx, &models.HourLedgerEntry{
+		StudentID:    history.StudentID,
+		Kind:         models.HourLedgerKindEntry,
+		HistoryID:    history.ID,
+		SkillType:    history.SkillType,
+		Status:       history.Status,
+		HourChange:   history.HourChange,
+		Hours:        EffectiveHours(history.Status, history.HourChange),
+		Remark:       history.Remark,
+		SourceType:   history.SourceType,
+		SourceID:     history.SourceID,
+		AcademicTerm: AcademicTermOf(history.ChangeAt),
+		Actor:        actor,
+		Reason:       reason,
+	})
+}
+
+// ========================================
+// Writers - ทุกการเขียน Hour_Change_Histories ต้องผ่านฟังก์ชันเหล่านี้
+// ========================================
+
+// InsertHistory สร้าง hour history และบันทึก entry แรกใน ledger
+func InsertHistory(ctx context.Context, history *models.HourChangeHistory, actor, reason string) error {
+	if history.ID.IsZero() {
+		history.ID = primitive.NewObjectID()
+	}
+	if _, err := DB.HourChangeHistoryCollection.InsertOne(ctx, history); err != nil {
+		return err
+	}
+	return recordHistoryChange(ctx, history, actor, reason)
+}
+
+// UpdateHistories อัปเดต hour history ที่ตรง filter ทีละรายการ แล้วบันทึกการเปลี่ยนแปลงลง ledger
+// คืนจำนวนรายการที่ตรง filter (เทียบเท่า MatchedCount)
+func UpdateHistories(ctx context.Context, filter, update bson.M, actor, reason string) (int64, error) {
+	cur, err := DB.HourChangeHistoryCollection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return 0, err
+	}
+	var rows []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cur.All(ctx, &rows); err != nil {
+		return 0, err
+	}
+
+	var matched int64
+	for _, row := range rows {
+		// filter เดิมยังต้องตรง (กันรายการที่ถูกเปลี่ยนระหว่างทาง)
+		res, err := DB.HourChangeHistoryCollection.UpdateOne(ctx,
+			bson.M{"$and": bson.A{bson.M{"_id": row.ID}, filter}}, update)
+		if err != nil {
+			return matched, err
+		}
+		if res.MatchedCount == 0 {
+			continue
+		}
+		matched++
+
+		var history models.HourChangeHistory
+		if err := DB.HourChangeHistoryCollection.FindOne(ctx, bson.M{"_id": row.ID}).Decode(&history); err != nil {
+			return matched, err
+		}
+		if err := recordHistoryChange(ctx, &history, actor, reason); err != nil {
+			return matched, err
+		}
+	}
+	return matched, nil
+}
+
+// UpsertHistory เหมือน UpdateHistories แต่ถ้าไม่มีรายการตรง filter จะสร้างใหม่ (upsert)
+func UpsertHistory(ctx context.Context, filter, update bson.M, actor, reason string) error {
+	matched, err := UpdateHistories(ctx, filter, update, actor, reason)
+	if err != nil || matched > 0 {
+		return err
+	}
+
+	res, err := DB.HourChangeHistoryCollection.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+	if res.UpsertedID == nil {
+		return nil
+	}
+	var history models.HourChangeHistory
+	if err := DB.HourChangeHistoryCollection.FindOne(ctx, bson.M{"_id": res.UpsertedID}).Decode(&history); err != nil {
+		return err
+	}
+	return recordHistoryChange(ctx, &history, actor, reason)
+}
+
+// VoidHistories ลบ hour history ที่ตรง filter โดยกลับรายการใน ledger (ประวัติใน ledger ยังอยู่)
+func VoidHistories(ctx context.Context, filter bson.M, actor, reason string) (int64, error) {
+	cur, err := DB.HourChangeHistoryCollection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return 0, err
+	}
+	var rows []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cur.All(ctx, &rows); err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	for _, row := range rows {
+		res, err := DB.HourChangeHistoryCollection.DeleteOne(ctx, bson.M{"_id": row.ID})
+		if err != nil {
+			return deleted, err
+		}
+		if res.DeletedCount == 0 {
+			continue
+		}
+		deleted++
+
+		active, err := activeLedgerEntry(ctx, row.ID)
+		if err != nil {
+			return deleted, err
+		}
+		if active != nil {
+			if err := reverseLedgerEntry(ctx, active, actor, reason); err != nil {
+				return deleted, err
+			}
+		}
+	}
+	return deleted, nil
+}
+
+// BackfillLedger บันทึกยอดยกมาของ hour history ที่ยังไม่เคยอยู่ใน ledger (ข้อมูลก่อนมี ledger)
+func BackfillLedger(ctx context.Context) (int, error) {
+	ids, err := DB.HourLedgerCollection.Distinct(ctx, "historyId", bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	cur, err := DB.HourChangeHistoryCollection.Find(ctx,
+		bson.M{"_id": bson.M{"$nin": ids}},
+		options.Find().SetSort(bson.D{{Key: "changeAt", Value: 1}}),
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(ctx)
+
+	count := 0
+	for cur.Next(ctx) {
+		var history models.HourChangeHistory
+		if err := cur.Decode(&history); err != nil {
+			return count, err
+		}
+		if err := recordHistoryChange(ctx, &history, models.HourLedgerActorSystem, "ยอดยกมาก่อนเริ่มใช้ ledger"); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, cur.Err()
+}
+
+// ========================================
+// Query / Verification
+// ========================================
+
+// GetLedgerEntries ดึงรายการ ledger ของนิสิต (เรียงตาม seq) และ/หรือของ hour history หนึ่งรายการ
+func GetLedgerEntries(ctx context.Context, studentID, historyID *primitive.ObjectID, limit, skip int) ([]models.HourLedgerEntry, int64, error) {
+	filter := bson.M{}
+	if studentID != nil {
+		filter["studentId"] = *studentID
+	}
+	if historyID != nil {
+		filter["historyId"] = *historyID
+	}
+
+	total, err := DB.HourLedgerCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "studentId", Value: 1}, {Key: "seq", Value: 1}}).
+		SetSkip(int64(skip))
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cur, err := DB.HourLedgerCollection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	entries := []models.HourLedgerEntry{}
+	if err := cur.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// ledgerHistoryState สถานะของ hour history หนึ่งรายการตาม ledger
+type ledgerHistoryState struct {
+	studentID primitive.ObjectID
+	active    *models.HourLedgerEntry
+	net       int
+}
+
+// VerifyLedger ตรวจสอบ ledger (studentID = nil → ทุกคน)
+// - tamper: hash / prevHash ไม่ตรง หรือ reversal ไม่ได้หักล้าง entry ที่ยังมีผลอยู่
+// - gap: seq ขาดหาย
+// - drift: hour history ปัจจุบัน (หรือที่ถูกลบ) ไม่ตรงกับ ledger
+func VerifyLedger(ctx context.Context, studentID *primitive.ObjectID) (*models.HourLedgerVerification, error) {
+	result := &models.HourLedgerVerification{Issues: []models.HourLedgerIssue{}}
+	addIssue := func(issue models.HourLedgerIssue) {
+		if len(result.Issues) < maxLedgerIssues {
+			result.Issues = append(result.Issues, issue)
+		}
+	}
+
+	filter := bson.M{}
+	if studentID != nil {
+		filter["studentId"] = *studentID
+	}
+
+	// 1) ไล่ chain ของนิสิตแต่ละคนตาม seq
+	cur, err := DB.HourLedgerCollection.Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "studentId", Value: 1}, {Key: "seq", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	states := map[primitive.ObjectID]*ledgerHistoryState{}
+	var currentStudent primitive.ObjectID
+	var expectedSeq int64
+	var prevHash string
+	for cur.Next(ctx) {
+		var e models.HourLedgerEntry
+		if err := cur.Decode(&e); err != nil {
+			return nil, err
+		}
+		result.CheckedEntries++
+		entryID := e.ID
+		historyID := e.HistoryID
+
+		if e.StudentID != currentStudent || result.CheckedEntries == 1 {
+			currentStudent = e.StudentID
+			expectedSeq, prevHash = 1, ""
+			result.CheckedStudents++
+		}
+
+		if e.Seq != expectedSeq {
+			addIssue(models.HourLedgerIssue{
+				Type: models.HourLedgerIssueGap, StudentID: e.StudentID, EntryID: &entryID, HistoryID: &historyID, Seq: e.Seq,
+				Message:  "seq ไม่ต่อเนื่อง",
+				Expected: strconv.FormatInt(expectedSeq, 10), Actual: strconv.FormatInt(e.Seq, 10),
+			})
+		}
+		if e.PrevHash != prevHash {
+			addIssue(models.HourLedgerIssue{
+				Type: models.HourLedgerIssueTamper, StudentID: e.StudentID, EntryID: &entryID, HistoryID: &historyID, Seq: e.Seq,
+				Message:  "prevHash ไม่ตรงกับ hash ของ entry ก่อนหน้า",
+				Expected: prevHash, Actual: e.PrevHash,
+			})
+		}
+		if hash := ledgerHash(&e); hash != e.Hash {
+			addIssue(models.HourLedgerIssue{
+				Type: models.HourLedgerIssueTamper, StudentID: e.StudentID, EntryID: &entryID, HistoryID: &historyID, Seq: e.Seq,
+				Message:  "ข้อมูลของ entry ไม่ตรงกับ hash",
+				Expected: hash, Actual: e.Hash,
+			})
+		}
+		expectedSeq = e.Seq + 1
+		prevHash = e.Hash
+
+		state := states[e.HistoryID]
+		if state == nil {
+			state = &ledgerHistoryState{studentID: e.StudentID}
+			states[e.HistoryID] = state
+		}
+		state.net += e.Hours
+		switch e.Kind {
+		case models.HourLedgerKindEntry:
+			if state.active != nil {
+				addIssue(models.HourLedgerIssue{
+					Type: models.HourLedgerIssueTamper, StudentID: e.StudentID, EntryID: &entryID, HistoryID: &historyID, Seq: e.Seq,
+					Message: "entry ใหม่โดยไม่กลับรายการ entry เดิม",
+				})
+			}
+			entry := e
+			state.active = &entry
+		case models.HourLedgerKindReversal:
+			if state.active == nil || e.ReversesID == nil || *e.ReversesID != state.active.ID || e.Hours != -state.active.Hours {
+				addIssue(models.HourLedgerIssue{
+					Type: models.HourLedgerIssueTamper, StudentID: e.StudentID, EntryID: &entryID, HistoryID: &historyID, Seq: e.Seq,
+					Message: "reversal ไม่ได้หักล้าง entry ที่ยังมีผลอยู่",
+				})
+			}
+			state.active = nil
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+
+	// 2) เทียบ hour history ปัจจุบันกับ ledger
+	hcur, err := DB.HourChangeHistoryCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer hcur.Close(ctx)
+
+	seen := map[primitive.ObjectID]bool{}
+	for hcur.Next(ctx) {
+		var h models.HourChangeHistory
+		if err := hcur.Decode(&h); err != nil {
+			return nil, err
+		}
+		result.CheckedHistories++
+		seen[h.ID] = true
+		historyID := h.ID
+		expected := EffectiveHours(h.Status, h.HourChange)
+
+		state := states[h.ID]
+		if state == nil || state.active == nil {
+			addIssue(models.HourLedgerIssue{
+				Type: models.HourLedgerIssueDrift, StudentID: h.StudentID, HistoryID: &historyID,
+				Message: "hour history ไม่มี entry ที่มีผลอยู่ใน ledger",
+				Actual:  fmt.Sprintf("%s/%d", h.Status, h.HourChange),
+			})
+			continue
+		}
+		active := state.active
+		if active.Status != h.Status || active.HourChange != h.HourChange || active.SkillType != h.SkillType {
+			addIssue(models.HourLedgerIssue{
+				Type: models.HourLedgerIssueDrift, StudentID: h.StudentID, HistoryID: &historyID, EntryID: &active.ID, Seq: active.Seq,
+				Message:  "hour history ถูกแก้ไขโดยไม่ผ่าน ledger",
+				Expected: fmt.Sprintf("%s/%s/%d", active.SkillType, active.Status, active.HourChange),
+				Actual:   fmt.Sprintf("%s/%s/%d", h.SkillType, h.Status, h.HourChange),
+			})
+		}
+		if state.net != expected {
+			addIssue(models.HourLedgerIssue{
+				Type: models.HourLedgerIssueDrift, StudentID: h.StudentID, HistoryID: &historyID,
+				Message:  "ยอดชั่วโมงใน ledger ไม่ตรงกับ hour history",
+				Expected: strconv.Itoa(expected), Actual: strconv.Itoa(state.net),
+			})
+		}
+	}
+	if err := hcur.Err(); err != nil {
+		return nil, err
+	}
+
+	// 3) hour history ที่ถูกลบไปแล้ว ต้องถูกกลับรายการจนยอดเป็น 0
+	for id, state := range states {
+		if seen[id] || (state.active == nil && state.net == 0) {
+			continue
+		}
+		historyID := id
+		addIssue(models.HourLedgerIssue{
+			Type: models.HourLedgerIssueDrift, StudentID: state.studentID, HistoryID: &historyID,
+			Message:  "hour history ถูกลบโดยไม่กลับรายการใน ledger",
+			Expected: "0", Actual: strconv.Itoa(state.net),
+		})
+	}
+
+	result.OK = len(result.Issues) == 0
+	return result, nil
+}
@@ -0,0 +1,415 @@
+package hourhistory
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ========================================
+// Hour Policy - เกณฑ์การให้ชั่วโมง (ใช้ทั้งตอนกิจกรรมเสร็จสิ้นและตอน preview)
+// ========================================
+
+var (
+	ErrInvalidHourPolicy = errors.New("invalid hour policy")
+	ErrProgramNotFound   = errors.New("program not found")
+)
+
+const (
+	defaultCheckinWindowMinutes = 30
+	maxCheckinWindowMinutes     = 240
+)
+
+// ValidateHourPolicy ตรวจสอบความถูกต้องของ hourPolicy ก่อนบันทึก (nil = ใช้เกณฑ์เดิม)
+func ValidateHourPolicy(policy *models.HourPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	switch policy.Mode {
+	case "", models.HourPolicyAllOrNothing, models.HourPolicyProRata:
+	default:
+		return fmt.Errorf("%w: mode must be %s or %s", ErrInvalidHourPolicy, models.HourPolicyAllOrNothing, models.HourPolicyProRata)
+	}
+	if policy.LateGraceDays < 0 {
+		return fmt.Errorf("%w: lateGraceDays must be a non-negative number", ErrInvalidHourPolicy)
+	}
+	if policy.CheckinWindowMinutes < 0 || policy.CheckinWindowMinutes > maxCheckinWindowMinutes {
+		return fmt.Errorf("%w: checkinWindowMinutes must be between 0 and %d", ErrInvalidHourPolicy, maxCheckinWindowMinutes)
+	}
+	return nil
+}
+
+// EffectiveHourPolicy เกณฑ์ที่ใช้กับกิจกรรมย่อย: ของ ProgramItem → ของ Program → เกณฑ์เดิม
+// ค่าที่คืนเติมค่า default ครบแล้ว (mode, checkinWindowMinutes, absencePenalty)
+// กิจกรรมที่ตั้ง evaluationRequired บังคับ requireForm ทุกกิจกรรมย่อย
+func EffectiveHourPolicy(program *models.Program, item *models.ProgramItem) models.HourPolicy {
+	var policy models.HourPolicy
+	switch {
+	case item != nil && item.HourPolicy != nil:
+		policy = *item.HourPolicy
+	case program != nil && program.HourPolicy != nil:
+		policy = *program.HourPolicy
+	}
+	return programHourPolicy(program, policy)
+}
+
+// programHourPolicy ใช้ evaluationRequired ของ Program (บังคับ requireForm) แล้วเติมค่า default
+// ใช้กับทั้งเกณฑ์ที่บันทึกไว้และเกณฑ์ทดลอง (override) ของ PreviewProgramHours
+func programHourPolicy(program *models.Program, policy models.HourPolicy) models.HourPolicy {
+	if program != nil && program.EvaluationRequired {
+		policy.RequireForm = true
+	}
+	return normalizeHourPolicy(policy)
+}
+
+func normalizeHourPolicy(policy models.HourPolicy) models.HourPolicy {
+	if policy.Mode == "" {
+		policy.Mode = models.HourPolicyAllOrNothing
+	}
+	if policy.CheckinWindowMinutes == 0 {
+		policy.CheckinWindowMinutes = defaultCheckinWindowMinutes
+	}
+	if policy.AbsencePenalty == nil {
+		penalty := true
+		policy.AbsencePenalty = &penalty
+	}
+	return policy
+}
+
+// attendanceSummary ผลการเช็คชื่อรายวันเทียบกับ programItem.Dates
+type attendanceSummary struct {
+	totalDays       int
+	daysOnTime      int      // มีทั้ง check-in/out และเช็คอินอยู่ในช่วงที่กำหนด
+	daysLate        int      // มีทั้ง check-in/out แต่เช็คอินนอกช่วง
+	daysIncomplete  int      // มีแต่ check-in หรือ check-out อย่างเดียว
+	daysAbsent      int      // ไม่มี record เลย
+	missingDates    []string // วันที่ไม่มาเลย
+	lateDates       []string // วันที่มาแต่สาย
+	incompleteDates []string // วันที่เช็คไม่ครบ
+}
+
+// classifyAttendance จัดกลุ่มการเช็คชื่อแต่ละวันของ enrollment ตามช่วงเช็คอิน ±windowMinutes จากเวลาเริ่ม
+func classifyAttendance(enrollment *models.Enrollment, item *models.ProgramItem, windowMinutes int) attendanceSummary {
+	loc, _ := time.LoadLocation("Asia/Bangkok")
+
+	// map ของ checkin/checkout records ตามวันที่
+	checkinoutMap := make(map[string]models.CheckinoutRecord)
+	if enrollment.CheckinoutRecord != nil {
+		for _, record := range *enrollment.CheckinoutRecord {
+			var dateKey string
+			if record.Checkin != nil {
+				dateKey = record.Checkin.In(loc).Format("2006-01-02")
+			} else if record.Checkout != nil {
+				dateKey = record.Checkout.In(loc).Format("2006-01-02")
+			}
+			if dateKey != "" {
+				checkinoutMap[dateKey] = record
+			}
+		}
+	}
+
+	window := time.Duration(windowMinutes) * time.Minute
+	summary := attendanceSummary{totalDays: len(item.Dates)}
+	for _, programDate := range item.Dates {
+		dateKey := programDate.Date
+		record, hasRecord := checkinoutMap[dateKey]
+
+		if !hasRecord || (record.Checkin == nil && record.Checkout == nil) {
+			summary.daysAbsent++
+			summary.missingDates = append(summary.missingDates, dateKey)
+			continue
+		}
+		if record.Checkin == nil || record.Checkout == nil {
+			summary.daysIncomplete++
+			summary.incompleteDates = append(summary.incompleteDates, dateKey)
+			continue
+		}
+
+		// ไม่มีเวลาเริ่ม หรือ parse ไม่ได้ → ถือว่าตรงเวลา (ให้ประโยชน์ของข้อสงสัย)
+		if programDate.Stime == "" {
+			summary.daysOnTime++
+			continue
+		}
+		startTime, err := time.ParseInLocation("2006-01-02 15:04", programDate.Date+" "+programDate.Stime, loc)
+		if err != nil {
+			summary.daysOnTime++
+			continue
+		}
+		checkinTime := record.Checkin.In(loc)
+		if !checkinTime.Before(startTime.Add(-window)) && !checkinTime.After(startTime.Add(window)) {
+			summary.daysOnTime++
+		} else {
+			summary.daysLate++
+			summary.lateDates = append(summary.lateDates, dateKey)
+		}
+	}
+	return summary
+}
+
+// gradeAttendance คำนวณสถานะ / ชั่วโมง / หมายเหตุตามเกณฑ์ (policy ต้องผ่าน normalizeHourPolicy แล้ว)
+func gradeAttendance(policy models.HourPolicy, hour int, att attendanceSummary, formSubmitted bool) models.HourGrade {
+	graceLate := min(att.daysLate, policy.LateGraceDays)
+	grade := models.HourGrade{
+		TotalDays:      att.totalDays,
+		DaysOnTime:     att.daysOnTime,
+		DaysLate:       att.daysLate,
+		DaysIncomplete: att.daysIncomplete,
+		DaysAbsent:     att.daysAbsent,
+		CountedDays:    att.daysOnTime + graceLate,
+		FormSubmitted:  formSubmitted,
+	}
+	totalDays := att.totalDays
+	totalValidDays := att.daysOnTime + att.daysLate + att.daysIncomplete
+
+	// ❌ ไม่มาเข้าร่วมเลยทุกวัน
+	if att.daysAbsent == totalDays {
+		grade.Status = models.HCStatusAbsent
+		grade.Remark = fmt.Sprintf("❌ ไม่มาเข้าร่วมกิจกรรมเลย (0/%d วัน)", totalDays)
+		if *policy.AbsencePenalty {
+			grade.HourChange = -hour
+		} else {
+			grade.Remark += " - ไม่หักชั่วโมง"
+		}
+		return grade
+	}
+
+	switch {
+	case grade.CountedDays == totalDays:
+		// ✅ มาครบทุกวัน + ตรงเวลา (หรือสายไม่เกินจำนวนวันที่ผ่อนผัน) → ได้ชั่วโมงเต็ม
+		grade.Status = models.HCStatusAttended
+		grade.HourChange = hour
+		if graceLate > 0 {
+			grade.Remark = fmt.Sprintf("✅ เข้าร่วมครบถ้วน (%d/%d วัน, สายภายในเกณฑ์ผ่อนผัน %d วัน) - ได้รับ %d ชั่วโมง", grade.CountedDays, totalDays, graceLate, hour)
+		} else {
+			grade.Remark = fmt.Sprintf("✅ เข้าร่วมครบถ้วนและตรงเวลาทุกวัน (%d/%d วัน) - ได้รับ %d ชั่วโมง", att.daysOnTime, totalDays, hour)
+		}
+	case policy.Mode == models.HourPolicyProRata && grade.CountedDays > 0:
+		// ✅ ได้ชั่วโมงตามสัดส่วนวันที่นับได้ (ปัดลง)
+		grade.Status = models.HCStatusAttended
+		grade.HourChange = hour * grade.CountedDays / totalDays
+		grade.Remark = fmt.Sprintf("✅ เข้าร่วม %d/%d วัน%s - ได้รับ %d/%d ชั่วโมงตามสัดส่วน",
+			grade.CountedDays, totalDays, attendanceDetails(att, true), grade.HourChange, hour) + problemDates(att, true)
+	case totalValidDays != totalDays:
+		// ⚠️ มาไม่ครบทุกวัน (ขาดบางวัน หรือ เช็คไม่ครบบางวัน) → incomplete และไม่ได้ชั่วโมง
+		grade.Status = models.HCStatusIncomplete
+		grade.Remark = fmt.Sprintf("⚠️ มาไม่ครบ - เข้าร่วมไม่ครบทุกวัน %d/%d วัน%s - ไม่ได้รับชั่วโมง", totalValidDays, totalDays, attendanceDetails(att, true)) +
+			problemDates(att, true)
+	default:
+		// ⚠️ มาครบทุกวันแล้ว แต่มีบางวันที่สาย → late และไม่ได้ชั่วโมง
+		grade.Status = models.HCStatusLate
+		grade.Remark = fmt.Sprintf("⚠️ มาสาย - เข้าร่วมครบทุกวัน แต่%s - ไม่ได้รับชั่วโมง", attendanceDetails(att, false)) +
+			problemDates(att, false)
+	}
+
+	// 📝 ต้องส่งแบบฟอร์มก่อนได้ชั่วโมง → เก็บชั่วโมงไว้เป็น pending_evaluation จนกว่าจะส่ง / เลยกำหนด
+	if policy.RequireForm && !formSubmitted && grade.HourChange > 0 {
+		grade.Remark = fmt.Sprintf("📝 รอส่งแบบประเมิน (เข้าร่วม %d/%d วัน) - จะได้รับ %d ชั่วโมงเมื่อส่งแบบประเมินภายในกำหนด", grade.CountedDays, totalDays, grade.HourChange)
+		grade.Status = models.HCStatusPendingEvaluation
+	}
+	return grade
+}
+
+// attendanceDetails สรุปจำนวนวันแต่ละประเภท เช่น " (ตรงเวลา 2 วัน, สาย 1 วัน)"
+func attendanceDetails(att attendanceSummary, withAbsent bool) string {
+	details := []string{}
+	if att.daysOnTime > 0 {
+		details = append(details, fmt.Sprintf("ตรงเวลา %d วัน", att.daysOnTime))
+	}
+	if att.daysLate > 0 {
+		details = append(details, fmt.Sprintf("สาย %d วัน", att.daysLate))
+	}
+	if att.daysIncomplete > 0 {
+		details = append(details, fmt.Sprintf("เช็คไม่ครบ %d วัน", att.daysIncomplete))
+	}
+	if withAbsent && att.daysAbsent > 0 {
+		details = append(details, fmt.Sprintf("ขาด %d วัน", att.daysAbsent))
+	}
+	if len(details) == 0 {
+		return ""
+	}
+	return " (" + joinStrings(details, ", ") + ")"
+}
+
+// problemDates รายละเอียดวันที่มีปัญหา (แสดงเมื่อไม่เกิน 3 วันต่อประเภท)
+func problemDates(att attendanceSummary, withAbsent bool) string {
+	remark := ""
+	if withAbsent && len(att.missingDates) > 0 && len(att.missingDates) <= 3 {
+		remark += fmt.Sprintf(" | ขาดวันที่: %s", joinStrings(att.missingDates, ", "))
+	}
+	if len(att.lateDates) > 0 && len(att.lateDates) <= 3 {
+		remark += fmt.Sprintf(" | สายวันที่: %s", joinStrings(att.lateDates, ", "))
+	}
+	if len(att.incompleteDates) > 0 && len(att.incompleteDates) <= 3 {
+		remark += fmt.Sprintf(" | เช็คไม่ครบวันที่: %s", joinStrings(att.incompleteDates, ", "))
+	}
+	return remark
+}
+
+// GradeEnrollmentHours ประเมินชั่วโมงของ enrollment ตามเกณฑ์ (ไม่บันทึก) — engine เดียวของ VerifyAndGrantHours และ preview
+func GradeEnrollmentHours(ctx context.Context, enrollment *models.Enrollment, program *models.Program, item *models.ProgramItem, policy models.HourPolicy) (models.HourGrade, error) {
+	if len(item.Dates) == 0 {
+		return models.HourGrade{}, fmt.Errorf("program item has no dates")
+	}
+	hour := 0
+	if item.Hour != nil {
+		hour = *item.Hour
+	}
+	policy = normalizeHourPolicy(policy)
+
+	formSubmitted := true
+	if policy.RequireForm {
+		submitted, err := hasSubmittedProgramForm(ctx, enrollment, program)
+		if err != nil {
+			return models.HourGrade{}, err
+		}
+		formSubmitted = submitted
+	}
+
+	att := classifyAttendance(enrollment, item, policy.CheckinWindowMinutes)
+	return gradeAttendance(policy, hour, att, formSubmitted), nil
+}
+
+// hasSubmittedProgramForm นิสิตส่งแบบฟอร์มของกิจกรรมแล้วหรือไม่ (กิจกรรมที่ไม่มีฟอร์ม = ถือว่าส่งแล้ว)
+// submission.userId อาจเป็น Student ID หรือ User ID ของนิสิต จึงเช็คทั้งสองแบบ
+func hasSubmittedProgramForm(ctx context.Context, enrollment *models.Enrollment, program *models.Program) (bool, error) {
+	if enrollment.SubmissionID != nil {
+		return true, nil
+	}
+	if program == nil || program.FormID.IsZero() {
+		return true, nil
+	}
+
+	userIDs := []primitive.ObjectID{enrollment.StudentID}
+	var user models.User
+	err := DB.UserCollection.FindOne(ctx, bson.M{"refId": enrollment.StudentID}).Decode(&user)
+	if err == nil {
+		userIDs = append(userIDs, user.ID)
+	} else if err != mongo.ErrNoDocuments {
+		return false, err
+	}
+
+	count, err := DB.SubmissionCollection.CountDocuments(ctx, bson.M{
+		"formId": program.FormID,
+		"userId": bson.M{"$in": userIDs},
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// PreviewProgramHours "what-if" ประเมินชั่วโมงของทุก enrollment ในกิจกรรม โดยไม่บันทึก
+// override != nil = ทดลองใช้เกณฑ์นี้กับทุกกิจกรรมย่อย, nil = ใช้เกณฑ์ที่บันทึกไว้
+func PreviewProgramHours(ctx context.Context, programID primitive.ObjectID, override *models.HourPolicy) (*models.HourPolicyPreview, error) {
+	if err := ValidateHourPolicy(override); err != nil {
+		return nil, err
+	}
+
+	var program models.Program
+	if err := DB.ProgramCollection.FindOne(ctx, bson.M{"_id": programID, "deletedAt": nil}).Decode(&program); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrProgramNotFound
+		}
+		return nil, err
+	}
+
+	cur, err := DB.ProgramItemCollection.Find(ctx, bson.M{"programId": programID})
+	if err != nil {
+		return nil, err
+	}
+	var items []models.ProgramItem
+	if err := cur.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	itemMap := make(map[primitive.ObjectID]*models.ProgramItem, len(items))
+	for i := range items {
+		itemMap[items[i].ID] = &items[i]
+	}
+
+	cur, err = DB.EnrollmentCollection.Find(ctx, bson.M{"programId": programID})
+	if err != nil {
+		return nil, err
+	}
+	var enrollments []models.Enrollment
+	if err := cur.All(ctx, &enrollments); err != nil {
+		return nil, err
+	}
+
+	// ค่าปัจจุบันจาก HourChangeHistory และข้อมูลนิสิตสำหรับแสดงผล
+	currentMap := map[primitive.ObjectID]models.HourChangeHistory{}
+	cur, err = DB.HourChangeHistoryCollection.Find(ctx, bson.M{"sourceType": "program", "sourceId": programID})
+	if err != nil {
+		return nil, err
+	}
+	var records []models.HourChangeHistory
+	if err := cur.All(ctx, &records); err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		if r.EnrollmentID != nil {
+			currentMap[*r.EnrollmentID] = r
+		}
+	}
+
+	studentIDs := make([]primitive.ObjectID, 0, len(enrollments))
+	for _, e := range enrollments {
+		studentIDs = append(studentIDs, e.StudentID)
+	}
+	studentMap := map[primitive.ObjectID]models.Student{}
+	if len(studentIDs) > 0 {
+		cur, err = DB.StudentCollection.Find(ctx, bson.M{"_id": bson.M{"$in": studentIDs}})
+		if err != nil {
+			return nil, err
+		}
+		var students []models.Student
+		if err := cur.All(ctx, &students); err != nil {
+			return nil, err
+		}
+		for _, s := range students {
+			studentMap[s.ID] = s
+		}
+	}
+
+	preview := &models.HourPolicyPreview{
+		ProgramID:   programID,
+		Policy:      override,
+		Enrollments: []models.HourGradePreview{},
+	}
+	for i := range enrollments {
+		enrollment := &enrollments[i]
+		item, ok := itemMap[enrollment.ProgramItemID]
+		if !ok || len(item.Dates) == 0 {
+			continue
+		}
+		policy := EffectiveHourPolicy(&program, item)
+		if override != nil {
+			policy = programHourPolicy(&program, *override)
+		}
+		grade, err := GradeEnrollmentHours(ctx, enrollment, &program, item, policy)
+		if err != nil {
+			return nil, err
+		}
+
+		student := studentMap[enrollment.StudentID]
+		current := currentMap[enrollment.ID]
+		preview.Enrollments = append(preview.Enrollments, models.HourGradePreview{
+			EnrollmentID:      enrollment.ID,
+			ProgramItemID:     enrollment.ProgramItemID,
+			StudentID:         enrollment.StudentID,
+			StudentCode:       student.Code,
+			StudentName:       student.Name,
+			CurrentStatus:     current.Status,
+			CurrentHourChange: current.HourChange,
+			Grade:             grade,
+		})
+		preview.TotalHours += grade.HourChange
+	}
+	return preview, nil
+}
@@ -0,0 +1,434 @@
+package hourhistory
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ========================================
+// Graduation Requirements - เกณฑ์ชั่วโมงแยกตามสาขา / รุ่น (ใช้คำนวณสถานะ, เพดานใบเซอร์ และรายงาน)
+// ========================================
+
+var (
+	ErrGraduationRequirementNotFound = errors.New("graduation requirement not found")
+	ErrGraduationRequirementExists   = errors.New("graduation requirement for this major and entry year already exists")
+	ErrInvalidGraduationRequirement  = errors.New("invalid graduation requirement")
+)
+
+// EnsureGraduationRequirements สร้าง unique index (major, entryYear) และบันทึกเกณฑ์เดิมของระบบเมื่อยังไม่มีเกณฑ์ใดเลย
+func EnsureGraduationRequirements(ctx context.Context) error {
+	if _, err := DB.GraduationRequirementCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "major", Value: 1}, {Key: "entryYear", Value: 1}},
+		Options: options.Index().SetName("major_entryYear").SetUnique(true),
+	}); err != nil {
+		return err
+	}
+
+	count, err := DB.GraduationRequirementCollection.CountDocuments(ctx, bson.M{})
+	if err != nil || count > 0 {
+		return err
+	}
+	now := time.Now()
+	docs := []interface{}{}
+	for _, major := range []string{"", "SE", "AAI"} {
+		req := models.DefaultGraduationRequirement(major)
+		req.Major = major
+		req.Note = "เกณฑ์เริ่มต้นของระบบ"
+		req.CreatedBy = models.HourLedgerActorSystem
+		req.CreatedAt = now
+		req.UpdatedBy = models.HourLedgerActorSystem
+		req.UpdatedAt = now
+		docs = append(docs, req)
+	}
+	_, err = DB.GraduationRequirementCollection.InsertMany(ctx, docs)
+	return err
+}
+
+// GraduationRequirementSet เกณฑ์ทั้งหมดที่โหลดไว้ สำหรับเลือกเกณฑ์ของนิสิตหลายคนโดยไม่ query ซ้ำ
+type GraduationRequirementSet struct {
+	byKey map[string]models.GraduationRequirement
+}
+
+func requirementKey(major string, entryYear int) string {
+	return fmt.Sprintf("%s|%d", major, entryYear)
+}
+
+func normalizeMajor(major string) string {
+	return strings.ToUpper(strings.TrimSpace(major))
+}
+
+// LoadGraduationRequirements โหลดเกณฑ์ทั้งหมด (collection เล็ก — หนึ่งแถวต่อสาขา / รุ่นที่ตั้งค่าไว้)
+func LoadGraduationRequirements(ctx context.Context) (*GraduationRequirementSet, error) {
+	cur, err := DB.GraduationRequirementCollection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	var reqs []models.GraduationRequirement
+	if err := cur.All(ctx, &reqs); err != nil {
+		return nil, err
+	}
+	set := &GraduationRequirementSet{byKey: make(map[string]models.GraduationRequirement, len(reqs))}
+	for _, r := range reqs {
+		set.byKey[requirementKey(r.Major, r.EntryYear)] = r
+	}
+	return set, nil
+}
+
+// For เกณฑ์ที่เจาะจงที่สุดของนิสิต: สาขา+รุ่น → สาขา → รุ่น → ทุกสาขาทุกรุ่น → เกณฑ์เดิมของระบบ
+func (s *GraduationRequirementSet) For(major string, entryYear int) models.GraduationRequirement {
+	major = normalizeMajor(major)
+	for _, key := range []string{
+		requirementKey(major, entryYear),
+		requirementKey(major, 0),
+		requirementKey("", entryYear),
+		requirementKey("", 0),
+	} {
+		if r, ok := s.byKey[key]; ok {
+			return r
+		}
+	}
+	return models.DefaultGraduationRequirement(major)
+}
+
+// ResolveGraduationRequirement เกณฑ์ที่ใช้กับนิสิตสาขา / รุ่นนี้
+func ResolveGraduationRequirement(ctx context.Context, major string, entryYear int) (models.GraduationRequirement, error) {
+	set, err := LoadGraduationRequirements(ctx)
+	if err != nil {
+		return models.GraduationRequirement{}, err
+	}
+	return set.For(major, entryYear), nil
+}
+
+// CurrentCertificateHours คำนวณชั่วโมงรวมจาก certificate ที่ approved แล้ว (sourceType = "certificate")
+// ใช้คู่กับ MaxCertificateHours / CertificateHoursToAdd ทั้งตอนอนุมัติใบรับรองและอนุมัติคำร้องขอทบทวน
+func CurrentCertificateHours(ctx context.Context, studentID primitive.ObjectID, skillType string) (int, error) {
+	// Query: หา HourChangeHistory ที่เป็น certificate และ approved
+	filter := bson.M{
+		"studentId":  studentID,
+		"sourceType": "certificate",
+		"status":     models.HCStatusApproved,
+		"skillType":  skillType,
+	}
+
+	cursor, err := DB.HourChangeHistoryCollection.Find(ctx, filter)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query hour change history: %v", err)
+	}
+	defer cursor.Close(ctx)
+
+	totalHours := 0
+	for cursor.Next(ctx) {
+		var record models.HourChangeHistory
+		if err := cursor.Decode(&record); err != nil {
+			continue
+		}
+		totalHours += record.HourChange
+	}
+
+	return totalHours, nil
+}
+
+// MaxCertificateHours ชั่วโมงอบรมสูงสุดตามประเภท skill จากเกณฑ์ของสาขา / รุ่นของนิสิต
+func MaxCertificateHours(ctx context.Context, skillType string, major string, entryYear int) (int, error) {
+	req, err := ResolveGraduationRequirement(ctx, major, entryYear)
+	if err != nil {
+		return 0, err
+	}
+	return req.CertificateCap(skillType), nil
+}
+
+// CertificateHoursToAdd คำนวณชั่วโมงที่สามารถเพิ่มได้จริง (ไม่เกิน max)
+func CertificateHoursToAdd(courseHour, currentHours, maxHours int, studentCode, skillType string) int {
+	hoursToAdd := courseHour
+
+	if currentHours+hoursToAdd > maxHours {
+		hoursToAdd = maxHours - currentHours
+		if hoursToAdd < 0 {
+			hoursToAdd = 0
+		}
+
+		if hoursToAdd > 0 {
+			fmt.Printf("⚠️ Certificate hours capped: Student %s already has %d/%d %s training hours, adding only %d (original: %d)\n",
+				studentCode, currentHours, maxHours, skillType, hoursToAdd, courseHour)
+		} else {
+			fmt.Printf("⚠️ Student %s has reached max %s training hours (%d/%d), no hours added\n",
+				studentCode, skillType, currentHours, maxHours)
+		}
+	}
+
+	return hoursToAdd
+}
+
+// ListGraduationRequirements เกณฑ์ทั้งหมด เรียงตามสาขาและรุ่น
+func ListGraduationRequirements(ctx context.Context) ([]models.GraduationRequirement, error) {
+	cur, err := DB.GraduationRequirementCollection.Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "major", Value: 1}, {Key: "entryYear", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	reqs := []models.GraduationRequirement{}
+	if err := cur.All(ctx, &reqs); err != nil {
+		return nil, err
+	}
+	return reqs, nil
+}
+
+// GetGraduationRequirement เกณฑ์ตาม ID
+func GetGraduationRequirement(ctx context.Context, id primitive.ObjectID) (*models.GraduationRequirement, error) {
+	var req models.GraduationRequirement
+	if err := DB.GraduationRequirementCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&req); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrGraduationRequirementNotFound
+		}
+		return nil, err
+	}
+	return &req, nil
+}
+
+// validateGraduationRequirement ตรวจสอบและจัดรูปแบบข้อมูลเกณฑ์ก่อนบันทึก
+func validateGraduationRequirement(input *models.GraduationRequirementInput) error {
+	input.Major = normalizeMajor(input.Major)
+	if input.EntryYear != 0 && (input.EntryYear < 2500 || input.EntryYear > 3000) {
+		return fmt.Errorf("%w: entryYear must be 0 (all cohorts) or a Buddhist-era year", ErrInvalidGraduationRequirement)
+	}
+	for name, v := range map[string]int{
+		"softSkillHours":     input.SoftSkillHours,
+		"hardSkillHours":     input.HardSkillHours,
+		"softCertificateCap": input.SoftCertificateCap,
+		"hardCertificateCap": input.HardCertificateCap,
+		"lowStatusHours":     input.LowStatusHours,
+		"termSoftMin":        input.TermSoftMin,
+		"termHardMin":        input.TermHardMin,
+		"termSoftCap":        input.TermSoftCap,
+		"termHardCap":        input.TermHardCap,
+	} {
+		if v < 0 {
+			return fmt.Errorf("%w: %s must not be negative", ErrInvalidGraduationRequirement, name)
+		}
+	}
+	if input.SoftSkillHours+input.HardSkillHours == 0 {
+		return fmt.Errorf("%w: softSkillHours or hardSkillHours is required", ErrInvalidGraduationRequirement)
+	}
+	if input.LowStatusHours > input.SoftSkillHours+input.HardSkillHours {
+		return fmt.Errorf("%w: lowStatusHours must not exceed softSkillHours + hardSkillHours", ErrInvalidGraduationRequirement)
+	}
+	if input.TermSoftCap > 0 && input.TermSoftMin > input.TermSoftCap {
+		return fmt.Errorf("%w: termSoftMin must not exceed termSoftCap", ErrInvalidGraduationRequirement)
+	}
+	if input.TermHardCap > 0 && input.TermHardMin > input.TermHardCap {
+		return fmt.Errorf("%w: termHardMin must not exceed termHardCap", ErrInvalidGraduationRequirement)
+	}
+	return nil
+}
+
+// CreateGraduationRequirement สร้างเกณฑ์ของสาขา / รุ่น แล้วคำนวณสถานะนิสิตที่ได้รับผลใหม่
+func CreateGraduationRequirement(ctx context.Context, input models.GraduationRequirementInput, actor string) (*models.GraduationRequirement, error) {
+	if err := validateGraduationRequirement(&input); err != nil {
+		return nil, err
+	}
+	capsBefore := termCapsConfigured(ctx)
+	now := time.Now()
+	req := models.GraduationRequirement{
+		Major:              input.Major,
+		EntryYear:          input.EntryYear,
+		SoftSkillHours:     input.SoftSkillHours,
+		HardSkillHours:     input.HardSkillHours,
+		SoftCertificateCap: input.SoftCertificateCap,
+		HardCertificateCap: input.HardCertificateCap,
+		LowStatusHours:     input.LowStatusHours,
+		TermSoftMin:        input.TermSoftMin,
+		TermHardMin:        input.TermHardMin,
+		TermSoftCap:        input.TermSoftCap,
+		TermHardCap:        input.TermHardCap,
+		Note:               input.Note,
+		CreatedBy:          actor,
+		CreatedAt:          now,
+		UpdatedBy:          actor,
+		UpdatedAt:          now,
+	}
+	res, err := DB.GraduationRequirementCollection.InsertOne(ctx, req)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrGraduationRequirementExists
+		}
+		return nil, err
+	}
+	req.ID = res.InsertedID.(primitive.ObjectID)
+
+	recalculateStatusesFor(ctx, req.Major, req.EntryYear, capsBefore || req.HasTermCaps())
+	return &req, nil
+}
+
+// UpdateGraduationRequirement แก้ไขเกณฑ์ (เปลี่ยนสาขา / รุ่นได้) แล้วคำนวณสถานะนิสิตที่ได้รับผลใหม่
+func UpdateGraduationRequirement(ctx context.Context, id primitive.ObjectID, input models.GraduationRequirementInput, actor string) (*models.GraduationRequirement, error) {
+	if err := validateGraduationRequirement(&input); err != nil {
+		return nil, err
+	}
+	old, err := GetGraduationRequirement(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	capsBefore := termCapsConfigured(ctx)
+
+	var req models.GraduationRequirement
+	err = DB.GraduationRequirementCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{
+			"major":              input.Major,
+			"entryYear":          input.EntryYear,
+			"softSkillHours":     input.SoftSkillHours,
+			"hardSkillHours":     input.HardSkillHours,
+			"softCertificateCap": input.SoftCertificateCap,
+			"hardCertificateCap": input.HardCertificateCap,
+			"lowStatusHours":     input.LowStatusHours,
+			"termSoftMin":        input.TermSoftMin,
+			"termHardMin":        input.TermHardMin,
+			"termSoftCap":        input.TermSoftCap,
+			"termHardCap":        input.TermHardCap,
+			"note":               input.Note,
+			"updatedBy":          actor,
+			"updatedAt":          time.Now(),
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&req)
+	if err != nil {
+		switch {
+		case err == mongo.ErrNoDocuments:
+			return nil, ErrGraduationRequirementNotFound
+		case mongo.IsDuplicateKeyError(err):
+			return nil, ErrGraduationRequirementExists
+		}
+		return nil, err
+	}
+
+	refreshTotals := capsBefore || termCapsConfigured(ctx)
+	recalculateStatusesFor(ctx, old.Major, old.EntryYear, refreshTotals)
+	if old.Major != req.Major || old.EntryYear != req.EntryYear {
+		recalculateStatusesFor(ctx, req.Major, req.EntryYear, refreshTotals)
+	}
+	return &req, nil
+}
+
+// DeleteGraduationRequirement ลบเกณฑ์ — นิสิตในกลุ่มนี้กลับไปใช้เกณฑ์ที่กว้างกว่า
+func DeleteGraduationRequirement(ctx context.Context, id primitive.ObjectID, actor string) error {
+	old, err := GetGraduationRequirement(ctx, id)
+	if err != nil {
+		return err
+	}
+	capsBefore := termCapsConfigured(ctx)
+	if _, err := DB.GraduationRequirementCollection.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return err
+	}
+	log.Printf("🎓 Graduation requirement %s (major=%q entryYear=%d) deleted by %s", id.Hex(), old.Major, old.EntryYear, actor)
+
+	recalculateStatusesFor(ctx, old.Major, old.EntryYear, capsBefore)
+	return nil
+}
+
+// recalculateStatusesFor คำนวณสถานะใหม่ของนิสิตในขอบเขตของเกณฑ์ (ไม่ให้การแก้เกณฑ์ล้มเพราะขั้นนี้)
+// refreshTotals = มีการใช้เพดานชั่วโมงต่อภาค → ยอดที่ cache ไว้อาจเปลี่ยน ต้องคำนวณจาก ledger ใหม่ก่อน
+func recalculateStatusesFor(ctx context.Context, major string, entryYear int, refreshTotals bool) {
+	if refreshTotals {
+		if err := refreshTotalsFor(ctx, major, entryYear); err != nil {
+			log.Printf("⚠️ Warning: Failed to refresh hour totals (major=%q entryYear=%d): %v", major, entryYear, err)
+		}
+	}
+	n, err := RecalculateStudentStatuses(ctx, major, entryYear)
+	if err != nil {
+		log.Printf("⚠️ Warning: Failed to recalculate student statuses (major=%q entryYear=%d): %v", major, entryYear, err)
+		return
+	}
+	if n > 0 {
+		log.Printf("🎓 Recalculated status of %d students after requirement change (major=%q entryYear=%d)", n, major, entryYear)
+	}
+}
+
+// RecalculateStudentStatuses คำนวณสถานะ 1-3 ใหม่จากยอดชั่วโมงที่ cache ไว้และเกณฑ์ปัจจุบัน
+// major = "" → ทุกสาขา, entryYear = 0 → ทุกรุ่น; ไม่แตะนิสิตสถานะ 0 (พ้นสภาพ) / 4 (ออกฝึกแล้ว) — คืนจำนวนที่เปลี่ยน
+func RecalculateStudentStatuses(ctx context.Context, major string, entryYear int) (int, error) {
+	set, err := LoadGraduationRequirements(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.M{"status": bson.M{"$in": bson.A{1, 2, 3}}, "deletedAt": nil}
+	if major != "" {
+		filter["major"] = major
+	}
+	if entryYear != 0 {
+		filter["entryYear"] = entryYear
+	}
+	cur, err := DB.StudentCollection.Find(ctx, filter,
+		options.Find().SetProjection(bson.M{"major": 1, "entryYear": 1, "status": 1, "softSkill": 1, "hardSkill": 1}))
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(ctx)
+
+	changed := 0
+	for cur.Next(ctx) {
+		var s models.Student
+		if err := cur.Decode(&s); err != nil {
+			return changed, err
+		}
+		newStatus := set.For(s.Major, s.EntryYear).StatusFor(s.SoftSkill, s.HardSkill)
+		if newStatus == s.Status {
+			continue
+		}
+		res, err := DB.StudentCollection.UpdateOne(ctx,
+			bson.M{"_id": s.ID, "status": s.Status},
+			bson.M{"$set": bson.M{"status": newStatus}})
+		if err != nil {
+			return changed, err
+		}
+		changed += int(res.ModifiedCount)
+	}
+	return changed, cur.Err()
+}
+
+// termCapsConfigured มีเกณฑ์ใดกำหนดเพดานชั่วโมงต่อภาคหรือไม่ (error = ถือว่ามี เพื่อคำนวณยอดใหม่ไว้ก่อน)
+func termCapsConfigured(ctx context.Context) bool {
+	n, err := DB.GraduationRequirementCollection.CountDocuments(ctx, bson.M{"$or": bson.A{
+		bson.M{"termSoftCap": bson.M{"$gt": 0}},
+		bson.M{"termHardCap": bson.M{"$gt": 0}},
+	}})
+	return err != nil || n > 0
+}
+
+// refreshTotalsFor คำนวณยอดชั่วโมงที่ cache ไว้ใหม่ของนิสิตในขอบเขตของเกณฑ์ (major = "" / entryYear = 0 → ทั้งหมด)
+func refreshTotalsFor(ctx context.Context, major string, entryYear int) error {
+	filter := bson.M{"deletedAt": nil}
+	if major != "" {
+		filter["major"] = major
+	}
+	if entryYear != 0 {
+		filter["entryYear"] = entryYear
+	}
+	cur, err := DB.StudentCollection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		var s models.Student
+		if err := cur.Decode(&s); err != nil {
+			return err
+		}
+		if err := refreshStudentTotals(ctx, s.ID); err != nil {
+			return err
+		}
+	}
+	return cur.Err()
+}
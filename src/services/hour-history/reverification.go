@@ -0,0 +1,781 @@
+package hourhistory
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/jobs"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ========================================
+// Reverification - ประเมินชั่วโมงใหม่ของกิจกรรมที่เสร็จสิ้นแล้ว (เช่น หลังแอดมินแก้เวลาเช็คอิน)
+// ========================================
+//
+// ขั้นที่ 1 (diff): ประเมินทุก enrollment ในขอบเขตด้วย GradeEnrollmentHours แล้วเก็บรายการที่ผลต่างจากปัจจุบัน
+// ขั้นที่ 2 (commit): แอดมินตรวจ diff แล้วยืนยัน → บันทึกผ่าน UpdateHistories (ข้ามรายการที่ถูกแก้ไปแล้วระหว่างนั้น)
+// ทั้งสองขั้นรันเป็นงาน Asynq (ไม่มี Redis = รันใน process) และอัปเดต processed / total ระหว่างทำงาน
+
+var (
+	ErrReverificationNotFound     = errors.New("hour reverification not found")
+	ErrInvalidReverification      = errors.New("invalid hour reverification")
+	ErrReverificationStateInvalid = errors.New("hour reverification is not in a valid state for this action")
+)
+
+const (
+	reverifyPhaseDiff   = "diff"
+	reverifyPhaseCommit = "commit"
+
+	reverifyProgressEvery = 25
+	maxReverifyRangeDays  = 366
+)
+
+// gradedProgramStatuses สถานะของ hour history ที่สรุปผลจากการเช็คชื่อแล้ว (ประเมินใหม่ได้)
+var gradedProgramStatuses = []string{
+	models.HCStatusAttended,
+	models.HCStatusLate,
+	models.HCStatusIncomplete,
+	models.HCStatusAbsent,
+	models.HCStatusPendingEvaluation,
+}
+
+// CreateReverification สร้างงานประเมินชั่วโมงใหม่และส่งให้ worker คำนวณ diff (ยังไม่บันทึกผล)
+func CreateReverification(ctx context.Context, input models.HourReverificationRequest, actor string) (*models.HourReverification, error) {
+	if err := validateReverificationScope(ctx, input.HourReverificationScope); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	rev := models.HourReverification{
+		Scope:       input.HourReverificationScope,
+		Reason:      input.Reason,
+		State:       models.HourReverificationStatePending,
+		Changes:     []models.HourReverificationChange{},
+		Students:    []models.HourReverificationStudent{},
+		RequestedBy: actor,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	res, err := DB.HourReverificationCollection.InsertOne(ctx, rev)
+	if err != nil {
+		return nil, err
+	}
+	rev.ID = res.InsertedID.(primitive.ObjectID)
+
+	if err := dispatchReverification(rev.ID, reverifyPhaseDiff); err != nil {
+		failReverification(ctx, rev.ID, err)
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// validateReverificationScope ต้องระบุ enrollmentId / programId / from-to อย่างใดอย่างหนึ่ง และกิจกรรมต้องเสร็จสิ้นแล้ว
+func validateReverificationScope(ctx context.Context, scope models.HourReverificationScope) error {
+	n := 0
+	if scope.EnrollmentID != nil {
+		n++
+	}
+	if scope.ProgramID != nil {
+		n++
+	}
+	if scope.From != "" || scope.To != "" {
+		n++
+	}
+	if n != 1 {
+		return fmt.Errorf("%w: specify exactly one of enrollmentId, programId or from/to", ErrInvalidReverification)
+	}
+
+	switch {
+	case scope.EnrollmentID != nil:
+		var enrollment models.Enrollment
+		if err := DB.EnrollmentCollection.FindOne(ctx, bson.M{"_id": *scope.EnrollmentID}).Decode(&enrollment); err != nil {
+			if err == mongo.ErrNoDocuments {
+				return fmt.Errorf("%w: enrollment not found", ErrInvalidReverification)
+			}
+			return err
+		}
+		return requireCompletedProgram(ctx, enrollment.ProgramID)
+	case scope.ProgramID != nil:
+		return requireCompletedProgram(ctx, *scope.ProgramID)
+	}
+
+	from, err := time.Parse("2006-01-02", scope.From)
+	if err != nil {
+		return fmt.Errorf("%w: from must be YYYY-MM-DD", ErrInvalidReverification)
+	}
+	to, err := time.Parse("2006-01-02", scope.To)
+	if err != nil {
+		return fmt.Errorf("%w: to must be YYYY-MM-DD", ErrInvalidReverification)
+	}
+	if to.Before(from) {
+		return fmt.Errorf("%w: to must not be before from", ErrInvalidReverification)
+	}
+	if to.Sub(from) > maxReverifyRangeDays*24*time.Hour {
+		return fmt.Errorf("%w: date range must not exceed %d days", ErrInvalidReverification, maxReverifyRangeDays)
+	}
+	return nil
+}
+
+func requireCompletedProgram(ctx context.Context, programID primitive.ObjectID) error {
+	var program models.Program
+	if err := DB.ProgramCollection.FindOne(ctx, bson.M{"_id": programID, "deletedAt": nil}).Decode(&program); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return ErrProgramNotFound
+		}
+		return err
+	}
+	if program.ProgramState != models.ProgramStateSuccess {
+		return fmt.Errorf("%w: program has not been completed (state=%s)", ErrInvalidReverification, program.ProgramState)
+	}
+	return nil
+}
+
+// GetReverification งานประเมินใหม่พร้อม diff
+func GetReverification(ctx context.Context, id primitive.ObjectID) (*models.HourReverification, error) {
+	var rev models.HourReverification
+	if err := DB.HourReverificationCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&rev); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrReverificationNotFound
+		}
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// ListReverifications งานประเมินใหม่ล่าสุดก่อน (ไม่รวมรายละเอียด diff)
+func ListReverifications(ctx context.Context, limit, skip int) ([]models.HourReverification, int64, error) {
+	total, err := DB.HourReverificationCollection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetProjection(bson.M{"changes": 0, "students": 0}).
+		SetSkip(int64(skip))
+	if limit > 0 {
+		opts.SetLimit(int64(limit))
+	}
+	cur, err := DB.HourReverificationCollection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	revs := []models.HourReverification{}
+	if err := cur.All(ctx, &revs); err != nil {
+		return nil, 0, err
+	}
+	return revs, total, nil
+}
+
+// CommitReverification ยืนยันบันทึก diff ที่พร้อมแล้ว (ready → committing) และส่งให้ worker บันทึก
+// งานที่ล้มเหลวระหว่างบันทึก (failed + committedBy) สั่งซ้ำได้ → บันทึกต่อเฉพาะรายการที่ยังไม่ applied / stale
+func CommitReverification(ctx context.Context, id primitive.ObjectID, actor string) (*models.HourReverification, error) {
+	rev, err := transitionReverification(ctx, id, models.HourReverificationStateReady, models.HourReverificationStateCommitting,
+		bson.M{"committedBy": actor})
+	if errors.Is(err, ErrReverificationStateInvalid) {
+		rev, err = resumeReverificationCommit(ctx, id, actor)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := dispatchReverification(id, reverifyPhaseCommit); err != nil {
+		failReverification(ctx, id, err)
+		return nil, err
+	}
+	return rev, nil
+}
+
+// resumeReverificationCommit failed → committing เฉพาะงานที่ล้มเหลวในขั้น commit (ขั้น diff ยังไม่มี committedBy)
+func resumeReverificationCommit(ctx context.Context, id primitive.ObjectID, actor string) (*models.HourReverification, error) {
+	var rev models.HourReverification
+	err := DB.HourReverificationCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "state": models.HourReverificationStateFailed, "committedBy": bson.M{"$exists": true}},
+		bson.M{
+			"$set":   bson.M{"state": models.HourReverificationStateCommitting, "committedBy": actor, "updatedAt": time.Now()},
+			"$unset": bson.M{"error": ""},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&rev)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("%w: expected state %s", ErrReverificationStateInvalid, models.HourReverificationStateReady)
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("🔁 Hour reverification %s commit resumed by %s", id.Hex(), actor)
+	return &rev, nil
+}
+
+// DiscardReverification ยกเลิก diff ที่พร้อมแล้วโดยไม่บันทึก
+func DiscardReverification(ctx context.Context, id primitive.ObjectID, actor string) (*models.HourReverification, error) {
+	rev, err := transitionReverification(ctx, id, models.HourReverificationStateReady, models.HourReverificationStateDiscarded, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("🗑️ Hour reverification %s discarded by %s", id.Hex(), actor)
+	return rev, nil
+}
+
+func transitionReverification(ctx context.Context, id primitive.ObjectID, from, to string, set bson.M) (*models.HourReverification, error) {
+	set["state"] = to
+	set["updatedAt"] = time.Now()
+	var rev models.HourReverification
+	err := DB.HourReverificationCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "state": from},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&rev)
+	if err == mongo.ErrNoDocuments {
+		if _, err := GetReverification(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: expected state %s", ErrReverificationStateInvalid, from)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// dispatchReverification ส่งงานให้ Asynq worker — ไม่มี Redis = รันใน process (background)
+func dispatchReverification(id primitive.ObjectID, phase string) error {
+	if DB.AsynqClient == nil {
+		log.Printf("⚠️ Redis/Asynq not available → run hour reverification %s (%s) in-process", id.Hex(), phase)
+		go func() {
+			if err := runReverification(context.Background(), id, phase); err != nil {
+				log.Printf("❌ Hour reverification %s (%s) failed: %v", id.Hex(), phase, err)
+			}
+		}()
+		return nil
+	}
+	task, err := jobs.NewReverifyHoursTask(id.Hex(), phase)
+	if err != nil {
+		return err
+	}
+	_, err = DB.AsynqClient.Enqueue(task,
+		asynq.TaskID("hours-reverify-"+id.Hex()+"-"+phase),
+		asynq.MaxRetry(0),
+		asynq.Timeout(30*time.Minute),
+	)
+	return err
+}
+
+// HandleReverifyHoursTask worker ของงานประเมินชั่วโมงใหม่ (ทั้งขั้น diff และ commit)
+func HandleReverifyHoursTask(ctx context.Context, t *asynq.Task) error {
+	var payload jobs.ReverifyHoursPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		log.Println("❌ Payload decode error:", err)
+		return err
+	}
+	id, err := primitive.ObjectIDFromHex(payload.ReverificationID)
+	if err != nil {
+		return err
+	}
+	return runReverification(ctx, id, payload.Phase)
+}
+
+func runReverification(ctx context.Context, id primitive.ObjectID, phase string) error {
+	var err error
+	switch phase {
+	case reverifyPhaseDiff:
+		err = computeReverificationDiff(ctx, id)
+	case reverifyPhaseCommit:
+		err = commitReverificationChanges(ctx, id)
+	default:
+		err = fmt.Errorf("unknown reverification phase %q", phase)
+	}
+	if err != nil {
+		failReverification(ctx, id, err)
+	}
+	return err
+}
+
+func failReverification(ctx context.Context, id primitive.ObjectID, cause error) {
+	if _, err := DB.HourReverificationCollection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"state":     models.HourReverificationStateFailed,
+		"error":     cause.Error(),
+		"updatedAt": time.Now(),
+	}}); err != nil {
+		log.Printf("⚠️ Warning: Failed to mark hour reverification %s as failed: %v", id.Hex(), err)
+	}
+}
+
+func reportReverificationProgress(ctx context.Context, id primitive.ObjectID, processed int) {
+	if _, err := DB.HourReverificationCollection.UpdateOne(ctx, bson.M{"_id": id},
+		bson.M{"$set": bson.M{"processed": processed, "updatedAt": time.Now()}}); err != nil {
+		log.Printf("⚠️ Warning: Failed to report hour reverification progress %s: %v", id.Hex(), err)
+	}
+}
+
+// reverificationEnrollments enrollment ทั้งหมดในขอบเขต (เฉพาะกิจกรรมที่เสร็จสิ้นแล้ว)
+func reverificationEnrollments(ctx context.Context, scope models.HourReverificationScope) ([]models.Enrollment, error) {
+	filter := bson.M{}
+	switch {
+	case scope.EnrollmentID != nil:
+		filter["_id"] = *scope.EnrollmentID
+	case scope.ProgramID != nil:
+		filter["programId"] = *scope.ProgramID
+	default:
+		programIDs, err := DB.ProgramItemCollection.Distinct(ctx, "programId",
+			bson.M{"dates.date": bson.M{"$gte": scope.From, "$lte": scope.To}})
+		if err != nil {
+			return nil, err
+		}
+		completed, err := DB.ProgramCollection.Distinct(ctx, "_id", bson.M{
+			"_id":          bson.M{"$in": programIDs},
+			"programState": models.ProgramStateSuccess,
+			"deletedAt":    nil,
+		})
+		if err != nil {
+			return nil, err
+		}
+		filter["programId"] = bson.M{"$in": completed}
+	}
+	cur, err := DB.EnrollmentCollection.Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	enrollments := []models.Enrollment{}
+	if err := cur.All(ctx, &enrollments); err != nil {
+		return nil, err
+	}
+	return enrollments, nil
+}
+
+// computeReverificationDiff ขั้นที่ 1: ประเมินใหม่โดยไม่บันทึก แล้วเก็บรายการที่เปลี่ยนและยอด / สถานะนิสิตที่คาดว่าจะเป็น
+func computeReverificationDiff(ctx context.Context, id primitive.ObjectID) error {
+	rev, err := transitionReverification(ctx, id, models.HourReverificationStatePending, models.HourReverificationStateRunning, bson.M{})
+	if err != nil {
+		return err
+	}
+
+	enrollments, err := reverificationEnrollments(ctx, rev.Scope)
+	if err != nil {
+		return err
+	}
+	if _, err := DB.HourReverificationCollection.UpdateOne(ctx, bson.M{"_id": id},
+		bson.M{"$set": bson.M{"total": len(enrollments), "processed": 0}}); err != nil {
+		return err
+	}
+
+	// hour history ที่สรุปผลแล้วของ enrollment ในขอบเขต
+	enrollmentIDs := make([]primitive.ObjectID, 0, len(enrollments))
+	for _, e := range enrollments {
+		enrollmentIDs = append(enrollmentIDs, e.ID)
+	}
+	histories := map[primitive.ObjectID]models.HourChangeHistory{}
+	if len(enrollmentIDs) > 0 {
+		cur, err := DB.HourChangeHistoryCollection.Find(ctx, bson.M{
+			"sourceType":   "program",
+			"enrollmentId": bson.M{"$in": enrollmentIDs},
+			"status":       bson.M{"$in": gradedProgramStatuses},
+		})
+		if err != nil {
+			return err
+		}
+		var records []models.HourChangeHistory
+		if err := cur.All(ctx, &records); err != nil {
+			return err
+		}
+		// ผลที่ได้จากคำร้องที่อนุมัติแล้วไม่ถูกประเมินใหม่ทับ
+		historyIDs := make([]primitive.ObjectID, 0, len(records))
+		for _, r := range records {
+			historyIDs = append(historyIDs, r.ID)
+		}
+		appealed, err := approvedAppealHistories(ctx, historyIDs)
+		if err != nil {
+			return err
+		}
+		for _, r := range records {
+			if appealed[r.ID] {
+				continue
+			}
+			histories[*r.EnrollmentID] = r
+		}
+	}
+
+	programs := map[primitive.ObjectID]*models.Program{}
+	items := map[primitive.ObjectID]*models.ProgramItem{}
+	now := time.Now()
+	changes := []models.HourReverificationChange{}
+	unchanged, skipped := 0, 0
+
+	for i := range enrollments {
+		enrollment := &enrollments[i]
+		if i > 0 && i%reverifyProgressEvery == 0 {
+			reportReverificationProgress(ctx, id, i)
+		}
+
+		current, ok := histories[enrollment.ID]
+		if !ok {
+			skipped++
+			continue
+		}
+		program, err := cachedProgram(ctx, programs, enrollment.ProgramID)
+		if err != nil {
+			return err
+		}
+		item, err := cachedProgramItem(ctx, items, enrollment.ProgramItemID)
+		if err != nil {
+			return err
+		}
+		if program == nil || item == nil || len(item.Dates) == 0 {
+			skipped++
+			continue
+		}
+
+		grade, err := GradeEnrollmentHours(ctx, enrollment, program, item, EffectiveHourPolicy(program, item))
+		if err != nil {
+			return err
+		}
+		change := models.HourReverificationChange{
+			HistoryID:         current.ID,
+			EnrollmentID:      enrollment.ID,
+			ProgramID:         program.ID,
+			StudentID:         enrollment.StudentID,
+			SkillType:         current.SkillType,
+			CurrentStatus:     current.Status,
+			CurrentHourChange: current.HourChange,
+			NewStatus:         grade.Status,
+			NewHourChange:     grade.HourChange,
+			NewRemark:         grade.Remark,
+		}
+		if program.Name != nil {
+			change.ProgramName = *program.Name
+		}
+		if grade.Status == models.HCStatusPendingEvaluation {
+			// คงกำหนดส่งเดิม — เลยกำหนดแล้ว = ตัดชั่วโมงเหมือน ForfeitExpiredEvaluations
+			deadline := EvaluationDeadline(program.EvaluationDays, now)
+			if current.EvaluationDeadline != nil {
+				deadline = *current.EvaluationDeadline
+			}
+			if now.After(deadline) {
+				change.NewStatus = models.HCStatusIncomplete
+				change.NewHourChange = 0
+				change.NewRemark = "⚠️ ไม่ได้ส่งแบบประเมินภายในกำหนด - ไม่ได้รับชั่วโมง"
+			} else {
+				change.NewEvaluationDeadline = &deadline
+			}
+		}
+		if change.NewStatus == change.CurrentStatus && change.NewHourChange == change.CurrentHourChange {
+			unchanged++
+			continue
+		}
+		change.HoursDelta = EffectiveHours(change.NewStatus, change.NewHourChange) - EffectiveHours(change.CurrentStatus, change.CurrentHourChange)
+		changes = append(changes, change)
+	}
+
+	students, err := projectReverificationStudents(ctx, changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.HourReverificationCollection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"state":     models.HourReverificationStateReady,
+		"processed": len(enrollments),
+		"unchanged": unchanged,
+		"skipped":   skipped,
+		"changes":   changes,
+		"students":  students,
+		"updatedAt": time.Now(),
+	}})
+	if err == nil {
+		log.Printf("🔁 Hour reverification %s ready: enrollments=%d changes=%d unchanged=%d skipped=%d",
+			id.Hex(), len(enrollments), len(changes), unchanged, skipped)
+	}
+	return err
+}
+
+func cachedProgram(ctx context.Context, cache map[primitive.ObjectID]*models.Program, id primitive.ObjectID) (*models.Program, error) {
+	if p, ok := cache[id]; ok {
+		return p, nil
+	}
+	var program models.Program
+	if err := DB.ProgramCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&program); err != nil {
+		if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+		cache[id] = nil
+		return nil, nil
+	}
+	cache[id] = &program
+	return &program, nil
+}
+
+func cachedProgramItem(ctx context.Context, cache map[primitive.ObjectID]*models.ProgramItem, id primitive.ObjectID) (*models.ProgramItem, error) {
+	if item, ok := cache[id]; ok {
+		return item, nil
+	}
+	var item models.ProgramItem
+	if err := DB.ProgramItemCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&item); err != nil {
+		if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+		cache[id] = nil
+		return nil, nil
+	}
+	cache[id] = &item
+	return &item, nil
+}
+
+// projectReverificationStudents ยอดชั่วโมง (cache จาก ledger) + ผลต่าง และสถานะตามเกณฑ์ของนิสิตที่ได้รับผล
+func projectReverificationStudents(ctx context.Context, changes []models.HourReverificationChange) ([]models.HourReverificationStudent, error) {
+	students := []models.HourReverificationStudent{}
+	if len(changes) == 0 {
+		return students, nil
+	}
+	studentIDs := []primitive.ObjectID{}
+	seen := map[primitive.ObjectID]bool{}
+	for _, c := range changes {
+		if !seen[c.StudentID] {
+			seen[c.StudentID] = true
+			studentIDs = append(studentIDs, c.StudentID)
+		}
+	}
+	cur, err := DB.StudentCollection.Find(ctx, bson.M{"_id": bson.M{"$in": studentIDs}})
+	if err != nil {
+		return nil, err
+	}
+	var docs []models.Student
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	requirements, err := LoadGraduationRequirements(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	index := map[primitive.ObjectID]int{}
+	for _, s := range docs {
+		index[s.ID] = len(students)
+		students = append(students, models.HourReverificationStudent{
+			StudentID:     s.ID,
+			Code:          s.Code,
+			Name:          s.Name,
+			CurrentSoft:   s.SoftSkill,
+			CurrentHard:   s.HardSkill,
+			NewSoft:       s.SoftSkill,
+			NewHard:       s.HardSkill,
+			CurrentStatus: s.Status,
+		})
+	}
+	for i := range changes {
+		j, ok := index[changes[i].StudentID]
+		if !ok {
+			continue
+		}
+		changes[i].StudentCode = students[j].Code
+		changes[i].StudentName = students[j].Name
+		if changes[i].SkillType == "hard" {
+			students[j].NewHard += changes[i].HoursDelta
+		} else {
+			students[j].NewSoft += changes[i].HoursDelta
+		}
+	}
+	for i, s := range docs {
+		req := requirements.For(s.Major, s.EntryYear)
+		if req.HasTermCaps() {
+			// มีเพดานต่อภาค → ผลต่างอาจไม่ถูกนับเต็ม ต้องคำนวณรายภาค
+			soft, hard, err := projectCappedTotals(ctx, s.ID, changes, req)
+			if err != nil {
+				return nil, err
+			}
+			students[i].NewSoft, students[i].NewHard = soft, hard
+		}
+		students[i].NewStatus = req.StatusFor(students[i].NewSoft, students[i].NewHard)
+	}
+	return students, nil
+}
+
+// projectCappedTotals ยอดรวมหลังบันทึก diff ตามเพดานต่อภาค: ชั่วโมงเดิมออกจากภาคของ entry ปัจจุบัน
+// ชั่วโมงใหม่เข้าภาคปัจจุบัน (ตอนบันทึก changeAt = เวลาที่ยืนยัน)
+func projectCappedTotals(ctx context.Context, studentID primitive.ObjectID, changes []models.HourReverificationChange, req models.GraduationRequirement) (int, int, error) {
+	rows, _, err := studentTermTotals(ctx, studentID)
+	if err != nil {
+		return 0, 0, err
+	}
+	buckets := map[models.AcademicTerm]termTotals{}
+	for _, r := range rows {
+		buckets[r.AcademicTerm] = r
+	}
+	add := func(term models.AcademicTerm, skillType string, hours int) {
+		b := buckets[term]
+		b.AcademicTerm = term
+		if skillType == "hard" {
+			b.Hard += int64(hours)
+		} else {
+			b.Soft += int64(hours)
+		}
+		buckets[term] = b
+	}
+
+	current := AcademicTermOf(time.Now())
+	for _, c := range changes {
+		if c.StudentID != studentID {
+			continue
+		}
+		active, err := activeLedgerEntry(ctx, c.HistoryID)
+		if err != nil {
+			return 0, 0, err
+		}
+		if active != nil {
+			term, err := ledgerEntryTerm(ctx, active)
+			if err != nil {
+				return 0, 0, err
+			}
+			add(term, active.SkillType, -active.Hours)
+		}
+		add(current, c.SkillType, EffectiveHours(c.NewStatus, c.NewHourChange))
+	}
+	projected := make([]termTotals, 0, len(buckets))
+	for _, b := range buckets {
+		projected = append(projected, b)
+	}
+	soft, hard := cappedTotals(projected, req)
+	return soft, hard, nil
+}
+
+// commitReverificationChanges ขั้นที่ 2: บันทึกผลที่เปลี่ยน — เฉพาะ hour history ที่ยังตรงกับค่าตอนคำนวณ diff
+func commitReverificationChanges(ctx context.Context, id primitive.ObjectID) error {
+	rev, err := GetReverification(ctx, id)
+	if err != nil {
+		return err
+	}
+	if rev.State != models.HourReverificationStateCommitting {
+		return fmt.Errorf("%w: expected state %s", ErrReverificationStateInvalid, models.HourReverificationStateCommitting)
+	}
+	if _, err := DB.HourReverificationCollection.UpdateOne(ctx, bson.M{"_id": id},
+		bson.M{"$set": bson.M{"total": len(rev.Changes), "processed": 0}}); err != nil {
+		return err
+	}
+
+	reason := "ประเมินชั่วโมงใหม่หลังกิจกรรมเสร็จสิ้น"
+	if rev.Reason != "" {
+		reason += " - " + rev.Reason
+	}
+	actor := rev.CommittedBy
+	if actor == "" {
+		actor = models.HourLedgerActorSystem
+	}
+
+	now := time.Now()
+	stale := 0
+	affected := map[primitive.ObjectID]bool{}
+	deadlinePrograms := map[primitive.ObjectID]time.Time{}
+	for i := range rev.Changes {
+		change := &rev.Changes[i]
+		if i > 0 && i%reverifyProgressEvery == 0 {
+			reportReverificationProgress(ctx, id, i)
+		}
+		// รายการที่บันทึกไปแล้วในรอบก่อน (งานที่ล้มเหลวแล้วสั่งซ้ำ) → ไม่บันทึกซ้ำ แต่ยังต้องคำนวณสถานะนิสิต / งานตัดชั่วโมง
+		if change.Stale {
+			stale++
+			continue
+		}
+		if change.NewEvaluationDeadline != nil && change.NewEvaluationDeadline.After(deadlinePrograms[change.ProgramID]) {
+			deadlinePrograms[change.ProgramID] = *change.NewEvaluationDeadline
+		}
+		if change.Applied {
+			affected[change.StudentID] = true
+			continue
+		}
+
+		set := bson.M{
+			"status":     change.NewStatus,
+			"hourChange": change.NewHourChange,
+			"remark":     change.NewRemark,
+			"changeAt":   now,
+		}
+		update := bson.M{"$set": set}
+		if change.NewEvaluationDeadline != nil {
+			set["evaluationDeadline"] = *change.NewEvaluationDeadline
+		} else {
+			update["$unset"] = bson.M{"evaluationDeadline": ""}
+		}
+
+		matched, err := UpdateHistories(ctx,
+			bson.M{"_id": change.HistoryID, "status": change.CurrentStatus, "hourChange": change.CurrentHourChange},
+			update, actor, reason)
+		if err != nil {
+			return err
+		}
+		if matched == 0 {
+			// ไม่ตรงค่าตอนคำนวณ diff: ถ้าเป็นค่าใหม่อยู่แล้ว = บันทึกไปแล้วแต่ยังไม่ได้ mark (ล้มเหลวระหว่างนั้น)
+			done, err := DB.HourChangeHistoryCollection.CountDocuments(ctx,
+				bson.M{"_id": change.HistoryID, "status": change.NewStatus, "hourChange": change.NewHourChange})
+			if err != nil {
+				return err
+			}
+			if done == 0 {
+				change.Stale = true
+			}
+		}
+		if !change.Stale {
+			change.Applied = true
+			affected[change.StudentID] = true
+		} else {
+			stale++
+		}
+
+		// บันทึกผลทีละรายการ → งานที่ล้มเหลวกลางทางรู้ว่ารายการใดบันทึกไปแล้ว และสั่งต่อได้
+		prefix := "changes." + strconv.Itoa(i) + "."
+		if _, err := DB.HourReverificationCollection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+			prefix + "applied": change.Applied,
+			prefix + "stale":   change.Stale,
+			"updatedAt":        time.Now(),
+		}}); err != nil {
+			return err
+		}
+	}
+
+	for studentID := range affected {
+		if err := UpdateStudentStatus(ctx, studentID); err != nil {
+			log.Printf("⚠️ Warning: Failed to update student status for %s: %v", studentID.Hex(), err)
+		}
+	}
+	scheduleReverifiedEvaluationDeadlines(id, deadlinePrograms)
+
+	_, err = DB.HourReverificationCollection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"state":       models.HourReverificationStateCommitted,
+		"processed":   len(rev.Changes),
+		"stale":       stale,
+		"changes":     rev.Changes,
+		"committedAt": now,
+		"updatedAt":   time.Now(),
+	}})
+	if err == nil {
+		log.Printf("🔁 Hour reverification %s committed by %s: applied=%d stale=%d students=%d",
+			id.Hex(), actor, len(rev.Changes)-stale, stale, len(affected))
+	}
+	return err
+}
+
+// scheduleReverifiedEvaluationDeadlines ตั้งงานตัดชั่วโมงของรายการที่กลับมารอแบบประเมิน
+// (งานเดิมของกิจกรรมอาจทำงานไปแล้ว) — ไม่มี Redis = รอ ForfeitExpiredEvaluations รอบถัดไป
+func scheduleReverifiedEvaluationDeadlines(id primitive.ObjectID, deadlines map[primitive.ObjectID]time.Time) {
+	if DB.AsynqClient == nil || len(deadlines) == 0 {
+		return
+	}
+	for programID, deadline := range deadlines {
+		task, err := jobs.NewEvaluationDeadlineTaskWithName(programID.Hex(), "")
+		if err != nil {
+			continue
+		}
+		// เผื่อเวลา 1 นาทีหลังกำหนด เหมือนงานปกติของกิจกรรม
+		if _, err := DB.AsynqClient.Enqueue(task,
+			asynq.ProcessAt(deadline.Add(time.Minute)),
+			asynq.TaskID("evaluation-deadline-"+programID.Hex()+"-reverify-"+id.Hex()),
+		); err != nil {
+			log.Printf("⚠️ Warning: Failed to schedule evaluation deadline for program %s: %v", programID.Hex(), err)
+		}
+	}
+}
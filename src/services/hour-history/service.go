@@ -3,10 +3,10 @@ package hourhistory
 import (
 	DB "Backend-Bluelock-007/src/database"
 	"Backend-Bluelock-007/src/models"
+	"Backend-Bluelock-007/src/services/search"
 	"context"
 	"fmt"
 	"log"
-	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -30,6 +30,7 @@ import (
 //   - remark: หมายเหตุ
 //   - enrollmentID: รหัสการลงทะเบียน (optional, สำหรับ program)
 //   - programItemID: รหัส program item (optional, สำหรับ program)
+//   - actor: ผู้บันทึก (email ของแอดมิน / "student:<id>" / "system") สำหรับ ledger
 func CreateHourChangeHistory(
 	ctx context.Context,
 	studentID primitive.ObjectID,
@@ -42,6 +43,7 @@ func CreateHourChangeHistory(
 	remark string,
 	enrollmentID *primitive.ObjectID,
 	programItemID *primitive.ObjectID,
+	actor string,
 ) (*models.HourChangeHistory, error) {
 	history := models.HourChangeHistory{
 		ID:            primitive.NewObjectID(),
@@ -58,8 +60,7 @@ func CreateHourChangeHistory(
 		ProgramItemID: programItemID,
 	}
 
-	_, err := DB.HourChangeHistoryCollection.InsertOne(ctx, history)
-	if err != nil {
+	if err := InsertHistory(ctx, &history, actor, remark); err != nil {
 		return nil, fmt.Errorf("failed to create hour change history: %v", err)
 	}
 
@@ -92,12 +93,12 @@ func RecordCheckinActivity(
 		},
 	}
 
-	result, err := DB.HourChangeHistoryCollection.UpdateOne(ctx, filter, update)
+	matched, err := UpdateHistories(ctx, filter, update, models.HourLedgerActorSystem, "เช็คอินเข้าร่วมกิจกรรม")
 	if err != nil {
 		return fmt.Errorf("failed to record checkin activity: %v", err)
 	}
 
-	if result.MatchedCount == 0 {
+	if matched == 0 {
 		return fmt.Errorf("no upcoming hour change record found for enrollmentId: %s", enrollmentID.Hex())
 	}
 
@@ -105,18 +106,15 @@ func RecordCheckinActivity(
 }
 
 // VerifyAndGrantHours ตรวจสอบและให้ชั่วโมงเมื่อกิจกรรมเสร็จสิ้น (trigger เมื่อ program success/complete)
-// Logic ใหม่:
-// - เช็คว่ามี check-in/out ครบทุกวันตาม programItem.Dates หรือไม่
-// - เช็คว่าเวลา check-in อยู่ในช่วงที่กำหนด (±30 นาที) หรือไม่
-// - เข้าร่วมครบทุกวัน + ตรงเวลาทุกวัน = attended + ได้ชั่วโมงเต็ม
-// - เข้าร่วมไม่ครบ หรือมาสาย = attended + 0 ชั่วโมง
-// - ไม่มาเลย = absent + 0 ชั่วโมง
+// ประเมินด้วย GradeEnrollmentHours ตาม hourPolicy ของ ProgramItem / Program (ไม่กำหนด = เกณฑ์เดิม):
+// - เข้าร่วมครบทุกวัน + ตรงเวลาทุกวัน (±30 นาที) = attended + ได้ชั่วโมงเต็ม
+// - เข้าร่วมไม่ครบ = incomplete + 0 ชั่วโมง, มาสาย = late + 0 ชั่วโมง
+// - ไม่มาเลย = absent + หักชั่วโมง
+// - proRata / lateGraceDays / requireForm / absencePenalty ปรับเกณฑ์ข้างต้นตาม policy
 func VerifyAndGrantHours(
 	ctx context.Context,
 	enrollmentID primitive.ObjectID,
 ) error {
-	loc, _ := time.LoadLocation("Asia/Bangkok")
-
 	// 1) ดึง Enrollment
 	var enrollment models.Enrollment
 	err := DB.EnrollmentCollection.FindOne(ctx, bson.M{"_id": enrollmentID}).Decode(&enrollment)
@@ -124,18 +122,22 @@ func VerifyAndGrantHours(
 		return fmt.Errorf("enrollment not found: %v", err)
 	}
 
-	// 2) ดึง ProgramItem เพื่อเช็คจำนวนวันทั้งหมด
+	// 2) ดึง ProgramItem เพื่อเช็คจำนวนวันทั้งหมด และ Program สำหรับ hourPolicy / ฟอร์ม
 	var programItem models.ProgramItem
 	err = DB.ProgramItemCollection.FindOne(ctx, bson.M{"_id": enrollment.ProgramItemID}).Decode(&programItem)
 	if err != nil {
 		return fmt.Errorf("program item not found: %v", err)
 	}
 
-	totalDays := len(programItem.Dates)
-	if totalDays == 0 {
+	if len(programItem.Dates) == 0 {
 		return fmt.Errorf("program item has no dates")
 	}
 
+	var program models.Program
+	if err := DB.ProgramCollection.FindOne(ctx, bson.M{"_id": enrollment.ProgramID}).Decode(&program); err != nil {
+		return fmt.Errorf("program not found: %v", err)
+	}
+
 	// 3) หา HourChangeHistory record
 	var hourRecord models.HourChangeHistory
 	err = DB.HourChangeHistoryCollection.FindOne(ctx, bson.M{
@@ -150,210 +152,19 @@ func VerifyAndGrantHours(
 		return nil
 	}
 
-	// 4) สร้าง map ของ checkin/checkout records ตามวันที่
-	checkinoutMap := make(map[string]models.CheckinoutRecord)
-	if enrollment.CheckinoutRecord != nil {
-		for _, record := range *enrollment.CheckinoutRecord {
-			var dateKey string
-			if record.Checkin != nil {
-				dateKey = record.Checkin.In(loc).Format("2006-01-02")
-			} else if record.Checkout != nil {
-				dateKey = record.Checkout.In(loc).Format("2006-01-02")
-			}
-			if dateKey != "" {
-				checkinoutMap[dateKey] = record
-			}
-		}
-	}
-
-	// 5) วิเคราะห์แต่ละวันใน programItem.Dates
-	daysOnTime := 0     // วันที่มา check-in/out ตรงเวลา
-	daysLate := 0       // วันที่มา check-in/out แต่สาย
-	daysIncomplete := 0 // วันที่มีแต่ checkin หรือ checkout อย่างเดียว
-	daysAbsent := 0     // วันที่ไม่มา
-
-	missingDates := []string{}    // วันที่ไม่มาเลย
-	lateDates := []string{}       // วันที่มาแต่สาย
-	incompleteDates := []string{} // วันที่เช็คไม่ครบ
-
-	log.Printf("🔍 [DEBUG] Enrollment %s - Starting verification for %d days", enrollmentID.Hex(), totalDays)
-	log.Printf("🔍 [DEBUG] Total checkinout records: %d", len(checkinoutMap))
-
-	for idx, programDate := range programItem.Dates {
-		dateKey := programDate.Date
-		record, hasRecord := checkinoutMap[dateKey]
-
-		log.Printf("🔍 [DEBUG] Day %d/%d - Date: %s", idx+1, totalDays, dateKey)
-		log.Printf("🔍 [DEBUG]   ├─ Activity Time: %s - %s", programDate.Stime, programDate.Etime)
-
-		if !hasRecord || (record.Checkin == nil && record.Checkout == nil) {
-			// ไม่มา check-in/out เลย
-			log.Printf("🔍 [DEBUG]   └─ ❌ ABSENT - No check-in/out record")
-			daysAbsent++
-			missingDates = append(missingDates, dateKey)
-			continue
-		}
-
-		// มี record แล้ว - แสดงเวลาที่เช็ค
-		checkinStr := "N/A"
-		checkoutStr := "N/A"
-		if record.Checkin != nil {
-			checkinStr = record.Checkin.In(loc).Format("15:04:05")
-		}
-		if record.Checkout != nil {
-			checkoutStr = record.Checkout.In(loc).Format("15:04:05")
-		}
-		log.Printf("🔍 [DEBUG]   ├─ Check-in: %s, Check-out: %s", checkinStr, checkoutStr)
-
-		// เช็คว่ามีทั้ง checkin และ checkout หรือไม่
-		if record.Checkin == nil || record.Checkout == nil {
-			// มีแต่ checkin หรือ checkout อย่างเดียว
-			log.Printf("🔍 [DEBUG]   └─ ⚠️ INCOMPLETE - Missing check-in or check-out")
-			daysIncomplete++
-			incompleteDates = append(incompleteDates, dateKey)
-			continue
-		}
-
-		// มีทั้ง checkin และ checkout แล้ว → เช็คเวลา
-		if programDate.Stime != "" {
-			// Parse เวลาเริ่มกิจกรรม
-			startTime, err := time.ParseInLocation("2006-01-02 15:04", programDate.Date+" "+programDate.Stime, loc)
-			if err == nil {
-				// อนุญาตเช็คอินก่อนเวลา 30 นาที และหลังเวลา 30 นาที
-				earlyLimit := startTime.Add(-30 * time.Minute)
-				lateLimit := startTime.Add(30 * time.Minute)
-				checkinTime := record.Checkin.In(loc)
-
-				log.Printf("🔍 [DEBUG]   ├─ Activity Start: %s", startTime.Format("15:04:05"))
-				log.Printf("🔍 [DEBUG]   ├─ Allowed Range: %s - %s (±30 min)", earlyLimit.Format("15:04:05"), lateLimit.Format("15:04:05"))
-				log.Printf("🔍 [DEBUG]   ├─ Actual Check-in: %s", checkinTime.Format("15:04:05"))
-
-				if (checkinTime.Equal(earlyLimit) || checkinTime.After(earlyLimit)) &&
-					(checkinTime.Before(lateLimit) || checkinTime.Equal(lateLimit)) {
-					// เช็คอินตรงเวลา (±30 นาที)
-					log.Printf("🔍 [DEBUG]   └─ ✅ ON TIME - Within allowed range")
-					daysOnTime++
-				} else {
-					// เช็คอินไม่ตรงเวลา (เร็วเกิน หรือ สายเกิน)
-					if checkinTime.Before(earlyLimit) {
-						diff := earlyLimit.Sub(checkinTime)
-						log.Printf("🔍 [DEBUG]   └─ ⚠️ TOO EARLY - %d minutes before allowed time", int(diff.Minutes()))
-					} else {
-						diff := checkinTime.Sub(lateLimit)
-						log.Printf("🔍 [DEBUG]   └─ ⚠️ TOO LATE - %d minutes after allowed time", int(diff.Minutes()))
-					}
-					daysLate++
-					lateDates = append(lateDates, dateKey)
-				}
-			} else {
-				// ถ้า parse เวลาไม่ได้ ถือว่ามา (ให้ประโยชน์ของข้อสงสัย)
-				log.Printf("🔍 [DEBUG]   └─ ✅ ON TIME - No time specified or parse error")
-				daysOnTime++
-			}
-		} else {
-			// ถ้าไม่มีเวลากำหนด ถือว่ามา
-			log.Printf("🔍 [DEBUG]   └─ ✅ ON TIME - No specific time required")
-			daysOnTime++
-		}
+	// 4-6) ประเมินการเข้าร่วมแต่ละวันและคำนวณชั่วโมงตามเกณฑ์
+	policy := EffectiveHourPolicy(&program, &programItem)
+	grade, err := GradeEnrollmentHours(ctx, &enrollment, &program, &programItem, policy)
+	if err != nil {
+		return fmt.Errorf("failed to grade hours: %v", err)
 	}
+	newStatus, newHourChange, newRemark := grade.Status, grade.HourChange, grade.Remark
 
-	totalValidDays := daysOnTime + daysLate + daysIncomplete
-	hasAttendedAllDays := (totalValidDays == totalDays)   // มาครบทุกวัน (เช็คอิน+เช็คเอาท์ครบ)
-	hasAttendedAllDaysOnTime := (daysOnTime == totalDays) // มาครบทุกวัน + ตรงเวลาทุกวัน
-
-	log.Printf("🔍 [DEBUG] Summary:")
-	log.Printf("🔍 [DEBUG]   ├─ Total Days Required: %d", totalDays)
-	log.Printf("🔍 [DEBUG]   ├─ Days On Time: %d", daysOnTime)
-	log.Printf("🔍 [DEBUG]   ├─ Days Late: %d", daysLate)
-	log.Printf("🔍 [DEBUG]   ├─ Days Incomplete: %d", daysIncomplete)
-	log.Printf("🔍 [DEBUG]   ├─ Days Absent: %d", daysAbsent)
-	log.Printf("🔍 [DEBUG]   ├─ Has Attended All Days: %v", hasAttendedAllDays)
-	log.Printf("🔍 [DEBUG]   └─ Has Attended All Days On Time: %v", hasAttendedAllDaysOnTime)
-
-	var newStatus string
-	var newHourChange int
-	var newRemark string
-
-	// 6) Logic การให้ชั่วโมง
-	if daysAbsent == totalDays {
-		// ❌ ไม่มาเข้าร่วมเลยทุกวัน
-		newStatus = models.HCStatusAbsent
-		newHourChange = -*programItem.Hour
-		newRemark = fmt.Sprintf("❌ ไม่มาเข้าร่วมกิจกรรมเลย (0/%d วัน)", totalDays)
-	} else if !hasAttendedAllDays {
-		// ⚠️ มาไม่ครบทุกวัน (ขาดบางวัน หรือ เช็คไม่ครบบางวัน) → incomplete และไม่ได้ชั่วโมง
-		newStatus = models.HCStatusIncomplete
-		newHourChange = 0
-
-		// สร้าง remark ที่ละเอียด
-		details := []string{}
-		if daysOnTime > 0 {
-			details = append(details, fmt.Sprintf("ตรงเวลา %d วัน", daysOnTime))
-		}
-		if daysLate > 0 {
-			details = append(details, fmt.Sprintf("สาย %d วัน", daysLate))
-		}
-		if daysIncomplete > 0 {
-			details = append(details, fmt.Sprintf("เช็คไม่ครบ %d วัน", daysIncomplete))
-		}
-		if daysAbsent > 0 {
-			details = append(details, fmt.Sprintf("ขาด %d วัน", daysAbsent))
-		}
-
-		detailsStr := ""
-		if len(details) > 0 {
-			detailsStr = " (" + joinStrings(details, ", ") + ")"
-		}
-
-		newRemark = fmt.Sprintf("⚠️ มาไม่ครบ - เข้าร่วมไม่ครบทุกวัน %d/%d วัน%s - ไม่ได้รับชั่วโมง", totalValidDays, totalDays, detailsStr)
-
-		// เพิ่มรายละเอียดวันที่มีปัญหา (ถ้ามี)
-		if len(missingDates) > 0 && len(missingDates) <= 3 {
-			newRemark += fmt.Sprintf(" | ขาดวันที่: %s", joinStrings(missingDates, ", "))
-		}
-		if len(lateDates) > 0 && len(lateDates) <= 3 {
-			newRemark += fmt.Sprintf(" | สายวันที่: %s", joinStrings(lateDates, ", "))
-		}
-		if len(incompleteDates) > 0 && len(incompleteDates) <= 3 {
-			newRemark += fmt.Sprintf(" | เช็คไม่ครบวันที่: %s", joinStrings(incompleteDates, ", "))
-		}
-	} else if hasAttendedAllDaysOnTime {
-		// ✅ มาครบทุกวัน + ตรงเวลาทุกวัน → ได้ชั่วโมงเต็ม
-		newStatus = models.HCStatusAttended
-		newHourChange = *programItem.Hour
-		newRemark = fmt.Sprintf("✅ เข้าร่วมครบถ้วนและตรงเวลาทุกวัน (%d/%d วัน) - ได้รับ %d ชั่วโมง", daysOnTime, totalDays, newHourChange)
-	} else {
-		// ⚠️ มาครบทุกวันแล้ว แต่มีบางวันที่สาย → late และไม่ได้ชั่วโมง
-		newStatus = models.HCStatusLate
-		newHourChange = 0
-
-		// สร้าง remark ที่ละเอียด
-		details := []string{}
-		if daysOnTime > 0 {
-			details = append(details, fmt.Sprintf("ตรงเวลา %d วัน", daysOnTime))
-		}
-		if daysLate > 0 {
-			details = append(details, fmt.Sprintf("สาย %d วัน", daysLate))
-		}
-		if daysIncomplete > 0 {
-			details = append(details, fmt.Sprintf("เช็คไม่ครบ %d วัน", daysIncomplete))
-		}
-
-		detailsStr := ""
-		if len(details) > 0 {
-			detailsStr = " (" + joinStrings(details, ", ") + ")"
-		}
-
-		newRemark = fmt.Sprintf("⚠️ มาสาย - เข้าร่วมครบทุกวัน แต่%s - ไม่ได้รับชั่วโมง", detailsStr)
-
-		// เพิ่มรายละเอียดวันที่มีปัญหา (ถ้ามี)
-		if len(lateDates) > 0 && len(lateDates) <= 3 {
-			newRemark += fmt.Sprintf(" | สายวันที่: %s", joinStrings(lateDates, ", "))
-		}
-		if len(incompleteDates) > 0 && len(incompleteDates) <= 3 {
-			newRemark += fmt.Sprintf(" | เช็คไม่ครบวันที่: %s", joinStrings(incompleteDates, ", "))
-		}
-	}
+	log.Printf("🔍 [DEBUG] Enrollment %s - policy=%s grace=%d window=%d requireForm=%v",
+		enrollmentID.Hex(), policy.Mode, policy.LateGraceDays, policy.CheckinWindowMinutes, policy.RequireForm)
+	log.Printf("🔍 [DEBUG]   ├─ Days: total=%d onTime=%d late=%d incomplete=%d absent=%d counted=%d",
+		grade.TotalDays, grade.DaysOnTime, grade.DaysLate, grade.DaysIncomplete, grade.DaysAbsent, grade.CountedDays)
+	log.Printf("🔍 [DEBUG]   └─ Form Submitted: %v", grade.FormSubmitted)
 
 	// 7) อัปเดต HourChangeHistory
 	filter := bson.M{
@@ -362,13 +173,19 @@ func VerifyAndGrantHours(
 		"sourceId":     enrollment.ProgramID,
 	}
 
-	update := bson.M{
-		"$set": bson.M{
-			"status":     newStatus,
-			"hourChange": newHourChange,
-			"remark":     newRemark,
-			"changeAt":   time.Now(),
-		},
+	now := time.Now()
+	set := bson.M{
+		"status":     newStatus,
+		"hourChange": newHourChange,
+		"remark":     newRemark,
+		"changeAt":   now,
+	}
+	update := bson.M{"$set": set}
+	// 📝 รอแบบประเมิน → บันทึกกำหนดส่ง (เลยกำหนด = ForfeitExpiredEvaluations)
+	if newStatus == models.HCStatusPendingEvaluation {
+		set["evaluationDeadline"] = EvaluationDeadline(program.EvaluationDays, now)
+	} else {
+		update["$unset"] = bson.M{"evaluationDeadline": ""}
 	}
 
 	log.Printf("� [DEBUG] Final Decision:")
@@ -378,7 +195,7 @@ func VerifyAndGrantHours(
 	log.Printf("📝 Updating hour change history for enrollment %s: status=%s, hours=%d",
 		enrollmentID.Hex(), newStatus, newHourChange)
 
-	_, err = DB.HourChangeHistoryCollection.UpdateOne(ctx, filter, update)
+	_, err = UpdateHistories(ctx, filter, update, models.HourLedgerActorSystem, "ประเมินชั่วโมงเมื่อกิจกรรมเสร็จสิ้น")
 	if err != nil {
 		return fmt.Errorf("failed to verify and grant hours: %v", err)
 	}
@@ -465,6 +282,34 @@ func ProcessEnrollmentsForCompletedProgram(ctx context.Context, programID primit
 	return nil
 }
 
+// CancelProgramHistories เปลี่ยน hour history ของ program ที่ยังไม่สรุปผล (upcoming / participating)
+// เป็น cancelled เมื่อกิจกรรมถูกยกเลิก โดยไม่ลบข้อมูลเดิม
+func CancelProgramHistories(ctx context.Context, programID primitive.ObjectID, actor, reason string) (int64, error) {
+	remark := "กิจกรรมถูกยกเลิก"
+	if reason != "" {
+		remark += " - " + reason
+	}
+
+	matched, err := UpdateHistories(ctx,
+		bson.M{
+			"sourceType": "program",
+			"sourceId":   programID,
+			"status":     bson.M{"$in": []string{models.HCStatusUpcoming, models.HCStatusParticipating}},
+		},
+		bson.M{"$set": bson.M{
+			"status":     models.HCStatusCancelled,
+			"hourChange": 0,
+			"remark":     remark,
+			"changeAt":   time.Now(),
+		}},
+		actor, remark,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to cancel hour histories: %v", err)
+	}
+	return matched, nil
+}
+
 // ========================================
 // Query Functions
 // ========================================
@@ -476,6 +321,7 @@ func GetHistoryWithFilters(
 	sourceType string,
 	statuses []string,
 	searchTitle string,
+	terms models.AcademicTermRange,
 	limit int,
 	skip int,
 ) ([]models.HourChangeHistory, int64, error) {
@@ -499,7 +345,12 @@ func GetHistoryWithFilters(
 
 	// Search by title (optional, case-insensitive)
 	if searchTitle != "" {
-		filter["title"] = bson.M{"$regex": primitive.Regex{Pattern: searchTitle, Options: "i"}}
+		filter["title"] = bson.M{"$regex": search.Contains(searchTitle)}
+	}
+
+	// Filter by academic term range (optional, ตาม changeAt)
+	if changeAt := termRangeFilter(terms); changeAt != nil {
+		filter["changeAt"] = changeAt
 	}
 
 	// Count total documents matching filter
@@ -574,11 +425,12 @@ func GetHistoryWithDetailsAndFilters(
 	sourceType string,
 	statuses []string,
 	searchTitle string,
+	terms models.AcademicTermRange,
 	limit int,
 	skip int,
 ) ([]models.HourChangeHistory, int64, error) {
 	// ใช้ GetHistoryWithFilters เดิม
-	histories, totalCount, err := GetHistoryWithFilters(ctx, studentID, sourceType, statuses, searchTitle, limit, skip)
+	histories, totalCount, err := GetHistoryWithFilters(ctx, studentID, sourceType, statuses, searchTitle, terms, limit, skip)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -592,61 +444,17 @@ func GetHistoryWithDetailsAndFilters(
 	return histories, totalCount, nil
 }
 
-// GetStudentHoursSummary คำนวณชั่วโมงรวมของนิสิตจาก hour history
-// รวมทั้ง attended (บวก), approved, manual และ absent (ลบ) เพื่อคำนวณชั่วโมงที่แท้จริง
+// GetStudentHoursSummary คำนวณชั่วโมงรวมของนิสิตจาก ledger (ดู CalculateNetHours)
 func GetStudentHoursSummary(ctx context.Context, studentID primitive.ObjectID) (map[string]interface{}, error) {
-	// Aggregate pipeline เพื่อรวมชั่วโมงตาม skillType
-	// รวมทั้ง attended, approved, manual และ absent (absent จะมี hourChange เป็นลบ)
-	// late และ incomplete ไม่นับเพราะไม่ได้ชั่วโมง (hourChange = 0)
-	pipeline := []bson.M{
-		{
-			"$match": bson.M{
-				"studentId": studentID,
-				"status": bson.M{
-					"$in": []string{models.HCStatusAttended, models.HCStatusAbsent, models.HCStatusApproved, models.HCStatusManual}, // รวมทั้ง attended, approved, manual และ absent (late, incomplete ไม่รวมเพราะ hourChange = 0)
-				},
-			},
-		},
-		{
-			"$group": bson.M{
-				"_id": "$skillType", // group ตาม soft/hard
-				"totalHours": bson.M{
-					"$sum": "$hourChange", // รวมชั่วโมง (attended/approved/manual = +, absent = -)
-				},
-			},
-		},
-	}
-
-	cursor, err := DB.HourChangeHistoryCollection.Aggregate(ctx, pipeline)
+	softNet, hardNet, err := CalculateNetHours(ctx, studentID)
 	if err != nil {
 		return nil, fmt.Errorf("ไม่สามารถคำนวณชั่วโมงรวมได้: %v", err)
 	}
-	defer cursor.Close(ctx)
-
-	var results []bson.M
-	if err := cursor.All(ctx, &results); err != nil {
-		return nil, fmt.Errorf("ไม่สามารถถอดรหัสผลลัพธ์ได้: %v", err)
-	}
 
-	// สร้าง summary object
-	summary := map[string]interface{}{
-		"softSkill": 0,
-		"hardSkill": 0,
-	}
-
-	// Map ผลลัพธ์จาก aggregation
-	for _, result := range results {
-		skillType, _ := result["_id"].(string)
-		totalHours, _ := result["totalHours"].(int32)
-
-		if skillType == "soft" {
-			summary["softSkill"] = int(totalHours)
-		} else if skillType == "hard" {
-			summary["hardSkill"] = int(totalHours)
-		}
-	}
-
-	return summary, nil
+	return map[string]interface{}{
+		"softSkill": softNet,
+		"hardSkill": hardNet,
+	}, nil
 }
 
 // ========================================
@@ -668,8 +476,11 @@ func UpdateStudentStatus(ctx context.Context, studentID primitive.ObjectID) erro
 		return err
 	}
 
-	// คำนวณสถานะใหม่
-	newStatus := CalculateStatus(softNet, hardNet)
+	// คำนวณสถานะใหม่ตามเกณฑ์ของสาขา / รุ่น
+	newStatus, err := CalculateStatus(ctx, student.Major, student.EntryYear, softNet, hardNet)
+	if err != nil {
+		return err
+	}
 
 	// อัปเดตสถานะ (ถ้าเปลี่ยนแปลง)
 	if student.Status != newStatus {
@@ -688,86 +499,19 @@ func UpdateStudentStatus(ctx context.Context, studentID primitive.ObjectID) erro
 	return nil
 }
 
-// CalculateNetHours คำนวณชั่วโมงรวมจาก HourChangeHistory
-// รวมเฉพาะ status: attended (+), approved (+), manual (+), absent (-)
-// late และ incomplete ไม่รวมเพราะ hourChange = 0
+// CalculateNetHours คำนวณชั่วโมงรวมจาก ledger (Hour_Ledger) — ดู ComputeStudentTotals
+// attended (+), approved (+), manual (+), absent (หัก) — สถานะอื่นไม่นับ (ดู EffectiveHours)
 func CalculateNetHours(ctx context.Context, studentID primitive.ObjectID) (softNet, hardNet int, err error) {
-	pipeline := []bson.M{
-		{"$match": bson.M{
-			"studentId": studentID,
-			"status": bson.M{"$in": []string{
-				models.HCStatusAttended, models.HCStatusAbsent, models.HCStatusApproved, models.HCStatusManual,
-			}},
-		}},
-		{"$addFields": bson.M{
-			"deltaHours": bson.M{
-				"$switch": bson.M{
-					"branches": bson.A{
-						bson.M{
-							"case": bson.M{"$in": bson.A{"$status", bson.A{models.HCStatusAttended, models.HCStatusApproved, models.HCStatusManual}}},
-							"then": bson.M{"$toInt": bson.M{"$ifNull": bson.A{"$hourChange", 0}}},
-						},
-						bson.M{
-							"case": bson.M{"$eq": bson.A{"$status", models.HCStatusAbsent}},
-							"then": bson.M{
-								"$multiply": bson.A{
-									-1,
-									bson.M{"$toInt": bson.M{"$ifNull": bson.A{"$hourChange", 0}}},
-								},
-							},
-						},
-					},
-					"default": 0,
-				},
-			},
-		}},
-		{"$group": bson.M{
-			"_id":        "$skillType", // "soft" | "hard"
-			"totalHours": bson.M{"$sum": "$deltaHours"},
-		}},
-	}
-
-	cursor, aggErr := DB.HourChangeHistoryCollection.Aggregate(ctx, pipeline)
-	if aggErr != nil {
-		return 0, 0, fmt.Errorf("aggregate hour deltas error: %v", aggErr)
-	}
-	defer cursor.Close(ctx)
-
-	type agg struct {
-		ID         string `bson:"_id"`
-		TotalHours int64  `bson:"totalHours"`
-	}
-	var aggRows []agg
-	if aggErr := cursor.All(ctx, &aggRows); aggErr != nil {
-		return 0, 0, fmt.Errorf("aggregate decode error: %v", aggErr)
-	}
-
-	// รวมชั่วโมงตาม skillType
-	softNet = 0
-	hardNet = 0
-	for _, r := range aggRows {
-		switch strings.ToLower(r.ID) {
-		case "soft":
-			softNet += int(r.TotalHours)
-		case "hard":
-			hardNet += int(r.TotalHours)
-		}
-	}
-
-	return softNet, hardNet, nil
+	softNet, hardNet, _, err = ComputeStudentTotals(ctx, studentID)
+	return softNet, hardNet, err
 }
 
-// CalculateStatus คำนวณสถานะนักศึกษาจากชั่วโมง soft และ hard skill
+// CalculateStatus คำนวณสถานะนักศึกษาจากชั่วโมง soft และ hard skill ตามเกณฑ์ของสาขา / รุ่น
 // Return: 1 = น้อยมาก, 2 = น้อย, 3 = ครบ
-func CalculateStatus(softSkill, hardSkill int) int {
-	total := softSkill + hardSkill
-
-	switch {
-	case softSkill >= 30 && hardSkill >= 12:
-		return 3 // ครบ
-	case total >= 20:
-		return 2 // น้อย
-	default:
-		return 1 // น้อยมาก
+func CalculateStatus(ctx context.Context, major string, entryYear, softSkill, hardSkill int) (int, error) {
+	req, err := ResolveGraduationRequirement(ctx, major, entryYear)
+	if err != nil {
+		return 0, err
 	}
+	return req.StatusFor(softSkill, hardSkill), nil
 }
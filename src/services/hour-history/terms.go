@@ -0,0 +1,396 @@
+package hourhistory
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ========================================
+// Terms - แบ่งชั่วโมงตามภาคการศึกษา (ปีการศึกษา พ.ศ. / ภาค) จาก changeAt
+// ========================================
+//
+// ทุก entry ใน ledger มีภาคการศึกษา → สรุปชั่วโมงรายภาค, ขั้นต่ำ / เพดานต่อภาคตามเกณฑ์ (GraduationRequirement)
+// ยอดรวมของนิสิต = ผลรวมชั่วโมงที่นับรวมของทุกภาค (ภาคที่เกินเพดานนับเท่าเพดาน)
+
+var ErrStudentNotFound = errors.New("student not found")
+
+// ACADEMIC_TERM_START_MONTHS เดือนที่เริ่มแต่ละภาค เรียงจากภาค 1 (ภาค 1 = เริ่มปีการศึกษา)
+// ค่าเริ่มต้น: ภาค 1 มิ.ย., ภาค 2 พ.ย., ภาค 3 (ฤดูร้อน) เม.ย. — override ด้วย env เช่น "6,11,4"
+var ACADEMIC_TERM_START_MONTHS = []time.Month{time.June, time.November, time.April}
+
+// termLocation เขตเวลาที่ใช้แบ่งภาค (โหลดครั้งเดียว ไม่ขึ้นกับ time.Local ของ process)
+var termLocation = loadTermLocation()
+
+func loadTermLocation() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Bangkok"); err == nil {
+		return loc
+	}
+	return time.FixedZone("UTC+7", 7*60*60)
+}
+
+// regularTermsPerYear ภาค 1 / 2 = ภาคปกติ (ตรวจขั้นต่ำต่อภาค), ภาคที่เหลือ = ฤดูร้อน
+const regularTermsPerYear = 2
+
+func init() {
+	if v := os.Getenv("ACADEMIC_TERM_START_MONTHS"); v != "" {
+		if months, err := parseTermStartMonths(v); err == nil {
+			ACADEMIC_TERM_START_MONTHS = months
+			log.Printf("ℹ️ ACADEMIC_TERM_START_MONTHS loaded from env: %v", months)
+		} else {
+			log.Printf("⚠️ Failed to parse ACADEMIC_TERM_START_MONTHS=%s: %v", v, err)
+		}
+	}
+}
+
+// parseTermStartMonths "6,11,4" → เดือนต้องไม่ซ้ำและเรียงตามลำดับในปีการศึกษา (นับจากเดือนแรก)
+func parseTermStartMonths(v string) ([]time.Month, error) {
+	months := []time.Month{}
+	for _, part := range strings.Split(v, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || n < 1 || n > 12 {
+			return nil, fmt.Errorf("invalid month %q", part)
+		}
+		m := time.Month(n)
+		if len(months) > 0 && monthOffset(m, months[0]) <= monthOffset(months[len(months)-1], months[0]) {
+			return nil, errors.New("months must be in academic-year order without repeats")
+		}
+		months = append(months, m)
+	}
+	return months, nil
+}
+
+// monthOffset จำนวนเดือนนับจากเดือนเริ่มปีการศึกษา (0-11)
+func monthOffset(m, first time.Month) int {
+	return (int(m) - int(first) + 12) % 12
+}
+
+// AcademicTermOf ภาคการศึกษาของเวลา t (ตามเวลาท้องถิ่น Asia/Bangkok)
+func AcademicTermOf(t time.Time) models.AcademicTerm {
+	t = t.In(termLocation)
+	first := ACADEMIC_TERM_START_MONTHS[0]
+	year := t.Year()
+	if t.Month() < first {
+		year--
+	}
+	term := 1
+	for i, m := range ACADEMIC_TERM_START_MONTHS {
+		if monthOffset(m, first) <= monthOffset(t.Month(), first) {
+			term = i + 1
+		}
+	}
+	return models.AcademicTerm{AcademicYear: year + 543, Term: term}
+}
+
+// AcademicTermStart วันแรกของภาค (ภาคเกินจำนวนที่กำหนด = ภาคสุดท้าย)
+func AcademicTermStart(term models.AcademicTerm) time.Time {
+	i := term.Term - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(ACADEMIC_TERM_START_MONTHS) {
+		i = len(ACADEMIC_TERM_START_MONTHS) - 1
+	}
+	m := ACADEMIC_TERM_START_MONTHS[i]
+	year := term.AcademicYear - 543
+	if m < ACADEMIC_TERM_START_MONTHS[0] {
+		year++
+	}
+	return time.Date(year, m, 1, 0, 0, 0, 0, termLocation)
+}
+
+// NextAcademicTerm ภาคถัดไป
+func NextAcademicTerm(term models.AcademicTerm) models.AcademicTerm {
+	if term.Term >= len(ACADEMIC_TERM_START_MONTHS) {
+		return models.AcademicTerm{AcademicYear: term.AcademicYear + 1, Term: 1}
+	}
+	return models.AcademicTerm{AcademicYear: term.AcademicYear, Term: term.Term + 1}
+}
+
+// termRangeFilter เงื่อนไข changeAt ของช่วงภาค (nil = ไม่กรอง)
+func termRangeFilter(r models.AcademicTermRange) bson.M {
+	cond := bson.M{}
+	if r.From != nil {
+		cond["$gte"] = AcademicTermStart(*r.From)
+	}
+	if r.To != nil {
+		cond["$lt"] = AcademicTermStart(NextAcademicTerm(*r.To))
+	}
+	if len(cond) == 0 {
+		return nil
+	}
+	return cond
+}
+
+// ========================================
+// Ledger terms
+// ========================================
+//
+// ภาคของ entry ตั้งตอน append เท่านั้น (อยู่ใน hash — ห้ามแก้ ledger ย้อนหลัง)
+// entry ที่บันทึกก่อนมีการแบ่งภาค (ไม่มี academicYear) หาภาคตอนอ่านจาก changeAt ปัจจุบันของ hour history
+// (ทุก entry ของ history เดียวกันอยู่ภาคเดียวกัน → reversal หักล้างในภาคเดิม) history ที่ถูกลบไปแล้วใช้เวลาที่บันทึก entry แรก
+//
+// ชั่วโมงยกมาจากระบบเก่า (sourceId = NilObjectID, ดู students.createLegacyHourHistory) ไม่ใช่ชั่วโมงของภาคที่นำเข้า
+// จึงอยู่ในภาคว่าง (carryOverTerm) ที่ไม่จำกัดเพดานต่อภาค
+
+// carryOverTerm ภาคของชั่วโมงยกมาจากระบบเก่า — นับรวมเต็มจำนวน ไม่แสดงเป็นภาค
+var carryOverTerm = models.AcademicTerm{}
+
+// isCarryOverEntry entry ของชั่วโมงยกมาจากระบบเก่า
+func isCarryOverEntry(e *models.HourLedgerEntry) bool {
+	return e.SourceID != nil && e.SourceID.IsZero()
+}
+
+// termTotals ชั่วโมงจาก ledger ของหนึ่งภาค
+type termTotals struct {
+	models.AcademicTerm
+	Soft int64
+	Hard int64
+}
+
+// ledgerTermRow ผล aggregate ของ ledger ต่อ (นิสิต, ภาค) — entry ที่ไม่มีภาคแยกตาม hour history
+type ledgerTermRow struct {
+	ID struct {
+		StudentID           primitive.ObjectID `bson:"studentId"`
+		models.AcademicTerm `bson:",inline"`
+		UntaggedHistoryID   *primitive.ObjectID `bson:"untaggedHistoryId"`
+		CarryOver           bool                `bson:"carryOver"`
+	} `bson:"_id"`
+	Soft      int64     `bson:"soft"`
+	Hard      int64     `bson:"hard"`
+	Seq       int64     `bson:"seq"`
+	CreatedAt time.Time `bson:"createdAt"`
+}
+
+// aggregateLedgerTerms ชั่วโมงจาก ledger แยกตามนิสิตและภาค (ยังไม่จำกัดเพดาน) และ seq ล่าสุดของแต่ละคน
+func aggregateLedgerTerms(ctx context.Context, match bson.M) (map[primitive.ObjectID][]termTotals, map[primitive.ObjectID]int64, error) {
+	group := ledgerTotalsGroup(bson.M{
+		"studentId":    "$studentId",
+		"academicYear": "$academicYear",
+		"term":         "$term",
+		"untaggedHistoryId": bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{"$academicYear", nil}}, nil, "$historyId",
+		}},
+		"carryOver": bson.M{"$eq": bson.A{"$sourceId", primitive.NilObjectID}},
+	})
+	group["createdAt"] = bson.M{"$min": "$createdAt"}
+	cursor, err := DB.HourLedgerCollection.Aggregate(ctx, []bson.M{
+		{"$match": match},
+		{"$group": group},
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("aggregate hour ledger error: %v", err)
+	}
+	var rows []ledgerTermRow
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, nil, fmt.Errorf("aggregate decode error: %v", err)
+	}
+
+	untagged := map[primitive.ObjectID]time.Time{}
+	for _, r := range rows {
+		if r.ID.UntaggedHistoryID != nil && !r.ID.CarryOver {
+			untagged[*r.ID.UntaggedHistoryID] = r.CreatedAt
+		}
+	}
+	historyTerms, err := untaggedHistoryTerms(ctx, untagged)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	buckets := map[primitive.ObjectID]map[models.AcademicTerm]*termTotals{}
+	seqs := map[primitive.ObjectID]int64{}
+	for _, r := range rows {
+		term := r.ID.AcademicTerm
+		switch {
+		case r.ID.CarryOver:
+			term = carryOverTerm
+		case r.ID.UntaggedHistoryID != nil:
+			term = historyTerms[*r.ID.UntaggedHistoryID]
+		}
+		if buckets[r.ID.StudentID] == nil {
+			buckets[r.ID.StudentID] = map[models.AcademicTerm]*termTotals{}
+		}
+		b := buckets[r.ID.StudentID][term]
+		if b == nil {
+			b = &termTotals{AcademicTerm: term}
+			buckets[r.ID.StudentID][term] = b
+		}
+		b.Soft += r.Soft
+		b.Hard += r.Hard
+		if r.Seq > seqs[r.ID.StudentID] {
+			seqs[r.ID.StudentID] = r.Seq
+		}
+	}
+	totals := make(map[primitive.ObjectID][]termTotals, len(buckets))
+	for studentID, terms := range buckets {
+		list := make([]termTotals, 0, len(terms))
+		for _, b := range terms {
+			list = append(list, *b)
+		}
+		sort.Slice(list, func(i, j int) bool { return list[i].Before(list[j].AcademicTerm) })
+		totals[studentID] = list
+	}
+	return totals, seqs, nil
+}
+
+// untaggedHistoryTerms ภาคของ hour history ที่ entry ใน ledger ไม่มีภาค (fallback = เวลาที่บันทึก entry แรก)
+func untaggedHistoryTerms(ctx context.Context, firstEntryAt map[primitive.ObjectID]time.Time) (map[primitive.ObjectID]models.AcademicTerm, error) {
+	terms := make(map[primitive.ObjectID]models.AcademicTerm, len(firstEntryAt))
+	if len(firstEntryAt) == 0 {
+		return terms, nil
+	}
+	ids := make([]primitive.ObjectID, 0, len(firstEntryAt))
+	for id, at := range firstEntryAt {
+		ids = append(ids, id)
+		terms[id] = AcademicTermOf(at)
+	}
+	cur, err := DB.HourChangeHistoryCollection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"changeAt": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var histories []models.HourChangeHistory
+	if err := cur.All(ctx, &histories); err != nil {
+		return nil, err
+	}
+	for _, h := range histories {
+		if !h.ChangeAt.IsZero() {
+			terms[h.ID] = AcademicTermOf(h.ChangeAt)
+		}
+	}
+	return terms, nil
+}
+
+// ledgerEntryTerm ภาคของ entry (entry ที่ไม่มีภาคใช้ภาคเดียวกับที่ aggregateLedgerTerms คำนวณ)
+func ledgerEntryTerm(ctx context.Context, e *models.HourLedgerEntry) (models.AcademicTerm, error) {
+	if isCarryOverEntry(e) {
+		return carryOverTerm, nil
+	}
+	if !e.AcademicTerm.IsZero() {
+		return e.AcademicTerm, nil
+	}
+	var first models.HourLedgerEntry
+	err := DB.HourLedgerCollection.FindOne(ctx, bson.M{"historyId": e.HistoryID, "academicYear": bson.M{"$exists": false}},
+		options.FindOne().SetSort(bson.D{{Key: "seq", Value: 1}}).SetProjection(bson.M{"createdAt": 1})).Decode(&first)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return models.AcademicTerm{}, err
+	}
+	if first.CreatedAt.IsZero() {
+		first.CreatedAt = e.CreatedAt
+	}
+	terms, err := untaggedHistoryTerms(ctx, map[primitive.ObjectID]time.Time{e.HistoryID: first.CreatedAt})
+	if err != nil {
+		return models.AcademicTerm{}, err
+	}
+	return terms[e.HistoryID], nil
+}
+
+// studentTermTotals ชั่วโมงจาก ledger แยกตามภาค (ยังไม่จำกัดเพดาน) และ seq ล่าสุด
+func studentTermTotals(ctx context.Context, studentID primitive.ObjectID) ([]termTotals, int64, error) {
+	totals, seqs, err := aggregateLedgerTerms(ctx, bson.M{"studentId": studentID})
+	if err != nil {
+		return nil, 0, err
+	}
+	return totals[studentID], seqs[studentID], nil
+}
+
+// cappedTotals ยอดรวมที่นับได้ตามเพดานชั่วโมงต่อภาคของเกณฑ์ (ชั่วโมงยกมานับเต็มจำนวน)
+func cappedTotals(terms []termTotals, req models.GraduationRequirement) (softNet, hardNet int) {
+	for _, t := range terms {
+		soft, hard := int(t.Soft), int(t.Hard)
+		if t.AcademicTerm != carryOverTerm {
+			soft, hard = req.CapTermHours(soft, hard)
+		}
+		softNet += soft
+		hardNet += hard
+	}
+	return softNet, hardNet
+}
+
+// GetStudentTermSummary ชั่วโมงของนิสิตรายภาค ตั้งแต่ภาค 1 ของปีที่เข้าศึกษาถึงภาคปัจจุบัน (รวมภาคที่ไม่มีชั่วโมง)
+func GetStudentTermSummary(ctx context.Context, studentID primitive.ObjectID) (*models.HourTermReport, error) {
+	var student models.Student
+	if err := DB.StudentCollection.FindOne(ctx, bson.M{"_id": studentID}).Decode(&student); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrStudentNotFound
+		}
+		return nil, err
+	}
+	req, err := ResolveGraduationRequirement(ctx, student.Major, student.EntryYear)
+	if err != nil {
+		return nil, err
+	}
+	rows, _, err := studentTermTotals(ctx, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	byTerm := map[models.AcademicTerm]termTotals{}
+	for _, r := range rows {
+		byTerm[r.AcademicTerm] = r
+	}
+	current := AcademicTermOf(time.Now())
+	if student.EntryYear > 0 {
+		start := models.AcademicTerm{AcademicYear: student.EntryYear, Term: 1}
+		for t := start; !current.Before(t); t = NextAcademicTerm(t) {
+			if _, ok := byTerm[t]; !ok && t.Term <= regularTermsPerYear {
+				byTerm[t] = termTotals{AcademicTerm: t}
+			}
+		}
+	}
+	terms := make([]models.AcademicTerm, 0, len(byTerm))
+	for t := range byTerm {
+		terms = append(terms, t)
+	}
+	sort.Slice(terms, func(i, j int) bool { return terms[i].Before(terms[j]) })
+
+	report := &models.HourTermReport{
+		StudentID:   studentID,
+		TermSoftMin: req.TermSoftMin,
+		TermHardMin: req.TermHardMin,
+		TermSoftCap: req.TermSoftCap,
+		TermHardCap: req.TermHardCap,
+		Terms:       []models.HourTermSummary{},
+	}
+	for _, t := range terms {
+		r := byTerm[t]
+		soft, hard := int(r.Soft), int(r.Hard)
+		if t == carryOverTerm {
+			report.SoftCarryOver, report.HardCarryOver = soft, hard
+			report.SoftTotal += soft
+			report.HardTotal += hard
+			continue
+		}
+		softCounted, hardCounted := req.CapTermHours(soft, hard)
+		report.SoftTotal += softCounted
+		report.HardTotal += hardCounted
+		regular := t.Term <= regularTermsPerYear
+		report.Terms = append(report.Terms, models.HourTermSummary{
+			AcademicTerm:     t,
+			Label:            t.String(),
+			StartDate:        AcademicTermStart(t),
+			EndDate:          AcademicTermStart(NextAcademicTerm(t)),
+			Current:          t == current,
+			SoftHours:        soft,
+			HardHours:        hard,
+			SoftCounted:      softCounted,
+			HardCounted:      hardCounted,
+			BelowSoftMinimum: regular && req.TermSoftMin > 0 && soft < req.TermSoftMin,
+			BelowHardMinimum: regular && req.TermHardMin > 0 && hard < req.TermHardMin,
+		})
+	}
+	return report, nil
+}
@@ -0,0 +1,179 @@
+package hourhistory
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"log"
+
+	"github.com/hibiken/asynq"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ========================================
+// Totals - ยอดชั่วโมงรวมของนิสิต (แหล่งเดียวของการคำนวณชั่วโมง)
+// ========================================
+//
+// ยอดจริงคือผลรวม hours ใน Hour_Ledger (แต่ละภาคไม่เกินเพดานต่อภาค ดู terms.go); Student.softSkill / hardSkill เป็น cache ที่อัปเดตทุกครั้งที่ append ledger
+// (hoursLedgerSeq = seq ล่าสุดที่นับรวมแล้ว) และตรวจ / แก้ได้ด้วย ReconcileHourTotals
+
+const maxReconcileItems = 500
+
+// ledgerTotalsGroup $group ผลรวมชั่วโมงแยก soft / hard และ seq ล่าสุด
+func ledgerTotalsGroup(id interface{}) bson.M {
+	sumSkill := func(skill string) bson.M {
+		return bson.M{"$sum": bson.M{"$cond": bson.A{
+			bson.M{"$eq": bson.A{bson.M{"$toLower": "$skillType"}, skill}},
+			"$hours",
+			0,
+		}}}
+	}
+	return bson.M{
+		"_id":  id,
+		"soft": sumSkill("soft"),
+		"hard": sumSkill("hard"),
+		"seq":  bson.M{"$max": "$seq"},
+	}
+}
+
+// ComputeStudentTotals คำนวณยอดชั่วโมงของนิสิตจาก ledger (คืน seq ล่าสุดที่นับรวมด้วย)
+// แต่ละภาคนับไม่เกินเพดานชั่วโมงต่อภาคตามเกณฑ์ของสาขา / รุ่น (ดู GraduationRequirement.CapTermHours)
+func ComputeStudentTotals(ctx context.Context, studentID primitive.ObjectID) (softNet, hardNet int, seq int64, err error) {
+	terms, seq, err := studentTermTotals(ctx, studentID)
+	if err != nil || len(terms) == 0 {
+		return 0, 0, 0, err
+	}
+	req, err := studentRequirement(ctx, studentID)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	softNet, hardNet = cappedTotals(terms, req)
+	return softNet, hardNet, seq, nil
+}
+
+// studentRequirement เกณฑ์ของนิสิต (ไม่พบนิสิต = เกณฑ์ทั่วไป)
+func studentRequirement(ctx context.Context, studentID primitive.ObjectID) (models.GraduationRequirement, error) {
+	var student models.Student
+	err := DB.StudentCollection.FindOne(ctx, bson.M{"_id": studentID},
+		options.FindOne().SetProjection(bson.M{"major": 1, "entryYear": 1})).Decode(&student)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return models.GraduationRequirement{}, err
+	}
+	return ResolveGraduationRequirement(ctx, student.Major, student.EntryYear)
+}
+
+// setCachedTotals เขียน cache ลง Student เฉพาะเมื่อ cache เดิมไม่ใหม่กว่า seq นี้ (กันเขียนทับด้วยยอดเก่า)
+func setCachedTotals(ctx context.Context, studentID primitive.ObjectID, softNet, hardNet int, seq int64) (bool, error) {
+	res, err := DB.StudentCollection.UpdateOne(ctx,
+		bson.M{"_id": studentID, "hoursLedgerSeq": bson.M{"$not": bson.M{"$gt": seq}}},
+		bson.M{"$set": bson.M{"softSkill": softNet, "hardSkill": hardNet, "hoursLedgerSeq": seq}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.MatchedCount > 0, nil
+}
+
+// refreshStudentTotals คำนวณยอดจาก ledger แล้วอัปเดต cache ของนิสิต (เรียกหลัง append ledger ทุกครั้ง)
+func refreshStudentTotals(ctx context.Context, studentID primitive.ObjectID) error {
+	softNet, hardNet, seq, err := ComputeStudentTotals(ctx, studentID)
+	if err != nil {
+		return err
+	}
+	_, err = setCachedTotals(ctx, studentID, softNet, hardNet, seq)
+	return err
+}
+
+// ReconcileHourTotals เทียบ cache softSkill / hardSkill ของนิสิตทุกคนกับ ledger
+// fix = true → แก้ cache ที่ไม่ตรงและคำนวณสถานะนิสิตใหม่
+func ReconcileHourTotals(ctx context.Context, fix bool) (*models.HourTotalsReconciliation, error) {
+	ledgerTerms, ledgerSeq, err := aggregateLedgerTerms(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	requirements, err := LoadGraduationRequirements(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	scur, err := DB.StudentCollection.Find(ctx, bson.M{},
+		options.Find().SetProjection(bson.M{"code": 1, "major": 1, "entryYear": 1, "status": 1, "softSkill": 1, "hardSkill": 1, "hoursLedgerSeq": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer scur.Close(ctx)
+
+	result := &models.HourTotalsReconciliation{Items: []models.HourTotalsMismatch{}}
+	for scur.Next(ctx) {
+		var s models.Student
+		if err := scur.Decode(&s); err != nil {
+			return nil, err
+		}
+		result.CheckedStudents++
+
+		soft, hard := cappedTotals(ledgerTerms[s.ID], requirements.For(s.Major, s.EntryYear))
+		seq := ledgerSeq[s.ID]
+		if s.SoftSkill == soft && s.HardSkill == hard && s.HoursLedgerSeq == seq {
+			continue
+		}
+		result.Mismatches++
+		item := models.HourTotalsMismatch{
+			StudentID:  s.ID,
+			Code:       s.Code,
+			CachedSoft: s.SoftSkill,
+			CachedHard: s.HardSkill,
+			LedgerSoft: soft,
+			LedgerHard: hard,
+			CachedSeq:  s.HoursLedgerSeq,
+			LedgerSeq:  seq,
+		}
+
+		if fix {
+			// คำนวณใหม่ทีละคน (ledger อาจมี entry ใหม่ระหว่างตรวจ)
+			softNet, hardNet, seq, err := ComputeStudentTotals(ctx, s.ID)
+			if err != nil {
+				return nil, err
+			}
+			fixed, err := setCachedTotals(ctx, s.ID, softNet, hardNet, seq)
+			if err != nil {
+				return nil, err
+			}
+			if fixed {
+				item.Fixed = true
+				result.Fixed++
+			}
+			// คำนวณสถานะใหม่เฉพาะเมื่อชั่วโมงเปลี่ยนจริง (ไม่ใช่แค่ตั้ง hoursLedgerSeq ครั้งแรก)
+			// และไม่แตะนิสิตที่พ้นสภาพ (0) / ฝึกงาน (4) เหมือน RecalculateStudentStatuses
+			hoursChanged := softNet != s.SoftSkill || hardNet != s.HardSkill
+			if fixed && hoursChanged && s.Status >= 1 && s.Status <= 3 {
+				if err := UpdateStudentStatus(ctx, s.ID); err != nil {
+					log.Printf("⚠️ Warning: Failed to update student status for %s: %v", s.ID.Hex(), err)
+				}
+			}
+		}
+
+		if len(result.Items) < maxReconcileItems {
+			result.Items = append(result.Items, item)
+		}
+	}
+	if err := scur.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// HandleReconcileHourTotalsTask งานตามรอบ: แก้ cache ยอดชั่วโมงที่ไม่ตรงกับ ledger
+func HandleReconcileHourTotalsTask(ctx context.Context, t *asynq.Task) error {
+	result, err := ReconcileHourTotals(ctx, true)
+	if err != nil {
+		return err
+	}
+	if result.Mismatches > 0 {
+		log.Printf("🧮 Reconciled hour totals: checked=%d mismatches=%d fixed=%d",
+			result.CheckedStudents, result.Mismatches, result.Fixed)
+	}
+	return nil
+}
@@ -0,0 +1,335 @@
+package programs
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ========================================
+// Program approval - draft → submitted → approved ก่อนเปิดลงทะเบียน (planning → open)
+// อีเมลแจ้งเปิดลงทะเบียนจึงถูกส่งเฉพาะกิจกรรมที่ผ่านการอนุมัติแล้ว
+// ========================================
+
+var ErrInvalidProgramApproval = errors.New("invalid program approval action")
+
+// approvalStatusOf สถานะการอนุมัติ (ค่าว่างของกิจกรรมที่ยังไม่เคยส่ง = draft)
+func approvalStatusOf(program *models.ProgramDto) string {
+	if program.ApprovalStatus == "" {
+		return models.ProgramApprovalDraft
+	}
+	return program.ApprovalStatus
+}
+
+// checkApprovedForOpen กิจกรรมต้องได้รับอนุมัติ และเนื้อหาต้องตรงกับที่อนุมัติไว้ ก่อนเปิดลงทะเบียนครั้งแรก
+func checkApprovedForOpen(program *models.ProgramDto) error {
+	if approvalStatusOf(program) != models.ProgramApprovalApproved {
+		return fmt.Errorf("%w: กิจกรรมต้องได้รับการอนุมัติก่อนเปิดลงทะเบียน (สถานะปัจจุบัน: %s)", ErrInvalidProgramTransition, approvalStatusOf(program))
+	}
+	if program.ApprovalHash != "" && ProgramContentHash(program) != program.ApprovalHash {
+		return fmt.Errorf("%w: กิจกรรมถูกแก้ไขหลังอนุมัติ ต้องส่งอนุมัติใหม่", ErrInvalidProgramTransition)
+	}
+	return nil
+}
+
+// ProgramContentHash hash ของเนื้อหาที่ผู้ตรวจพิจารณา (ไม่รวมรูป, จำนวนผู้ลงทะเบียน และ vote อาหาร)
+// ลำดับของรายการไม่มีผล เพื่อให้ข้อมูลที่ส่งกลับมาจากหน้าแก้ไขได้ hash เดิม
+func ProgramContentHash(program *models.ProgramDto) string {
+	type itemContent struct {
+		Name             string                   `json:"name"`
+		Description      string                   `json:"description"`
+		StudentYears     []int                    `json:"studentYears"`
+		MaxParticipants  *int                     `json:"maxParticipants"`
+		Majors           []string                 `json:"majors"`
+		Rooms            []string                 `json:"rooms"`
+		Operator         string                   `json:"operator"`
+		Dates            []models.Dates           `json:"dates"`
+		Hour             *int                     `json:"hour"`
+		EligibilityRules []models.EligibilityRule `json:"eligibilityRules"`
+		HourPolicy       *models.HourPolicy       `json:"hourPolicy,omitempty"`
+	}
+	content := struct {
+		Name          string             `json:"name"`
+		Type          string             `json:"type"`
+		Skill         string             `json:"skill"`
+		FormID        string             `json:"formId"`
+		EndDateEnroll string             `json:"endDateEnroll"`
+		FoodDeadline  string             `json:"foodDeadline"`
+		Foods         []string           `json:"foods"`
+		Items         []string           `json:"items"`
+		HourPolicy    *models.HourPolicy `json:"hourPolicy,omitempty"`
+		Evaluation    bool               `json:"evaluationRequired,omitempty"`
+		EvalDays      int                `json:"evaluationDays,omitempty"`
+	}{
+		Name:          deref(program.Name),
+		Type:          program.Type,
+		Skill:         program.Skill,
+		FormID:        program.FormID.Hex(),
+		EndDateEnroll: program.EndDateEnroll,
+		FoodDeadline:  program.FoodDeadline,
+		HourPolicy:    program.HourPolicy,
+		Evaluation:    program.EvaluationRequired,
+		EvalDays:      program.EvaluationDays,
+	}
+	for _, fv := range program.FoodVotes {
+		content.Foods = append(content.Foods, strings.ToLower(strings.TrimSpace(fv.FoodName)))
+	}
+	sort.Strings(content.Foods)
+
+	for _, item := range program.ProgramItems {
+		c := itemContent{
+			Name:             deref(item.Name),
+			Description:      deref(item.Description),
+			StudentYears:     append([]int(nil), item.StudentYears...),
+			MaxParticipants:  item.MaxParticipants,
+			Operator:         deref(item.Operator),
+			Dates:            append([]models.Dates(nil), item.Dates...),
+			Hour:             item.Hour,
+			EligibilityRules: item.EligibilityRules,
+			HourPolicy:       item.HourPolicy,
+		}
+		sort.Ints(c.StudentYears)
+		for _, m := range item.Majors {
+			c.Majors = append(c.Majors, strings.ToLower(strings.TrimSpace(m)))
+		}
+		sort.Strings(c.Majors)
+		if item.Rooms != nil {
+			for _, r := range *item.Rooms {
+				c.Rooms = append(c.Rooms, strings.ToLower(strings.TrimSpace(r)))
+			}
+		}
+		sort.Strings(c.Rooms)
+		sort.Slice(c.Dates, func(i, j int) bool {
+			return c.Dates[i].Date+c.Dates[i].Stime < c.Dates[j].Date+c.Dates[j].Stime
+		})
+		b, _ := json.Marshal(c)
+		content.Items = append(content.Items, string(b))
+	}
+	sort.Strings(content.Items)
+
+	b, _ := json.Marshal(content)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// SubmitProgramForApproval ส่งกิจกรรมให้ผู้ตรวจพิจารณา (draft / changes_requested → submitted)
+// ข้อมูลต้องครบตามเงื่อนไขการเปิดลงทะเบียนก่อนส่ง
+func SubmitProgramForApproval(ctx context.Context, programID primitive.ObjectID, actor, comment string) (*models.ProgramApproval, error) {
+	program, err := loadProgramForApproval(ctx, programID)
+	if err != nil {
+		return nil, err
+	}
+	from := approvalStatusOf(program)
+	if from != models.ProgramApprovalDraft && from != models.ProgramApprovalChangesRequested {
+		return nil, fmt.Errorf("%w: ส่งอนุมัติได้เฉพาะกิจกรรมที่เป็นร่างหรือถูกขอให้แก้ไข (สถานะปัจจุบัน: %s)", ErrInvalidProgramApproval, from)
+	}
+	if err := checkStatePreconditions(program, models.ProgramStateOpen); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidProgramApproval, strings.TrimPrefix(err.Error(), ErrInvalidProgramTransition.Error()+": "))
+	}
+
+	now := time.Now()
+	set := bson.M{
+		"approvalStatus": models.ProgramApprovalSubmitted,
+		"submittedBy":    actor,
+		"submittedAt":    now,
+		"approvalHash":   ProgramContentHash(program),
+	}
+	if err := updateApprovalStatus(ctx, program, from, set, bson.M{"approvedBy": "", "approvedAt": ""}); err != nil {
+		return nil, err
+	}
+	recordProgramReview(ctx, programID, models.ProgramReviewSubmit, from, models.ProgramApprovalSubmitted, actor, comment)
+	return GetProgramApproval(ctx, programID)
+}
+
+// ApproveProgram อนุมัติกิจกรรม (submitted → approved) — ผู้อนุมัติต้องไม่ใช่ผู้ส่ง
+func ApproveProgram(ctx context.Context, programID primitive.ObjectID, actor, comment string) (*models.ProgramApproval, error) {
+	program, err := loadProgramForApproval(ctx, programID)
+	if err != nil {
+		return nil, err
+	}
+	from := approvalStatusOf(program)
+	if from != models.ProgramApprovalSubmitted {
+		return nil, fmt.Errorf("%w: อนุมัติได้เฉพาะกิจกรรมที่ส่งอนุมัติแล้ว (สถานะปัจจุบัน: %s)", ErrInvalidProgramApproval, from)
+	}
+	if strings.EqualFold(program.SubmittedBy, actor) {
+		return nil, fmt.Errorf("%w: ผู้ส่งอนุมัติไม่สามารถอนุมัติกิจกรรมของตนเองได้", ErrInvalidProgramApproval)
+	}
+
+	set := bson.M{
+		"approvalStatus": models.ProgramApprovalApproved,
+		"approvedBy":     actor,
+		"approvedAt":     time.Now(),
+	}
+	if err := updateApprovalStatus(ctx, program, from, set, nil); err != nil {
+		return nil, err
+	}
+	recordProgramReview(ctx, programID, models.ProgramReviewApprove, from, models.ProgramApprovalApproved, actor, comment)
+	return GetProgramApproval(ctx, programID)
+}
+
+// RequestProgramChanges ผู้ตรวจขอให้แก้ไข (submitted → changes_requested) ต้องระบุความเห็น
+func RequestProgramChanges(ctx context.Context, programID primitive.ObjectID, actor, comment string) (*models.ProgramApproval, error) {
+	if strings.TrimSpace(comment) == "" {
+		return nil, fmt.Errorf("%w: ต้องระบุสิ่งที่ต้องการให้แก้ไข", ErrInvalidProgramApproval)
+	}
+	program, err := loadProgramForApproval(ctx, programID)
+	if err != nil {
+		return nil, err
+	}
+	from := approvalStatusOf(program)
+	if from != models.ProgramApprovalSubmitted {
+		return nil, fmt.Errorf("%w: ขอแก้ไขได้เฉพาะกิจกรรมที่ส่งอนุมัติแล้ว (สถานะปัจจุบัน: %s)", ErrInvalidProgramApproval, from)
+	}
+	set := bson.M{"approvalStatus": models.ProgramApprovalChangesRequested}
+	if err := updateApprovalStatus(ctx, program, from, set, bson.M{"approvalHash": ""}); err != nil {
+		return nil, err
+	}
+	recordProgramReview(ctx, programID, models.ProgramReviewRequestChanges, from, models.ProgramApprovalChangesRequested, actor, comment)
+	return GetProgramApproval(ctx, programID)
+}
+
+// CommentOnProgram เพิ่มความเห็นโดยไม่เปลี่ยนสถานะ
+func CommentOnProgram(ctx context.Context, programID primitive.ObjectID, actor, comment string) (*models.ProgramApproval, error) {
+	if strings.TrimSpace(comment) == "" {
+		return nil, fmt.Errorf("%w: ต้องระบุความเห็น", ErrInvalidProgramApproval)
+	}
+	program, err := loadProgramForApproval(ctx, programID)
+	if err != nil {
+		return nil, err
+	}
+	status := approvalStatusOf(program)
+	recordProgramReview(ctx, programID, models.ProgramReviewComment, status, status, actor, comment)
+	return GetProgramApproval(ctx, programID)
+}
+
+// GetProgramApproval สถานะการอนุมัติและประวัติการตรวจ
+func GetProgramApproval(ctx context.Context, programID primitive.ObjectID) (*models.ProgramApproval, error) {
+	var program models.ProgramDto
+	if err := DB.ProgramCollection.FindOne(ctx, bson.M{"_id": programID, "deletedAt": nil}).Decode(&program); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrProgramNotFound
+		}
+		return nil, err
+	}
+	cur, err := DB.ProgramReviewCollection.Find(ctx,
+		bson.M{"programId": programID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	reviews := []models.ProgramReview{}
+	if err := cur.All(ctx, &reviews); err != nil {
+		return nil, err
+	}
+	return &models.ProgramApproval{
+		ProgramID:      program.ID,
+		ApprovalStatus: approvalStatusOf(&program),
+		SubmittedBy:    program.SubmittedBy,
+		SubmittedAt:    program.SubmittedAt,
+		ApprovedBy:     program.ApprovedBy,
+		ApprovedAt:     program.ApprovedAt,
+		Reviews:        reviews,
+	}, nil
+}
+
+// resetProgramApproval ยกเลิกการส่ง / การอนุมัติ กลับเป็น draft (ไม่ทำอะไรถ้าเป็น draft อยู่แล้ว)
+func resetProgramApproval(ctx context.Context, program *models.ProgramDto, actor, reason string) {
+	from := approvalStatusOf(program)
+	if from == models.ProgramApprovalDraft {
+		return
+	}
+	_, err := DB.ProgramCollection.UpdateOne(ctx,
+		bson.M{"_id": program.ID},
+		bson.M{
+			"$set":   bson.M{"approvalStatus": models.ProgramApprovalDraft},
+			"$unset": bson.M{"approvedBy": "", "approvedAt": "", "approvalHash": ""},
+		},
+	)
+	if err != nil {
+		log.Printf("⚠️ Warning: Failed to reset approval for program %s: %v", program.ID.Hex(), err)
+		return
+	}
+	delCache("program:" + program.ID.Hex())
+	recordProgramReview(ctx, program.ID, models.ProgramReviewReset, from, models.ProgramApprovalDraft, actor, reason)
+}
+
+// loadProgramForApproval ขั้นตอนอนุมัติใช้ได้เฉพาะกิจกรรมที่ยังวางแผนอยู่
+func loadProgramForApproval(ctx context.Context, programID primitive.ObjectID) (*models.ProgramDto, error) {
+	program, err := loadProgramWithItems(ctx, programID)
+	if err != nil {
+		return nil, err
+	}
+	if program.DeletedAt != nil {
+		return nil, ErrProgramNotFound
+	}
+	if NormalizeProgramState(program.ProgramState) != models.ProgramStatePlanning {
+		return nil, fmt.Errorf("%w: ขั้นตอนอนุมัติใช้ได้เฉพาะกิจกรรมสถานะ planning (สถานะปัจจุบัน: %s)", ErrInvalidProgramApproval, program.ProgramState)
+	}
+	return program, nil
+}
+
+// updateApprovalStatus เปลี่ยนสถานะเฉพาะเมื่อยังเป็น from อยู่ (กันผู้ตรวจสองคนกดพร้อมกัน)
+func updateApprovalStatus(ctx context.Context, program *models.ProgramDto, from string, set, unset bson.M) error {
+	filter := bson.M{"_id": program.ID, "programState": program.ProgramState}
+	if program.ApprovalStatus == "" {
+		filter["approvalStatus"] = bson.M{"$in": bson.A{nil, ""}}
+	} else {
+		filter["approvalStatus"] = from
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	res, err := DB.ProgramCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrProgramStateConflict
+	}
+	invalidateAllProgramsListCache()
+	delCache("program:" + program.ID.Hex())
+	return nil
+}
+
+// recordProgramReview บันทึกประวัติการตรวจ (ไม่ทำให้ action ล้มเหลวถ้าบันทึกไม่สำเร็จ)
+func recordProgramReview(ctx context.Context, programID primitive.ObjectID, action, from, to, actor, comment string) {
+	if actor == "" {
+		actor = "unknown"
+	}
+	review := models.ProgramReview{
+		ID:         primitive.NewObjectID(),
+		ProgramID:  programID,
+		Action:     action,
+		FromStatus: from,
+		ToStatus:   to,
+		Actor:      actor,
+		Comment:    strings.TrimSpace(comment),
+		CreatedAt:  time.Now(),
+	}
+	if _, err := DB.ProgramReviewCollection.InsertOne(ctx, review); err != nil {
+		log.Printf("⚠️ Warning: Failed to record review for program %s (%s): %v", programID.Hex(), action, err)
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,154 @@
+package programs
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"Backend-Bluelock-007/src/services/programs/email"
+	"Backend-Bluelock-007/src/services/rooms"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ========================================
+// Cancel / Postpone - ยกเลิกหรือเลื่อนกิจกรรมโดยไม่ลบข้อมูลการลงทะเบียน
+// ========================================
+
+var ErrInvalidProgramSchedule = errors.New("invalid program schedule")
+
+// CancelProgram ยกเลิกกิจกรรมผ่าน state machine (→ cancelled)
+// side effect: ยกเลิก task ที่ตั้งไว้, ปิด hour history ที่ยังไม่สรุปผล, แจ้งนิสิตพร้อมเหตุผล
+func CancelProgram(ctx context.Context, programID primitive.ObjectID, actor, reason string) (*models.ProgramStateHistory, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: ต้องระบุเหตุผลการยกเลิกกิจกรรม", ErrInvalidProgramTransition)
+	}
+	return TransitionProgramState(ctx, programID, models.ProgramStateCancelled, actor, reason, false)
+}
+
+// PostponeProgram เลื่อนวันจัดของกิจกรรมย่อย (และวันปิดรับสมัคร) แล้วตั้ง schedule ใหม่
+// ทำได้เฉพาะกิจกรรมที่ยังไม่จบ (planning / open / close)
+func PostponeProgram(ctx context.Context, programID primitive.ObjectID, input models.ProgramPostponeInput, actor string) (*models.ProgramDto, error) {
+	reason := strings.TrimSpace(input.Reason)
+	if reason == "" {
+		return nil, fmt.Errorf("%w: ต้องระบุเหตุผลการเลื่อนกิจกรรม", ErrInvalidProgramSchedule)
+	}
+	if len(input.Items) == 0 && input.EndDateEnroll == "" {
+		return nil, fmt.Errorf("%w: ต้องระบุวันจัดใหม่หรือวันปิดรับสมัครใหม่", ErrInvalidProgramSchedule)
+	}
+
+	program, err := loadProgramWithItems(ctx, programID)
+	if err != nil {
+		return nil, err
+	}
+	state := NormalizeProgramState(program.ProgramState)
+	switch state {
+	case models.ProgramStatePlanning, models.ProgramStateOpen, models.ProgramStateClose:
+	default:
+		return nil, fmt.Errorf("%w: ไม่สามารถเลื่อนกิจกรรมที่มีสถานะ %s ได้", ErrInvalidProgramTransition, state)
+	}
+
+	// ✅ ตรวจข้อมูลทั้งหมดก่อนบันทึก
+	itemIDs := map[primitive.ObjectID]bool{}
+	for _, item := range program.ProgramItems {
+		itemIDs[item.ID] = true
+	}
+	reschedules := make(map[primitive.ObjectID][]models.Dates, len(input.Items))
+	for _, r := range input.Items {
+		id, err := primitive.ObjectIDFromHex(r.ProgramItemID)
+		if err != nil || !itemIDs[id] {
+			return nil, fmt.Errorf("%w: programItemId %q ไม่อยู่ในกิจกรรมนี้", ErrInvalidProgramSchedule, r.ProgramItemID)
+		}
+		if err := validateScheduleDates(r.Dates); err != nil {
+			return nil, err
+		}
+		reschedules[id] = r.Dates
+	}
+	if input.EndDateEnroll != "" {
+		if _, err := time.ParseInLocation("2006-01-02", input.EndDateEnroll, time.Local); err != nil {
+			return nil, fmt.Errorf("%w: endDateEnroll ต้องอยู่ในรูปแบบ YYYY-MM-DD", ErrInvalidProgramSchedule)
+		}
+	}
+
+	// ✅ ห้องที่จองไว้ต้องว่างในวันใหม่
+	rescheduled := make([]models.ProgramItemDto, 0, len(program.ProgramItems))
+	for _, item := range program.ProgramItems {
+		if dates, ok := reschedules[item.ID]; ok {
+			item.Dates = dates
+		}
+		rescheduled = append(rescheduled, item)
+	}
+	if conflicts, err := rooms.FindConflicts(ctx, programID, rescheduled); err != nil {
+		return nil, err
+	} else if len(conflicts) > 0 {
+		return nil, &rooms.ConflictError{Conflicts: conflicts}
+	}
+
+	// ✅ บันทึกวันใหม่
+	for id, dates := range reschedules {
+		if _, err := DB.ProgramItemCollection.UpdateOne(ctx,
+			bson.M{"_id": id},
+			bson.M{"$set": bson.M{"dates": dates}},
+		); err != nil {
+			return nil, err
+		}
+	}
+	if input.EndDateEnroll != "" {
+		if _, err := DB.ProgramCollection.UpdateOne(ctx,
+			bson.M{"_id": programID},
+			bson.M{"$set": bson.M{"endDateEnroll": input.EndDateEnroll}},
+		); err != nil {
+			return nil, err
+		}
+	}
+	invalidateAllProgramsListCache()
+	delCache("program:" + programID.Hex())
+
+	program, err = loadProgramWithItems(ctx, programID)
+	if err != nil {
+		return nil, err
+	}
+	recordProgramStateHistory(ctx, programID, state, state, actor, "เลื่อนกิจกรรม: "+reason, false)
+
+	// ⏱️ ตั้ง schedule ปิดรับสมัคร / เสร็จสิ้น / แจ้งเตือน ตามวันใหม่
+	if state != models.ProgramStatePlanning {
+		scheduleProgramStateJobs(program)
+		email.DeleteReminderJobs(program)
+		email.ScheduleReminderJobs(program)
+	}
+
+	// 📧 แจ้งนิสิตที่ลงทะเบียนพร้อมเหตุผลและวันใหม่
+	programName := ""
+	if program.Name != nil {
+		programName = *program.Name
+	}
+	email.NotifyStudentsOnChanged(programID.Hex(), programName, email.ProgramChangePostponed, reason, GetProgramByID)
+
+	return GetProgramByID(programID.Hex())
+}
+
+// validateScheduleDates ตรวจรูปแบบวัน/เวลาของกิจกรรมย่อย
+func validateScheduleDates(dates []models.Dates) error {
+	if len(dates) == 0 {
+		return fmt.Errorf("%w: กิจกรรมย่อยต้องมีวันจัดอย่างน้อย 1 วัน", ErrInvalidProgramSchedule)
+	}
+	for _, d := range dates {
+		start, err := time.ParseInLocation("2006-01-02 15:04", d.Date+" "+d.Stime, time.Local)
+		if err != nil {
+			return fmt.Errorf("%w: วันเวลาเริ่ม %s %s ไม่ถูกต้อง", ErrInvalidProgramSchedule, d.Date, d.Stime)
+		}
+		end, err := time.ParseInLocation("2006-01-02 15:04", d.Date+" "+d.Etime, time.Local)
+		if err != nil {
+			return fmt.Errorf("%w: วันเวลาสิ้นสุด %s %s ไม่ถูกต้อง", ErrInvalidProgramSchedule, d.Date, d.Etime)
+		}
+		if !end.After(start) {
+			return fmt.Errorf("%w: เวลาสิ้นสุดต้องหลังเวลาเริ่ม (%s)", ErrInvalidProgramSchedule, d.Date)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package programs
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ========================================
+// Duplicate - คัดลอกกิจกรรม (กิจกรรมย่อย, ตัวเลือกอาหาร, ฟอร์ม) พร้อมเลื่อนวัน
+// ========================================
+
+// programImageDir โฟลเดอร์รูปกิจกรรม (ตรงกับ controllers.UploadProgramImage)
+const programImageDir = "./uploads/program/images/"
+
+// DuplicateProgram คัดลอกกิจกรรมเป็นกิจกรรมใหม่สถานะ planning
+// วันจัด / วันปิดรับสมัคร / วันปิดเลือกอาหาร ถูกเลื่อนไป shiftDays วัน, ฟอร์มถูกคัดลอกเป็นฉบับใหม่
+func DuplicateProgram(ctx context.Context, programID primitive.ObjectID, input models.DuplicateProgramInput, actor string) (*models.ProgramDto, error) {
+	source, err := loadProgramWithItems(ctx, programID)
+	if err != nil {
+		return nil, err
+	}
+
+	program, err := cloneProgramDto(ctx, source, input.ShiftDays)
+	if err != nil {
+		return nil, err
+	}
+	if input.Name != nil && strings.TrimSpace(*input.Name) != "" {
+		name := strings.TrimSpace(*input.Name)
+		program.Name = &name
+	}
+	return CreateProgram(program, actor)
+}
+
+// cloneProgramDto สร้าง ProgramDto ใหม่จากกิจกรรมต้นแบบ (ยังไม่บันทึก)
+// ตัวเลือกอาหารเริ่ม vote ที่ 0, กิจกรรมย่อยไม่มี ID (CreateProgram จะสร้างให้)
+func cloneProgramDto(ctx context.Context, source *models.ProgramDto, shiftDays int) (*models.ProgramDto, error) {
+	formID, err := cloneForm(ctx, source.FormID)
+	if err != nil {
+		return nil, err
+	}
+
+	program := &models.ProgramDto{
+		FormID:             formID,
+		Name:               cloneString(source.Name),
+		Type:               source.Type,
+		ProgramState:       models.ProgramStatePlanning,
+		Skill:              source.Skill,
+		File:               copyProgramImage(source.File),
+		EndDateEnroll:      shiftDateString(source.EndDateEnroll, shiftDays),
+		FoodDeadline:       shiftDateString(source.FoodDeadline, shiftDays),
+		HourPolicy:         cloneHourPolicy(source.HourPolicy),
+		EvaluationRequired: source.EvaluationRequired,
+		EvaluationDays:     source.EvaluationDays,
+	}
+
+	program.FoodVotes = make([]models.FoodVote, 0, len(source.FoodVotes))
+	for _, fv := range source.FoodVotes {
+		program.FoodVotes = append(program.FoodVotes, models.FoodVote{FoodID: fv.FoodID, FoodName: fv.FoodName})
+	}
+
+	program.ProgramItems = make([]models.ProgramItemDto, 0, len(source.ProgramItems))
+	for _, item := range source.ProgramItems {
+		program.ProgramItems = append(program.ProgramItems, models.ProgramItemDto{
+			Name:             cloneString(item.Name),
+			Description:      cloneString(item.Description),
+			StudentYears:     append([]int(nil), item.StudentYears...),
+			MaxParticipants:  cloneInt(item.MaxParticipants),
+			Majors:           append([]string(nil), item.Majors...),
+			Rooms:            cloneStrings(item.Rooms),
+			Operator:         cloneString(item.Operator),
+			Dates:            shiftDates(item.Dates, shiftDays),
+			Hour:             cloneInt(item.Hour),
+			EligibilityRules: append([]models.EligibilityRule(nil), item.EligibilityRules...),
+			RoomIDs:          append([]primitive.ObjectID(nil), item.RoomIDs...),
+			HourPolicy:       cloneHourPolicy(item.HourPolicy),
+		})
+	}
+	return program, nil
+}
+
+// cloneForm คัดลอกฟอร์มพร้อม block / choice / row เป็น ID ใหม่ทั้งหมด
+// (submission ผูกกับ formId จึงต้องแยกฟอร์มต่อกิจกรรม) — คืน ObjectID ว่างถ้าไม่มีฟอร์ม
+func cloneForm(ctx context.Context, formID primitive.ObjectID) (primitive.ObjectID, error) {
+	if formID.IsZero() {
+		return primitive.NilObjectID, nil
+	}
+	var form models.Form
+	if err := DB.FormCollection.FindOne(ctx, bson.M{"_id": formID}).Decode(&form); err != nil {
+		if err == mongo.ErrNoDocuments {
+			log.Printf("⚠️ Form %s not found, duplicate without form", formID.Hex())
+			return primitive.NilObjectID, nil
+		}
+		return primitive.NilObjectID, err
+	}
+
+	form.ID = primitive.NewObjectID()
+	form.IsOrigin = false
+	form.DeletedAt = nil
+	form.DeletedBy = ""
+	for i := range form.Blocks {
+		block := &form.Blocks[i]
+		block.ID = primitive.NewObjectID()
+		block.FormID = form.ID
+		for j := range block.Choices {
+			block.Choices[j].ID = primitive.NewObjectID()
+			block.Choices[j].BlockID = block.ID
+		}
+		for j := range block.Rows {
+			block.Rows[j].ID = primitive.NewObjectID()
+			block.Rows[j].BlockID = block.ID
+		}
+	}
+
+	if _, err := DB.FormCollection.InsertOne(ctx, form); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return form.ID, nil
+}
+
+// copyProgramImage คัดลอกไฟล์รูปเป็นชื่อใหม่ เพื่อให้แต่ละกิจกรรมเปลี่ยน/ลบรูปได้อิสระ
+// คัดลอกไม่สำเร็จ → กิจกรรมใหม่ไม่มีรูป
+func copyProgramImage(fileName string) string {
+	if fileName == "" {
+		return ""
+	}
+	src, err := os.Open(programImageDir + fileName)
+	if err != nil {
+		log.Printf("⚠️ Cannot copy program image %s: %v", fileName, err)
+		return ""
+	}
+	defer src.Close()
+
+	newName := fmt.Sprintf("%d%s", time.Now().UnixNano(), filepath.Ext(fileName))
+	dst, err := os.Create(programImageDir + newName)
+	if err != nil {
+		log.Printf("⚠️ Cannot copy program image %s: %v", fileName, err)
+		return ""
+	}
+	defer dst.Close()
+
+	if _, err := io.Copy(dst, src); err != nil {
+		log.Printf("⚠️ Cannot copy program image %s: %v", fileName, err)
+		os.Remove(programImageDir + newName)
+		return ""
+	}
+	return newName
+}
+
+// shiftDates เลื่อนวันจัดไป days วัน (เวลาเริ่ม/จบคงเดิม)
+func shiftDates(dates []models.Dates, days int) []models.Dates {
+	shifted := make([]models.Dates, 0, len(dates))
+	for _, d := range dates {
+		d.Date = shiftDateString(d.Date, days)
+		shifted = append(shifted, d)
+	}
+	return shifted
+}
+
+// shiftDateString เลื่อนวันรูปแบบ YYYY-MM-DD (ค่าว่างหรือรูปแบบผิดคืนค่าเดิม)
+func shiftDateString(date string, days int) string {
+	if date == "" || days == 0 {
+		return date
+	}
+	t, err := time.ParseInLocation("2006-01-02", date, time.Local)
+	if err != nil {
+		return date
+	}
+	return t.AddDate(0, 0, days).Format("2006-01-02")
+}
+
+// daysBetween จำนวนวันจาก from ถึง to (รูปแบบ YYYY-MM-DD)
+func daysBetween(from, to string) (int, error) {
+	f, err := time.ParseInLocation("2006-01-02", from, time.Local)
+	if err != nil {
+		return 0, err
+	}
+	t, err := time.ParseInLocation("2006-01-02", to, time.Local)
+	if err != nil {
+		return 0, err
+	}
+	return int(math.Round(t.Sub(f).Hours() / 24)), nil
+}
+
+// firstProgramDate วันจัดแรกสุดของกิจกรรม ("" ถ้าไม่มีวันจัด)
+func firstProgramDate(program *models.ProgramDto) string {
+	first := ""
+	for _, item := range program.ProgramItems {
+		for _, d := range item.Dates {
+			if first == "" || d.Date < first {
+				first = d.Date
+			}
+		}
+	}
+	return first
+}
+
+func cloneString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := *s
+	return &v
+}
+
+func cloneInt(i *int) *int {
+	if i == nil {
+		return nil
+	}
+	v := *i
+	return &v
+}
+
+func cloneStrings(s *[]string) *[]string {
+	if s == nil {
+		return nil
+	}
+	v := append([]string(nil), (*s)...)
+	return &v
+}
+
+func cloneHourPolicy(p *models.HourPolicy) *models.HourPolicy {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	if p.AbsencePenalty != nil {
+		penalty := *p.AbsencePenalty
+		v.AbsencePenalty = &penalty
+	}
+	return &v
+}
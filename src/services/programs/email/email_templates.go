@@ -195,3 +195,115 @@ func RenderCompletedEmailHTML(data CompletedEmailData) (string, error) {
 	}
 	return buf.String(), nil
 }
+
+// แจ้งยกเลิก / เลื่อนกิจกรรม
+type ChangedEmailData struct {
+	StudentName string
+	ProgramName string
+	Postponed   bool
+	Reason      string
+	Dates       []models.Dates
+	DetailLink  string
+}
+
+//go:embed email_program_changed.html
+var changedEmailHTML string
+
+func RenderChangedEmailHTML(data ChangedEmailData) (string, error) {
+	tmpl, err := template.New("changed").
+		Funcs(template.FuncMap{
+			"formatDateThai": func(s string) string {
+				loc, _ := time.LoadLocation("Asia/Bangkok")
+				t, err := time.ParseInLocation("2006-01-02", s, loc)
+				if err != nil {
+					return s
+				}
+				months := []string{"", "มกราคม", "กุมภาพันธ์", "มีนาคม", "เมษายน", "พฤษภาคม", "มิถุนายน",
+					"กรกฎาคม", "สิงหาคม", "กันยายน", "ตุลาคม", "พฤศจิกายน", "ธันวาคม"}
+				return fmt.Sprintf("%d %s %d", t.Day(), months[int(t.Month())], t.Year()+543)
+			},
+		}).
+		Parse(changedEmailHTML)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// เตือนส่งแบบประเมินกิจกรรม (ชั่วโมงรอแบบประเมิน)
+type EvaluationReminderEmailData struct {
+	StudentName string
+	ProgramName string
+	Hours       int
+	Deadline    time.Time
+	DetailLink  string
+}
+
+//go:embed email_evaluation_reminder.html
+var evaluationReminderEmailHTML string
+
+func RenderEvaluationReminderEmailHTML(data EvaluationReminderEmailData) (string, error) {
+	tmpl, err := template.New("evaluation").
+		Funcs(template.FuncMap{
+			"formatDeadlineThai": func(t time.Time) string {
+				loc, _ := time.LoadLocation("Asia/Bangkok")
+				t = t.In(loc)
+				months := []string{"", "มกราคม", "กุมภาพันธ์", "มีนาคม", "เมษายน", "พฤษภาคม", "มิถุนายน",
+					"กรกฎาคม", "สิงหาคม", "กันยายน", "ตุลาคม", "พฤศจิกายน", "ธันวาคม"}
+				return fmt.Sprintf("%d %s %d เวลา %s น.", t.Day(), months[int(t.Month())], t.Year()+543, t.Format("15:04"))
+			},
+		}).
+		Parse(evaluationReminderEmailHTML)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// แจ้งผลพิจารณาคำร้องขอทบทวนชั่วโมง
+type AppealDecisionEmailData struct {
+	StudentName    string
+	Title          string
+	Skill          string
+	OriginalStatus string
+	Approved       bool
+	Hours          int
+	Note           string
+	DecidedAt      time.Time
+}
+
+//go:embed email_appeal_decision.html
+var appealDecisionEmailHTML string
+
+func RenderAppealDecisionEmailHTML(data AppealDecisionEmailData) (string, error) {
+	tmpl, err := template.New("appeal").
+		Funcs(template.FuncMap{
+			"formatDecidedAtThai": func(t time.Time) string {
+				loc, _ := time.LoadLocation("Asia/Bangkok")
+				t = t.In(loc)
+				months := []string{"", "มกราคม", "กุมภาพันธ์", "มีนาคม", "เมษายน", "พฤษภาคม", "มิถุนายน",
+					"กรกฎาคม", "สิงหาคม", "กันยายน", "ตุลาคม", "พฤศจิกายน", "ธันวาคม"}
+				return fmt.Sprintf("%d %s %d เวลา %s น.", t.Day(), months[int(t.Month())], t.Year()+543, t.Format("15:04"))
+			},
+		}).
+		Parse(appealDecisionEmailHTML)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
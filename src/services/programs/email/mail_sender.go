@@ -3,6 +3,7 @@ package email
 import (
 	// "crypto/tls"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -11,7 +12,23 @@ import (
 )
 
 type MailSender interface {
-	Send(to, subject, html string) error
+	Send(to, subject, html string, attachments ...Attachment) error
+}
+
+// Attachment ไฟล์แนบอีเมล (เช่น .ics ของกิจกรรม)
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// icsAttachment แนบไฟล์ปฏิทินของกิจกรรม (method ตรงกับ METHOD ใน VCALENDAR)
+func icsAttachment(data []byte, method string) Attachment {
+	return Attachment{
+		Filename:    "program.ics",
+		ContentType: "text/calendar; charset=utf-8; method=" + method,
+		Data:        data,
+	}
 }
 
 type SMTPSender struct {
@@ -44,12 +61,22 @@ func NewSMTPSenderFromEnv() (*SMTPSender, error) {
 }
 
 
-func (s *SMTPSender) Send(to, subject, html string) error {
+func (s *SMTPSender) Send(to, subject, html string, attachments ...Attachment) error {
 	m := gomail.NewMessage()
 	m.SetHeader("From", s.From)
 	m.SetHeader("To", to)
 	m.SetHeader("Subject", subject)
 	m.SetBody("text/html", html)
+	for _, a := range attachments {
+		data := a.Data
+		m.Attach(a.Filename,
+			gomail.SetCopyFunc(func(w io.Writer) error {
+				_, err := w.Write(data)
+				return err
+			}),
+			gomail.SetHeader(map[string][]string{"Content-Type": {a.ContentType}}),
+		)
+	}
 
 	d := gomail.NewDialer(s.Host, s.Port, s.User, s.Pass)
 
@@ -0,0 +1,97 @@
+package email
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/jobs"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// appealOriginalStatusLabels ผลเดิมของ hour history ที่ยื่นคำร้อง
+var appealOriginalStatusLabels = map[string]string{
+	models.HCStatusLate:       "มาสาย (ไม่ได้รับชั่วโมง)",
+	models.HCStatusIncomplete: "เข้าร่วมไม่ครบ (ไม่ได้รับชั่วโมง)",
+	models.HCStatusAbsent:     "ไม่มาเข้าร่วม",
+	models.HCStatusRejected:   "ใบรับรองถูกปฏิเสธ",
+}
+
+// HandleNotifyAppealDecision แจ้งผลพิจารณาคำร้องขอทบทวนชั่วโมงให้นิสิตเจ้าของคำร้อง
+func HandleNotifyAppealDecision(sender MailSender) asynq.HandlerFunc {
+	return func(ctx context.Context, t *asynq.Task) error {
+		var p jobs.AppealDecisionPayload
+		if err := json.Unmarshal(t.Payload(), &p); err != nil {
+			return err
+		}
+		appealID, err := primitive.ObjectIDFromHex(p.AppealID)
+		if err != nil {
+			return err
+		}
+
+		var appeal models.HourAppeal
+		if err := DB.HourAppealCollection.FindOne(ctx, bson.M{"_id": appealID}).Decode(&appeal); err != nil {
+			return err
+		}
+		if appeal.State != models.HourAppealStateApproved && appeal.State != models.HourAppealStateRejected {
+			log.Printf("appeal: %s is %s, nothing to notify", p.AppealID, appeal.State)
+			return nil
+		}
+
+		var st models.Student
+		if err := DB.StudentCollection.FindOne(ctx, bson.M{"_id": appeal.StudentID}).Decode(&st); err != nil {
+			return err
+		}
+		if st.Code == "" {
+			log.Printf("appeal: student %s has no code, skip email", appeal.StudentID.Hex())
+			return nil
+		}
+
+		const emailDomain = "@go.buu.ac.th"
+		to := st.Code + emailDomain
+		approved := appeal.State == models.HourAppealStateApproved
+		subject := "ผลพิจารณาคำร้องขอทบทวนชั่วโมง: " + appeal.Title
+
+		original := appealOriginalStatusLabels[appeal.OriginalStatus]
+		if original == "" {
+			original = appeal.OriginalStatus
+		}
+		skill := "Soft Skill"
+		if appeal.SkillType == "hard" {
+			skill = "Hard Skill"
+		}
+		hours := 0
+		if appeal.GrantedHourChange != nil {
+			hours = *appeal.GrantedHourChange
+		}
+		decidedAt := time.Now()
+		if appeal.DecidedAt != nil {
+			decidedAt = *appeal.DecidedAt
+		}
+
+		html, err := RenderAppealDecisionEmailHTML(AppealDecisionEmailData{
+			StudentName:    st.Name,
+			Title:          appeal.Title,
+			Skill:          skill,
+			OriginalStatus: original,
+			Approved:       approved,
+			Hours:          hours,
+			Note:           appeal.DecisionNote,
+			DecidedAt:      decidedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if err := sender.Send(to, subject, html); err != nil {
+			return err
+		}
+
+		log.Printf("appeal: decision (%s) sent to %s for appeal=%s", appeal.State, to, p.AppealID)
+		return nil
+	}
+}
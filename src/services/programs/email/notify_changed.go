@@ -0,0 +1,44 @@
+package email
+
+import (
+	"context"
+	"log"
+
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+
+	"github.com/hibiken/asynq"
+)
+
+// NotifyStudentsOnChanged แจ้งนิสิตที่ลงทะเบียนแล้วเมื่อกิจกรรมถูกยกเลิกหรือเลื่อน พร้อมเหตุผล
+func NotifyStudentsOnChanged(
+	programID, programName, kind, reason string,
+	resolveProgram func(string) (*models.ProgramDto, error),
+) {
+	task, _ := NewNotifyProgramChangedTask(programID, programName, kind, reason)
+
+	// ถ้ามี Redis → เข้าคิว
+	if DB.AsynqClient != nil {
+		if _, err := DB.AsynqClient.Enqueue(task, asynq.MaxRetry(3)); err != nil {
+			log.Println("❌ enqueue notify-changed task:", err)
+		} else {
+			log.Printf("✅ Enqueued notify-changed task (%s): %s", kind, programID)
+		}
+		return
+	}
+
+	// ไม่มี Redis → ส่งทันที (sync)
+	log.Println("⚠️ Redis not available → sending changed emails synchronously")
+	sender, err := NewSMTPSenderFromEnv()
+	if err != nil {
+		log.Println("❌ init mail sender:", err)
+		return
+	}
+
+	handler := HandleNotifyProgramChanged(sender, resolveProgram)
+	if err := handler(context.Background(), task); err != nil {
+		log.Printf("❌ send changed emails: %v", err)
+	} else {
+		log.Printf("✅ sent %s emails for program %s", kind, programID)
+	}
+}
@@ -0,0 +1,115 @@
+package email
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"Backend-Bluelock-007/src/services/calendar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/hibiken/asynq"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HandleNotifyProgramChanged ส่งอีเมลแจ้งยกเลิก/เลื่อนกิจกรรมให้นิสิตทุกคนที่ลงทะเบียน (คนละ 1 ฉบับ)
+func HandleNotifyProgramChanged(
+	sender MailSender,
+	programResolver func(programID string) (*models.ProgramDto, error),
+) asynq.HandlerFunc {
+	return func(ctx context.Context, t *asynq.Task) error {
+		var p NotifyProgramChangedPayload
+		if err := json.Unmarshal(t.Payload(), &p); err != nil {
+			return err
+		}
+
+		prog, err := programResolver(p.ProgramID)
+		if err != nil || prog == nil {
+			return fmt.Errorf("program not found: %s", p.ProgramID)
+		}
+
+		// รวมวันจัดทั้งหมด (สำหรับการเลื่อน = วันใหม่)
+		var allDates []models.Dates
+		for _, it := range prog.ProgramItems {
+			allDates = append(allDates, it.Dates...)
+		}
+
+		programID, err := primitive.ObjectIDFromHex(p.ProgramID)
+		if err != nil {
+			return err
+		}
+		cur, err := DB.EnrollmentCollection.Find(ctx, bson.M{"programId": programID})
+		if err != nil {
+			return err
+		}
+		defer cur.Close(ctx)
+
+		base := strings.TrimRight(os.Getenv("FRONTEND_URL"), "/")
+		if base == "" {
+			base = "http://localhost:9000"
+		}
+		detailURL := base + "/Student/Program/MyProgramDetail/" + p.ProgramID
+		const emailDomain = "@go.buu.ac.th"
+
+		subject := "แจ้งยกเลิกกิจกรรม: " + p.ProgramName
+		if p.Kind == ProgramChangePostponed {
+			subject = "แจ้งเลื่อนกิจกรรม: " + p.ProgramName
+		}
+
+		// 📅 .ics ของกิจกรรมย่อยที่นิสิตแต่ละคนลงทะเบียน (ยกเลิก = CANCEL, เลื่อน = REQUEST ที่ SEQUENCE ใหม่)
+		method := calendar.MethodCancel
+		if p.Kind == ProgramChangePostponed {
+			method = calendar.MethodRequest
+		}
+		studentItems := map[primitive.ObjectID]map[primitive.ObjectID]bool{}
+		var studentIDs []primitive.ObjectID
+		for cur.Next(ctx) {
+			var en models.Enrollment
+			if err := cur.Decode(&en); err != nil {
+				continue
+			}
+			if studentItems[en.StudentID] == nil {
+				studentItems[en.StudentID] = map[primitive.ObjectID]bool{}
+				studentIDs = append(studentIDs, en.StudentID)
+			}
+			studentItems[en.StudentID][en.ProgramItemID] = true
+		}
+
+		sent := map[primitive.ObjectID]bool{}
+		for _, studentID := range studentIDs {
+			var st models.Student
+			if err := DB.StudentCollection.FindOne(ctx, bson.M{"_id": studentID}).Decode(&st); err != nil {
+				continue
+			}
+			if st.Code == "" {
+				continue
+			}
+			sent[studentID] = true
+
+			to := st.Code + emailDomain
+			html, err := RenderChangedEmailHTML(ChangedEmailData{
+				StudentName: st.Name,
+				ProgramName: p.ProgramName,
+				Postponed:   p.Kind == ProgramChangePostponed,
+				Reason:      p.Reason,
+				Dates:       allDates,
+				DetailLink:  detailURL,
+			})
+			if err != nil {
+				log.Printf("changed: render failed for %s: %v", to, err)
+				continue
+			}
+			invite := icsAttachment(calendar.ProgramInvite(ctx, prog, studentItems[studentID], method), method)
+			if err := sender.Send(to, subject, html, invite); err != nil {
+				log.Printf("changed: send failed to %s: %v", to, err)
+			}
+		}
+
+		log.Printf("changed: notify %s done for program=%s (%d students)", p.Kind, p.ProgramID, len(sent))
+		return nil
+	}
+}
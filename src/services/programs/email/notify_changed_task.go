@@ -0,0 +1,30 @@
+package email
+
+import (
+	"github.com/hibiken/asynq"
+)
+
+const TypeNotifyProgramChanged = "email:notify-program-changed"
+
+// ประเภทการเปลี่ยนแปลงที่ต้องแจ้งนิสิตที่ลงทะเบียนแล้ว
+const (
+	ProgramChangeCancelled = "cancelled"
+	ProgramChangePostponed = "postponed"
+)
+
+type NotifyProgramChangedPayload struct {
+	ProgramID   string `json:"programId"`
+	ProgramName string `json:"programName"`
+	Kind        string `json:"kind"`   // cancelled | postponed
+	Reason      string `json:"reason"` // เหตุผลที่แจ้งนิสิต
+}
+
+func NewNotifyProgramChangedTask(programID, programName, kind, reason string) (*asynq.Task, error) {
+	p := NotifyProgramChangedPayload{
+		ProgramID:   programID,
+		ProgramName: programName,
+		Kind:        kind,
+		Reason:      reason,
+	}
+	return asynq.NewTask(TypeNotifyProgramChanged, mustJSON(p)), nil
+}
@@ -0,0 +1,34 @@
+package email
+
+import (
+	"log"
+	"time"
+
+	DB "Backend-Bluelock-007/src/database"
+
+	"github.com/hibiken/asynq"
+)
+
+// evaluationReminderLead เตือนนิสิตที่ยังไม่ส่งแบบประเมินก่อนถึงกำหนด
+const evaluationReminderLead = 24 * time.Hour
+
+// ScheduleEvaluationReminder ตั้งงานเตือนส่งแบบประเมินก่อนกำหนด 1 วัน (ถ้ายังไม่เลยเวลา)
+// ใช้หลังกิจกรรมเสร็จสิ้นและให้ชั่วโมงแบบรอแบบประเมินแล้ว
+func ScheduleEvaluationReminder(programID, programName string, deadline time.Time) {
+	if DB.AsynqClient == nil {
+		log.Println("⚠️ Redis/Asynq not available → skip scheduling evaluation reminder")
+		return
+	}
+	runAt := deadline.Add(-evaluationReminderLead)
+	if runAt.Before(time.Now()) {
+		return
+	}
+
+	task, _ := NewNotifyEvaluationReminderTask(programID, programName)
+	taskID := EvaluationReminderTaskID(programID)
+	if _, err := DB.AsynqClient.Enqueue(task, asynq.ProcessAt(runAt), asynq.TaskID(taskID), asynq.MaxRetry(3)); err != nil {
+		log.Println("❌ enqueue notify-evaluation task:", err)
+	} else {
+		log.Printf("✅ scheduled evaluation reminder: %s at %s", taskID, runAt.Format(time.RFC3339))
+	}
+}
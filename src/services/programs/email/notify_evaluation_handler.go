@@ -0,0 +1,88 @@
+package email
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HandleNotifyEvaluationReminder เตือนนิสิตที่ยังไม่ส่งแบบประเมิน (hour history = pending_evaluation และยังไม่เลยกำหนด)
+func HandleNotifyEvaluationReminder(sender MailSender) asynq.HandlerFunc {
+	return func(ctx context.Context, t *asynq.Task) error {
+		var p NotifyEvaluationReminderPayload
+		if err := json.Unmarshal(t.Payload(), &p); err != nil {
+			return err
+		}
+		programID, err := primitive.ObjectIDFromHex(p.ProgramID)
+		if err != nil {
+			return err
+		}
+
+		cur, err := DB.HourChangeHistoryCollection.Find(ctx, bson.M{
+			"sourceType":         "program",
+			"sourceId":           programID,
+			"status":             models.HCStatusPendingEvaluation,
+			"evaluationDeadline": bson.M{"$gte": time.Now()},
+		})
+		if err != nil {
+			return err
+		}
+		var records []models.HourChangeHistory
+		if err := cur.All(ctx, &records); err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			log.Printf("evaluation: no pending students for program %s", p.ProgramID)
+			return nil
+		}
+
+		base := strings.TrimRight(os.Getenv("FRONTEND_URL"), "/")
+		if base == "" {
+			base = "http://localhost:9000"
+		}
+		detailURL := base + "/Student/Program/MyProgramDetail/" + p.ProgramID
+		const emailDomain = "@go.buu.ac.th"
+		subject := "เตือนส่งแบบประเมินกิจกรรม: " + p.ProgramName
+
+		sent := 0
+		for _, r := range records {
+			var st models.Student
+			if err := DB.StudentCollection.FindOne(ctx, bson.M{"_id": r.StudentID}).Decode(&st); err != nil {
+				continue
+			}
+			if st.Code == "" {
+				continue
+			}
+
+			to := st.Code + emailDomain
+			html, err := RenderEvaluationReminderEmailHTML(EvaluationReminderEmailData{
+				StudentName: st.Name,
+				ProgramName: p.ProgramName,
+				Hours:       r.HourChange,
+				Deadline:    *r.EvaluationDeadline,
+				DetailLink:  detailURL,
+			})
+			if err != nil {
+				log.Printf("evaluation: render failed for %s: %v", to, err)
+				continue
+			}
+			if err := sender.Send(to, subject, html); err != nil {
+				log.Printf("evaluation: send failed to %s: %v", to, err)
+				continue
+			}
+			sent++
+		}
+
+		log.Printf("evaluation: reminder done for program=%s (%d students)", p.ProgramID, sent)
+		return nil
+	}
+}
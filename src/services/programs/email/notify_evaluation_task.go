@@ -0,0 +1,25 @@
+package email
+
+import (
+	"github.com/hibiken/asynq"
+)
+
+const TypeNotifyEvaluationReminder = "email:notify-evaluation-reminder"
+
+type NotifyEvaluationReminderPayload struct {
+	ProgramID   string `json:"programId"`
+	ProgramName string `json:"programName"`
+}
+
+// EvaluationReminderTaskID TaskID ของงานเตือนส่งแบบประเมิน (1 งานต่อกิจกรรม)
+func EvaluationReminderTaskID(programID string) string {
+	return "notify-evaluation-" + programID
+}
+
+func NewNotifyEvaluationReminderTask(programID, programName string) (*asynq.Task, error) {
+	p := NotifyEvaluationReminderPayload{
+		ProgramID:   programID,
+		ProgramName: programName,
+	}
+	return asynq.NewTask(TypeNotifyEvaluationReminder, mustJSON(p)), nil
+}
@@ -14,7 +14,7 @@ import (
 func NotifyStudentsOnOpen(
 	programID, programName string,
 	resolveProgram func(string) (*models.ProgramDto, error),
-	codePrefixFn func([]int) []string,
+	eligibleFn func(context.Context, *models.ProgramItem, *models.Student) error,
 ) {
 	// มี Redis → เข้าคิว
 	if DB.AsynqClient != nil {
@@ -58,7 +58,7 @@ func NotifyStudentsOnOpen(
 		sender,
 		func(pid string) string { return base + "/Student/Programs/" + pid },
 		resolveProgram,
-		codePrefixFn,
+		eligibleFn,
 	)
 
 	task, _ := NewNotifyOpenProgramTask(programID, programName)
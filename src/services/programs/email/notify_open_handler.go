@@ -3,11 +3,11 @@ package email
 import (
 	DB "Backend-Bluelock-007/src/database"
 	"Backend-Bluelock-007/src/models"
+	"Backend-Bluelock-007/src/services/calendar"
 	"context"
 	"encoding/json"
 	"fmt"
 	"log"
-	"regexp"
 	"strings"
 
 	"github.com/hibiken/asynq"
@@ -19,7 +19,7 @@ func HandleNotifyOpenProgram(
 	sender MailSender,
 	registerURLBuilder func(programID string) string,
 	programResolver func(programID string) (*models.ProgramDto, error),
-	codePrefixFn func(years []int) []string,
+	eligibleFn func(ctx context.Context, item *models.ProgramItem, student *models.Student) error,
 ) asynq.HandlerFunc {
 	return func(ctx context.Context, t *asynq.Task) error {
 		var p NotifyOpenProgramPayload
@@ -32,6 +32,9 @@ func HandleNotifyOpenProgram(
 			return fmt.Errorf("program not found: %s", p.ProgramID)
 		}
 
+		// 📅 กำหนดการทั้งหมดของกิจกรรม (แนบเป็นไฟล์ .ics)
+		invite := icsAttachment(calendar.ProgramInvite(ctx, prog, nil, calendar.MethodPublish), calendar.MethodPublish)
+
 		majorsSet := map[string]struct{}{}
 		yearsSet := map[int]struct{}{}
 		for _, it := range prog.ProgramItems {
@@ -60,32 +63,29 @@ func HandleNotifyOpenProgram(
 			years = append(years, k)
 		}
 
-		// 3) ใช้ฟังก์ชันสร้าง prefix ที่ถูกส่งเข้ามา
-		prefixes := codePrefixFn(years)
-		if len(prefixes) == 0 {
-			log.Println("notify-open: empty prefixes, skip")
+		// 3) แปลงชั้นปีเป็นปีที่เข้าศึกษา (entryYear)
+		entryYears := models.EntryYearsForStudentYears(years)
+		if len(entryYears) == 0 {
+			log.Println("notify-open: empty entry years, skip")
 			return nil
 		}
-		re := "^(" + strings.Join(prefixes, "|") + ")"
-		if _, err := regexp.Compile(re); err != nil {
-			return fmt.Errorf("bad student code regex: %s", re)
-		}
 
 		// 4) query students
 		match := bson.M{
-			"major":  bson.M{"$in": majors},
-			"code":   bson.M{"$regex": re},
-			"status": bson.M{"$in": bson.A{1, 2}},
+			"major":     bson.M{"$in": majors},
+			"entryYear": bson.M{"$in": entryYears},
+			"status":    bson.M{"$in": bson.A{1, 2}},
+			"deletedAt": nil,
 		}
 
 		total, _ := DB.StudentCollection.CountDocuments(ctx, match)
-		log.Printf("notify-open: matched students=%d majors=%v regex=%s", total, majors, re)
+		log.Printf("notify-open: matched students=%d majors=%v entryYears=%v", total, majors, entryYears)
 		if total == 0 {
 			return nil
 		}
 
 		findOpts := options.Find().
-			SetProjection(bson.M{"name": 1, "code": 1, "major": 1}).
+			SetProjection(bson.M{"name": 1, "code": 1, "major": 1, "status": 1, "entryYear": 1}).
 			SetBatchSize(500)
 
 		cur, err := DB.StudentCollection.Find(ctx, match, findOpts)
@@ -96,6 +96,17 @@ func HandleNotifyOpenProgram(
 
 		const emailDomain = "@go.buu.ac.th"
 
+		// 5) ส่งเฉพาะนิสิตที่ผ่านเงื่อนไขของ programItem อย่างน้อย 1 รายการ (evaluator เดียวกับการลงทะเบียน)
+		eligible := func(s models.Student) bool {
+			for _, it := range prog.ProgramItems {
+				item := models.ProgramItem(it)
+				if err := eligibleFn(ctx, &item, &s); err == nil {
+					return true
+				}
+			}
+			return false
+		}
+
 		send := func(s models.Student) {
 			// --- สรุปค่าจาก ProgramItems ---
 
@@ -174,18 +185,23 @@ func HandleNotifyOpenProgram(
 			}
 			to := s.Code + emailDomain
 			subject := "เปิดลงทะเบียน: " + p.ProgramName
-			if err := sender.Send(to, subject, html); err != nil {
+			if err := sender.Send(to, subject, html, invite); err != nil {
 				log.Printf("send mail failed to %s: %v", to, err)
 			}
 		}
 
 		batch := 100
+		skipped := 0
 		buf := make([]models.Student, 0, batch)
 		for cur.Next(ctx) {
 			var st models.Student
 			if err := cur.Decode(&st); err != nil {
 				continue
 			}
+			if !eligible(st) {
+				skipped++
+				continue
+			}
 			buf = append(buf, st)
 			if len(buf) >= batch {
 				for _, x := range buf {
@@ -198,7 +214,7 @@ func HandleNotifyOpenProgram(
 			send(x)
 		}
 
-		log.Printf("notify-open done program=%s recipients majors=%v years=%v", p.ProgramID, majors, years)
+		log.Printf("notify-open done program=%s recipients majors=%v years=%v skipped(ineligible)=%d", p.ProgramID, majors, years, skipped)
 		return nil
 	}
 }
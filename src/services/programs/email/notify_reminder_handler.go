@@ -4,6 +4,7 @@ package email
 import (
 	DB "Backend-Bluelock-007/src/database"
 	"Backend-Bluelock-007/src/models"
+	"Backend-Bluelock-007/src/services/calendar"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -32,6 +33,10 @@ func HandleProgramReminder(sender MailSender,
 		if err != nil || prog == nil {
 			return fmt.Errorf("program not found: %s", p.ProgramID)
 		}
+		if prog.ProgramState == models.ProgramStateCancelled {
+			log.Printf("reminder: program %s was cancelled, skip", p.ProgramID)
+			return nil
+		}
 
 		// หา ProgramItem ตาม payload
 		var item *models.ProgramItemDto
@@ -51,6 +56,10 @@ func HandleProgramReminder(sender MailSender,
 		}
 
 		first := item.Dates[0]
+		invite := icsAttachment(
+			calendar.ProgramInvite(ctx, prog, map[primitive.ObjectID]bool{item.ID: true}, calendar.MethodRequest),
+			calendar.MethodRequest,
+		)
 		base := strings.TrimRight(os.Getenv("FRONTEND_URL"), "/")
 		if base == "" {
 			base = "http://localhost:9000"
@@ -147,7 +156,7 @@ func HandleProgramReminder(sender MailSender,
 				continue
 			}
 			subject := "แจ้งเตือนก่อนกิจกรรม 3 วัน: " + p.ProgramName
-			if err := sender.Send(to, subject, html); err != nil {
+			if err := sender.Send(to, subject, html, invite); err != nil {
 				log.Printf("reminder: send failed to %s: %v", to, err)
 			}
 		}
@@ -46,7 +46,7 @@ func ScheduleReminderJobs(prog *models.ProgramDto) {
 			continue
 		}
 
-		taskID := "reminder-3d-" + prog.ID.Hex() + "-" + item.ID.Hex()
+		taskID := ReminderTaskID(prog.ID.Hex(), item.ID.Hex())
 		if _, err := DB.AsynqClient.Enqueue(
 			task,
 			asynq.ProcessAt(runAt),
@@ -59,6 +59,27 @@ func ScheduleReminderJobs(prog *models.ProgramDto) {
 	}
 }
 
+// ReminderTaskID TaskID ของงานแจ้งเตือนต่อ ProgramItem
+func ReminderTaskID(programID, programItemID string) string {
+	return "reminder-3d-" + programID + "-" + programItemID
+}
+
+// DeleteReminderJobs ลบงานแจ้งเตือนที่ตั้งไว้ของทุก ProgramItem (ใช้ตอนยกเลิก/เลื่อนกิจกรรม)
+func DeleteReminderJobs(prog *models.ProgramDto) {
+	if DB.AsynqClient == nil {
+		return
+	}
+	inspector := asynq.NewInspector(asynq.RedisClientOpt{Addr: DB.RedisURI})
+	for _, item := range prog.ProgramItems {
+		taskID := ReminderTaskID(prog.ID.Hex(), item.ID.Hex())
+		if err := inspector.DeleteTask("default", taskID); err != nil && err != asynq.ErrTaskNotFound {
+			log.Printf("⚠️ Failed to delete reminder task %s: %v", taskID, err)
+		} else if err == nil {
+			log.Printf("🗑️ Deleted reminder task: %s", taskID)
+		}
+	}
+}
+
 // 🕒 คำนวณเวลาแจ้งเตือนก่อน 3 วัน "เวลาเดียวกัน"
 func computeReminderTime(dateStr, stime string) (time.Time, error) {
     if stime == "" { stime = "00:00" }
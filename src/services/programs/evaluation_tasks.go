@@ -0,0 +1,79 @@
+package programs
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/jobs"
+	"Backend-Bluelock-007/src/models"
+	hourhistory "Backend-Bluelock-007/src/services/hour-history"
+	"Backend-Bluelock-007/src/services/programs/email"
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// evaluationDeadlineTaskID TaskID ของงานปิดรายการที่รอแบบประเมินหลังเลยกำหนด
+func evaluationDeadlineTaskID(programID string) string {
+	return "evaluation-deadline-" + programID
+}
+
+// scheduleEvaluationJobs ตั้งงานเตือนส่งแบบประเมิน (ก่อนกำหนด 1 วัน) และงานตัดชั่วโมงเมื่อเลยกำหนด
+// ใช้หลังกิจกรรมเสร็จสิ้น (ชั่วโมงของนิสิตที่ยังไม่ส่งฟอร์มอยู่ในสถานะ pending_evaluation)
+func scheduleEvaluationJobs(program *models.ProgramDto, programName string, completedAt time.Time) {
+	if !programRequiresEvaluation(program) {
+		return
+	}
+	if DB.AsynqClient == nil {
+		log.Println("⚠️ Redis/Asynq not available → skip scheduling evaluation jobs")
+		return
+	}
+	programIDHex := program.ID.Hex()
+	deadline := hourhistory.EvaluationDeadline(program.EvaluationDays, completedAt)
+
+	createDeadline := func(id string) (*asynq.Task, error) {
+		return jobs.NewEvaluationDeadlineTaskWithName(id, programName)
+	}
+	// เผื่อเวลา 1 นาทีหลังกำหนด เพื่อไม่ตัดรายการที่ส่งทันในวินาทีสุดท้าย
+	if err := enqueueTask(DB.AsynqClient, evaluationDeadlineTaskID(programIDHex), createDeadline, deadline.Add(time.Minute), programIDHex, DB.RedisURI); err != nil {
+		log.Printf("❌ Failed to schedule evaluation deadline for program %s: %v", programIDHex, err)
+	}
+	email.ScheduleEvaluationReminder(programIDHex, programName, deadline)
+}
+
+// programRequiresEvaluation มีกิจกรรมย่อยที่ต้องส่งแบบประเมินก่อนได้ชั่วโมง
+// (evaluationRequired หรือ hourPolicy.requireForm ของ Program / ProgramItem — ดู hourhistory.EffectiveHourPolicy)
+func programRequiresEvaluation(program *models.ProgramDto) bool {
+	if program.EvaluationRequired || (program.HourPolicy != nil && program.HourPolicy.RequireForm) {
+		return true
+	}
+	for _, item := range program.ProgramItems {
+		if item.HourPolicy != nil && item.HourPolicy.RequireForm {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleEvaluationDeadlineTask ตัดชั่วโมงที่รอแบบประเมินเมื่อเลยกำหนดส่ง (→ incomplete + 0 ชั่วโมง)
+func HandleEvaluationDeadlineTask(ctx context.Context, t *asynq.Task) error {
+	var payload jobs.ProgramPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		log.Println("❌ Payload decode error:", err)
+		return err
+	}
+	id, err := primitive.ObjectIDFromHex(payload.ProgramID)
+	if err != nil {
+		return err
+	}
+
+	n, err := hourhistory.ForfeitExpiredEvaluations(ctx, id)
+	if err != nil {
+		log.Printf("❌ Failed to close pending evaluations for program %s: %v", id.Hex(), err)
+		return err
+	}
+	log.Printf("📝 Closed %d pending evaluation hour records for program %s", n, id.Hex())
+	return nil
+}
@@ -0,0 +1,161 @@
+package programs
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ========================================
+// Food options - ตัวเลือกอาหารของ Program อ้างอิง Foods ด้วย FoodID
+// ========================================
+
+// NormalizeFoodOptions ผูกตัวเลือกอาหารทุกตัวกับเอกสารใน Foods
+// - มี foodId → ใช้ชื่อจาก Foods
+// - มีแค่ foodName → หา/สร้าง Food ตามชื่อ แล้วผูก foodId
+// vote ที่ส่งมาจาก client จะถูกละทิ้ง (คำนวณใหม่จาก Enrollments ด้วย RecalculateFoodVotes)
+func NormalizeFoodOptions(ctx context.Context, votes []models.FoodVote) ([]models.FoodVote, error) {
+	out := make([]models.FoodVote, 0, len(votes))
+	seen := map[primitive.ObjectID]bool{}
+
+	for _, v := range votes {
+		var food models.Food
+		switch {
+		case v.FoodID != nil && !v.FoodID.IsZero():
+			if err := DB.FoodCollection.FindOne(ctx, bson.M{"_id": *v.FoodID}).Decode(&food); err != nil {
+				return nil, fmt.Errorf("food %s not found", v.FoodID.Hex())
+			}
+		case strings.TrimSpace(v.FoodName) != "":
+			name := strings.TrimSpace(v.FoodName)
+			opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+			if err := DB.FoodCollection.FindOneAndUpdate(ctx,
+				bson.M{"name": name},
+				bson.M{"$setOnInsert": bson.M{"name": name}},
+				opts,
+			).Decode(&food); err != nil {
+				return nil, fmt.Errorf("failed to resolve food %q: %w", name, err)
+			}
+		default:
+			continue
+		}
+
+		if seen[food.ID] {
+			continue
+		}
+		seen[food.ID] = true
+
+		id := food.ID
+		out = append(out, models.FoodVote{FoodID: &id, FoodName: food.Name, Vote: 0})
+	}
+	return out, nil
+}
+
+// FindFoodOption หาตัวเลือกอาหารของ Program จาก foodId (หรือชื่ออาหารสำหรับ client เดิม)
+// คืน nil, nil เมื่อไม่ได้เลือกอาหาร
+func FindFoodOption(program *models.Program, foodID *primitive.ObjectID, foodName *string) (*models.FoodVote, error) {
+	if foodID == nil && (foodName == nil || strings.TrimSpace(*foodName) == "") {
+		return nil, nil
+	}
+	for i := range program.FoodVotes {
+		opt := &program.FoodVotes[i]
+		if foodID != nil {
+			if opt.FoodID != nil && *opt.FoodID == *foodID {
+				return opt, nil
+			}
+			continue
+		}
+		if strings.EqualFold(strings.TrimSpace(opt.FoodName), strings.TrimSpace(*foodName)) {
+			return opt, nil
+		}
+	}
+	return nil, errors.New("ตัวเลือกอาหารไม่อยู่ในรายการของกิจกรรมนี้")
+}
+
+// FoodDeadline คืนวันสุดท้ายที่เปลี่ยนอาหารได้ (YYYY-MM-DD) — foodDeadline ถ้ามี ไม่งั้นใช้ endDateEnroll
+func FoodDeadline(program *models.Program) string {
+	if program.FoodDeadline != "" {
+		return program.FoodDeadline
+	}
+	return program.EndDateEnroll
+}
+
+// IsFoodSelectionOpen ตรวจว่ายังอยู่ในช่วงที่เปลี่ยนอาหารได้หรือไม่ (เทียบตามวันเวลาไทย)
+func IsFoodSelectionOpen(program *models.Program, now time.Time) bool {
+	deadline := FoodDeadline(program)
+	if deadline == "" {
+		return true
+	}
+	// time.Local ถูกตั้งเป็น Asia/Bangkok ใน init() ของ package นี้
+	return now.In(time.Local).Format("2006-01-02") <= deadline
+}
+
+// RecalculateFoodVotes นับ vote ของแต่ละตัวเลือกอาหารใหม่จาก Enrollments แล้วบันทึกลง Program.foodVotes
+// ใช้หลังลงทะเบียน / ยกเลิก / เปลี่ยนอาหาร เพื่อให้ยอดตรงกับข้อมูลจริงเสมอ
+func RecalculateFoodVotes(ctx context.Context, programID primitive.ObjectID) error {
+	var program models.Program
+	if err := DB.ProgramCollection.FindOne(ctx, bson.M{"_id": programID}).Decode(&program); err != nil {
+		return err
+	}
+	if len(program.FoodVotes) == 0 {
+		return nil
+	}
+
+	cur, err := DB.EnrollmentCollection.Aggregate(ctx, bson.A{
+		bson.M{"$match": bson.M{"programId": programID, "food": bson.M{"$ne": nil}}},
+		bson.M{"$group": bson.M{
+			"_id":   bson.M{"foodId": "$foodId", "food": "$food"},
+			"count": bson.M{"$sum": 1},
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to count food votes: %w", err)
+	}
+	var rows []struct {
+		ID struct {
+			FoodID *primitive.ObjectID `bson:"foodId"`
+			Food   string              `bson:"food"`
+		} `bson:"_id"`
+		Count int `bson:"count"`
+	}
+	if err := cur.All(ctx, &rows); err != nil {
+		return err
+	}
+
+	votes := make([]models.FoodVote, len(program.FoodVotes))
+	copy(votes, program.FoodVotes)
+	for i := range votes {
+		votes[i].Vote = 0
+	}
+	for _, r := range rows {
+		// enrollment ใหม่ผูกด้วย foodId, enrollment เดิมมีแค่ชื่ออาหาร
+		var fid *primitive.ObjectID
+		if r.ID.FoodID != nil && !r.ID.FoodID.IsZero() {
+			fid = r.ID.FoodID
+		}
+		food := r.ID.Food
+		opt, _ := FindFoodOption(&models.Program{FoodVotes: votes}, fid, &food)
+		if opt != nil {
+			opt.Vote += r.Count
+		}
+	}
+
+	_, err = DB.ProgramCollection.UpdateOne(ctx,
+		bson.M{"_id": programID},
+		bson.M{"$set": bson.M{"foodVotes": votes}},
+	)
+	if err != nil {
+		return err
+	}
+
+	invalidateAllProgramsListCache()
+	delCache("program:" + programID.Hex())
+	return nil
+}
@@ -4,12 +4,12 @@ import (
 	DB "Backend-Bluelock-007/src/database"
 	"Backend-Bluelock-007/src/jobs"
 	"Backend-Bluelock-007/src/models"
+	"Backend-Bluelock-007/src/services/search"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
-	"strconv"
 	"strings"
 	"time"
 
@@ -36,32 +36,6 @@ func init() {
 
 // ===== Pipeline Helper Functions =====
 
-// 🔢 คำนวณปีการศึกษาปัจจุบัน (พ.ศ.)
-func GetCurrentAcademicYear() int {
-	now := time.Now()        // เวลาปัจจุบัน
-	year := now.Year() + 543 // แปลง ค.ศ. เป็น พ.ศ.
-
-	// ถ้ายังไม่ถึงเดือนกรกฎาคม ถือว่ายังเป็นปีการศึกษาที่แล้ว
-	if now.Month() < 7 {
-		year -= 1
-	}
-	return year % 100 // ✅ เอาเฉพาะ 2 หลักท้าย (2568 → 68)
-}
-
-// 🎯 ฟังก์ชันสำหรับสร้างเงื่อนไขการคัดกรองรหัสนิสิต
-func GenerateStudentCodeFilter(studentYears []int) []string {
-	currentYear := GetCurrentAcademicYear()
-	var codes []string
-
-	for _, year := range studentYears {
-		if year >= 1 && year <= 4 {
-			studentYearPrefix := strconv.Itoa(currentYear - (year - 1))
-			codes = append(codes, studentYearPrefix) // เพิ่ม Prefix 67, 66, 65, 64 ตามปี
-		}
-	}
-	return codes
-}
-
 // MaxEndTimeFromItem คำนวณเวลาสิ้นสุดที่มากที่สุดจาก ProgramItemDto
 func MaxEndTimeFromItem(item models.ProgramItemDto, latestTime time.Time) time.Time {
 	// Use process local timezone (set in init) for parsing/comparisons.
@@ -239,10 +213,10 @@ func getProgramsFromCache(key string) (*programsCache, error) {
 }
 
 func buildProgramsFilter(params models.PaginationParams, skills, states []string) (bson.M, bool) {
-	filter := bson.M{}
+	filter := bson.M{"deletedAt": nil} // ไม่รวมกิจกรรมที่อยู่ในถังขยะ
 	isSortNearest := false
 	if params.Search != "" {
-		searchRegex := bson.M{"$regex": params.Search, "$options": "i"}
+		searchRegex := bson.M{"$regex": search.Contains(params.Search)}
 		filter["$or"] = bson.A{
 			bson.M{"name": searchRegex},
 			bson.M{"skill": searchRegex},
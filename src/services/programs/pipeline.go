@@ -416,6 +416,8 @@ func GetAllProgramCalendarPipeline(startDateStr string, endDateStr string) mongo
 		}}},
 		// Stage E: Unwind the Program details.
 		{{Key: "$unwind", Value: "$programInfo"}}, // Use {Key: "$unwind", Value: bson.M{"path": "$programInfo", "preserveNullAndEmptyArrays": true}} if you want to keep programs that might not have items after filtering, or items whose programId doesn't match an program.
+		// ไม่รวมกิจกรรมที่อยู่ในถังขยะ
+		{{Key: "$match", Value: bson.M{"programInfo.deletedAt": nil}}},
 
 		// ท่านี้สั้น แต่อาจจะเข้าใจยากหน่อย performance น้อยกว่า เลยใช้ $project แทน
 		// {{Key: "$replaceRoot", Value: bson.M{
@@ -5,11 +5,23 @@ import (
 	"os"
 	"strings"
 
+	"Backend-Bluelock-007/src/jobs"
+	"Backend-Bluelock-007/src/services/eligibility"
+	hourhistory "Backend-Bluelock-007/src/services/hour-history"
 	emailpkg "Backend-Bluelock-007/src/services/programs/email"
 	"github.com/hibiken/asynq"
 )
 
 func RegisterProgramHandlers(mux *asynq.ServeMux) error {
+	// ✅ งานเปลี่ยนสถานะโปรแกรมผ่าน state machine (ไม่ขึ้นกับ SMTP)
+	mux.HandleFunc(jobs.TypeCloseEnroll, HandleCloseEnrollTask)
+	mux.HandleFunc(jobs.TypeCompleteProgram, HandleCompleteProgramTask)
+	mux.HandleFunc(jobs.TypeEvaluationDeadline, HandleEvaluationDeadlineTask)
+	// ✅ แก้ยอดชั่วโมงที่ cache ไว้ให้ตรงกับ ledger (ตามรอบ — ดู main.go)
+	mux.HandleFunc(jobs.TypeReconcileHourTotals, hourhistory.HandleReconcileHourTotalsTask)
+	// ✅ ประเมินชั่วโมงใหม่ของกิจกรรมที่เสร็จสิ้นแล้ว (คำนวณ diff / บันทึกหลังแอดมินยืนยัน)
+	mux.HandleFunc(jobs.TypeReverifyHours, hourhistory.HandleReverifyHoursTask)
+
 	sender, err := emailpkg.NewSMTPSenderFromEnv()
 	if err != nil {
 		return err
@@ -29,7 +41,7 @@ func RegisterProgramHandlers(mux *asynq.ServeMux) error {
 			sender,
 			registerURL,
 			GetProgramByID,
-			GenerateStudentCodeFilter,
+			eligibility.Evaluate,
 		),
 	)
 
@@ -52,5 +64,26 @@ func RegisterProgramHandlers(mux *asynq.ServeMux) error {
 		),
 	)
 
+	// ✅ แจ้งยกเลิก / เลื่อนกิจกรรม (นิสิตที่ลงทะเบียนแล้ว)
+	mux.HandleFunc(
+		emailpkg.TypeNotifyProgramChanged,
+		emailpkg.HandleNotifyProgramChanged(
+			sender,
+			GetProgramByID,
+		),
+	)
+
+	// ✅ เตือนส่งแบบประเมิน (นิสิตที่ชั่วโมงยังรอแบบประเมิน)
+	mux.HandleFunc(
+		emailpkg.TypeNotifyEvaluationReminder,
+		emailpkg.HandleNotifyEvaluationReminder(sender),
+	)
+
+	// ✅ แจ้งผลพิจารณาคำร้องขอทบทวนชั่วโมง (นิสิตเจ้าของคำร้อง)
+	mux.HandleFunc(
+		jobs.TypeNotifyAppealDecision,
+		emailpkg.HandleNotifyAppealDecision(sender),
+	)
+
 	return nil
 }
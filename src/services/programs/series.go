@@ -0,0 +1,290 @@
+package programs
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ========================================
+// Program Series - กิจกรรมที่จัดซ้ำตาม RecurrenceRule
+// ========================================
+
+var (
+	ErrProgramSeriesNotFound = errors.New("program series not found")
+	ErrInvalidRecurrenceRule = errors.New("invalid recurrence rule")
+)
+
+// maxSeriesOccurrences จำนวนครั้งสูงสุดของหนึ่ง series (ประมาณ 1 ปีของกิจกรรมรายสัปดาห์)
+const maxSeriesOccurrences = 52
+
+// GenerateRecurrenceDates สร้างวันเริ่มของแต่ละครั้งตามกฎ (เรียงจากน้อยไปมาก ไม่ซ้ำ)
+// defaultStart ใช้เมื่อกฎ weekly ไม่ระบุ startDate
+func GenerateRecurrenceDates(rule models.RecurrenceRule, defaultStart string) ([]string, error) {
+	switch strings.ToLower(strings.TrimSpace(rule.Frequency)) {
+	case models.RecurrenceWeekly:
+		start := rule.StartDate
+		if start == "" {
+			start = defaultStart
+		}
+		startTime, err := time.ParseInLocation("2006-01-02", start, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: startDate ต้องอยู่ในรูปแบบ YYYY-MM-DD", ErrInvalidRecurrenceRule)
+		}
+		if rule.Count <= 0 && rule.Until == "" {
+			return nil, fmt.Errorf("%w: ต้องระบุ count หรือ until", ErrInvalidRecurrenceRule)
+		}
+		var until time.Time
+		if rule.Until != "" {
+			if until, err = time.ParseInLocation("2006-01-02", rule.Until, time.Local); err != nil {
+				return nil, fmt.Errorf("%w: until ต้องอยู่ในรูปแบบ YYYY-MM-DD", ErrInvalidRecurrenceRule)
+			}
+		}
+		interval := rule.Interval
+		if interval <= 0 {
+			interval = 1
+		}
+
+		var dates []string
+		for d := startTime; ; d = d.AddDate(0, 0, 7*interval) {
+			if rule.Count > 0 && len(dates) >= rule.Count {
+				break
+			}
+			if !until.IsZero() && d.After(until) {
+				break
+			}
+			if len(dates) >= maxSeriesOccurrences {
+				return nil, fmt.Errorf("%w: สร้างได้ไม่เกิน %d ครั้ง", ErrInvalidRecurrenceRule, maxSeriesOccurrences)
+			}
+			dates = append(dates, d.Format("2006-01-02"))
+		}
+		if len(dates) == 0 {
+			return nil, fmt.Errorf("%w: ไม่มีวันจัดในช่วงที่กำหนด", ErrInvalidRecurrenceRule)
+		}
+		return dates, nil
+
+	case models.RecurrenceCustom:
+		seen := map[string]bool{}
+		var dates []string
+		for _, d := range rule.Dates {
+			if _, err := time.ParseInLocation("2006-01-02", d, time.Local); err != nil {
+				return nil, fmt.Errorf("%w: วัน %q ต้องอยู่ในรูปแบบ YYYY-MM-DD", ErrInvalidRecurrenceRule, d)
+			}
+			if !seen[d] {
+				seen[d] = true
+				dates = append(dates, d)
+			}
+		}
+		if len(dates) == 0 {
+			return nil, fmt.Errorf("%w: ต้องระบุ dates อย่างน้อย 1 วัน", ErrInvalidRecurrenceRule)
+		}
+		if len(dates) > maxSeriesOccurrences {
+			return nil, fmt.Errorf("%w: สร้างได้ไม่เกิน %d ครั้ง", ErrInvalidRecurrenceRule, maxSeriesOccurrences)
+		}
+		sort.Strings(dates)
+		return dates, nil
+	}
+	return nil, fmt.Errorf("%w: frequency ต้องเป็น weekly หรือ custom", ErrInvalidRecurrenceRule)
+}
+
+// CreateProgramSeries สร้าง series จากกิจกรรมต้นแบบ
+// แต่ละวันที่ได้จากกฎ = กิจกรรมใหม่ 1 รายการ (คัดลอกจากต้นแบบแล้วเลื่อนวันตามระยะห่างจากวันแรกของต้นแบบ)
+// ถ้าวันที่ได้ตรงกับวันแรกของต้นแบบ กิจกรรมต้นแบบจะถูกนับเป็นครั้งนั้นของ series แทนการคัดลอก
+func CreateProgramSeries(ctx context.Context, input models.CreateProgramSeriesInput, actor string) (*models.ProgramSeriesDetail, error) {
+	sourceID, err := primitive.ObjectIDFromHex(input.SourceProgramID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: sourceProgramId ไม่ถูกต้อง", ErrInvalidRecurrenceRule)
+	}
+	source, err := loadProgramWithItems(ctx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	if source.DeletedAt != nil {
+		return nil, ErrProgramNotFound
+	}
+	if source.SeriesID != nil {
+		return nil, fmt.Errorf("%w: กิจกรรมต้นแบบอยู่ใน series อื่นแล้ว", ErrInvalidRecurrenceRule)
+	}
+	sourceFirst := firstProgramDate(source)
+	if sourceFirst == "" {
+		return nil, fmt.Errorf("%w: กิจกรรมต้นแบบต้องมีวันจัดอย่างน้อย 1 วัน", ErrInvalidRecurrenceRule)
+	}
+
+	dates, err := GenerateRecurrenceDates(input.Rule, sourceFirst)
+	if err != nil {
+		return nil, err
+	}
+
+	series := models.ProgramSeries{
+		ID:              primitive.NewObjectID(),
+		Name:            strings.TrimSpace(input.Name),
+		SourceProgramID: sourceID,
+		Rule:            input.Rule,
+		CreatedBy:       actor,
+		CreatedAt:       time.Now(),
+	}
+	if series.Name == "" && source.Name != nil {
+		series.Name = *source.Name
+	}
+	if _, err := DB.ProgramSeriesCollection.InsertOne(ctx, series); err != nil {
+		return nil, err
+	}
+
+	for i, date := range dates {
+		shift, err := daysBetween(sourceFirst, date)
+		if err != nil {
+			return nil, err
+		}
+		if shift == 0 {
+			if _, err := DB.ProgramCollection.UpdateOne(ctx,
+				bson.M{"_id": sourceID},
+				bson.M{"$set": bson.M{"seriesId": series.ID, "seriesIndex": i + 1}},
+			); err != nil {
+				return nil, err
+			}
+			delCache("program:" + sourceID.Hex())
+			continue
+		}
+
+		program, err := cloneProgramDto(ctx, source, shift)
+		if err != nil {
+			return nil, err
+		}
+		program.SeriesID = &series.ID
+		program.SeriesIndex = i + 1
+		if _, err := CreateProgram(program, actor); err != nil {
+			return nil, fmt.Errorf("สร้างครั้งที่ %d (%s) ไม่สำเร็จ: %w", i+1, date, err)
+		}
+	}
+	invalidateAllProgramsListCache()
+
+	return GetProgramSeries(ctx, series.ID)
+}
+
+// GetProgramSeries ดึง series พร้อมกิจกรรมที่ยังไม่ถูกลบ (เรียงตาม seriesIndex)
+func GetProgramSeries(ctx context.Context, seriesID primitive.ObjectID) (*models.ProgramSeriesDetail, error) {
+	var detail models.ProgramSeriesDetail
+	if err := DB.ProgramSeriesCollection.FindOne(ctx, bson.M{"_id": seriesID}).Decode(&detail.ProgramSeries); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrProgramSeriesNotFound
+		}
+		return nil, err
+	}
+
+	ids, err := seriesProgramIDs(ctx, seriesID, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	detail.Programs = make([]models.ProgramDto, 0, len(ids))
+	for _, id := range ids {
+		program, err := GetProgramByID(id.Hex())
+		if err != nil {
+			return nil, err
+		}
+		detail.Programs = append(detail.Programs, *program)
+	}
+	return &detail, nil
+}
+
+// UpdateProgramInSeries แก้ไขกิจกรรมที่อยู่ใน series
+//   - scope "this" (หรือกิจกรรมไม่อยู่ใน series) = UpdateProgram ปกติ
+//   - scope "all" = แก้กิจกรรมนี้ แล้วนำข้อมูลที่ไม่ใช่วันไปใช้กับทุกครั้งใน series ที่ยังไม่จบ (ไม่ใช่ success / cancelled)
+//     กิจกรรมย่อยจับคู่ตามลำดับ แต่ละครั้งคงวันจัด, วันปิดรับสมัคร, วันปิดเลือกอาหาร, สถานะ และฟอร์มของตัวเอง
+func UpdateProgramInSeries(ctx context.Context, programID primitive.ObjectID, program models.ProgramDto, scope, actor string) ([]models.ProgramDto, error) {
+	target, err := loadProgramWithItems(ctx, programID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := UpdateProgram(programID, program, actor)
+	if err != nil {
+		return nil, err
+	}
+	result := []models.ProgramDto{*updated}
+	if scope != models.SeriesEditAll || target.SeriesID == nil {
+		return result, nil
+	}
+
+	ids, err := seriesProgramIDs(ctx, *target.SeriesID, bson.M{
+		"_id":          bson.M{"$ne": programID},
+		"programState": bson.M{"$nin": []string{models.ProgramStateSuccess, models.ProgramStateCancelled}},
+	})
+	if err != nil {
+		return result, err
+	}
+	targetFirst := firstProgramDate(target)
+
+	for _, id := range ids {
+		occurrence, err := loadProgramWithItems(ctx, id)
+		if err != nil {
+			return result, err
+		}
+		shift := 0
+		if first := firstProgramDate(occurrence); targetFirst != "" && first != "" {
+			shift, _ = daysBetween(targetFirst, first)
+		}
+
+		dto := program
+		dto.ID = occurrence.ID
+		dto.FormID = occurrence.FormID
+		dto.ProgramState = occurrence.ProgramState
+		dto.EndDateEnroll = occurrence.EndDateEnroll
+		dto.FoodDeadline = occurrence.FoodDeadline
+		dto.ProgramItems = make([]models.ProgramItemDto, 0, len(program.ProgramItems))
+		for i, item := range program.ProgramItems {
+			if i < len(occurrence.ProgramItems) {
+				item.ID = occurrence.ProgramItems[i].ID
+				item.Dates = occurrence.ProgramItems[i].Dates
+			} else {
+				item.ID = primitive.NilObjectID
+				item.Dates = shiftDates(item.Dates, shift)
+			}
+			item.ProgramID = occurrence.ID
+			dto.ProgramItems = append(dto.ProgramItems, item)
+		}
+
+		updated, err := UpdateProgram(occurrence.ID, dto, actor)
+		if err != nil {
+			return result, fmt.Errorf("อัปเดตครั้งที่ %d ของ series ไม่สำเร็จ: %w", occurrence.SeriesIndex, err)
+		}
+		result = append(result, *updated)
+	}
+	return result, nil
+}
+
+// seriesProgramIDs ID ของกิจกรรมใน series ที่ยังไม่ถูกลบ (เรียงตาม seriesIndex) พร้อมเงื่อนไขเพิ่มเติม
+func seriesProgramIDs(ctx context.Context, seriesID primitive.ObjectID, extra bson.M) ([]primitive.ObjectID, error) {
+	filter := bson.M{"seriesId": seriesID, "deletedAt": nil}
+	for k, v := range extra {
+		filter[k] = v
+	}
+	cur, err := DB.ProgramCollection.Find(ctx, filter,
+		options.Find().
+			SetSort(bson.D{{Key: "seriesIndex", Value: 1}}).
+			SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cur.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	ids := make([]primitive.ObjectID, 0, len(docs))
+	for _, d := range docs {
+		ids = append(ids, d.ID)
+	}
+	return ids, nil
+}
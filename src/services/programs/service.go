@@ -5,7 +5,7 @@ import (
 	"Backend-Bluelock-007/src/models"
 	hourhistory "Backend-Bluelock-007/src/services/hour-history"
 	"Backend-Bluelock-007/src/services/programs/email"
-	"strings"
+	"Backend-Bluelock-007/src/services/rooms"
 
 	// "Backend-Bluelock-007/src/services/programs/email"
 	"Backend-Bluelock-007/src/services/summary_reports"
@@ -22,6 +22,7 @@ import (
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -71,7 +72,7 @@ func invalidateAllProgramsListCache() {
 
 var ctx = context.Background()
 
-func CreateProgram(program *models.ProgramDto) (*models.ProgramDto, error) {
+func CreateProgram(program *models.ProgramDto, actor string) (*models.ProgramDto, error) {
 	defer invalidateAllProgramsListCache()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -79,16 +80,58 @@ func CreateProgram(program *models.ProgramDto) (*models.ProgramDto, error) {
 
 	program.ID = primitive.NewObjectID()
 
+	// ✅ กิจกรรมใหม่เริ่มได้เฉพาะ planning หรือ open (open ต้องผ่านเงื่อนไขของ state machine)
+	program.ProgramState = NormalizeProgramState(program.ProgramState)
+	if program.ProgramState == "" {
+		program.ProgramState = models.ProgramStatePlanning
+	}
+	if program.ProgramState != models.ProgramStatePlanning && program.ProgramState != models.ProgramStateOpen {
+		return nil, fmt.Errorf("%w: กิจกรรมใหม่ต้องมีสถานะ planning หรือ open", ErrInvalidProgramTransition)
+	}
+	// ✅ กิจกรรมใหม่เริ่มเป็นร่างเสมอ → สร้างเป็น open ทันทีไม่ได้จนกว่าจะผ่านการอนุมัติ
+	program.ApprovalStatus = models.ProgramApprovalDraft
+	program.SubmittedBy, program.SubmittedAt = "", nil
+	program.ApprovedBy, program.ApprovedAt, program.ApprovalHash = "", nil, ""
+	if program.ProgramState == models.ProgramStateOpen {
+		if err := checkApprovedForOpen(program); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkStatePreconditions(program, program.ProgramState); err != nil {
+		return nil, err
+	}
+
+	// ✅ ผูกห้องกับแคตตาล็อก ตรวจความจุ และตรวจการจองห้องซ้อน
+	if err := rooms.ValidateProgramItems(ctx, program.ID, program.ProgramItems); err != nil {
+		return nil, err
+	}
+
+	// ✅ ผูกตัวเลือกอาหารกับ Foods (vote เริ่มที่ 0 เสมอ)
+	foodVotes, err := NormalizeFoodOptions(ctx, program.FoodVotes)
+	if err != nil {
+		return nil, err
+	}
+	program.FoodVotes = foodVotes
+
 	programToInsert := models.Program{
-		ID:            program.ID,
-		FormID:        program.FormID,
-		Name:          program.Name,
-		Type:          program.Type,
-		ProgramState:  program.ProgramState,
-		Skill:         program.Skill,
-		File:          program.File,
-		FoodVotes:     program.FoodVotes,
-		EndDateEnroll: program.EndDateEnroll,
+		ID:                 program.ID,
+		FormID:             program.FormID,
+		Name:               program.Name,
+		Type:               program.Type,
+		ProgramState:       program.ProgramState,
+		Skill:              program.Skill,
+		File:               program.File,
+		FoodVotes:          program.FoodVotes,
+		FoodDeadline:       program.FoodDeadline,
+		EndDateEnroll:      program.EndDateEnroll,
+		SeriesID:           program.SeriesID,
+		SeriesIndex:        program.SeriesIndex,
+		TemplateID:         program.TemplateID,
+		TemplateVersion:    program.TemplateVersion,
+		ApprovalStatus:     program.ApprovalStatus,
+		HourPolicy:         program.HourPolicy,
+		EvaluationRequired: program.EvaluationRequired,
+		EvaluationDays:     program.EvaluationDays,
 	}
 
 	if _, err := DB.ProgramCollection.InsertOne(ctx, programToInsert); err != nil {
@@ -96,23 +139,24 @@ func CreateProgram(program *models.ProgramDto) (*models.ProgramDto, error) {
 	}
 
 	var itemsToInsert []any
-	var latestTime time.Time
 
 	for _, item := range program.ProgramItems {
 		itemsToInsert = append(itemsToInsert, models.ProgramItem{
-			ID:              primitive.NewObjectID(),
-			ProgramID:       program.ID,
-			Name:            item.Name,
-			Description:     item.Description,
-			StudentYears:    item.StudentYears,
-			MaxParticipants: item.MaxParticipants,
-			Majors:          item.Majors,
-			Rooms:           item.Rooms,
-			Operator:        item.Operator,
-			Dates:           item.Dates,
-			Hour:            item.Hour,
+			ID:               primitive.NewObjectID(),
+			ProgramID:        program.ID,
+			Name:             item.Name,
+			Description:      item.Description,
+			StudentYears:     item.StudentYears,
+			MaxParticipants:  item.MaxParticipants,
+			Majors:           item.Majors,
+			Rooms:            item.Rooms,
+			Operator:         item.Operator,
+			Dates:            item.Dates,
+			Hour:             item.Hour,
+			EligibilityRules: item.EligibilityRules,
+			RoomIDs:          item.RoomIDs,
+			HourPolicy:       item.HourPolicy,
 		})
-		latestTime = MaxEndTimeFromItem(item, latestTime)
 	}
 
 	if len(itemsToInsert) > 0 {
@@ -121,23 +165,10 @@ func CreateProgram(program *models.ProgramDto) (*models.ProgramDto, error) {
 		}
 	}
 
-	// ⏱️ 2) ตั้ง schedule เปลี่ยนสถานะ (close-enroll / success)
-	if DB.AsynqClient != nil && program.ProgramState == "open" {
-		progName := ""
-		if program.Name != nil {
-			progName = *program.Name
-		}
-		if err := ScheduleChangeProgramStateJob(
-			DB.AsynqClient,
-			DB.RedisURI,
-			latestTime,
-			program.EndDateEnroll,
-			program.ID.Hex(),
-			progName,
-		); err != nil {
-			log.Println("❌ Failed to schedule state transitions:", err)
-			// ไม่ return error เพื่อไม่ให้การสร้างโปรแกรม fail
-		}
+	// ⏱️ 2) บันทึกสถานะเริ่มต้น + side effect ของสถานะ open (schedule close-enroll / success, แจ้งเปิดลงทะเบียน)
+	recordProgramStateHistory(ctx, program.ID, "", program.ProgramState, actor, "สร้างกิจกรรม", false)
+	if program.ProgramState == models.ProgramStateOpen {
+		onEnterProgramState(ctx, program, "", program.ProgramState, actor, "สร้างกิจกรรม")
 	}
 
 	return GetProgramByID(program.ID.Hex())
@@ -372,7 +403,8 @@ func GetProgramItemsByProgramID(programID primitive.ObjectID) ([]models.ProgramI
 	return programItems, nil
 }
 
-func UpdateProgram(id primitive.ObjectID, program models.ProgramDto) (*models.ProgramDto, error) {
+// UpdateProgram แก้ไข Program + ProgramItems; การเปลี่ยน programState จะผ่าน state machine (TransitionProgramState)
+func UpdateProgram(id primitive.ObjectID, program models.ProgramDto, actor string) (*models.ProgramDto, error) {
 	defer func() {
 		invalidateAllProgramsListCache()
 		delCache("program:" + id.Hex())
@@ -381,10 +413,13 @@ func UpdateProgram(id primitive.ObjectID, program models.ProgramDto) (*models.Pr
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Get the old program to compare states and dates
+	// Get the old program to compare states and dates (กิจกรรมที่อยู่ในถังขยะแก้ไขไม่ได้)
 	var oldProgram models.ProgramDto
-	err := DB.ProgramCollection.FindOne(ctx, bson.M{"_id": id}).Decode(&oldProgram)
+	err := DB.ProgramCollection.FindOne(ctx, bson.M{"_id": id, "deletedAt": nil}).Decode(&oldProgram)
 	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrProgramNotFound
+		}
 		return nil, err
 	}
 
@@ -406,24 +441,67 @@ func UpdateProgram(id primitive.ObjectID, program models.ProgramDto) (*models.Pr
 	}
 	oldProgram.ProgramItems = oldProgramItemDtos
 
+	// ✅ ตรวจการเปลี่ยนสถานะก่อนบันทึกอะไรทั้งสิ้น (ตาราง transition + เงื่อนไขของข้อมูลใหม่)
+	oldState := NormalizeProgramState(oldProgram.ProgramState)
+	newState := NormalizeProgramState(program.ProgramState)
+	if newState == "" {
+		newState = oldState
+	}
+	// สถานะการอนุมัติเปลี่ยนผ่าน approval API เท่านั้น (ไม่รับจาก body)
+	program.ApprovalStatus, program.ApprovalHash = oldProgram.ApprovalStatus, oldProgram.ApprovalHash
+	program.SubmittedBy, program.SubmittedAt = oldProgram.SubmittedBy, oldProgram.SubmittedAt
+	program.ApprovedBy, program.ApprovedAt = oldProgram.ApprovedBy, oldProgram.ApprovedAt
+	if err := ValidateProgramTransition(&program, oldState, newState, false); err != nil {
+		return nil, err
+	}
+	if newState != models.ProgramStateCancelled {
+		if err := rooms.ValidateProgramItems(ctx, id, program.ProgramItems); err != nil {
+			return nil, err
+		}
+	}
+
+	// ✅ ผูกตัวเลือกอาหารกับ Foods (vote จะถูกนับใหม่จาก Enrollments หลังอัปเดต)
+	foodVotes, err := NormalizeFoodOptions(ctx, program.FoodVotes)
+	if err != nil {
+		return nil, err
+	}
+	program.FoodVotes = foodVotes
+
 	// ✅ อัปเดต Program หลัก
 	update := bson.M{
 		"$set": bson.M{
-			"name":          program.Name,
-			"formId":        program.FormID,
-			"type":          program.Type,
-			"programState":  program.ProgramState,
-			"skill":         program.Skill,
-			"file":          program.File,
-			"foodVotes":     program.FoodVotes,
-			"endDateEnroll": program.EndDateEnroll,
+			"name":               program.Name,
+			"formId":             program.FormID,
+			"type":               program.Type,
+			"skill":              program.Skill,
+			"file":               program.File,
+			"foodVotes":          program.FoodVotes,
+			"foodDeadline":       program.FoodDeadline,
+			"endDateEnroll":      program.EndDateEnroll,
+			"hourPolicy":         program.HourPolicy,
+			"evaluationRequired": program.EvaluationRequired,
+			"evaluationDays":     program.EvaluationDays,
 		},
+		// 🗓️ ทุกการแก้ไขต้องเพิ่ม SEQUENCE ของปฏิทิน (แม้ไม่เปลี่ยนสถานะ)
+		"$inc": bson.M{"revision": 1},
 	}
 
-	_, err = DB.ProgramCollection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	res, err := DB.ProgramCollection.UpdateOne(ctx, bson.M{"_id": id, "deletedAt": nil}, update)
 	if err != nil {
 		return nil, err
 	}
+	if res.MatchedCount == 0 {
+		return nil, ErrProgramNotFound // ถูกย้ายไปถังขยะระหว่างแก้ไข
+	}
+	if err := RecalculateFoodVotes(ctx, id); err != nil {
+		log.Printf("⚠️ Warning: Failed to recalculate food votes for program %s: %v", id.Hex(), err)
+	}
+
+	// ✅ แก้ไขเนื้อหาหลังส่งอนุมัติ / อนุมัติแล้ว (ยังไม่เปิดลงทะเบียน) → กลับเป็นร่าง ต้องส่งใหม่
+	if oldState == models.ProgramStatePlanning && newState == models.ProgramStatePlanning &&
+		oldProgram.ApprovalHash != "" && ProgramContentHash(&program) != oldProgram.ApprovalHash {
+		resetProgramApproval(ctx, &oldProgram, actor, "แก้ไขกิจกรรมหลังส่งอนุมัติ")
+	}
 
 	// ✅ ดึงรายการ `ProgramItems` ที่มีอยู่
 	var existingItems []models.ProgramItem
@@ -466,16 +544,19 @@ func UpdateProgram(id primitive.ObjectID, program models.ProgramDto) (*models.Pr
 			_, err := DB.ProgramItemCollection.UpdateOne(ctx,
 				bson.M{"_id": newItem.ID},
 				bson.M{"$set": bson.M{
-					"programId":       newItem.ProgramID,
-					"name":            newItem.Name,
-					"description":     newItem.Description,
-					"maxParticipants": newItem.MaxParticipants,
-					"rooms":           newItem.Rooms,
-					"dates":           newItem.Dates,
-					"hour":            newItem.Hour,
-					"operator":        newItem.Operator,
-					"studentYears":    newItem.StudentYears,
-					"majors":          newItem.Majors,
+					"programId":        newItem.ProgramID,
+					"name":             newItem.Name,
+					"description":      newItem.Description,
+					"maxParticipants":  newItem.MaxParticipants,
+					"rooms":            newItem.Rooms,
+					"dates":            newItem.Dates,
+					"hour":             newItem.Hour,
+					"operator":         newItem.Operator,
+					"studentYears":     newItem.StudentYears,
+					"majors":           newItem.Majors,
+					"eligibilityRules": newItem.EligibilityRules,
+					"roomIds":          newItem.RoomIDs,
+					"hourPolicy":       newItem.HourPolicy,
 				}},
 			)
 			if err != nil {
@@ -515,7 +596,7 @@ func UpdateProgram(id primitive.ObjectID, program models.ProgramDto) (*models.Pr
 		}
 
 		// 4) ลบ Hour Change Histories ที่เกี่ยวข้องกับ ProgramItems เหล่านี้
-		if _, err := DB.HourChangeHistoryCollection.DeleteMany(ctx, bson.M{"enrollmentId": bson.M{"$in": itemsToDelete}}); err != nil {
+		if _, err := hourhistory.VoidHistories(ctx, bson.M{"programItemId": bson.M{"$in": itemsToDelete}}, actor, "ลบกิจกรรมย่อย"); err != nil {
 			log.Printf("⚠️ Warning: Failed to delete hour change histories for programItems: %v", err)
 		}
 
@@ -598,96 +679,130 @@ func UpdateProgram(id primitive.ObjectID, program models.ProgramDto) (*models.Pr
 		log.Printf("✅ Deleted %d program items and related data for program %s", len(itemsToDelete), id.Hex())
 	}
 
-	// Handle scheduling of state transitions based on program state changes
-	if DB.AsynqClient != nil {
-		stateChanged := oldProgram.ProgramState != program.ProgramState
+	// ✅ เปลี่ยนสถานะผ่าน state machine (บันทึกประวัติ + schedule jobs / อีเมล / ให้ชั่วโมง ตามสถานะใหม่)
+	if newState != oldState {
+		if _, err := TransitionProgramState(ctx, id, newState, actor, "แก้ไขข้อมูลกิจกรรม", false); err != nil {
+			return nil, err
+		}
+	} else if newState == models.ProgramStateOpen && DB.AsynqClient != nil {
+		// สถานะยัง open แต่วันปิดรับสมัคร/กิจกรรมย่อยเปลี่ยน → ตั้ง schedule ใหม่
 		datesChanged := oldProgram.EndDateEnroll != program.EndDateEnroll
 		itemsChanged := len(program.ProgramItems) != len(oldProgram.ProgramItems)
-
-		fmt.Println("State changed:", stateChanged)
-
-		// Case 1: Program is set to "open" (either newly or was something else before)
-		if program.ProgramState == "open" {
-			// Schedule state transitions when:
-			// - State changed to "open" from something else
-			// - State was already "open" but dates or items changed
-			if stateChanged || datesChanged || itemsChanged {
-				log.Println("✅ Scheduling state transitions for program:", id.Hex())
-				programName := ""
-				if program.Name != nil {
-					programName = *program.Name
-				}
-				err = ScheduleChangeProgramStateJob(DB.AsynqClient, DB.RedisURI, latestTime, program.EndDateEnroll, id.Hex(), programName)
-
-				if err != nil {
-					log.Println("❌ Failed to schedule state transitions:", err)
-					return nil, err
-				}
-			}
-		} else if stateChanged && (oldProgram.ProgramState == "open" && program.ProgramState == "planning") {
-			// Case 2: Program was "open" to "planning" but manually changed to something else
-			// Delete any scheduled jobs since manual intervention takes precedence
-			programIDHex := id.Hex()
-			DeleteTask("close-enroll-"+programIDHex, programIDHex, DB.RedisURI)
-			log.Println("✅ Removed scheduled jobs due to manual state change for program:", programIDHex)
-		} else if stateChanged && oldProgram.ProgramState == "close" && program.ProgramState == "success" {
-			programIDHex := id.Hex()
-			DeleteTask("complete-program-"+programIDHex, programIDHex, DB.RedisURI)
-			log.Println("✅ Ensured no scheduled jobs for success program:", programIDHex)
-
-			if err := hourhistory.ProcessEnrollmentsForCompletedProgram(ctx, id); err != nil {
-				log.Printf("⚠️ Warning: failed to process enrollments for program %s: %v", id.Hex(), err)
-				// allow continue
-			}
-
-			// ✅ ส่งอีเมลแบบที่ 3: Completed
-			progName := ""
+		if datesChanged || itemsChanged {
+			programName := ""
 			if program.Name != nil {
-				progName = *program.Name
+				programName = *program.Name
+			}
+			if err := ScheduleChangeProgramStateJob(DB.AsynqClient, DB.RedisURI, latestTime, program.EndDateEnroll, id.Hex(), programName); err != nil {
+				log.Println("❌ Failed to schedule state transitions:", err)
+				return nil, err
 			}
-			email.NotifyStudentsOnCompleted(
-				programIDHex,
-				progName,
-				GetProgramByID,
-			)
 		}
+	}
+
+	updated, err := GetProgramByID(id.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if updated.ProgramState != models.ProgramStateCancelled {
+		email.ScheduleReminderJobs(updated)
+	}
+
+	// ✅ ดึงข้อมูล Program ที่เพิ่งสร้างเสร็จกลับมาให้ Response ✅
+	return GetProgramByID(id.Hex())
+}
+
+// DeleteProgram - ย้ายกิจกรรมไปถังขยะ (soft delete) โดยเก็บ Enrollments / Hour_Change_Histories ไว้ทั้งหมด
+func DeleteProgram(id primitive.ObjectID, actor string) error {
+	defer func() {
+		invalidateAllProgramsListCache()
+		delCache("program:" + id.Hex())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
+	res, err := DB.ProgramCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "deletedAt": nil},
+		bson.M{"$set": bson.M{"deletedAt": time.Now(), "deletedBy": actor}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrProgramNotFound
 	}
 
-	newState := strings.ToLower(program.ProgramState)
-	oldState := strings.ToLower(oldProgram.ProgramState)
+	// ยกเลิก scheduled jobs ทั้งหมดของกิจกรรมที่อยู่ในถังขยะ
+	program, err := findProgramWithItems(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	deleteProgramJobs(program)
+	return nil
+}
 
-	if oldState != "open" && newState == "open" {
-		progName := ""
-		if program.Name != nil {
-			progName = *program.Name
-		}
+// RestoreProgram - กู้คืนกิจกรรมจากถังขยะ และตั้ง schedule ใหม่ถ้ากิจกรรมยังเปิดอยู่
+func RestoreProgram(id primitive.ObjectID) error {
+	defer func() {
+		invalidateAllProgramsListCache()
+		delCache("program:" + id.Hex())
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
 
-		email.NotifyStudentsOnOpen(
-			id.Hex(),
-			progName,
-			GetProgramByID,
-			GenerateStudentCodeFilter,
-		)
+	program, err := findProgramWithItems(ctx, bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}})
+	if err != nil {
+		if err == ErrProgramNotFound {
+			return models.ErrNotInTrash
+		}
+		return err
+	}
+	// ห้องของกิจกรรมในถังขยะถูกจองต่อได้ → ต้องว่างก่อนกู้คืน (กิจกรรมที่ยกเลิกแล้วไม่ได้ใช้ห้อง)
+	if NormalizeProgramState(program.ProgramState) != models.ProgramStateCancelled {
+		conflicts, err := rooms.FindConflicts(ctx, id, program.ProgramItems)
+		if err != nil {
+			return err
+		}
+		if len(conflicts) > 0 {
+			return &rooms.ConflictError{Conflicts: conflicts}
+		}
 	}
 
-	updated, err := GetProgramByID(id.Hex())
+	res, err := DB.ProgramCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}},
+		bson.M{"$unset": bson.M{"deletedAt": "", "deletedBy": ""}},
+	)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return models.ErrNotInTrash
 	}
-	email.ScheduleReminderJobs(updated)
 
-	// ✅ ดึงข้อมูล Program ที่เพิ่งสร้างเสร็จกลับมาให้ Response ✅
-	return GetProgramByID(id.Hex())
+	switch NormalizeProgramState(program.ProgramState) {
+	case models.ProgramStateOpen, models.ProgramStateClose:
+		scheduleProgramStateJobs(program)
+		email.ScheduleReminderJobs(program)
+	}
+	return nil
 }
 
-// DeleteProgram - ลบกิจกรรมและ ProgramItems ที่เกี่ยวข้อง
-func DeleteProgram(id primitive.ObjectID) error {
+// PurgeProgram - ลบกิจกรรมถาวร พร้อม ProgramItems, Enrollments และ Hour_Change_Histories ที่เกี่ยวข้อง
+// ลบได้เฉพาะกิจกรรมที่อยู่ในถังขยะแล้วเท่านั้น
+func PurgeProgram(id primitive.ObjectID, actor string) error {
 	defer func() {
 		invalidateAllProgramsListCache()
 		delCache("program:" + id.Hex())
 	}()
 
+	if n, err := DB.ProgramCollection.CountDocuments(ctx, bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}}); err != nil {
+		return err
+	} else if n == 0 {
+		return models.ErrNotInTrash
+	}
+
 	// 1) หา ProgramItem IDs ทั้งหมดของโปรแกรมนี้
 	itemCursor, err := DB.ProgramItemCollection.Find(ctx, bson.M{"programId": id}, options.Find().SetProjection(bson.M{"_id": 1}))
 	if err != nil {
@@ -718,7 +833,7 @@ func DeleteProgram(id primitive.ObjectID) error {
 	}
 
 	// 4) ลบประวัติการเปลี่ยนแปลงชั่วโมงที่มาจากโปรแกรมนี้
-	if _, err := DB.HourChangeHistoryCollection.DeleteMany(ctx, bson.M{"sourceType": "program", "sourceId": id}); err != nil {
+	if _, err := hourhistory.VoidHistories(ctx, bson.M{"sourceType": "program", "sourceId": id}, actor, "ลบกิจกรรมถาวร"); err != nil {
 		return err
 	}
 
@@ -734,14 +849,5 @@ func DeleteProgram(id primitive.ObjectID) error {
 		return err
 	}
 
-	// 7) ลบ scheduled jobs ที่เกี่ยวข้องกับ program นี้
-	if DB.RedisURI != "" {
-		programIDHex := id.Hex()
-		// ลบ task ที่เกี่ยวข้องโดยใช้ task ID ที่ถูกต้อง
-		DeleteTask("complete-program-"+programIDHex, programIDHex, DB.RedisURI)
-		DeleteTask("close-enroll-"+programIDHex, programIDHex, DB.RedisURI)
-		log.Println("✅ Deleted scheduled jobs for program:", programIDHex)
-	}
-
 	return nil
 }
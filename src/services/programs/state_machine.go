@@ -0,0 +1,304 @@
+package programs
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"Backend-Bluelock-007/src/services/eligibility"
+	hourhistory "Backend-Bluelock-007/src/services/hour-history"
+	"Backend-Bluelock-007/src/services/programs/email"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ========================================
+// Program state machine
+// planning ⇄ open → close → success  (close → open = เปิดรับสมัครอีกครั้ง)
+// planning / open / close → cancelled (ยกเลิกกิจกรรม)
+// planning → open ต้องผ่านการอนุมัติก่อน (ดู approval.go)
+// ========================================
+
+var (
+	ErrProgramNotFound          = errors.New("program not found")
+	ErrInvalidProgramTransition = errors.New("invalid program state transition")
+	ErrProgramStateConflict     = errors.New("program state was changed by another request, please retry")
+)
+
+// programTransitions สถานะปลายทางที่อนุญาตจากแต่ละสถานะ (นอกเหนือจากนี้ต้องใช้ override)
+var programTransitions = map[string][]string{
+	models.ProgramStatePlanning:  {models.ProgramStateOpen, models.ProgramStateCancelled},
+	models.ProgramStateOpen:      {models.ProgramStatePlanning, models.ProgramStateClose, models.ProgramStateSuccess, models.ProgramStateCancelled},
+	models.ProgramStateClose:     {models.ProgramStateOpen, models.ProgramStateSuccess, models.ProgramStateCancelled},
+	models.ProgramStateSuccess:   {}, // จบแล้ว: ย้อนกลับได้เฉพาะ override
+	models.ProgramStateCancelled: {}, // ยกเลิกแล้ว: กู้คืนได้เฉพาะ override
+}
+
+// NormalizeProgramState แปลงสถานะเป็นตัวพิมพ์เล็กและตัดช่องว่าง
+func NormalizeProgramState(state string) string {
+	return strings.ToLower(strings.TrimSpace(state))
+}
+
+// IsValidProgramState ตรวจว่าเป็นสถานะที่ระบบรู้จักหรือไม่
+func IsValidProgramState(state string) bool {
+	_, ok := programTransitions[state]
+	return ok
+}
+
+// CanTransitionProgramState ตรวจว่าเปลี่ยนจาก from → to ได้หรือไม่ตามตาราง transition
+func CanTransitionProgramState(from, to string, override bool) error {
+	if !IsValidProgramState(to) {
+		return fmt.Errorf("%w: unknown state %q", ErrInvalidProgramTransition, to)
+	}
+	if from == to || override {
+		return nil
+	}
+	for _, allowed := range programTransitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	if from == models.ProgramStateSuccess {
+		return fmt.Errorf("%w: กิจกรรมเสร็จสิ้นแล้ว ไม่สามารถเปลี่ยนเป็น %s ได้ (ต้องใช้ override)", ErrInvalidProgramTransition, to)
+	}
+	if from == models.ProgramStateCancelled {
+		return fmt.Errorf("%w: กิจกรรมถูกยกเลิกแล้ว ไม่สามารถเปลี่ยนเป็น %s ได้ (ต้องใช้ override)", ErrInvalidProgramTransition, to)
+	}
+	return fmt.Errorf("%w: ไม่สามารถเปลี่ยนสถานะจาก %s เป็น %s ได้", ErrInvalidProgramTransition, from, to)
+}
+
+// checkStatePreconditions เงื่อนไขของข้อมูลกิจกรรมก่อนเข้าสู่สถานะปลายทาง
+func checkStatePreconditions(program *models.ProgramDto, to string) error {
+	if to != models.ProgramStateOpen {
+		return nil
+	}
+	if program.EndDateEnroll == "" {
+		return fmt.Errorf("%w: ต้องกำหนด endDateEnroll ก่อนเปิดลงทะเบียน", ErrInvalidProgramTransition)
+	}
+	if len(program.ProgramItems) == 0 {
+		return fmt.Errorf("%w: ต้องมีกิจกรรมย่อยอย่างน้อย 1 รายการก่อนเปิดลงทะเบียน", ErrInvalidProgramTransition)
+	}
+	for _, item := range program.ProgramItems {
+		if len(item.Dates) == 0 {
+			return fmt.Errorf("%w: กิจกรรมย่อยทุกรายการต้องมีวันจัดก่อนเปิดลงทะเบียน", ErrInvalidProgramTransition)
+		}
+	}
+	return nil
+}
+
+// ValidateProgramTransition ตรวจตาราง transition + เงื่อนไขของข้อมูล (ใช้ก่อนบันทึกการแก้ไข Program)
+func ValidateProgramTransition(program *models.ProgramDto, from, to string, override bool) error {
+	if err := CanTransitionProgramState(from, to, override); err != nil {
+		return err
+	}
+	if from == to {
+		return nil
+	}
+	// เปิดลงทะเบียนครั้งแรก (ส่งอีเมลถึงนิสิต) ต้องผ่านการอนุมัติ — close → open คือเปิดรับสมัครอีกครั้งของกิจกรรมที่เผยแพร่แล้ว
+	if from == models.ProgramStatePlanning && to == models.ProgramStateOpen {
+		if err := checkApprovedForOpen(program); err != nil {
+			return err
+		}
+	}
+	return checkStatePreconditions(program, to)
+}
+
+// TransitionProgramState เปลี่ยนสถานะ Program ตาม state machine, บันทึกประวัติ และทำ side effect ของสถานะใหม่
+// คืน nil history เมื่อสถานะเดิมเท่ากับสถานะใหม่ (ไม่มีการเปลี่ยนแปลง)
+func TransitionProgramState(ctx context.Context, programID primitive.ObjectID, to, actor, reason string, override bool) (*models.ProgramStateHistory, error) {
+	to = NormalizeProgramState(to)
+
+	program, err := loadProgramWithItems(ctx, programID)
+	if err != nil {
+		return nil, err
+	}
+	from := NormalizeProgramState(program.ProgramState)
+	if from == to {
+		return nil, nil
+	}
+	if err := ValidateProgramTransition(program, from, to, override); err != nil {
+		return nil, err
+	}
+
+	// อัปเดตเฉพาะเมื่อสถานะยังเป็น from อยู่ (กัน job กับแอดมินเปลี่ยนพร้อมกัน)
+	res, err := DB.ProgramCollection.UpdateOne(ctx,
+		bson.M{"_id": programID, "programState": program.ProgramState, "deletedAt": nil},
+		bson.M{"$set": bson.M{"programState": to}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if res.MatchedCount == 0 {
+		return nil, ErrProgramStateConflict
+	}
+	invalidateAllProgramsListCache()
+	delCache("program:" + programID.Hex())
+
+	history := recordProgramStateHistory(ctx, programID, from, to, actor, reason, override)
+	if to == models.ProgramStatePlanning {
+		// กลับไปวางแผน: ต้องส่งอนุมัติใหม่ก่อนเปิดลงทะเบียนอีกครั้ง
+		resetProgramApproval(ctx, program, actor, "เปลี่ยนสถานะกลับเป็น planning")
+	}
+
+	program.ProgramState = to
+	onEnterProgramState(ctx, program, from, to, actor, reason)
+
+	return history, nil
+}
+
+// recordProgramStateHistory บันทึกประวัติการเปลี่ยนสถานะ (ไม่ทำให้การเปลี่ยนสถานะล้มเหลวถ้าบันทึกไม่สำเร็จ)
+func recordProgramStateHistory(ctx context.Context, programID primitive.ObjectID, from, to, actor, reason string, override bool) *models.ProgramStateHistory {
+	if actor == "" {
+		actor = "unknown"
+	}
+	history := &models.ProgramStateHistory{
+		ID:        primitive.NewObjectID(),
+		ProgramID: programID,
+		FromState: from,
+		ToState:   to,
+		Actor:     actor,
+		Reason:    reason,
+		Override:  override,
+		ChangedAt: time.Now(),
+	}
+	if _, err := DB.ProgramStateHistoryCollection.InsertOne(ctx, history); err != nil {
+		log.Printf("⚠️ Warning: Failed to record state history for program %s (%s → %s): %v", programID.Hex(), from, to, err)
+	}
+	return history
+}
+
+// onEnterProgramState side effects เมื่อเข้าสู่สถานะใหม่
+func onEnterProgramState(ctx context.Context, program *models.ProgramDto, from, to, actor, reason string) {
+	programIDHex := program.ID.Hex()
+	programName := ""
+	if program.Name != nil {
+		programName = *program.Name
+	}
+
+	switch to {
+	case models.ProgramStateOpen:
+		// ⏱️ ตั้ง schedule ปิดรับสมัคร / เสร็จสิ้น + 📧 แจ้งเปิดลงทะเบียน
+		scheduleProgramStateJobs(program)
+		email.NotifyStudentsOnOpen(programIDHex, programName, GetProgramByID, eligibility.Evaluate)
+
+	case models.ProgramStatePlanning:
+		// กลับไปวางแผน: ยกเลิกงานอัตโนมัติทั้งหมด
+		if DB.AsynqClient != nil {
+			DeleteTask("close-enroll-"+programIDHex, programIDHex, DB.RedisURI)
+			DeleteTask("complete-program-"+programIDHex, programIDHex, DB.RedisURI)
+		}
+
+	case models.ProgramStateSuccess:
+		if DB.AsynqClient != nil {
+			DeleteTask("complete-program-"+programIDHex, programIDHex, DB.RedisURI)
+		}
+		// 📝 ตรวจสอบและให้ชั่วโมงนิสิตที่เข้าร่วมกิจกรรม
+		if err := hourhistory.ProcessEnrollmentsForCompletedProgram(ctx, program.ID); err != nil {
+			log.Printf("⚠️ Warning: failed to process enrollments for program %s: %v", programIDHex, err)
+		}
+		// 📝 ชั่วโมงที่รอแบบประเมิน: ตั้งงานเตือน + ตัดชั่วโมงเมื่อเลยกำหนด
+		scheduleEvaluationJobs(program, programName, time.Now())
+		// 📧 แจ้งนิสิตว่ากิจกรรมเสร็จสิ้น
+		email.NotifyStudentsOnCompleted(programIDHex, programName, GetProgramByID)
+
+	case models.ProgramStateCancelled:
+		deleteProgramJobs(program)
+		// 📝 เก็บ enrollment ไว้ แต่ปิด hour history ที่ยังไม่สรุปผลเป็น cancelled
+		if n, err := hourhistory.CancelProgramHistories(ctx, program.ID, actor, reason); err != nil {
+			log.Printf("⚠️ Warning: failed to cancel hour histories for program %s: %v", programIDHex, err)
+		} else {
+			log.Printf("📝 Cancelled %d hour histories for program %s", n, programIDHex)
+		}
+		// 📧 แจ้งนิสิตที่ลงทะเบียนพร้อมเหตุผล
+		email.NotifyStudentsOnChanged(programIDHex, programName, email.ProgramChangeCancelled, reason, GetProgramByID)
+	}
+
+	log.Printf("✅ Program %s state: %s → %s", programIDHex, from, to)
+}
+
+// deleteProgramJobs ยกเลิกงานอัตโนมัติทั้งหมดของ Program (เปลี่ยนสถานะ / แจ้งเปิดลงทะเบียน / แจ้งเตือนก่อนเริ่ม)
+func deleteProgramJobs(program *models.ProgramDto) {
+	if DB.AsynqClient == nil {
+		return
+	}
+	programIDHex := program.ID.Hex()
+	DeleteTask("close-enroll-"+programIDHex, programIDHex, DB.RedisURI)
+	DeleteTask("complete-program-"+programIDHex, programIDHex, DB.RedisURI)
+	DeleteTask(email.NotifyOpenTaskID(programIDHex), programIDHex, DB.RedisURI)
+	email.DeleteReminderJobs(program)
+	log.Println("✅ Deleted scheduled jobs for program:", programIDHex)
+}
+
+// scheduleProgramStateJobs ตั้ง schedule งานเปลี่ยนสถานะ close-enroll / complete-program ตามข้อมูลปัจจุบัน
+func scheduleProgramStateJobs(program *models.ProgramDto) {
+	if DB.AsynqClient == nil {
+		return
+	}
+	var latestTime time.Time
+	for _, item := range program.ProgramItems {
+		latestTime = MaxEndTimeFromItem(item, latestTime)
+	}
+	programName := ""
+	if program.Name != nil {
+		programName = *program.Name
+	}
+	if err := ScheduleChangeProgramStateJob(DB.AsynqClient, DB.RedisURI, latestTime, program.EndDateEnroll, program.ID.Hex(), programName); err != nil {
+		log.Println("❌ Failed to schedule state transitions:", err)
+	}
+}
+
+// loadProgramWithItems โหลด Program + ProgramItems จาก DB โดยตรง (ไม่ผ่าน cache)
+// ไม่รวมกิจกรรมที่อยู่ในถังขยะ (เปลี่ยนสถานะ / อนุมัติ / ยกเลิกไม่ได้จนกว่าจะกู้คืน)
+func loadProgramWithItems(ctx context.Context, programID primitive.ObjectID) (*models.ProgramDto, error) {
+	return findProgramWithItems(ctx, bson.M{"_id": programID, "deletedAt": nil})
+}
+
+// findProgramWithItems เหมือน loadProgramWithItems แต่ใช้ filter ที่กำหนด (เช่น รวมกิจกรรมในถังขยะ)
+func findProgramWithItems(ctx context.Context, filter bson.M) (*models.ProgramDto, error) {
+	var program models.ProgramDto
+	if err := DB.ProgramCollection.FindOne(ctx, filter).Decode(&program); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrProgramNotFound
+		}
+		return nil, err
+	}
+	var items []models.ProgramItem
+	cur, err := DB.ProgramItemCollection.Find(ctx, bson.M{"programId": program.ID})
+	if err != nil {
+		return nil, err
+	}
+	if err := cur.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	program.ProgramItems = make([]models.ProgramItemDto, 0, len(items))
+	for _, item := range items {
+		program.ProgramItems = append(program.ProgramItems, models.ProgramItemDto(item))
+	}
+	return &program, nil
+}
+
+// GetProgramStateHistory ประวัติการเปลี่ยนสถานะของ Program (ล่าสุดก่อน)
+func GetProgramStateHistory(programID primitive.ObjectID) ([]models.ProgramStateHistory, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cur, err := DB.ProgramStateHistoryCollection.Find(ctx,
+		bson.M{"programId": programID},
+		options.Find().SetSort(bson.D{{Key: "changedAt", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	histories := []models.ProgramStateHistory{}
+	if err := cur.All(ctx, &histories); err != nil {
+		return nil, err
+	}
+	return histories, nil
+}
@@ -0,0 +1,52 @@
+package programs
+
+import (
+	"Backend-Bluelock-007/src/jobs"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+
+	"github.com/hibiken/asynq"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// HandleCloseEnrollTask ปิดรับสมัครอัตโนมัติเมื่อครบ endDateEnroll (open → close)
+func HandleCloseEnrollTask(ctx context.Context, t *asynq.Task) error {
+	return handleScheduledTransition(ctx, t, models.ProgramStateClose, "ปิดรับสมัครอัตโนมัติเมื่อครบกำหนด endDateEnroll")
+}
+
+// HandleCompleteProgramTask ปิดกิจกรรมอัตโนมัติหลังเวลาจบกิจกรรม (→ success) และให้ชั่วโมงนิสิต
+func HandleCompleteProgramTask(ctx context.Context, t *asynq.Task) error {
+	return handleScheduledTransition(ctx, t, models.ProgramStateSuccess, "กิจกรรมเสร็จสิ้นอัตโนมัติหลังเวลาจบกิจกรรม")
+}
+
+// handleScheduledTransition เปลี่ยนสถานะจาก job โดยผ่าน state machine
+// สถานะที่ไม่อนุญาต (เช่น ถูกเปลี่ยนกลับเป็น planning แล้ว) จะถูกข้ามโดยไม่ retry
+func handleScheduledTransition(ctx context.Context, t *asynq.Task, to, reason string) error {
+	var payload jobs.ProgramPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		log.Println("❌ Payload decode error:", err)
+		return err
+	}
+	id, err := primitive.ObjectIDFromHex(payload.ProgramID)
+	if err != nil {
+		return err
+	}
+
+	_, err = TransitionProgramState(ctx, id, to, "system", reason, false)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrProgramNotFound):
+		log.Println("⚠️ Program not found. Possibly deleted. Skipping task:", id.Hex())
+		return nil
+	case errors.Is(err, ErrInvalidProgramTransition):
+		log.Printf("⏩ Skipped scheduled transition to %s for program %s: %v", to, id.Hex(), err)
+		return nil
+	default:
+		log.Printf("❌ Failed to transition program %s to %s: %v", id.Hex(), to, err)
+		return err
+	}
+}
@@ -0,0 +1,276 @@
+package programs
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ========================================
+// Program Templates - เทมเพลตกิจกรรม (มีเวอร์ชัน) สำหรับสร้างกิจกรรมซ้ำโดยไม่ต้องกรอกใหม่
+// ========================================
+
+var (
+	ErrProgramTemplateNotFound = errors.New("program template not found")
+	ErrInvalidProgramTemplate  = errors.New("invalid program template")
+)
+
+// ListProgramTemplates เทมเพลตทั้งหมด (เวอร์ชันล่าสุด, ไม่รวมที่ถูกลบ)
+func ListProgramTemplates(ctx context.Context) ([]models.ProgramTemplate, error) {
+	cur, err := DB.ProgramTemplateCollection.Find(ctx,
+		bson.M{"deletedAt": nil},
+		options.Find().SetSort(bson.D{{Key: "name", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	templates := []models.ProgramTemplate{}
+	if err := cur.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// GetProgramTemplate เทมเพลตเวอร์ชันล่าสุด
+func GetProgramTemplate(ctx context.Context, id primitive.ObjectID) (*models.ProgramTemplate, error) {
+	var template models.ProgramTemplate
+	if err := DB.ProgramTemplateCollection.FindOne(ctx, bson.M{"_id": id, "deletedAt": nil}).Decode(&template); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrProgramTemplateNotFound
+		}
+		return nil, err
+	}
+	return &template, nil
+}
+
+// GetProgramTemplateVersions ทุกเวอร์ชันของเทมเพลต (ล่าสุดก่อน)
+func GetProgramTemplateVersions(ctx context.Context, id primitive.ObjectID) ([]models.ProgramTemplateVersion, error) {
+	if _, err := GetProgramTemplate(ctx, id); err != nil {
+		return nil, err
+	}
+	cur, err := DB.ProgramTemplateVersionCollection.Find(ctx,
+		bson.M{"templateId": id},
+		options.Find().SetSort(bson.D{{Key: "version", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	versions := []models.ProgramTemplateVersion{}
+	if err := cur.All(ctx, &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetProgramTemplateVersion เทมเพลตเวอร์ชันที่ระบุ (version <= 0 = ล่าสุด)
+func GetProgramTemplateVersion(ctx context.Context, id primitive.ObjectID, version int) (*models.ProgramTemplateVersion, error) {
+	template, err := GetProgramTemplate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if version <= 0 {
+		version = template.Version
+	}
+	var snapshot models.ProgramTemplateVersion
+	if err := DB.ProgramTemplateVersionCollection.FindOne(ctx, bson.M{"templateId": id, "version": version}).Decode(&snapshot); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("%w: ไม่พบเวอร์ชัน %d", ErrProgramTemplateNotFound, version)
+		}
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// CreateProgramTemplate สร้างเทมเพลตใหม่ (เวอร์ชัน 1)
+func CreateProgramTemplate(ctx context.Context, input models.ProgramTemplateInput, actor string) (*models.ProgramTemplate, error) {
+	content, err := normalizeTemplateInput(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template := models.ProgramTemplate{
+		ID:          primitive.NewObjectID(),
+		Name:        input.Name,
+		Description: input.Description,
+		Version:     1,
+		Content:     content,
+		CreatedBy:   actor,
+		CreatedAt:   now,
+		UpdatedBy:   actor,
+		UpdatedAt:   now,
+	}
+	if _, err := DB.ProgramTemplateCollection.InsertOne(ctx, template); err != nil {
+		return nil, err
+	}
+	if err := insertTemplateVersion(ctx, &template); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// UpdateProgramTemplate แก้ไขเทมเพลต → เพิ่มเวอร์ชันใหม่ (เวอร์ชันเก่ายังใช้สร้างกิจกรรมได้)
+func UpdateProgramTemplate(ctx context.Context, id primitive.ObjectID, input models.ProgramTemplateInput, actor string) (*models.ProgramTemplate, error) {
+	content, err := normalizeTemplateInput(ctx, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	var template models.ProgramTemplate
+	err = DB.ProgramTemplateCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "deletedAt": nil},
+		bson.M{
+			"$set": bson.M{
+				"name":        input.Name,
+				"description": input.Description,
+				"content":     content,
+				"updatedBy":   actor,
+				"updatedAt":   time.Now(),
+			},
+			"$inc": bson.M{"version": 1},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&template)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrProgramTemplateNotFound
+		}
+		return nil, err
+	}
+	if err := insertTemplateVersion(ctx, &template); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// DeleteProgramTemplate ซ่อนเทมเพลต (กิจกรรมที่สร้างไปแล้วยังอ้างอิงเวอร์ชันเดิมได้)
+func DeleteProgramTemplate(ctx context.Context, id primitive.ObjectID) error {
+	result, err := DB.ProgramTemplateCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "deletedAt": nil},
+		bson.M{"$set": bson.M{"deletedAt": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrProgramTemplateNotFound
+	}
+	return nil
+}
+
+// CreateProgramFromTemplate สร้างกิจกรรมจากเทมเพลต + วันจัด ผ่าน CreateProgram
+// กิจกรรมที่ได้จะบันทึก templateId / templateVersion ไว้
+func CreateProgramFromTemplate(ctx context.Context, id primitive.ObjectID, input models.ProgramFromTemplateInput, actor string) (*models.ProgramDto, error) {
+	snapshot, err := GetProgramTemplateVersion(ctx, id, input.Version)
+	if err != nil {
+		return nil, err
+	}
+	content := snapshot.Content
+
+	if len(input.Items) != len(content.Items) {
+		return nil, fmt.Errorf("%w: ต้องระบุวันจัดให้ครบ %d กิจกรรมย่อย", ErrInvalidProgramTemplate, len(content.Items))
+	}
+	for _, schedule := range input.Items {
+		if err := validateScheduleDates(schedule.Dates); err != nil {
+			return nil, err
+		}
+	}
+
+	formID, err := cloneForm(ctx, content.FormID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := cloneString(content.ProgramName)
+	if input.Name != nil && strings.TrimSpace(*input.Name) != "" {
+		n := strings.TrimSpace(*input.Name)
+		name = &n
+	}
+	templateID := snapshot.TemplateID
+	program := &models.ProgramDto{
+		FormID:             formID,
+		Name:               name,
+		Type:               content.Type,
+		ProgramState:       input.ProgramState,
+		Skill:              content.Skill,
+		File:               input.File,
+		EndDateEnroll:      input.EndDateEnroll,
+		FoodDeadline:       input.FoodDeadline,
+		FoodVotes:          append([]models.FoodVote(nil), content.FoodVotes...),
+		TemplateID:         &templateID,
+		TemplateVersion:    snapshot.Version,
+		HourPolicy:         cloneHourPolicy(content.HourPolicy),
+		EvaluationRequired: content.EvaluationRequired,
+		EvaluationDays:     content.EvaluationDays,
+	}
+	program.ProgramItems = make([]models.ProgramItemDto, 0, len(content.Items))
+	for i, item := range content.Items {
+		program.ProgramItems = append(program.ProgramItems, models.ProgramItemDto{
+			Name:             cloneString(item.Name),
+			Description:      cloneString(item.Description),
+			StudentYears:     append([]int(nil), item.StudentYears...),
+			MaxParticipants:  cloneInt(item.MaxParticipants),
+			Majors:           append([]string(nil), item.Majors...),
+			Rooms:            cloneStrings(item.Rooms),
+			Operator:         cloneString(item.Operator),
+			Dates:            input.Items[i].Dates,
+			Hour:             cloneInt(item.Hour),
+			EligibilityRules: append([]models.EligibilityRule(nil), item.EligibilityRules...),
+			RoomIDs:          append([]primitive.ObjectID(nil), item.RoomIDs...),
+			HourPolicy:       cloneHourPolicy(item.HourPolicy),
+		})
+	}
+
+	return CreateProgram(program, actor)
+}
+
+// normalizeTemplateInput ตรวจข้อมูลเทมเพลตและผูกตัวเลือกอาหารกับ Foods
+func normalizeTemplateInput(ctx context.Context, input *models.ProgramTemplateInput) (models.ProgramTemplateContent, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		return models.ProgramTemplateContent{}, fmt.Errorf("%w: ต้องระบุชื่อเทมเพลต", ErrInvalidProgramTemplate)
+	}
+	content := input.Content
+	if len(content.Items) == 0 {
+		return content, fmt.Errorf("%w: ต้องมีกิจกรรมย่อยอย่างน้อย 1 รายการ", ErrInvalidProgramTemplate)
+	}
+	if !content.FormID.IsZero() {
+		count, err := DB.FormCollection.CountDocuments(ctx, bson.M{"_id": content.FormID, "deletedAt": nil})
+		if err != nil {
+			return content, err
+		}
+		if count == 0 {
+			return content, fmt.Errorf("%w: ไม่พบฟอร์ม %s", ErrInvalidProgramTemplate, content.FormID.Hex())
+		}
+	}
+	foodVotes, err := NormalizeFoodOptions(ctx, content.FoodVotes)
+	if err != nil {
+		return content, fmt.Errorf("%w: %v", ErrInvalidProgramTemplate, err)
+	}
+	content.FoodVotes = foodVotes
+	return content, nil
+}
+
+// insertTemplateVersion บันทึก snapshot ของเวอร์ชันปัจจุบัน
+func insertTemplateVersion(ctx context.Context, template *models.ProgramTemplate) error {
+	_, err := DB.ProgramTemplateVersionCollection.InsertOne(ctx, models.ProgramTemplateVersion{
+		ID:          primitive.NewObjectID(),
+		TemplateID:  template.ID,
+		Version:     template.Version,
+		Name:        template.Name,
+		Description: template.Description,
+		Content:     template.Content,
+		CreatedBy:   template.UpdatedBy,
+		CreatedAt:   template.UpdatedAt,
+	})
+	return err
+}
@@ -0,0 +1,470 @@
+package rooms
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrRoomNotFound       = errors.New("room not found")
+	ErrInvalidRoom        = errors.New("invalid room")
+	ErrInvalidRoomBooking = errors.New("invalid room booking")
+	ErrRoomConflict       = errors.New("room is already booked")
+)
+
+// ConflictError การจองห้องซ้อนกับกิจกรรมอื่น (errors.Is(err, ErrRoomConflict) == true)
+type ConflictError struct {
+	Conflicts []models.RoomConflict
+}
+
+func (e *ConflictError) Error() string {
+	msgs := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		msgs = append(msgs, fmt.Sprintf("%s ห้อง %s วันที่ %s %s-%s ชนกับ %s / %s (%s-%s)",
+			c.ItemName, c.Booking.RoomName, c.Date.Date, c.Date.Stime, c.Date.Etime,
+			c.Booking.ProgramName, c.Booking.ProgramItemName, c.Booking.Stime, c.Booking.Etime))
+	}
+	return ErrRoomConflict.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+func (e *ConflictError) Unwrap() error { return ErrRoomConflict }
+
+// ========================================
+// Rooms catalog - ห้อง / ทรัพยากรที่จองได้
+// ========================================
+
+// ListRooms ห้องทั้งหมดที่ยังไม่ถูกลบ (เรียงตามชื่อ)
+func ListRooms(ctx context.Context) ([]models.Room, error) {
+	return findRooms(ctx, bson.M{"deletedAt": nil})
+}
+
+func findRooms(ctx context.Context, filter bson.M) ([]models.Room, error) {
+	cur, err := DB.RoomCollection.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	rooms := []models.Room{}
+	if err := cur.All(ctx, &rooms); err != nil {
+		return nil, err
+	}
+	return rooms, nil
+}
+
+// GetRoom ดึงห้องตาม ID
+func GetRoom(ctx context.Context, id primitive.ObjectID) (*models.Room, error) {
+	var room models.Room
+	if err := DB.RoomCollection.FindOne(ctx, bson.M{"_id": id, "deletedAt": nil}).Decode(&room); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrRoomNotFound
+		}
+		return nil, err
+	}
+	return &room, nil
+}
+
+// CreateRoom เพิ่มห้อง (ชื่อห้องต้องไม่ซ้ำ)
+func CreateRoom(ctx context.Context, input models.RoomInput) (*models.Room, error) {
+	room, err := roomFromInput(ctx, primitive.NewObjectID(), input)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := DB.RoomCollection.InsertOne(ctx, room); err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
+// UpdateRoom แก้ไขห้อง — ชื่อใหม่จะถูกใช้แสดงใน ProgramItem.Rooms เมื่อกิจกรรมถูกบันทึกครั้งถัดไป
+func UpdateRoom(ctx context.Context, id primitive.ObjectID, input models.RoomInput) (*models.Room, error) {
+	if _, err := GetRoom(ctx, id); err != nil {
+		return nil, err
+	}
+	room, err := roomFromInput(ctx, id, input)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := DB.RoomCollection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{
+		"name":     room.Name,
+		"type":     room.Type,
+		"building": room.Building,
+		"capacity": room.Capacity,
+		"note":     room.Note,
+	}}); err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
+// DeleteRoom ซ่อนห้องจากแคตตาล็อก (กิจกรรมที่จองไว้แล้วยังเก็บ roomIds / ชื่อห้องเดิม)
+func DeleteRoom(ctx context.Context, id primitive.ObjectID) error {
+	result, err := DB.RoomCollection.UpdateOne(ctx,
+		bson.M{"_id": id, "deletedAt": nil},
+		bson.M{"$set": bson.M{"deletedAt": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrRoomNotFound
+	}
+	return nil
+}
+
+func roomFromInput(ctx context.Context, id primitive.ObjectID, input models.RoomInput) (*models.Room, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: ต้องระบุชื่อห้อง", ErrInvalidRoom)
+	}
+	roomType := strings.ToLower(strings.TrimSpace(input.Type))
+	if roomType == "" {
+		roomType = models.RoomTypeRoom
+	}
+	if roomType != models.RoomTypeRoom && roomType != models.RoomTypeEquipment {
+		return nil, fmt.Errorf("%w: type ต้องเป็น room หรือ equipment", ErrInvalidRoom)
+	}
+	if input.Capacity < 0 {
+		return nil, fmt.Errorf("%w: capacity ต้องไม่ติดลบ", ErrInvalidRoom)
+	}
+
+	count, err := DB.RoomCollection.CountDocuments(ctx, bson.M{
+		"_id":       bson.M{"$ne": id},
+		"deletedAt": nil,
+		"name":      primitive.Regex{Pattern: "^" + regexp.QuoteMeta(name) + "$", Options: "i"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("%w: มีห้องชื่อ %s แล้ว", ErrInvalidRoom, name)
+	}
+
+	return &models.Room{
+		ID:       id,
+		Name:     name,
+		Type:     roomType,
+		Building: strings.TrimSpace(input.Building),
+		Capacity: input.Capacity,
+		Note:     strings.TrimSpace(input.Note),
+	}, nil
+}
+
+// ========================================
+// Booking - ผูกกิจกรรมย่อยกับห้อง และตรวจการจองซ้อน
+// ========================================
+
+// ValidateProgramItems ผูกห้องของกิจกรรมย่อยกับแคตตาล็อก, ตรวจความจุ และตรวจการจองซ้อน
+// excludeProgramID = กิจกรรมที่กำลังแก้ไข (การจองเดิมของตัวเองไม่นับว่าชน)
+func ValidateProgramItems(ctx context.Context, excludeProgramID primitive.ObjectID, items []models.ProgramItemDto) error {
+	if err := NormalizeItemRooms(ctx, items); err != nil {
+		return err
+	}
+	conflicts, err := FindConflicts(ctx, excludeProgramID, items)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		return &ConflictError{Conflicts: conflicts}
+	}
+	return nil
+}
+
+// NormalizeItemRooms ผูก ProgramItem กับ Rooms
+//   - roomIds ต้องเป็นห้องในแคตตาล็อก → Rooms (ชื่อ) ถูกเขียนใหม่จากแคตตาล็อก
+//     (ห้องที่ถูกลบไปแล้วยังคงอยู่กับกิจกรรมเดิมได้ แต่ไม่ถูกผูกจากชื่อ)
+//   - ชื่อใน Rooms ที่ตรงกับห้องในแคตตาล็อก (ไม่สนตัวพิมพ์) จะถูกผูก roomId ให้อัตโนมัติ
+//   - ชื่อที่ไม่อยู่ในแคตตาล็อก (เช่น "Online") เก็บไว้เป็นข้อความเหมือนเดิม
+//
+// และตรวจว่า MaxParticipants ไม่เกินความจุรวมของห้องที่มีความจุ
+func NormalizeItemRooms(ctx context.Context, items []models.ProgramItemDto) error {
+	catalog, err := findRooms(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	byID := make(map[primitive.ObjectID]models.Room, len(catalog))
+	byName := make(map[string]models.Room, len(catalog))
+	for _, r := range catalog {
+		byID[r.ID] = r
+		if r.DeletedAt == nil {
+			byName[strings.ToLower(r.Name)] = r
+		}
+	}
+
+	for i := range items {
+		item := &items[i]
+		var ids []primitive.ObjectID
+		var names, freeText []string
+		seen := map[primitive.ObjectID]bool{}
+		link := func(r models.Room) {
+			if !seen[r.ID] {
+				seen[r.ID] = true
+				ids = append(ids, r.ID)
+				names = append(names, r.Name)
+			}
+		}
+
+		for _, id := range item.RoomIDs {
+			r, ok := byID[id]
+			if !ok {
+				return fmt.Errorf("%w: ไม่พบห้อง %s", ErrInvalidRoomBooking, id.Hex())
+			}
+			link(r)
+		}
+		if item.Rooms != nil {
+			for _, name := range *item.Rooms {
+				name = strings.TrimSpace(name)
+				if name == "" {
+					continue
+				}
+				if r, ok := byName[strings.ToLower(name)]; ok {
+					link(r)
+				} else {
+					freeText = append(freeText, name)
+				}
+			}
+		}
+		item.RoomIDs = ids
+		names = append(names, freeText...) // ชื่อห้องในแคตตาล็อกอยู่ลำดับเดียวกับ roomIds
+		if len(names) > 0 || item.Rooms != nil {
+			item.Rooms = &names
+		}
+
+		capacity := 0
+		for _, id := range ids {
+			if byID[id].Type == models.RoomTypeRoom {
+				capacity += byID[id].Capacity
+			}
+		}
+		if capacity > 0 && item.MaxParticipants != nil && *item.MaxParticipants > capacity {
+			return fmt.Errorf("%w: %s รับได้ %d คน แต่ห้องที่เลือกจุได้ %d คน",
+				ErrInvalidRoomBooking, itemName(item), *item.MaxParticipants, capacity)
+		}
+	}
+	return nil
+}
+
+// FindConflicts หาการจองห้องที่ซ้อนกับกิจกรรมย่อยที่กำลังบันทึก
+// นับทั้งกิจกรรมอื่น (ที่ไม่ถูกลบ/ยกเลิก) และกิจกรรมย่อยในชุดเดียวกันที่ใช้ห้องเดียวกันเวลาเดียวกัน
+func FindConflicts(ctx context.Context, excludeProgramID primitive.ObjectID, items []models.ProgramItemDto) ([]models.RoomConflict, error) {
+	roomSet := map[primitive.ObjectID]bool{}
+	dateSet := map[string]bool{}
+	for _, item := range items {
+		for _, id := range item.RoomIDs {
+			roomSet[id] = true
+		}
+		for _, d := range item.Dates {
+			dateSet[d.Date] = true
+		}
+	}
+	if len(roomSet) == 0 || len(dateSet) == 0 {
+		return nil, nil
+	}
+	roomIDs := make([]primitive.ObjectID, 0, len(roomSet))
+	for id := range roomSet {
+		roomIDs = append(roomIDs, id)
+	}
+	dates := make([]string, 0, len(dateSet))
+	for d := range dateSet {
+		dates = append(dates, d)
+	}
+
+	filter := bson.M{"roomIds": bson.M{"$in": roomIDs}, "dates.date": bson.M{"$in": dates}}
+	if !excludeProgramID.IsZero() {
+		filter["programId"] = bson.M{"$ne": excludeProgramID}
+	}
+	bookings, err := findBookings(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var conflicts []models.RoomConflict
+	for i, item := range items {
+		for _, d := range item.Dates {
+			for _, roomID := range item.RoomIDs {
+				for _, b := range bookings {
+					if b.RoomID == roomID && d.Overlaps(models.Dates{Date: b.Date, Stime: b.Stime, Etime: b.Etime}) {
+						conflicts = append(conflicts, models.RoomConflict{ItemName: itemName(&items[i]), Date: d, Booking: b})
+					}
+				}
+				// กิจกรรมย่อยในชุดเดียวกัน
+				for j := i + 1; j < len(items); j++ {
+					other := items[j]
+					if !containsID(other.RoomIDs, roomID) {
+						continue
+					}
+					for _, od := range other.Dates {
+						if d.Overlaps(od) {
+							conflicts = append(conflicts, models.RoomConflict{
+								ItemName: itemName(&items[i]),
+								Date:     d,
+								Booking: models.RoomBooking{
+									RoomID:          roomID,
+									RoomName:        roomNameOf(&items[i], roomID),
+									ProgramItemName: itemName(&items[j]),
+									Date:            od.Date,
+									Stime:           od.Stime,
+									Etime:           od.Etime,
+								},
+							})
+						}
+					}
+				}
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// GetAvailability การจองของห้องในช่วงวันที่ from..to (roomID ว่าง = ทุกห้อง)
+func GetAvailability(ctx context.Context, roomID *primitive.ObjectID, from, to string) ([]models.RoomAvailability, error) {
+	if _, err := time.Parse("2006-01-02", from); err != nil {
+		return nil, fmt.Errorf("%w: from ต้องอยู่ในรูปแบบ YYYY-MM-DD", ErrInvalidRoomBooking)
+	}
+	if _, err := time.Parse("2006-01-02", to); err != nil || to < from {
+		return nil, fmt.Errorf("%w: to ต้องอยู่ในรูปแบบ YYYY-MM-DD และไม่ก่อน from", ErrInvalidRoomBooking)
+	}
+
+	var catalog []models.Room
+	if roomID != nil {
+		room, err := GetRoom(ctx, *roomID)
+		if err != nil {
+			return nil, err
+		}
+		catalog = []models.Room{*room}
+	} else {
+		var err error
+		if catalog, err = ListRooms(ctx); err != nil {
+			return nil, err
+		}
+	}
+	ids := make([]primitive.ObjectID, 0, len(catalog))
+	for _, r := range catalog {
+		ids = append(ids, r.ID)
+	}
+
+	bookings, err := findBookings(ctx, bson.M{
+		"roomIds":    bson.M{"$in": ids},
+		"dates.date": bson.M{"$gte": from, "$lte": to},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.RoomAvailability, 0, len(catalog))
+	for _, r := range catalog {
+		availability := models.RoomAvailability{Room: r, Bookings: []models.RoomBooking{}}
+		for _, b := range bookings {
+			if b.RoomID == r.ID && b.Date >= from && b.Date <= to {
+				availability.Bookings = append(availability.Bookings, b)
+			}
+		}
+		sort.Slice(availability.Bookings, func(i, j int) bool {
+			a, b := availability.Bookings[i], availability.Bookings[j]
+			if a.Date != b.Date {
+				return a.Date < b.Date
+			}
+			return a.Stime < b.Stime
+		})
+		result = append(result, availability)
+	}
+	return result, nil
+}
+
+// findBookings กิจกรรมย่อยตาม filter ที่อยู่ในกิจกรรมที่ยังใช้ห้องจริง (ไม่ถูกลบ / ไม่ถูกยกเลิก)
+// แตกเป็นรายการต่อห้องต่อวัน
+func findBookings(ctx context.Context, itemFilter bson.M) ([]models.RoomBooking, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: itemFilter}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "Programs",
+			"localField":   "programId",
+			"foreignField": "_id",
+			"as":           "program",
+		}}},
+		{{Key: "$unwind", Value: "$program"}},
+		{{Key: "$match", Value: bson.M{
+			"program.deletedAt":    nil,
+			"program.programState": bson.M{"$ne": models.ProgramStateCancelled},
+		}}},
+	}
+	cur, err := DB.ProgramItemCollection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		models.ProgramItem `bson:",inline"`
+		Program            models.Program `bson:"program"`
+	}
+	if err := cur.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+
+	names := map[primitive.ObjectID]string{}
+	if catalog, err := findRooms(ctx, bson.M{}); err == nil {
+		for _, r := range catalog {
+			names[r.ID] = r.Name
+		}
+	}
+
+	var bookings []models.RoomBooking
+	for _, row := range rows {
+		programName := ""
+		if row.Program.Name != nil {
+			programName = *row.Program.Name
+		}
+		item := models.ProgramItemDto(row.ProgramItem)
+		for _, roomID := range row.RoomIDs {
+			for _, d := range row.Dates {
+				bookings = append(bookings, models.RoomBooking{
+					RoomID:          roomID,
+					RoomName:        names[roomID],
+					ProgramID:       row.Program.ID,
+					ProgramName:     programName,
+					ProgramItemID:   row.ID,
+					ProgramItemName: itemName(&item),
+					ProgramState:    row.Program.ProgramState,
+					Date:            d.Date,
+					Stime:           d.Stime,
+					Etime:           d.Etime,
+				})
+			}
+		}
+	}
+	return bookings, nil
+}
+
+func itemName(item *models.ProgramItemDto) string {
+	if item.Name != nil && *item.Name != "" {
+		return *item.Name
+	}
+	return "ไม่ระบุชื่อ"
+}
+
+func roomNameOf(item *models.ProgramItemDto, roomID primitive.ObjectID) string {
+	for i, id := range item.RoomIDs {
+		if id == roomID && item.Rooms != nil && i < len(*item.Rooms) {
+			return (*item.Rooms)[i]
+		}
+	}
+	return roomID.Hex()
+}
+
+func containsID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Contains regex แบบ "มีคำนี้อยู่" (ไม่สนตัวพิมพ์) จากข้อความที่ผู้ใช้พิมพ์
+// escape อักขระพิเศษทั้งหมด — ห้ามส่ง input ของผู้ใช้เข้า $regex ตรง ๆ
+func Contains(s string) primitive.Regex {
+	return primitive.Regex{Pattern: regexp.QuoteMeta(strings.TrimSpace(s)), Options: "i"}
+}
+
+// Prefix regex แบบ "ขึ้นต้นด้วย" แยกตัวพิมพ์ (ใช้ index ได้) เช่น รหัสนิสิต
+func Prefix(s string) primitive.Regex {
+	return primitive.Regex{Pattern: "^" + regexp.QuoteMeta(strings.TrimSpace(s))}
+}
+
+// Tokenize แยกคำค้นตามช่องว่าง/เครื่องหมาย (ไม่ซ้ำ, ตัวพิมพ์เล็ก)
+// ภาษาไทยไม่เว้นวรรคระหว่างคำ คำไทยทั้งก้อนจึงเป็น 1 token และต้องค้นแบบ substring (ดู hasThai)
+func Tokenize(query string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return unicode.IsSpace(r) || (unicode.IsPunct(r) && r != '-' && r != '_')
+	})
+	seen := map[string]bool{}
+	terms := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if f != "" && !seen[f] {
+			seen[f] = true
+			terms = append(terms, f)
+		}
+	}
+	return terms
+}
+
+// hasThai มีอักษรไทยหรือไม่ (text index ตัดคำไทยไม่ได้ → ต้อง fallback เป็น regex)
+func hasThai(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Thai, r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,343 @@
+package search
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"context"
+	"log"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ========================================
+// Search - ค้นหาแบบ full-text พร้อมคะแนนความเกี่ยวข้อง
+// ใช้ MongoDB text index (ใช้ได้ทั้ง self-hosted และ Atlas) + fallback เป็น regex ที่ escape แล้ว
+// สำหรับคำภาษาไทยที่ text index ตัดคำไม่ได้
+// ========================================
+
+const (
+	DefaultLimit   = 20
+	MaxLimit       = 100
+	candidateLimit = 200 // จำนวนเอกสารสูงสุดที่ดึงจากแต่ละ query ก่อนรวมคะแนน
+)
+
+// น้ำหนักของแต่ละฟิลด์ (ใช้ทั้งใน text index และการให้คะแนน regex fallback)
+var (
+	programWeights = bson.D{{Key: "name", Value: 10}}
+	itemWeights    = bson.D{{Key: "name", Value: 5}, {Key: "operator", Value: 3}, {Key: "description", Value: 1}}
+	studentWeights = bson.D{{Key: "code", Value: 10}, {Key: "name", Value: 5}, {Key: "engName", Value: 5}}
+)
+
+// EnsureIndexes สร้าง text index ของ Programs / Program_Items / Students
+// default_language = none: ไม่ตัด stop word / stemming (Mongo ไม่รองรับภาษาไทย)
+func EnsureIndexes(ctx context.Context) error {
+	indexes := []struct {
+		collection *mongo.Collection
+		name       string
+		weights    bson.D
+	}{
+		{DB.ProgramCollection, "program_text", programWeights},
+		{DB.ProgramItemCollection, "program_item_text", itemWeights},
+		{DB.StudentCollection, "student_text", studentWeights},
+	}
+	for _, idx := range indexes {
+		keys := bson.D{}
+		for _, w := range idx.weights {
+			keys = append(keys, bson.E{Key: w.Key, Value: "text"})
+		}
+		_, err := idx.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: keys,
+			Options: options.Index().
+				SetName(idx.name).
+				SetDefaultLanguage("none").
+				SetWeights(idx.weights),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ClampLimit จำกัดจำนวนผลลัพธ์ (<= 0 = ค่าเริ่มต้น)
+func ClampLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultLimit
+	}
+	if limit > MaxLimit {
+		return MaxLimit
+	}
+	return limit
+}
+
+// SearchPrograms ค้นหากิจกรรมจากชื่อกิจกรรม และชื่อ / รายละเอียด / ผู้ดูแลของกิจกรรมย่อย
+// คะแนนของกิจกรรม = คะแนนชื่อกิจกรรม + คะแนนกิจกรรมย่อยที่ตรงสูงสุด
+func SearchPrograms(ctx context.Context, query string, limit int) ([]models.ProgramSearchHit, error) {
+	limit = ClampLimit(limit)
+	terms := Tokenize(query)
+	if len(terms) == 0 {
+		return []models.ProgramSearchHit{}, nil
+	}
+
+	programScores := map[primitive.ObjectID]float64{}
+	itemScores := map[primitive.ObjectID]map[primitive.ObjectID]float64{} // programId → itemId → score
+	itemNames := map[primitive.ObjectID]*string{}
+
+	type programDoc struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Name  *string            `bson:"name"`
+		Score float64            `bson:"score"`
+	}
+	type itemDoc struct {
+		ID          primitive.ObjectID `bson:"_id"`
+		ProgramID   primitive.ObjectID `bson:"programId"`
+		Name        *string            `bson:"name"`
+		Description *string            `bson:"description"`
+		Operator    *string            `bson:"operator"`
+		Score       float64            `bson:"score"`
+	}
+	addItem := func(it itemDoc, score float64) {
+		if itemScores[it.ProgramID] == nil {
+			itemScores[it.ProgramID] = map[primitive.ObjectID]float64{}
+		}
+		itemScores[it.ProgramID][it.ID] += score
+		itemNames[it.ID] = it.Name
+	}
+
+	// 1) text index
+	var programs []programDoc
+	if err := textSearch(ctx, DB.ProgramCollection, terms, bson.M{"deletedAt": nil}, &programs); err != nil {
+		return nil, err
+	}
+	for _, p := range programs {
+		programScores[p.ID] += p.Score
+	}
+	var items []itemDoc
+	if err := textSearch(ctx, DB.ProgramItemCollection, terms, bson.M{}, &items); err != nil {
+		return nil, err
+	}
+	for _, it := range items {
+		addItem(it, it.Score)
+	}
+
+	// 2) regex fallback: คำไทย (ตัดคำไม่ได้) หรือ text index ได้ผลน้อยกว่าที่ขอ (ค้นบางส่วนของคำ)
+	if fallback := fallbackTerms(terms, len(programScores)+len(items) < limit); len(fallback) > 0 {
+		var programs []programDoc
+		if err := regexSearch(ctx, DB.ProgramCollection, fallback, programWeights, bson.M{"deletedAt": nil}, &programs); err != nil {
+			return nil, err
+		}
+		for _, p := range programs {
+			programScores[p.ID] += regexScore(fallback, programWeights, map[string]string{"name": deref(p.Name)})
+		}
+		var items []itemDoc
+		if err := regexSearch(ctx, DB.ProgramItemCollection, fallback, itemWeights, bson.M{}, &items); err != nil {
+			return nil, err
+		}
+		for _, it := range items {
+			addItem(it, regexScore(fallback, itemWeights, map[string]string{
+				"name":        deref(it.Name),
+				"operator":    deref(it.Operator),
+				"description": deref(it.Description),
+			}))
+		}
+	}
+
+	// 3) รวมคะแนนต่อกิจกรรม (ไม่รวมกิจกรรมที่ถูกลบ)
+	ids := make([]primitive.ObjectID, 0, len(programScores)+len(itemScores))
+	for id := range programScores {
+		ids = append(ids, id)
+	}
+	for id := range itemScores {
+		if _, ok := programScores[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return []models.ProgramSearchHit{}, nil
+	}
+	cur, err := DB.ProgramCollection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}, "deletedAt": nil},
+		options.Find().SetProjection(bson.M{"name": 1, "skill": 1, "programState": 1, "file": 1}))
+	if err != nil {
+		return nil, err
+	}
+	var found []models.Program
+	if err := cur.All(ctx, &found); err != nil {
+		return nil, err
+	}
+
+	hits := make([]models.ProgramSearchHit, 0, len(found))
+	for _, p := range found {
+		hit := models.ProgramSearchHit{
+			ID:           p.ID,
+			Name:         p.Name,
+			Skill:        p.Skill,
+			ProgramState: p.ProgramState,
+			File:         p.File,
+		}
+		best := 0.0
+		for itemID, score := range itemScores[p.ID] {
+			hit.MatchedItems = append(hit.MatchedItems, models.ProgramItemSearchHit{ID: itemID, Name: itemNames[itemID], Score: score})
+			if score > best {
+				best = score
+			}
+		}
+		sort.Slice(hit.MatchedItems, func(i, j int) bool { return hit.MatchedItems[i].Score > hit.MatchedItems[j].Score })
+		hit.Score = programScores[p.ID] + best
+		hits = append(hits, hit)
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return deref(hits[i].Name) < deref(hits[j].Name)
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// SearchStudents ค้นหานิสิตจากรหัสและชื่อ (ไทย/อังกฤษ) ไม่รวมนิสิตที่อยู่ในถังขยะ
+func SearchStudents(ctx context.Context, query string, limit int) ([]models.StudentSearchHit, error) {
+	limit = ClampLimit(limit)
+	terms := Tokenize(query)
+	if len(terms) == 0 {
+		return []models.StudentSearchHit{}, nil
+	}
+
+	type studentDoc struct {
+		ID      primitive.ObjectID `bson:"_id"`
+		Code    string             `bson:"code"`
+		Name    string             `bson:"name"`
+		EngName string             `bson:"engName"`
+		Major   string             `bson:"major"`
+		Score   float64            `bson:"score"`
+	}
+	byID := map[primitive.ObjectID]*models.StudentSearchHit{}
+	add := func(s studentDoc, score float64) {
+		hit, ok := byID[s.ID]
+		if !ok {
+			hit = &models.StudentSearchHit{ID: s.ID, Code: s.Code, Name: s.Name, EngName: s.EngName, Major: s.Major}
+			byID[s.ID] = hit
+		}
+		hit.Score += score
+	}
+
+	var students []studentDoc
+	if err := textSearch(ctx, DB.StudentCollection, terms, bson.M{"deletedAt": nil}, &students); err != nil {
+		return nil, err
+	}
+	for _, s := range students {
+		add(s, s.Score)
+	}
+
+	// รหัสนิสิตมักค้นด้วยเลขบางส่วน (เช่น 6516) → ใช้ regex fallback เช่นเดียวกับคำไทย
+	if fallback := fallbackTerms(terms, len(byID) < limit); len(fallback) > 0 {
+		var students []studentDoc
+		if err := regexSearch(ctx, DB.StudentCollection, fallback, studentWeights, bson.M{"deletedAt": nil}, &students); err != nil {
+			return nil, err
+		}
+		for _, s := range students {
+			add(s, regexScore(fallback, studentWeights, map[string]string{"code": s.Code, "name": s.Name, "engName": s.EngName}))
+		}
+	}
+
+	hits := make([]models.StudentSearchHit, 0, len(byID))
+	for _, hit := range byID {
+		hits = append(hits, *hit)
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Code < hits[j].Code
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// textSearch $text + textScore (ถ้ายังไม่มี text index จะ log แล้วคืนผลว่าง ให้ regex fallback ทำงานแทน)
+func textSearch(ctx context.Context, coll *mongo.Collection, terms []string, filter bson.M, out interface{}) error {
+	query := bson.M{"$text": bson.M{"$search": strings.Join(terms, " ")}}
+	for k, v := range filter {
+		query[k] = v
+	}
+	cur, err := coll.Find(ctx, query, options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetLimit(candidateLimit))
+	if err != nil {
+		log.Printf("⚠️ Text search on %s failed: %v", coll.Name(), err)
+		return nil
+	}
+	return cur.All(ctx, out)
+}
+
+// regexSearch ค้นแบบ substring (escape แล้ว) — ทุกคำต้องปรากฏในฟิลด์ใดฟิลด์หนึ่ง
+func regexSearch(ctx context.Context, coll *mongo.Collection, terms []string, weights bson.D, filter bson.M, out interface{}) error {
+	and := bson.A{}
+	for _, term := range terms {
+		or := bson.A{}
+		for _, w := range weights {
+			or = append(or, bson.M{w.Key: Contains(term)})
+		}
+		and = append(and, bson.M{"$or": or})
+	}
+	query := bson.M{"$and": and}
+	for k, v := range filter {
+		query[k] = v
+	}
+	cur, err := coll.Find(ctx, query, options.Find().SetLimit(candidateLimit))
+	if err != nil {
+		return err
+	}
+	return cur.All(ctx, out)
+}
+
+// fallbackTerms คำที่ต้องค้นด้วย regex: คำไทยเสมอ, คำอื่นเมื่อ text index ได้ผลไม่พอ
+func fallbackTerms(terms []string, needMore bool) []string {
+	if needMore {
+		return terms
+	}
+	var thai []string
+	for _, t := range terms {
+		if hasThai(t) {
+			thai = append(thai, t)
+		}
+	}
+	return thai
+}
+
+// regexScore คะแนนของผล regex: ตรงทั้งข้อความ 1.5 / ขึ้นต้นด้วย 1.0 / มีอยู่ในข้อความ 0.5 × น้ำหนักฟิลด์
+func regexScore(terms []string, weights bson.D, values map[string]string) float64 {
+	score := 0.0
+	for _, w := range weights {
+		value := strings.ToLower(values[w.Key])
+		weight := float64(w.Value.(int))
+		for _, term := range terms {
+			switch {
+			case value == term:
+				score += 1.5 * weight
+			case strings.HasPrefix(value, term):
+				score += 1.0 * weight
+			case strings.Contains(value, term):
+				score += 0.5 * weight
+			}
+		}
+	}
+	return score
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
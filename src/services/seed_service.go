@@ -211,6 +211,7 @@ func SeedInitialUsers() ([]GeneratedPassword, error) {
 				"code":        seedUser.Code,
 				"major":       seedUser.Major,
 				"studentYear": seedUser.Year,
+				"entryYear":   models.EntryYearFromCode(seedUser.Code),
 				"isActive":    true,
 				"createdAt":   time.Now(),
 			}
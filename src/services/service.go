@@ -2,7 +2,12 @@ package services
 
 import (
 	DB "Backend-Bluelock-007/src/database"
+	hourhistory "Backend-Bluelock-007/src/services/hour-history"
+	"Backend-Bluelock-007/src/services/search"
+	"Backend-Bluelock-007/src/services/transcripts"
+	"context"
 	"log"
+	"time"
 )
 
 // Use the database name provided by the database package (loaded from MONGO_DATABASE)
@@ -34,6 +39,18 @@ func init() {
 		"Courses",
 		"Upload_Certificates",
 		"Hour_Change_Histories",
+		"Program_State_Histories",
+		"Program_Series",
+		"Program_Templates",
+		"Program_Template_Versions",
+		"Rooms",
+		"Program_Reviews",
+		"Hour_Ledger",
+		"Graduation_Requirements",
+		"Hour_Reverifications",
+		"Hour_Transcripts",
+		"Hour_Adjustment_Batches",
+		"Hour_Appeals",
 	}); err != nil {
 		log.Fatal("Failed ensuring collections:", err)
 	}
@@ -52,6 +69,58 @@ func init() {
 	DB.CourseCollection = DB.GetDefaultCollection("Courses")
 	DB.UploadCertificateCollection = DB.GetDefaultCollection("Upload_Certificates")
 	DB.HourChangeHistoryCollection = DB.GetDefaultCollection("Hour_Change_Histories")
+	DB.ProgramStateHistoryCollection = DB.GetDefaultCollection("Program_State_Histories")
+	DB.ProgramSeriesCollection = DB.GetDefaultCollection("Program_Series")
+	DB.ProgramTemplateCollection = DB.GetDefaultCollection("Program_Templates")
+	DB.ProgramTemplateVersionCollection = DB.GetDefaultCollection("Program_Template_Versions")
+	DB.RoomCollection = DB.GetDefaultCollection("Rooms")
+	DB.ProgramReviewCollection = DB.GetDefaultCollection("Program_Reviews")
+	DB.HourLedgerCollection = DB.GetDefaultCollection("Hour_Ledger")
+	DB.GraduationRequirementCollection = DB.GetDefaultCollection("Graduation_Requirements")
+	DB.HourReverificationCollection = DB.GetDefaultCollection("Hour_Reverifications")
+	DB.HourTranscriptCollection = DB.GetDefaultCollection("Hour_Transcripts")
+	DB.HourAdjustmentBatchCollection = DB.GetDefaultCollection("Hour_Adjustment_Batches")
+	DB.HourAppealCollection = DB.GetDefaultCollection("Hour_Appeals")
+
+	// 🔍 text index สำหรับค้นหา (สร้างไม่สำเร็จยังใช้งานได้ผ่าน regex fallback)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := search.EnsureIndexes(ctx); err != nil {
+		log.Println("⚠️ Failed ensuring search indexes:", err)
+	}
+
+	// 🎓 เกณฑ์ชั่วโมงตามสาขา / รุ่น (สร้างเกณฑ์เริ่มต้นเมื่อยังไม่มี)
+	if err := hourhistory.EnsureGraduationRequirements(ctx); err != nil {
+		log.Println("⚠️ Failed ensuring graduation requirements:", err)
+	}
+
+	// 📨 คำร้องขอทบทวนชั่วโมง: รอพิจารณาได้ทีละ 1 คำร้องต่อ hour history
+	if err := hourhistory.EnsureHourAppealIndexes(ctx); err != nil {
+		log.Println("⚠️ Failed ensuring hour appeal indexes:", err)
+	}
+
+	// 🧾 ใบรับรองชั่วโมง: token ตรวจสอบใน QR ห้ามซ้ำ
+	if err := transcripts.EnsureTranscriptIndexes(ctx); err != nil {
+		log.Println("⚠️ Failed ensuring transcript indexes:", err)
+	}
+
+	// 📒 ledger ชั่วโมง: index + บันทึกยอดยกมาของ hour history ที่ยังไม่อยู่ใน ledger
+	if err := hourhistory.EnsureLedgerIndexes(ctx); err != nil {
+		log.Println("⚠️ Failed ensuring hour ledger indexes:", err)
+	}
+	backfillCtx, cancelBackfill := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancelBackfill()
+	if n, err := hourhistory.BackfillLedger(backfillCtx); err != nil {
+		log.Println("⚠️ Failed backfilling hour ledger:", err)
+	} else if n > 0 {
+		log.Printf("📒 Backfilled %d hour histories into ledger", n)
+	}
+	// 🧮 ยอดชั่วโมงที่ cache ใน Student ต้องตรงกับ ledger ก่อนเปิดให้ใช้งาน
+	if result, err := hourhistory.ReconcileHourTotals(backfillCtx, true); err != nil {
+		log.Println("⚠️ Failed reconciling hour totals:", err)
+	} else if result.Fixed > 0 {
+		log.Printf("🧮 Reconciled hour totals for %d students", result.Fixed)
+	}
 
 	// Note: Asynq initialization is now handled in main.go after Redis connection check
 
@@ -4,7 +4,7 @@ import (
 	DB "Backend-Bluelock-007/src/database"
 	"Backend-Bluelock-007/src/models"
 	hourhistory "Backend-Bluelock-007/src/services/hour-history"
-	"Backend-Bluelock-007/src/services/programs"
+	"Backend-Bluelock-007/src/services/search"
 	"context"
 	"errors"
 	"fmt"
@@ -23,16 +23,24 @@ import (
 
 // Collections are now initialized in service.go
 
+var (
+	ErrInvalidStudentID = errors.New("invalid student ID")
+	ErrStudentNotFound  = errors.New("student not found")
+)
+
 // GetStudentsWithFilter - ดึงข้อมูลนิสิตทั้งหมดที่ผ่านการ filter ตามเงื่อนไขที่ระบุ
 func GetStudentsWithFilter(params models.PaginationParams, majors []string, studentYears []string, studentStatus []string,studentCode []string,) ([]bson.M, int64, int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
-	pipeline := mongo.Pipeline{}
+	// 🗑️ ไม่รวมนิสิตที่อยู่ในถังขยะ
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"deletedAt": nil}}},
+	}
 
 	// 🔍 Search (name, code)
 	if params.Search != "" {
-		regex := bson.M{"$regex": params.Search, "$options": "i"}
+		regex := bson.M{"$regex": search.Contains(params.Search)}
 		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{
 			"$or": bson.A{
 				bson.M{"name": regex},
@@ -63,7 +71,7 @@ func GetStudentsWithFilter(params models.PaginationParams, majors []string, stud
 		}
 	}
 
-	// 🔍 Filter: studentYears (prefix by code)
+	// 🔍 Filter: studentYears (ชั้นปีปัจจุบัน → entryYear)
 	if len(studentYears) > 0 {
 		intYears := make([]int, 0, len(studentYears))
 		for _, y := range studentYears {
@@ -72,13 +80,8 @@ func GetStudentsWithFilter(params models.PaginationParams, majors []string, stud
 			}
 		}
 		if len(intYears) > 0 {
-			yearPrefixes := programs.GenerateStudentCodeFilter(intYears)
-			var regexFilters []bson.M
-			for _, prefix := range yearPrefixes {
-				regexFilters = append(regexFilters, bson.M{"code": bson.M{"$regex": "^" + prefix}})
-			}
 			pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{
-				"$or": regexFilters,
+				"entryYear": bson.M{"$in": models.EntryYearsForStudentYears(intYears)},
 			}}})
 		}
 	}
@@ -94,7 +97,7 @@ func GetStudentsWithFilter(params models.PaginationParams, majors []string, stud
 			if len(clean) >= 2 {
 				prefix2 := clean[:2]
 				codePrefixes = append(codePrefixes, bson.M{
-					"code": bson.M{"$regex": "^" + prefix2},
+					"code": bson.M{"$regex": search.Prefix(prefix2)},
 				})
 			}
 		}
@@ -125,71 +128,7 @@ func GetStudentsWithFilter(params models.PaginationParams, majors []string, stud
 		"as":           "user",
 	}}})
 
-	// 🔗 Lookup: Hour_Change_Histories → delta ต่อ skillType
-	pipeline = append(pipeline, bson.D{{Key: "$lookup", Value: bson.M{
-		"from": "Hour_Change_Histories",
-		"let":  bson.M{"sid": "$_id"},
-		"pipeline": mongo.Pipeline{
-			// match ตาม studentId และสถานะที่นับจริง (late ไม่นับเพราะ hourChange = 0)
-			bson.D{{Key: "$match", Value: bson.M{
-				"$expr": bson.M{"$eq": bson.A{"$studentId", "$$sid"}},
-				"status": bson.M{"$in": bson.A{
-					models.HCStatusAttended, models.HCStatusApproved, models.HCStatusAbsent, models.HCStatusManual,
-				}},
-			}}},
-			// คำนวณ deltaHours (ใช้ค่า hourChange โดยตรง)
-			bson.D{{Key: "$addFields", Value: bson.M{
-				"deltaHours": bson.M{
-					"$switch": bson.M{
-						"branches": bson.A{
-							bson.M{
-								"case": bson.M{"$in": bson.A{"$status", bson.A{models.HCStatusAttended, models.HCStatusApproved, models.HCStatusManual}}},
-								"then": bson.M{"$toInt": bson.M{"$ifNull": bson.A{"$hourChange", 0}}},
-							},
-							bson.M{
-								"case": bson.M{"$eq": bson.A{"$status", models.HCStatusAbsent}},
-								"then": bson.M{
-									"$multiply": bson.A{
-										-1,
-										bson.M{"$toInt": bson.M{"$ifNull": bson.A{"$hourChange", 0}}},
-									},
-								},
-							},
-						},
-						"default": 0,
-					},
-				},
-			}}},
-			// group รวมตาม skillType
-			bson.D{{Key: "$group", Value: bson.M{
-				"_id":        "$skillType", // "soft" | "hard"
-				"totalHours": bson.M{"$sum": "$deltaHours"},
-			}}},
-			// รีเชปเป็น key-value แล้วรวมเป็น object {soft: X, hard: Y}
-			bson.D{{Key: "$project", Value: bson.M{"k": "$_id", "v": "$totalHours", "_id": 0}}},
-			bson.D{{Key: "$group", Value: bson.M{"_id": nil, "asMap": bson.M{"$push": bson.M{"k": "$k", "v": "$v"}}}}},
-			bson.D{{Key: "$project", Value: bson.M{"_id": 0, "mapObj": bson.M{"$arrayToObject": "$asMap"}}}},
-		},
-		"as": "hourDeltaArr",
-	}}})
-
-	// 🔧 แตก softDelta / hardDelta ออกมา
-	pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: bson.M{
-		"softDelta": bson.M{
-			"$ifNull": bson.A{
-				bson.M{"$arrayElemAt": bson.A{"$hourDeltaArr.mapObj.soft", 0}},
-				0,
-			},
-		},
-		"hardDelta": bson.M{
-			"$ifNull": bson.A{
-				bson.M{"$arrayElemAt": bson.A{"$hourDeltaArr.mapObj.hard", 0}},
-				0,
-			},
-		},
-	}}})
-
-	// 📌 Project: ใช้เฉพาะ delta จาก hour history (ไม่บวกกับ base hours จาก student)
+	// 📌 Project: softSkill / hardSkill = ยอดชั่วโมงที่ cache จาก ledger (hourhistory อัปเดตทุกครั้งที่ชั่วโมงเปลี่ยน)
 	pipeline = append(pipeline, bson.D{{Key: "$project", Value: bson.M{
 		"_id":     0,
 		"id":      "$_id",
@@ -198,9 +137,16 @@ func GetStudentsWithFilter(params models.PaginationParams, majors []string, stud
 		"engName": 1,
 		"status":  1,
 		"major":   1,
+		"entryYear": 1,
+		// ชั้นปีปัจจุบัน = ปีการศึกษาปัจจุบัน - entryYear + 1 (0 = ไม่ทราบ)
+		"studentYear": bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{bson.M{"$ifNull": bson.A{"$entryYear", 0}}, 0}},
+			bson.M{"$add": bson.A{bson.M{"$subtract": bson.A{models.CurrentAcademicYear(), "$entryYear"}}, 1}},
+			0,
+		}},
 		"email":   bson.M{"$arrayElemAt": bson.A{"$user.email", 0}},
-		"softSkill": bson.M{"$ifNull": bson.A{"$softDelta", 0}},
-		"hardSkill": bson.M{"$ifNull": bson.A{"$hardDelta", 0}},
+		"softSkill": bson.M{"$ifNull": bson.A{"$softSkill", 0}},
+		"hardSkill": bson.M{"$ifNull": bson.A{"$hardSkill", 0}},
 	}}})
 
 	// 🔁 Sort / Skip / Limit
@@ -339,6 +285,28 @@ func GetStudentById(id primitive.ObjectID) (*models.Student, error) {
 	return &student, nil
 }
 
+// UpdateDietaryProfile บันทึกข้อมูลอาหาร/การแพ้อาหารของนิสิต (ค่าถูก normalize เป็นตัวพิมพ์เล็กไม่ซ้ำ)
+func UpdateDietaryProfile(id primitive.ObjectID, profile models.DietaryProfile) (*models.DietaryProfile, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	profile.Diets = models.NormalizeFoodTags(profile.Diets)
+	profile.Allergies = models.NormalizeFoodTags(profile.Allergies)
+	profile.Note = strings.TrimSpace(profile.Note)
+
+	res, err := DB.StudentCollection.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"dietaryProfile": profile}},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if res.MatchedCount == 0 {
+		return nil, errors.New("student not found")
+	}
+	return &profile, nil
+}
+
 // ✅ ฟังก์ชันเข้ารหัส Password
 func hashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -384,8 +352,13 @@ func CreateStudent(userInput *models.User, studentInput *models.Student) error {
 	}
 	userInput.Password = hashedPassword
 
-	// ✅ สร้าง student ก่อน
+	// ✅ สร้าง student ก่อน (ยอดชั่วโมงเริ่มที่ 0 — เพิ่มผ่าน hour history / ledger เท่านั้น)
 	studentInput.ID = primitive.NewObjectID()
+	studentInput.SoftSkill = 0
+	studentInput.HardSkill = 0
+	if studentInput.EntryYear == 0 {
+		studentInput.EntryYear = models.EntryYearFromCode(studentInput.Code)
+	}
 	_, err = DB.StudentCollection.InsertOne(ctx, studentInput)
 	if err != nil {
 		return err
@@ -448,6 +421,9 @@ func createNewStudentWithHourHistory(ctx context.Context, userInput *models.User
 	// ไม่ใช้ soft/hard skill จาก studentInput แต่จะเก็บเป็น 0 เพราะจะใช้ hour history แทน
 	studentInput.SoftSkill = 0
 	studentInput.HardSkill = 0
+	if studentInput.EntryYear == 0 {
+		studentInput.EntryYear = models.EntryYearFromCode(studentInput.Code)
+	}
 	
 	_, err = DB.StudentCollection.InsertOne(ctx, studentInput)
 	if err != nil {
@@ -501,8 +477,7 @@ func createLegacyHourHistory(ctx context.Context, studentID primitive.ObjectID,
 		SourceID:   &primitive.NilObjectID, // ใช้ zero value ของ ObjectID
 	}
 	
-	_, err := DB.HourChangeHistoryCollection.InsertOne(ctx, history)
-	return err
+	return hourhistory.InsertHistory(ctx, &history, models.HourLedgerActorSystem, "นำเข้าชั่วโมงจากระบบเก่า")
 }
 
 // upsertLegacyHourHistory - helper function สำหรับ upsert hour history สำหรับ legacy import
@@ -539,9 +514,7 @@ func upsertLegacyHourHistory(ctx context.Context, studentID primitive.ObjectID,
 	}
 
 	// ใช้ upsert: update ถ้าพบ, insert ถ้าไม่พบ
-	opts := options.Update().SetUpsert(true)
-	_, err := DB.HourChangeHistoryCollection.UpdateOne(ctx, filter, update, opts)
-	return err
+	return hourhistory.UpsertHistory(ctx, filter, update, models.HourLedgerActorSystem, "นำเข้าชั่วโมงจากระบบเก่า")
 }
 
 // helper function สำหรับอัปเดต student ที่มีอยู่แล้วพร้อม hour history
@@ -553,6 +526,9 @@ func updateExistingStudentWithHourHistory(ctx context.Context, studentID primiti
 		"major":   studentInput.Major,
 		"status":  studentInput.Status,
 	}
+	if studentInput.EntryYear > 0 {
+		updateData["entryYear"] = studentInput.EntryYear
+	}
 	
 	_, err := DB.StudentCollection.UpdateOne(ctx, bson.M{"_id": studentID}, bson.M{"$set": updateData})
 	if err != nil {
@@ -589,9 +565,13 @@ func UpdateStudent(id string, student *models.Student, email string) error {
 		return errors.New("invalid student ID")
 	}
 
-	// ✅ อัปเดต student
+	// ✅ อัปเดต student (ยกเว้นยอดชั่วโมง — เป็น cache จาก ledger แก้ผ่าน hour history เท่านั้น)
+	set, err := studentUpdateFields(student)
+	if err != nil {
+		return err
+	}
 	filter := bson.M{"_id": objID}
-	update := bson.M{"$set": student}
+	update := bson.M{"$set": set}
 	if _, err := DB.StudentCollection.UpdateOne(context.Background(), filter, update); err != nil {
 		return err
 	}
@@ -607,21 +587,113 @@ func UpdateStudent(id string, student *models.Student, email string) error {
 	return err
 }
 
-// DeleteStudent - ลบ Student พร้อมลบ User ที่อ้างถึง
-func DeleteStudent(id string) error {
+// studentUpdateFields แปลง Student เป็น $set โดยตัดฟิลด์ยอดชั่วโมงที่ cache จาก ledger ออก
+func studentUpdateFields(student *models.Student) (bson.M, error) {
+	raw, err := bson.Marshal(student)
+	if err != nil {
+		return nil, err
+	}
+	var set bson.M
+	if err := bson.Unmarshal(raw, &set); err != nil {
+		return nil, err
+	}
+	delete(set, "_id")
+	delete(set, "softSkill")
+	delete(set, "hardSkill")
+	delete(set, "hoursLedgerSeq")
+	return set, nil
+}
+
+// DeleteStudent - ย้าย Student ไปถังขยะ (soft delete) และปิดการเข้าสู่ระบบของ User ที่อ้างถึง
+// ชั่วโมงและประวัติการลงทะเบียนยังอยู่ครบ กู้คืนได้ด้วย RestoreStudent
+func DeleteStudent(id string, actor string) error {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return errors.New("invalid student ID")
+		return ErrInvalidStudentID
 	}
 
-	// ลบ user ที่ refId เป็น student.id และ role เป็น "student"
-	_, err = DB.UserCollection.DeleteOne(context.Background(), bson.M{
+	res, err := DB.StudentCollection.UpdateOne(context.Background(),
+		bson.M{"_id": objID, "deletedAt": nil},
+		bson.M{"$set": bson.M{"deletedAt": time.Now(), "deletedBy": actor}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrStudentNotFound
+	}
+
+	// ปิดการเข้าสู่ระบบ โดยเก็บ isActive เดิมไว้ใน isActiveBeforeDelete เพื่อคืนค่าตอนกู้คืน
+	userRes, err := DB.UserCollection.UpdateOne(context.Background(),
+		bson.M{"refId": objID, "role": "Student"},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"isActiveBeforeDelete": "$isActive", "isActive": false}}},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if userRes.MatchedCount == 0 {
+		log.Printf("⚠️ Warning: No user account found for deleted student %s", objID.Hex())
+	}
+	return nil
+}
+
+// RestoreStudent - กู้คืน Student จากถังขยะ และคืนสถานะการเข้าสู่ระบบ (isActive) ให้เป็นค่าเดิมก่อนลบ
+func RestoreStudent(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var student models.Student
+	if err := DB.StudentCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "deletedAt": bson.M{"$ne": nil}},
+		bson.M{"$unset": bson.M{"deletedAt": "", "deletedBy": ""}},
+	).Decode(&student); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return models.ErrNotInTrash
+		}
+		return err
+	}
+
+	// คืน isActive เดิมก่อนลบ — รายการที่ลบก่อนมี isActiveBeforeDelete: พ้นสภาพ (status 0) = false, อื่น ๆ = true
+	userRes, err := DB.UserCollection.UpdateOne(ctx,
+		bson.M{"refId": id, "role": "Student"},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"isActive": bson.M{"$ifNull": bson.A{"$isActiveBeforeDelete", student.Status != 0}}}}},
+			{{Key: "$unset", Value: "isActiveBeforeDelete"}},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if userRes.MatchedCount == 0 {
+		log.Printf("⚠️ Warning: No user account found for restored student %s", id.Hex())
+	}
+	return nil
+}
+
+// PurgeStudent - ลบ Student ถาวรพร้อมลบ User ที่อ้างถึง (เฉพาะที่อยู่ในถังขยะแล้ว)
+func PurgeStudent(id primitive.ObjectID) error {
+	objID := id
+	count, err := DB.StudentCollection.CountDocuments(context.Background(), bson.M{"_id": objID, "deletedAt": bson.M{"$ne": nil}})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return models.ErrNotInTrash
+	}
+
+	// ลบ user ที่ refId เป็น student.id และ role เป็น "Student"
+	userRes, err := DB.UserCollection.DeleteOne(context.Background(), bson.M{
 		"refId": objID,
-		"role":  "student",
+		"role":  "Student",
 	})
 	if err != nil {
 		return err
 	}
+	if userRes.DeletedCount == 0 {
+		log.Printf("⚠️ Warning: No user account found for purged student %s", objID.Hex())
+	}
 
 	// ลบ student
 	_, err = DB.StudentCollection.DeleteOne(context.Background(), bson.M{"_id": objID})
@@ -824,14 +896,11 @@ type StudentSummary struct {
 
 // GetStudentSummary - summary ตาม format ที่ต้องการ (เฉพาะนักเรียนที่มี status ไม่ใช่ 0)
 func GetStudentSummary(majors []string, studentYears []string) (StudentSummary, error) {
-	const softSkillTarget = 30
-	const hardSkillTarget = 12
-
 	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 	defer cancel()
 
 	// ---------- Build filter ----------
-	filter := bson.M{"status": bson.M{"$ne": 0}}
+	filter := bson.M{"status": bson.M{"$ne": 0}, "deletedAt": nil}
 
 	if len(majors) > 0 {
 		filter["major"] = bson.M{"$in": majors}
@@ -845,12 +914,7 @@ func GetStudentSummary(majors []string, studentYears []string) (StudentSummary,
 			}
 		}
 		if len(intYears) > 0 {
-			yearPrefixes := programs.GenerateStudentCodeFilter(intYears)
-			regexFilters := make([]bson.M, 0, len(yearPrefixes))
-			for _, prefix := range yearPrefixes {
-				regexFilters = append(regexFilters, bson.M{"code": bson.M{"$regex": "^" + prefix}})
-			}
-			filter["$or"] = regexFilters
+			filter["entryYear"] = bson.M{"$in": models.EntryYearsForStudentYears(intYears)}
 		}
 	}
 
@@ -881,104 +945,22 @@ func GetStudentSummary(majors []string, studentYears []string) (StudentSummary,
 		return summary, nil
 	}
 
-	// ---------- Collect student IDs ----------
-	ids := make([]primitive.ObjectID, 0, total)
-	for _, s := range students {
-		ids = append(ids, s.ID)
-	}
-
-	// ---------- Aggregate deltas from Hour_Change_Histories ----------
-	// NOTE: ให้แน่ใจว่า DB.HourChangeHistoryCollection ชี้คอลเลกชันชื่อถูกต้อง
-	// late ไม่นับเพราะ hourChange = 0
-	deltaPipe := mongo.Pipeline{
-		{{Key: "$match", Value: bson.M{
-			"studentId": bson.M{"$in": ids},
-			"status": bson.M{"$in": bson.A{
-				models.HCStatusAttended, models.HCStatusApproved, models.HCStatusAbsent, models.HCStatusManual,
-			}},
-		}}},
-		// normalize skillType -> skillKey (lower-case)
-		{{Key: "$addFields", Value: bson.M{
-			"skillKey": bson.M{"$toLower": "$skillType"},
-		}}},
-		// compute deltaHours (ใช้ค่า hourChange โดยตรง)
-		{{Key: "$addFields", Value: bson.M{
-			"deltaHours": bson.M{
-				"$switch": bson.M{
-					"branches": bson.A{
-						bson.M{
-							"case": bson.M{"$in": bson.A{"$status", bson.A{models.HCStatusAttended, models.HCStatusApproved, models.HCStatusManual}}},
-							"then": bson.M{"$toInt": bson.M{"$ifNull": bson.A{"$hourChange", 0}}},
-						},
-						bson.M{
-							"case": bson.M{"$eq": bson.A{"$status", models.HCStatusAbsent}},
-							"then": bson.M{
-								"$multiply": bson.A{
-									-1,
-									bson.M{"$toInt": bson.M{"$ifNull": bson.A{"$hourChange", 0}}},
-								},
-							},
-						},
-					},
-					"default": 0,
-				},
-			},
-		}}},
-		// group per (studentId, skillKey)
-		{{Key: "$group", Value: bson.M{
-			"_id": bson.M{
-				"studentId": "$studentId",
-				"skillKey":  "$skillKey", // "soft" | "hard"
-			},
-			"totalHours": bson.M{"$sum": "$deltaHours"},
-		}}},
-	}
-
-	type deltaRow struct {
-		ID struct {
-			StudentID primitive.ObjectID `bson:"studentId"`
-			SkillKey  string             `bson:"skillKey"`
-		} `bson:"_id"`
-		TotalHours int64 `bson:"totalHours"`
-	}
-
-	dc, err := DB.HourChangeHistoryCollection.Aggregate(ctx, deltaPipe)
+	// ---------- Count completion using NET hours (เกณฑ์ตามสาขา / รุ่นของนิสิตแต่ละคน) ----------
+	requirements, err := hourhistory.LoadGraduationRequirements(ctx)
 	if err != nil {
-		return StudentSummary{}, fmt.Errorf("aggregate hour deltas error: %v", err)
-	}
-	defer dc.Close(ctx)
-
-	type pair struct{ soft, hard int64 }
-	deltaMap := make(map[primitive.ObjectID]pair, total)
-
-	for dc.Next(ctx) {
-		var r deltaRow
-		if err := dc.Decode(&r); err != nil {
-			return StudentSummary{}, fmt.Errorf("decode delta row error: %v", err)
-		}
-		p := deltaMap[r.ID.StudentID]
-		switch r.ID.SkillKey {
-		case "soft":
-			p.soft += r.TotalHours
-		case "hard":
-			p.hard += r.TotalHours
-		}
-		deltaMap[r.ID.StudentID] = p
-	}
-	if err := dc.Err(); err != nil {
-		return StudentSummary{}, fmt.Errorf("cursor error: %v", err)
+		return StudentSummary{}, err
 	}
-
-	// ---------- Count completion using NET hours ----------
 	completed := 0
 	softCompleted := 0
 	hardCompleted := 0
 
 	for _, s := range students {
-		d := deltaMap[s.ID]
-		// คำนวณจาก hour history เท่านั้น (ไม่ใช้ base hours จาก student)
-		netSoft := d.soft
-		netHard := d.hard
+		// ยอดชั่วโมงที่ cache จาก ledger (ดู hourhistory.ComputeStudentTotals)
+		netSoft := int64(s.SoftSkill)
+		netHard := int64(s.HardSkill)
+		req := requirements.For(s.Major, s.EntryYear)
+		softSkillTarget := req.SoftSkillHours
+		hardSkillTarget := req.HardSkillHours
 
 		if netSoft >= int64(softSkillTarget) {
 			softCompleted++
@@ -1048,6 +1030,53 @@ func FindExistingCodes(codes []string) ([]string, error) {
 	return exists, nil
 }
 
+// BackfillStudentEntryYears - migration: เติม entryYear ให้นิสิตที่ยังไม่มี โดยคำนวณจากรหัสนิสิต
+// (fallback เป็น field year เดิมถ้าเป็นปี พ.ศ. 4 หลัก) — รันซ้ำได้ เพราะแตะเฉพาะเอกสารที่ยังไม่มี entryYear
+func BackfillStudentEntryYears(ctx context.Context) (int, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"entryYear": bson.M{"$exists": false}},
+		bson.M{"entryYear": 0},
+	}}
+	cur, err := DB.StudentCollection.Find(ctx, filter, options.Find().SetProjection(bson.M{"code": 1, "year": 1}))
+	if err != nil {
+		return 0, fmt.Errorf("failed to query students for entryYear backfill: %v", err)
+	}
+	defer cur.Close(ctx)
+
+	var writes []mongo.WriteModel
+	for cur.Next(ctx) {
+		var s models.Student
+		if err := cur.Decode(&s); err != nil {
+			continue
+		}
+		entryYear := models.EntryYearFromCode(strings.TrimSpace(s.Code))
+		if entryYear == 0 {
+			if y, err := strconv.Atoi(strings.TrimSpace(s.Year)); err == nil && y > 2500 {
+				entryYear = y
+			}
+		}
+		if entryYear == 0 {
+			log.Printf("⚠️ [BackfillStudentEntryYears] cannot derive entryYear for student %s (code=%q)", s.ID.Hex(), s.Code)
+			continue
+		}
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": s.ID}).
+			SetUpdate(bson.M{"$set": bson.M{"entryYear": entryYear}}))
+	}
+	if err := cur.Err(); err != nil {
+		return 0, fmt.Errorf("cursor error: %v", err)
+	}
+	if len(writes) == 0 {
+		return 0, nil
+	}
+
+	res, err := DB.StudentCollection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, fmt.Errorf("failed to backfill entryYear: %v", err)
+	}
+	return int(res.ModifiedCount), nil
+}
+
 // UpdateStudentStatus - อัปเดตสถานะนักศึกษาจากชั่วโมงสุทธิ (รับ string ID)
 func UpdateStudentStatus(id string) error {
 	objID, err := primitive.ObjectIDFromHex(id)
@@ -2,6 +2,7 @@ package submission
 
 import (
 	DB "Backend-Bluelock-007/src/database"
+	hourhistory "Backend-Bluelock-007/src/services/hour-history"
 	"context"
 	"errors"
 	"log"
@@ -57,6 +58,11 @@ func CreateSubmission(ctx context.Context, submission *models.Submission) (*mode
 	log.Printf("[submission] inserted id=%s db=%s coll=%s responses=%d",
 		submission.ID.Hex(), DB.SubmissionCollection.Database().Name(), DB.SubmissionCollection.Name(), len(submission.Responses))
 
+	// 📝 ส่งแบบประเมินของกิจกรรม → ปล่อยชั่วโมงที่รอแบบประเมิน (ไม่ทำให้การส่งฟอร์มล้มเหลว)
+	if _, err := hourhistory.ReleaseEvaluationHours(ctx, submission); err != nil {
+		log.Printf("⚠️ Warning: failed to release evaluation hours for submission %s: %v", submission.ID.Hex(), err)
+	}
+
 	return submission, nil
 }
 
@@ -0,0 +1,300 @@
+package transcripts
+
+import (
+	"Backend-Bluelock-007/src/models"
+	hourhistory "Backend-Bluelock-007/src/services/hour-history"
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// ฟอนต์ TTF ที่รองรับภาษาไทย (ตั้งผ่าน env ได้) — ไม่พบไฟล์ = ใช้ Helvetica (แสดงได้เฉพาะอักษรละติน)
+const (
+	defaultFontPath     = "/usr/share/fonts/truetype/tlwg/Garuda.ttf"
+	defaultBoldFontPath = "/usr/share/fonts/truetype/tlwg/Garuda-Bold.ttf"
+
+	pageMarginMM   = 15.0
+	bottomMarginMM = 20.0
+	rowHeightMM    = 6.5
+)
+
+var studentStatusLabels = map[int]string{
+	0: "Withdrawn",
+	1: "Very low hours",
+	2: "Low hours",
+	3: "Requirements met",
+	4: "Internship",
+}
+
+var historyStatusLabels = map[string]string{
+	models.HCStatusAttended: "Attended",
+	models.HCStatusApproved: "Approved",
+	models.HCStatusManual:   "Adjusted",
+	models.HCStatusAbsent:   "Absent",
+}
+
+var skillLabels = map[string]string{
+	"soft": "Soft",
+	"hard": "Hard",
+}
+
+var sourceTypeLabels = map[string]string{
+	"program":     "Activity",
+	"certificate": "Training",
+	"manual":      "Adjustment",
+}
+
+// transcriptDoc ตัวช่วยวาด PDF (family = ฟอนต์ที่ใช้, tr = แปลงข้อความให้ตรงกับ encoding ของฟอนต์)
+type transcriptDoc struct {
+	pdf    *gofpdf.Fpdf
+	family string
+	tr     func(string) string
+}
+
+func newTranscriptDoc() *transcriptDoc {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pageMarginMM, pageMarginMM, pageMarginMM)
+	pdf.SetAutoPageBreak(true, bottomMarginMM)
+
+	doc := &transcriptDoc{pdf: pdf, family: "Helvetica", tr: pdf.UnicodeTranslatorFromDescriptor("")}
+	regular := envOr("TRANSCRIPT_FONT", defaultFontPath)
+	if _, err := os.Stat(regular); err != nil {
+		log.Printf("⚠️ Transcript font %s not found → using Helvetica (Thai text will not render)", regular)
+		return doc
+	}
+	bold := envOr("TRANSCRIPT_FONT_BOLD", defaultBoldFontPath)
+	if _, err := os.Stat(bold); err != nil {
+		bold = regular
+	}
+	pdf.AddUTF8Font("transcript", "", regular)
+	pdf.AddUTF8Font("transcript", "B", bold)
+	doc.family = "transcript"
+	doc.tr = func(s string) string { return s }
+	return doc
+}
+
+func envOr(key, fallback string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func (d *transcriptDoc) font(style string, size float64) {
+	d.pdf.SetFont(d.family, style, size)
+}
+
+func (d *transcriptDoc) cell(w, h float64, text, border, align string, fill bool) {
+	d.pdf.CellFormat(w, h, d.tr(d.fit(text, w)), border, 0, align, fill, 0, "")
+}
+
+// fit ตัดข้อความให้พอดีความกว้างของช่อง (เติม "..." ท้ายข้อความ)
+func (d *transcriptDoc) fit(text string, w float64) string {
+	limit := w - 2
+	if d.pdf.GetStringWidth(d.tr(text)) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && d.pdf.GetStringWidth(d.tr(string(runes)+"...")) > limit {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// renderTranscript วาดใบรับรองชั่วโมง: ข้อมูลนิสิต, ยอดรวมเทียบเกณฑ์, รายการทั้งหมด และ QR สำหรับตรวจสอบ
+func renderTranscript(t *models.HourTranscript, histories []models.HourChangeHistory, verifyURL string) ([]byte, error) {
+	d := newTranscriptDoc()
+	pdf := d.pdf
+	loc, _ := time.LoadLocation("Asia/Bangkok")
+	issuedAt := t.IssuedAt.In(loc)
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		d.font("", 8)
+		pdf.SetTextColor(110, 110, 110)
+		pdf.CellFormat(0, 5, d.tr(fmt.Sprintf("Transcript No. %s  |  Issued %s  |  Page %d/{nb}",
+			t.ID.Hex(), issuedAt.Format("2006-01-02 15:04"), pdf.PageNo())), "", 0, "C", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+	pdf.AliasNbPages("")
+	pdf.AddPage()
+	pageW, _ := pdf.GetPageSize()
+	contentW := pageW - 2*pageMarginMM
+
+	// ---------- Header ----------
+	d.font("B", 16)
+	d.cell(contentW, 9, "Activity & Training Hours Transcript", "", "C", false)
+	pdf.Ln(9)
+	if issuer := strings.TrimSpace(os.Getenv("TRANSCRIPT_ISSUER")); issuer != "" {
+		d.font("", 11)
+		d.cell(contentW, 6, issuer, "", "C", false)
+		pdf.Ln(6)
+	}
+	pdf.Ln(2)
+	pdf.SetDrawColor(40, 70, 140)
+	pdf.SetLineWidth(0.6)
+	pdf.Line(pageMarginMM, pdf.GetY(), pageW-pageMarginMM, pdf.GetY())
+	pdf.SetLineWidth(0.2)
+	pdf.SetDrawColor(0, 0, 0)
+	pdf.Ln(4)
+
+	// ---------- Student + QR ----------
+	qrSize := 32.0
+	top := pdf.GetY()
+	png, err := qrcode.Encode(verifyURL, qrcode.Medium, 256)
+	if err != nil {
+		return nil, err
+	}
+	opts := gofpdf.ImageOptions{ImageType: "PNG"}
+	pdf.RegisterImageOptionsReader("verify-qr", opts, bytes.NewReader(png))
+	pdf.ImageOptions("verify-qr", pageW-pageMarginMM-qrSize, top, qrSize, qrSize, false, opts, 0, verifyURL)
+
+	infoW := contentW - qrSize - 4
+	labelW := 32.0
+	status := studentStatusLabels[t.Status]
+	entryYear := "-"
+	if t.EntryYear > 0 {
+		entryYear = fmt.Sprint(t.EntryYear)
+	}
+	for _, row := range [][2]string{
+		{"Student ID", t.Code},
+		{"Name", t.Name},
+		{"English name", t.EngName},
+		{"Major", t.Major},
+		{"Entry year", entryYear},
+		{"Status", status},
+	} {
+		d.font("B", 10)
+		d.cell(labelW, 5.5, row[0], "", "L", false)
+		d.font("", 10)
+		d.cell(infoW-labelW, 5.5, row[1], "", "L", false)
+		pdf.Ln(5.5)
+	}
+	pdf.SetY(max(pdf.GetY(), top+qrSize) + 6)
+
+	// ---------- Totals vs requirements ----------
+	d.font("B", 12)
+	d.cell(contentW, 7, "Summary", "", "L", false)
+	pdf.Ln(8)
+	colW := []float64{contentW * 0.31, contentW * 0.23, contentW * 0.23, contentW * 0.23}
+	pdf.SetFillColor(228, 234, 247)
+	d.font("B", 10)
+	for i, h := range []string{"Skill", "Earned hours", "Required hours", "Result"} {
+		d.cell(colW[i], rowHeightMM, h, "1", "C", true)
+	}
+	pdf.Ln(rowHeightMM)
+	d.font("", 10)
+	for _, row := range []struct {
+		skill            string
+		earned, required int
+	}{
+		{"Soft skill", t.SoftSkill, t.SoftRequired},
+		{"Hard skill", t.HardSkill, t.HardRequired},
+	} {
+		result := "Met"
+		if row.earned < row.required {
+			result = fmt.Sprintf("%d hours remaining", row.required-row.earned)
+		}
+		d.cell(colW[0], rowHeightMM, row.skill, "1", "L", false)
+		d.cell(colW[1], rowHeightMM, fmt.Sprint(row.earned), "1", "C", false)
+		d.cell(colW[2], rowHeightMM, fmt.Sprint(row.required), "1", "C", false)
+		d.cell(colW[3], rowHeightMM, result, "1", "C", false)
+		pdf.Ln(rowHeightMM)
+	}
+	pdf.Ln(6)
+
+	// ---------- Entries ----------
+	d.font("B", 12)
+	d.cell(contentW, 7, fmt.Sprintf("Hour records (%d)", len(histories)), "", "L", false)
+	pdf.Ln(8)
+	entryW := []float64{22, 20, contentW - 22 - 20 - 16 - 22 - 16, 16, 22, 16}
+	entryHeader := func() {
+		pdf.SetFillColor(228, 234, 247)
+		d.font("B", 9)
+		for i, h := range []string{"Date", "Type", "Title", "Skill", "Result", "Hours"} {
+			d.cell(entryW[i], rowHeightMM, h, "1", "C", true)
+		}
+		pdf.Ln(rowHeightMM)
+		d.font("", 9)
+	}
+	entryHeader()
+	_, pageH := pdf.GetPageSize()
+	if len(histories) == 0 {
+		d.cell(contentW, rowHeightMM, "No hour records", "1", "C", false)
+		pdf.Ln(rowHeightMM)
+	}
+	for _, h := range histories {
+		if pdf.GetY()+rowHeightMM > pageH-bottomMarginMM {
+			pdf.AddPage()
+			entryHeader()
+		}
+		hours := effectiveHoursLabel(h)
+		d.cell(entryW[0], rowHeightMM, h.ChangeAt.In(loc).Format("2006-01-02"), "1", "C", false)
+		d.cell(entryW[1], rowHeightMM, sourceLabel(h), "1", "C", false)
+		d.cell(entryW[2], rowHeightMM, historyTitle(h), "1", "L", false)
+		d.cell(entryW[3], rowHeightMM, skillLabels[strings.ToLower(h.SkillType)], "1", "C", false)
+		d.cell(entryW[4], rowHeightMM, historyStatusLabels[h.Status], "1", "C", false)
+		d.cell(entryW[5], rowHeightMM, hours, "1", "R", false)
+		pdf.Ln(rowHeightMM)
+	}
+
+	// ---------- Verification ----------
+	if pdf.GetY()+24 > pageH-bottomMarginMM {
+		pdf.AddPage()
+	}
+	pdf.Ln(6)
+	d.font("B", 10)
+	d.cell(contentW, 5.5, "Verification", "", "L", false)
+	pdf.Ln(6)
+	d.font("", 9)
+	pdf.MultiCell(contentW, 4.5, d.tr("Scan the QR code or open the link below to confirm this transcript was issued by the system "+
+		"and to check whether the student's hours have changed since it was issued."), "", "L", false)
+	pdf.SetTextColor(40, 70, 140)
+	pdf.CellFormat(contentW, 5, d.tr(verifyURL), "", 1, "L", false, 0, verifyURL)
+	pdf.SetTextColor(0, 0, 0)
+	d.cell(contentW, 5, "Content hash (SHA-256): "+t.ContentHash, "", "L", false)
+	pdf.Ln(5)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func sourceLabel(h models.HourChangeHistory) string {
+	if h.Status == models.HCStatusManual {
+		return "Adjustment"
+	}
+	if label, ok := sourceTypeLabels[h.SourceType]; ok {
+		return label
+	}
+	return h.SourceType
+}
+
+// historyTitle ชื่อรายการ: กิจกรรม (+ กิจกรรมย่อย) / ชื่อรายการที่บันทึกไว้
+func historyTitle(h models.HourChangeHistory) string {
+	if h.Program != nil && h.Program.Name != nil {
+		title := *h.Program.Name
+		if h.ProgramItem != nil && h.ProgramItem.Name != nil && *h.ProgramItem.Name != "" && *h.ProgramItem.Name != title {
+			title += " - " + *h.ProgramItem.Name
+		}
+		return title
+	}
+	return h.Title
+}
+
+// effectiveHoursLabel ชั่วโมงที่มีผลต่อยอดรวม (absent = หักชั่วโมง)
+func effectiveHoursLabel(h models.HourChangeHistory) string {
+	hours := hourhistory.EffectiveHours(h.Status, h.HourChange)
+	if hours > 0 {
+		return fmt.Sprintf("+%d", hours)
+	}
+	return fmt.Sprint(hours)
+}
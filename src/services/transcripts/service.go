@@ -0,0 +1,166 @@
+package transcripts
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	hourhistory "Backend-Bluelock-007/src/services/hour-history"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ========================================
+// Transcripts - ใบรับรองชั่วโมงกิจกรรม / อบรมของนิสิต (PDF พร้อม QR ตรวจสอบ)
+// ========================================
+
+var (
+	ErrStudentNotFound    = errors.New("student not found")
+	ErrTranscriptNotFound = errors.New("transcript not found")
+)
+
+// transcriptStatuses สถานะของ hour history ที่มีผลต่อยอดชั่วโมง (ดู hourhistory.EffectiveHours)
+var transcriptStatuses = []string{
+	models.HCStatusAttended,
+	models.HCStatusApproved,
+	models.HCStatusManual,
+	models.HCStatusAbsent,
+}
+
+// verifyTokenBytes ความยาว token ตรวจสอบ (128 บิต → hex 32 ตัวอักษร)
+const verifyTokenBytes = 16
+
+// EnsureTranscriptIndexes สร้าง index ของ Hour_Transcripts (verifyToken ห้ามซ้ำ)
+func EnsureTranscriptIndexes(ctx context.Context) error {
+	_, err := DB.HourTranscriptCollection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "verifyToken", Value: 1}},
+		Options: options.Index().SetName("verify_token").SetUnique(true).SetSparse(true),
+	})
+	return err
+}
+
+// newVerifyToken token สุ่มสำหรับ URL ตรวจสอบใน QR
+func newVerifyToken() (string, error) {
+	b := make([]byte, verifyTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Generate ออกใบรับรองชั่วโมงของนิสิต: บันทึก snapshot แล้วสร้าง PDF ที่มี QR ไปยัง verifyBaseURL/{verifyToken}
+func Generate(ctx context.Context, studentID primitive.ObjectID, issuedBy, verifyBaseURL string) ([]byte, *models.HourTranscript, error) {
+	var student models.Student
+	if err := DB.StudentCollection.FindOne(ctx, bson.M{"_id": studentID, "deletedAt": nil}).Decode(&student); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil, ErrStudentNotFound
+		}
+		return nil, nil, err
+	}
+
+	cur, err := DB.HourChangeHistoryCollection.Find(ctx,
+		bson.M{"studentId": studentID, "status": bson.M{"$in": transcriptStatuses}},
+		options.Find().SetSort(bson.D{{Key: "changeAt", Value: 1}}),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	histories := []models.HourChangeHistory{}
+	if err := cur.All(ctx, &histories); err != nil {
+		return nil, nil, err
+	}
+	if err := hourhistory.PopulateHistoryDetails(ctx, histories); err != nil {
+		return nil, nil, err
+	}
+
+	// ยอดรวมจาก ledger (แหล่งเดียวกับสถานะนิสิต) และเกณฑ์ของสาขา / รุ่น
+	softNet, hardNet, err := hourhistory.CalculateNetHours(ctx, studentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	requirement, err := hourhistory.ResolveGraduationRequirement(ctx, student.Major, student.EntryYear)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transcript := models.HourTranscript{
+		StudentID:    student.ID,
+		Code:         student.Code,
+		Name:         student.Name,
+		EngName:      student.EngName,
+		Major:        student.Major,
+		EntryYear:    student.EntryYear,
+		SoftSkill:    softNet,
+		HardSkill:    hardNet,
+		SoftRequired: requirement.SoftSkillHours,
+		HardRequired: requirement.HardSkillHours,
+		Status:       student.Status,
+		Entries:      len(histories),
+		IssuedBy:     issuedBy,
+		IssuedAt:     time.Now(),
+	}
+	transcript.ContentHash = contentHash(&transcript, histories)
+	if transcript.VerifyToken, err = newVerifyToken(); err != nil {
+		return nil, nil, err
+	}
+
+	res, err := DB.HourTranscriptCollection.InsertOne(ctx, transcript)
+	if err != nil {
+		return nil, nil, err
+	}
+	transcript.ID = res.InsertedID.(primitive.ObjectID)
+
+	verifyURL := strings.TrimRight(verifyBaseURL, "/") + "/" + transcript.VerifyToken
+	pdf, err := renderTranscript(&transcript, histories, verifyURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("render transcript error: %v", err)
+	}
+	return pdf, &transcript, nil
+}
+
+// contentHash sha256 ของข้อมูลที่พิมพ์ในเอกสาร (ยอดรวม + ทุกรายการ) — ใช้เทียบกับเอกสารที่ถูกแก้ไข
+func contentHash(t *models.HourTranscript, histories []models.HourChangeHistory) string {
+	parts := []string{
+		t.StudentID.Hex(), t.Code,
+		fmt.Sprint(t.SoftSkill), fmt.Sprint(t.HardSkill),
+		fmt.Sprint(t.SoftRequired), fmt.Sprint(t.HardRequired),
+		fmt.Sprint(t.Status), fmt.Sprint(t.IssuedAt.UnixMilli()),
+	}
+	for _, h := range histories {
+		parts = append(parts, fmt.Sprintf("%s:%s:%s:%d", h.ID.Hex(), h.SkillType, h.Status, h.HourChange))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "|")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Verify ตรวจสอบใบรับรองจาก token ที่อยู่ใน QR — outdated = ยอดชั่วโมงปัจจุบันเปลี่ยนไปจากตอนออกเอกสาร
+func Verify(ctx context.Context, token string) (*models.HourTranscriptVerification, error) {
+	if len(token) != verifyTokenBytes*2 {
+		return nil, ErrTranscriptNotFound
+	}
+	var transcript models.HourTranscript
+	if err := DB.HourTranscriptCollection.FindOne(ctx, bson.M{"verifyToken": token}).Decode(&transcript); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrTranscriptNotFound
+		}
+		return nil, err
+	}
+	softNet, hardNet, err := hourhistory.CalculateNetHours(ctx, transcript.StudentID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.HourTranscriptVerification{
+		Valid:      true,
+		Transcript: &transcript,
+		Outdated:   softNet != transcript.SoftSkill || hardNet != transcript.HardSkill,
+	}, nil
+}
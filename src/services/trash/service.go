@@ -0,0 +1,114 @@
+package trash
+
+import (
+	DB "Backend-Bluelock-007/src/database"
+	"Backend-Bluelock-007/src/models"
+	"Backend-Bluelock-007/src/services/courses"
+	forms "Backend-Bluelock-007/src/services/forms"
+	"Backend-Bluelock-007/src/services/programs"
+	"Backend-Bluelock-007/src/services/students"
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// ========================================
+// Trash - รายการที่ถูกลบแบบ soft delete (Programs / Courses / Forms / Students)
+// ========================================
+
+var ErrUnknownTrashType = errors.New("unknown trash type (program, course, form, student)")
+
+var trashTypes = []string{models.TrashTypeProgram, models.TrashTypeCourse, models.TrashTypeForm, models.TrashTypeStudent}
+
+// trashSource คืน collection และชื่อ field ที่ใช้แสดงชื่อรายการของแต่ละประเภท
+func trashSource(itemType string) (*mongo.Collection, any, error) {
+	switch itemType {
+	case models.TrashTypeProgram:
+		return DB.ProgramCollection, "$name", nil
+	case models.TrashTypeCourse:
+		return DB.CourseCollection, "$name", nil
+	case models.TrashTypeForm:
+		return DB.FormCollection, "$title", nil
+	case models.TrashTypeStudent:
+		return DB.StudentCollection, bson.M{"$concat": bson.A{"$code", " ", "$name"}}, nil
+	}
+	return nil, nil, ErrUnknownTrashType
+}
+
+// GetTrash ดึงรายการในถังขยะ (itemType ว่าง = ทุกประเภท) เรียงตามเวลาที่ลบล่าสุดก่อน
+func GetTrash(itemType string) ([]models.TrashItem, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	types := trashTypes
+	if itemType != "" {
+		types = []string{itemType}
+	}
+
+	items := []models.TrashItem{}
+	for _, t := range types {
+		coll, nameExpr, err := trashSource(t)
+		if err != nil {
+			return nil, err
+		}
+		cur, err := coll.Aggregate(ctx, mongo.Pipeline{
+			{{Key: "$match", Value: bson.M{"deletedAt": bson.M{"$ne": nil}}}},
+			{{Key: "$project", Value: bson.M{
+				"type":      bson.M{"$literal": t},
+				"name":      nameExpr,
+				"deletedAt": 1,
+				"deletedBy": bson.M{"$ifNull": bson.A{"$deletedBy", ""}},
+			}}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		var rows []models.TrashItem
+		if err := cur.All(ctx, &rows); err != nil {
+			return nil, err
+		}
+		items = append(items, rows...)
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].DeletedAt.After(items[j].DeletedAt) })
+	return items, nil
+}
+
+// Restore กู้คืนรายการจากถังขยะ
+func Restore(itemType string, id primitive.ObjectID) error {
+	switch itemType {
+	case models.TrashTypeProgram:
+		return programs.RestoreProgram(id)
+	case models.TrashTypeCourse:
+		return courses.RestoreCourse(id)
+	case models.TrashTypeForm:
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return forms.RestoreFormByID(ctx, id)
+	case models.TrashTypeStudent:
+		return students.RestoreStudent(id)
+	}
+	return ErrUnknownTrashType
+}
+
+// Purge ลบรายการในถังขยะถาวร (รายการต้องถูก soft delete ก่อน)
+func Purge(itemType string, id primitive.ObjectID, actor string) error {
+	switch itemType {
+	case models.TrashTypeProgram:
+		return programs.PurgeProgram(id, actor)
+	case models.TrashTypeCourse:
+		return courses.PurgeCourse(id)
+	case models.TrashTypeForm:
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return forms.PurgeFormByID(ctx, id)
+	case models.TrashTypeStudent:
+		return students.PurgeStudent(id)
+	}
+	return ErrUnknownTrashType
+}
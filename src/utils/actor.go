@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ActorFromCtx คืนตัวตนของผู้เรียก API สำหรับบันทึกประวัติ (email จาก JWT)
+// รองรับ route ที่ไม่ได้ผ่าน middleware.AuthJWT โดยอ่าน Bearer token เองถ้ามี
+func ActorFromCtx(c *fiber.Ctx) string {
+	if email, ok := c.Locals("email").(string); ok && email != "" {
+		return email
+	}
+	if claims := bearerClaims(c); claims != nil && claims.Email != "" {
+		return claims.Email
+	}
+	return "anonymous"
+}
+
+// RoleFromCtx คืน role ของผู้เรียก API ("" ถ้าไม่มี token)
+func RoleFromCtx(c *fiber.Ctx) string {
+	if role, ok := c.Locals("role").(string); ok && role != "" {
+		return role
+	}
+	if claims := bearerClaims(c); claims != nil {
+		return claims.Role
+	}
+	return ""
+}
+
+// bearerClaims อ่าน claims จาก Authorization header (nil ถ้าไม่มีหรือ token ไม่ถูกต้อง)
+func bearerClaims(c *fiber.Ctx) *JWTClaims {
+	auth := c.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return nil
+	}
+	claims, err := ParseJWT(strings.TrimPrefix(auth, "Bearer "))
+	if err != nil {
+		return nil
+	}
+	return claims
+}
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+// SignCalendarToken token สำหรับ URL subscribe ปฏิทินส่วนตัวของนิสิต
+// (HMAC-SHA256 ของ studentId ด้วย JWT_SECRET — ไม่หมดอายุ เพราะแอปปฏิทินดึง feed เองเป็นระยะ)
+func SignCalendarToken(studentID string) string {
+	mac := hmac.New(sha256.New, getJWTSecret())
+	mac.Write([]byte("calendar:" + studentID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyCalendarToken ตรวจ token ของ URL subscribe ปฏิทิน
+func VerifyCalendarToken(studentID, token string) bool {
+	return hmac.Equal([]byte(SignCalendarToken(studentID)), []byte(token))
+}